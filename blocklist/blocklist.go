@@ -0,0 +1,75 @@
+// Package blocklist keeps the IDs of revoked tokens until the tokens expire,
+// so a token can be invalidated on logout before its exp claim.
+package blocklist
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Blocklist holds the jti claims of revoked tokens
+type Blocklist interface {
+	// Block revokes the token with ID jti until exp, when the token expires
+	// anyway and needn't be kept any longer
+	Block(jti string, exp time.Time) error
+	// IsBlocked reports whether the token with ID jti was revoked
+	IsBlocked(jti string) bool
+}
+
+// MemoryBlocklist is a Blocklist local to this process. Run removes the
+// entries of expired tokens.
+type MemoryBlocklist struct {
+	mu      sync.RWMutex
+	entries map[string]time.Time
+}
+
+// NewMemoryBlocklist creates an empty in-memory blocklist
+func NewMemoryBlocklist() *MemoryBlocklist {
+	return &MemoryBlocklist{entries: make(map[string]time.Time)}
+}
+
+// Block implements Blocklist. A token that has already expired isn't kept.
+func (b *MemoryBlocklist) Block(jti string, exp time.Time) error {
+	if !exp.After(time.Now()) {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[jti] = exp
+	return nil
+}
+
+// IsBlocked implements Blocklist
+func (b *MemoryBlocklist) IsBlocked(jti string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	exp, ok := b.entries[jti]
+	return ok && exp.After(time.Now())
+}
+
+// Run removes the entries of expired tokens every minute until ctx is
+// cancelled
+func (b *MemoryBlocklist) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.sweep(now)
+		}
+	}
+}
+
+// sweep removes the entries that expired before now
+func (b *MemoryBlocklist) sweep(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for jti, exp := range b.entries {
+		if !exp.After(now) {
+			delete(b.entries, jti)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package changelog holds the record of route additions and removals in
+// each API version, embedded from changelog.json.
+package changelog
+
+import (
+	"cmp"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Change types
+const (
+	Added      = "added"
+	Removed    = "removed"
+	Changed    = "changed"
+	Deprecated = "deprecated"
+)
+
+// changelogJSON is the changelog committed with the code. Routes that are
+// only registered under some settings are left out of it, since Validate
+// would fail without them.
+//
+//go:embed changelog.json
+var changelogJSON []byte
+
+// Changelog lists the API versions, oldest first
+type Changelog []Entry
+
+// Entry is the set of route changes released in one version
+type Entry struct {
+	Version string   `json:"version"`
+	Date    string   `json:"date"`
+	Changes []Change `json:"changes"`
+}
+
+// Change is one route change. Route is the method and path template, such
+// as "GET /api/v1/items/{id}".
+type Change struct {
+	Type        string `json:"type"`
+	Route       string `json:"route"`
+	Description string `json:"description"`
+}
+
+// Load parses the embedded changelog and checks that its versions are
+// valid and in ascending order
+func Load() (Changelog, error) {
+	return Parse(changelogJSON)
+}
+
+// Parse parses a changelog document and checks that its versions are
+// valid and in ascending order
+func Parse(data []byte) (Changelog, error) {
+	var log Changelog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("changelog: %w", err)
+	}
+
+	var prev Version
+	for i, entry := range log {
+		v, err := ParseVersion(entry.Version)
+		if err != nil {
+			return nil, fmt.Errorf("changelog: %w", err)
+		}
+		if i > 0 && v.Compare(prev) <= 0 {
+			return nil, fmt.Errorf("changelog: version %s must come after %s", v, prev)
+		}
+		prev = v
+		for _, change := range entry.Changes {
+			if !slices.Contains([]string{Added, Removed, Changed, Deprecated}, change.Type) {
+				return nil, fmt.Errorf("changelog: %s: unknown change type %q", entry.Version, change.Type)
+			}
+		}
+	}
+	return log, nil
+}
+
+// Since returns the entries of versions greater than v
+func (c Changelog) Since(v Version) Changelog {
+	since := make(Changelog, 0)
+	for _, entry := range c {
+		// Load has checked every version
+		if ev, _ := ParseVersion(entry.Version); ev.Compare(v) > 0 {
+			since = append(since, entry)
+		}
+	}
+	return since
+}
+
+// Validate checks that every route the changelog lists as added, and does
+// not list as removed later, is registered in r
+func Validate(r *mux.Router, log Changelog) error {
+	registered := make(map[string]bool)
+	r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		pattern, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			registered[method+" "+pattern] = true
+		}
+		return nil
+	})
+
+	current := make(map[string]string)
+	for _, entry := range log {
+		for _, change := range entry.Changes {
+			switch change.Type {
+			case Added:
+				current[change.Route] = entry.Version
+			case Removed:
+				delete(current, change.Route)
+			}
+		}
+	}
+
+	var errs []error
+	for _, route := range slices.Sorted(maps.Keys(current)) {
+		if !registered[route] {
+			errs = append(errs, fmt.Errorf("changelog: %s, added in %s, is not registered", route, current[route]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Version is a semantic version without pre-release or build metadata
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses "MAJOR.MINOR.PATCH"
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q: want MAJOR.MINOR.PATCH", s)
+	}
+	var nums [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q: want MAJOR.MINOR.PATCH", s)
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// Compare returns -1, 0 or 1 as v is less than, equal to or greater than w
+func (v Version) Compare(w Version) int {
+	return cmp.Or(cmp.Compare(v.Major, w.Major), cmp.Compare(v.Minor, w.Minor), cmp.Compare(v.Patch, w.Patch))
+}
+
+// String returns v as "MAJOR.MINOR.PATCH"
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
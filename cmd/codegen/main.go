@@ -0,0 +1,132 @@
+// Command codegen writes the CRUD handler of each entity listed in
+// entities.yaml to handlers/<name>_handler.go. It runs from the module root
+// through go generate:
+//
+//	go generate ./...
+//
+// Files it didn't write are never overwritten, so hand-written handlers are
+// safe from an entity listed by mistake.
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// header marks the files codegen writes
+const header = "// Code generated by codegen. DO NOT EDIT."
+
+//go:embed handler.go.tmpl
+var handlerTemplate string
+
+// namePattern is the form of an entity name, which names the handler file
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// entity is one entry of entities.yaml
+type entity struct {
+	// Name is the lower-case entity name, such as "order"
+	Name string `yaml:"name"`
+	// Model is the import path and name of the model type, such as
+	// "go-api/models.Order"
+	Model string `yaml:"model"`
+	// Plural names the routes; Name with an "s" by default
+	Plural string `yaml:"plural"`
+}
+
+// templateData is what handler.go.tmpl renders from an entity
+type templateData struct {
+	Name, Plural, Title string
+	// Import is the import path of the model's package
+	Import string
+	// Model is the qualified model type, such as "models.Order"
+	Model string
+	// Type is the model type name, such as "Order"
+	Type    string
+	Handler string
+}
+
+func main() {
+	config := flag.String("config", "entities.yaml", "entities file")
+	out := flag.String("out", "handlers", "directory the handlers are written to")
+	flag.Parse()
+	log.SetFlags(0)
+
+	data, err := os.ReadFile(*config)
+	if err != nil {
+		log.Fatalf("codegen: %v", err)
+	}
+	var file struct {
+		Entities []entity `yaml:"entities"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		log.Fatalf("codegen: %s: %v", *config, err)
+	}
+
+	tmpl := template.Must(template.New("handler").Parse(handlerTemplate))
+	for _, e := range file.Entities {
+		td, err := e.data()
+		if err != nil {
+			log.Fatalf("codegen: %s: %v", *config, err)
+		}
+		target := filepath.Join(*out, e.Name+"_handler.go")
+		if err := render(tmpl, td, target); err != nil {
+			log.Fatalf("codegen: %v", err)
+		}
+		log.Printf("codegen: wrote %s", target)
+	}
+}
+
+// data checks e and derives the names the template uses
+func (e entity) data() (templateData, error) {
+	if !namePattern.MatchString(e.Name) {
+		return templateData{}, fmt.Errorf("entity name %q must be lower-case letters, digits and _", e.Name)
+	}
+	dot := strings.LastIndexByte(e.Model, '.')
+	if dot <= 0 || dot == len(e.Model)-1 || strings.Contains(e.Model[dot+1:], "/") {
+		return templateData{}, fmt.Errorf("entity %s: model %q must be <import path>.<type>", e.Name, e.Model)
+	}
+	importPath, typeName := e.Model[:dot], e.Model[dot+1:]
+	plural := e.Plural
+	if plural == "" {
+		plural = e.Name + "s"
+	}
+	return templateData{
+		Name:    strings.ReplaceAll(e.Name, "_", " "),
+		Plural:  plural,
+		Title:   typeName,
+		Import:  importPath,
+		Model:   path.Base(importPath) + "." + typeName,
+		Type:    typeName,
+		Handler: typeName + "Handler",
+	}, nil
+}
+
+// render writes the handler of td to target, refusing to replace a file
+// that codegen didn't write
+func render(tmpl *template.Template, td templateData, target string) error {
+	if existing, err := os.ReadFile(target); err == nil && !bytes.HasPrefix(existing, []byte(header)) {
+		return fmt.Errorf("%s was not generated by codegen; not overwriting it", target)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, td); err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format %s: %w", target, err)
+	}
+	return os.WriteFile(target, src, 0o644)
+}
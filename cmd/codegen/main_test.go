@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// widgetModel is a model the tree doesn't have, so the generated handler
+// can't clash with a hand-written one
+const widgetModel = `package models
+
+import "time"
+
+type Widget struct {
+	ID        string    ` + "`json:\"id\"`" + `
+	Name      string    ` + "`json:\"name\"`" + `
+	TenantID  string    ` + "`json:\"tenant_id\"`" + `
+	Version   int       ` + "`json:\"version\"`" + `
+	CreatedAt time.Time ` + "`json:\"created_at\"`" + `
+	UpdatedAt time.Time ` + "`json:\"updated_at\"`" + `
+}
+`
+
+func TestGeneratedHandlerCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the handlers package")
+	}
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	config := filepath.Join(dir, "entities.yaml")
+	if err := os.WriteFile(config, []byte("entities:\n  - name: widget\n    model: go-api/models.Widget\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Run codegen as go generate does, writing into the temp directory
+	run := exec.Command(gocmd, "run", ".", "-config", config, "-out", dir)
+	if out, err := run.CombinedOutput(); err != nil {
+		t.Fatalf("codegen: %v\n%s", err, out)
+	}
+	generated := filepath.Join(dir, "widget_handler.go")
+	src, err := os.ReadFile(generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(src, []byte(header)) {
+		t.Errorf("generated file starts %q, want the codegen header", src[:min(len(src), 60)])
+	}
+	for _, method := range []string{"GetAll", "GetByID", "Create", "BulkCreate", "Update", "Patch", "Delete"} {
+		if !bytes.Contains(src, []byte("func (h *WidgetHandler) "+method+"(")) {
+			t.Errorf("generated handler has no %s", method)
+		}
+	}
+
+	// Build the handlers package with the generated file and the model laid
+	// over the tree, without writing to it
+	model := filepath.Join(dir, "widget.go")
+	if err := os.WriteFile(model, []byte(widgetModel), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {
+		filepath.Join(root, "handlers", "widget_handler.go"): generated,
+		filepath.Join(root, "models", "widget.go"):           model,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlayFile := filepath.Join(dir, "overlay.json")
+	if err := os.WriteFile(overlayFile, overlay, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	build := exec.Command(gocmd, "vet", "-overlay", overlayFile, "./handlers")
+	build.Dir = root
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("generated handler doesn't compile: %v\n%s", err, out)
+	}
+}
+
+func TestEntityData(t *testing.T) {
+	td, err := entity{Name: "line_item", Model: "go-api/models.LineItem"}.data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := templateData{
+		Name: "line item", Plural: "line_items", Title: "LineItem",
+		Import: "go-api/models", Model: "models.LineItem", Type: "LineItem", Handler: "LineItemHandler",
+	}
+	if td != want {
+		t.Errorf("data = %+v\nwant %+v", td, want)
+	}
+
+	for _, e := range []entity{
+		{Name: "Order", Model: "go-api/models.Order"},
+		{Name: "order-line", Model: "go-api/models.Order"},
+		{Name: "order", Model: "Order"},
+		{Name: "order", Model: "go-api/models."},
+		{Name: "order", Model: "go-api.models/Order"},
+	} {
+		if _, err := e.data(); err == nil {
+			t.Errorf("entity %+v passed, want an error", e)
+		}
+	}
+}
+
+func TestRenderKeepsHandWrittenFiles(t *testing.T) {
+	tmpl := template.Must(template.New("handler").Parse(handlerTemplate))
+	td, err := entity{Name: "order", Model: "go-api/models.Order"}.data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(t.TempDir(), "order_handler.go")
+
+	if err := os.WriteFile(target, []byte("package handlers\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := render(tmpl, td, target); err == nil || !strings.Contains(err.Error(), "not generated by codegen") {
+		t.Errorf("render over a hand-written file: %v, want a refusal", err)
+	}
+
+	// A file codegen wrote is regenerated
+	if err := os.WriteFile(target, []byte(header+"\n\npackage handlers\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := render(tmpl, td, target); err != nil {
+		t.Fatalf("render over a generated file: %v", err)
+	}
+	src, _ := os.ReadFile(target)
+	if !bytes.Contains(src, []byte("type OrderHandler struct")) {
+		t.Error("the generated file wasn't replaced")
+	}
+}
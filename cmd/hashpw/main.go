@@ -0,0 +1,39 @@
+// Command hashpw reads a password from stdin and prints its bcrypt hash,
+// for the bcrypt_hash entries of a BASIC_AUTH_FILE:
+//
+//	printf '%s' 'secret' | go run ./cmd/hashpw
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func main() {
+	cost := flag.Int("cost", bcrypt.DefaultCost, "bcrypt cost, from 4 to 31")
+	flag.Parse()
+	log.SetFlags(0)
+
+	// Only the first line is the password, so a trailing newline from echo
+	// is not part of it
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		log.Fatal("hashpw: no password on stdin")
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		log.Fatal("hashpw: the password is empty")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), *cost)
+	if err != nil {
+		log.Fatalf("hashpw: %v", err)
+	}
+	fmt.Println(string(hash))
+}
@@ -0,0 +1,282 @@
+// Command loadtest drives the create, get, update and delete cycle of items
+// or clients against a running API and reports the latencies, throughput
+// and error rate:
+//
+//	go run ./cmd/loadtest --url http://localhost:8080 --concurrency 20 --duration 30s
+//
+// Progress is printed to stderr every second and the summary to stdout as
+// JSON.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets is the number of latency histogram buckets. Bucket i
+// counts requests taking about e^(i/100) microseconds, so each is 1% wider
+// than the last and the largest holds anything over a minute.
+const latencyBuckets = 1800
+
+// stats aggregates the results of every worker
+type stats struct {
+	requests atomic.Int64
+	errors   atomic.Int64
+	maxNanos atomic.Int64
+	buckets  [latencyBuckets]atomic.Int64
+}
+
+// record adds one request taking d
+func (s *stats) record(d time.Duration, failed bool) {
+	s.requests.Add(1)
+	if failed {
+		s.errors.Add(1)
+	}
+	for {
+		longest := s.maxNanos.Load()
+		if int64(d) <= longest || s.maxNanos.CompareAndSwap(longest, int64(d)) {
+			break
+		}
+	}
+	i := int(math.Log(float64(d.Microseconds())+1) * 100)
+	s.buckets[min(i, latencyBuckets-1)].Add(1)
+}
+
+// percentile returns the latency below which fraction p of the requests
+// finished, in milliseconds
+func (s *stats) percentile(p float64) float64 {
+	var counts [latencyBuckets]int64
+	var total int64
+	for i := range s.buckets {
+		counts[i] = s.buckets[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return 0
+	}
+	if p >= 1 {
+		return float64(s.maxNanos.Load()) / 1e6
+	}
+	rank := int64(math.Ceil(p * float64(total)))
+	var seen int64
+	for i, n := range counts {
+		if seen += n; seen >= rank {
+			return (math.Exp(float64(i+1)/100) - 1) / 1e3
+		}
+	}
+	return float64(s.maxNanos.Load()) / 1e6
+}
+
+// summary is the JSON report printed at the end
+type summary struct {
+	Entity            string             `json:"entity"`
+	URL               string             `json:"url"`
+	Concurrency       int                `json:"concurrency"`
+	DurationSeconds   float64            `json:"duration_seconds"`
+	Seeded            int                `json:"seeded"`
+	Requests          int64              `json:"requests"`
+	Errors            int64              `json:"errors"`
+	ErrorRate         float64            `json:"error_rate"`
+	RequestsPerSecond float64            `json:"requests_per_second"`
+	LatencyMillis     map[string]float64 `json:"latency_ms"`
+}
+
+// client sends the requests of the cycle for one entity
+type client struct {
+	http   *http.Client
+	base   string
+	auth   string
+	entity string
+	// run tells this run's records from those of earlier runs, so client
+	// emails stay unique
+	run   string
+	stats *stats
+}
+
+// newBody returns the body of a create or update of the entity
+func (c *client) newBody(n int) any {
+	name := fmt.Sprintf("loadtest %s %d", c.run, n)
+	if c.entity == "clients" {
+		return map[string]any{"name": name, "email": fmt.Sprintf("loadtest-%s-%d@example.com", c.run, n)}
+	}
+	return map[string]any{"name": name, "quantity": n % 1000}
+}
+
+// do sends one request, recording its latency, and decodes a JSON response
+// into out when it's not nil. A transport error or a status outside 2xx
+// counts as an error.
+func (c *client) do(ctx context.Context, method, path string, body any, out any) bool {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			log.Fatalf("loadtest: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		log.Fatalf("loadtest: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != "" {
+		req.Header.Set("Authorization", c.auth)
+	}
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// Requests cut short by the end of the run don't count
+		if ctx.Err() == nil {
+			c.stats.record(time.Since(start), true)
+		}
+		return false
+	}
+	defer resp.Body.Close()
+	ok := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if ok && out != nil {
+		ok = json.NewDecoder(resp.Body).Decode(out) == nil
+	} else {
+		io.Copy(io.Discard, resp.Body)
+	}
+	c.stats.record(time.Since(start), !ok)
+	return ok
+}
+
+// create creates a record and returns its ID, or "" when it failed
+func (c *client) create(ctx context.Context, n int) string {
+	var created struct {
+		ID string `json:"id"`
+	}
+	if !c.do(ctx, http.MethodPost, "/"+c.entity, c.newBody(n), &created) {
+		return ""
+	}
+	return created.ID
+}
+
+func main() {
+	url := flag.String("url", "http://localhost:8080", "base URL of the API")
+	concurrency := flag.Int("concurrency", 10, "number of concurrent workers")
+	duration := flag.Duration("duration", 30*time.Second, "length of the load phase")
+	entity := flag.String("entity", "items", "entity to exercise: items or clients")
+	seed := flag.Int("seed", 100, "records created before the load phase for gets and updates")
+	auth := flag.String("auth", "", "Authorization header sent with every request")
+	flag.Parse()
+	log.SetFlags(0)
+
+	if *entity != "items" && *entity != "clients" {
+		log.Fatalf("loadtest: --entity must be items or clients, not %q", *entity)
+	}
+	if *concurrency < 1 || *seed < 1 || *duration <= 0 {
+		log.Fatal("loadtest: --concurrency, --seed and --duration must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := &client{
+		http:   &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency}},
+		base:   *url + "/api/v1",
+		auth:   *auth,
+		entity: *entity,
+		run:    fmt.Sprintf("%06x", rand.IntN(1<<24)),
+		stats:  &stats{},
+	}
+
+	// Seed the records gets and updates pick from; seeding isn't measured
+	ids := make([]string, 0, *seed)
+	for n := range *seed {
+		if id := c.create(ctx, n); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		log.Fatalf("loadtest: could not create any %s at %s", *entity, c.base)
+	}
+	log.Printf("loadtest: seeded %d %s; running %d workers for %s", len(ids), *entity, *concurrency, *duration)
+	c.stats = &stats{}
+
+	loadCtx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	var cycles atomic.Int64
+	for range *concurrency {
+		wg.Go(func() {
+			for loadCtx.Err() == nil {
+				n := int(cycles.Add(1)) + *seed
+				id := c.create(loadCtx, n)
+				target := ids[rand.IntN(len(ids))]
+				c.do(loadCtx, http.MethodGet, "/"+*entity+"/"+target, nil, nil)
+				c.do(loadCtx, http.MethodPut, "/"+*entity+"/"+target, c.newBody(n), nil)
+				if id != "" {
+					c.do(loadCtx, http.MethodDelete, "/"+*entity+"/"+id, nil, nil)
+				}
+			}
+		})
+	}
+
+	// Report progress every second until the workers stop
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var lastRequests, lastErrors int64
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case <-ticker.C:
+			requests, errors := c.stats.requests.Load(), c.stats.errors.Load()
+			log.Printf("loadtest: %3.0fs  %6d req/s  %4d errors  p99 %.1fms",
+				time.Since(start).Seconds(), requests-lastRequests, errors-lastErrors, c.stats.percentile(0.99))
+			lastRequests, lastErrors = requests, errors
+		}
+	}
+	elapsed := time.Since(start)
+
+	requests, errors := c.stats.requests.Load(), c.stats.errors.Load()
+	report := summary{
+		Entity:            *entity,
+		URL:               *url,
+		Concurrency:       *concurrency,
+		DurationSeconds:   elapsed.Seconds(),
+		Seeded:            len(ids),
+		Requests:          requests,
+		Errors:            errors,
+		RequestsPerSecond: float64(requests) / elapsed.Seconds(),
+		LatencyMillis: map[string]float64{
+			"p50":  c.stats.percentile(0.50),
+			"p95":  c.stats.percentile(0.95),
+			"p99":  c.stats.percentile(0.99),
+			"p100": c.stats.percentile(1),
+		},
+	}
+	if requests > 0 {
+		report.ErrorRate = float64(errors) / float64(requests)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatalf("loadtest: %v", err)
+	}
+}
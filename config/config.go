@@ -0,0 +1,451 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the config file picked up from the working directory
+// when CONFIG_FILE is not set
+const DefaultFile = "config.yaml"
+
+// Config holds the application configuration
+type Config struct {
+	Port int `yaml:"port"`
+	// Env names the deployment environment, such as production, staging or
+	// development
+	Env string `yaml:"env"`
+	// GRPCPort is the gRPC listen port; the gRPC server is off when 0
+	GRPCPort int `yaml:"grpc_port"`
+	// LogLevel is debug, info, warn or error; debug also logs request and
+	// response bodies
+	LogLevel string `yaml:"log_level"`
+	// BodyLogMaxBytes caps how much of each body is logged at debug level
+	BodyLogMaxBytes int64 `yaml:"body_log_max_bytes"`
+	// TLSCertFile and TLSKeyFile serve HTTPS, and HTTP/2, when both are set
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file"`
+	// ReadinessPath serves the readiness probe, which fails until the stores
+	// have finished loading
+	ReadinessPath string `yaml:"readiness_path"`
+
+	// AuthEnabled turns on scope checks for the item and client routes
+	AuthEnabled bool `yaml:"auth_enabled"`
+	// BasicAuthFile is a JSON file of users with bcrypt password hashes,
+	// read again when it changes; it replaces BASIC_AUTH_USERS
+	BasicAuthFile string `yaml:"basic_auth_file"`
+
+	// StorageBackend selects the store implementation: memory, sharded,
+	// replicated, eventsource, bolt or redis
+	StorageBackend string `yaml:"storage_backend"`
+	// ReplicationInterval is how long the replicated backend waits after a
+	// write before copying the primary to the read replica
+	ReplicationInterval time.Duration `yaml:"replication_interval"`
+	// BoltPath is the database file used by the bolt backend
+	BoltPath string `yaml:"bolt_path"`
+	// ReadReplica keeps an in-memory copy of the bolt stores to serve reads
+	ReadReplica bool `yaml:"read_replica"`
+	// RedisURL is the connection URL used by the redis backend
+	RedisURL string `yaml:"redis_url"`
+
+	// StoreMaxItems caps the records per entity of the memory backend;
+	// unbounded when 0
+	StoreMaxItems int `yaml:"store_max_items"`
+	// ItemIndexes lists the item fields the memory backend indexes, such as
+	// client_id, so lookups by them skip the full scan
+	ItemIndexes []string `yaml:"item_indexes"`
+	// StoreEvictionPolicy picks the record dropped when a bounded store is
+	// full: oldest, lru or none (creates then fail)
+	StoreEvictionPolicy string `yaml:"store_eviction_policy"`
+
+	// CacheSize is the number of records per entity kept in the read
+	// cache; caching is off when 0
+	CacheSize int `yaml:"cache_size"`
+
+	// MaxBodySizeBytes caps the request body size of POST and PUT routes
+	MaxBodySizeBytes int64 `yaml:"max_body_size_bytes"`
+
+	// AdminAPIKey guards the /admin routes; they are disabled when empty
+	AdminAPIKey string `yaml:"admin_api_key"`
+
+	// APIV2Enabled serves the v2 API under /api/v2 alongside v1
+	APIV2Enabled bool `yaml:"api_v2_enabled"`
+	// DeleteReturnsBody makes item and client deletes respond 200 with the
+	// deleted record instead of 204
+	DeleteReturnsBody bool `yaml:"delete_returns_body"`
+	// StreamLists writes unsorted item and client lists as the store is
+	// read, trading their ETag for less memory
+	StreamLists bool `yaml:"stream_lists"`
+	// ValidationRulesPath is a YAML file of extra item validation rules,
+	// reloaded when it changes; only the built-in checks apply while it
+	// doesn't exist
+	ValidationRulesPath string `yaml:"validation_rules_path"`
+
+	// DocsEnabled serves the OpenAPI document and the Swagger UI at /docs/
+	DocsEnabled bool `yaml:"docs_enabled"`
+
+	// FeatureFlagsFile is a JSON flags file; when empty flags come from
+	// FEATURE_<NAME> environment variables
+	FeatureFlagsFile string `yaml:"feature_flags_file"`
+
+	// WebhookURLs receive a POST for every item and client change of their
+	// tenant, each given as <tenant>=<url>, or a bare URL for the default
+	// tenant
+	WebhookURLs []string `yaml:"webhook_urls"`
+	// WebhookDLQPath is the bolt file holding failed webhook deliveries
+	WebhookDLQPath string `yaml:"webhook_dlq_path"`
+
+	// CORSAllowedOrigins lists the exact origins allowed by CORS; "*" allows any
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins"`
+	// CORSOriginPatterns is a comma-separated list of origin regexes
+	CORSOriginPatterns string `yaml:"cors_origin_patterns"`
+
+	// TransformPipeline lists the transformers run over every JSON response
+	// body, such as "strip:cost;rename:name=title"
+	TransformPipeline string `yaml:"transform_pipeline"`
+	// PIIFields are masked in response bodies when Env is staging or
+	// development
+	PIIFields []string `yaml:"pii_fields"`
+
+	// SLOLimits maps "METHOD /path/template" to the slowest acceptable
+	// response time of that route
+	SLOLimits map[string]time.Duration `yaml:"slo_limits"`
+
+	// LongPollTimeout is how long a poll request waits for a change
+	LongPollTimeout time.Duration `yaml:"long_poll_timeout"`
+	// ReservationTTL is how long an item reservation holds its quantity
+	// when the request doesn't say
+	ReservationTTL time.Duration `yaml:"reservation_ttl"`
+	// ExportWorkers is how many export jobs run at the same time
+	ExportWorkers int `yaml:"export_workers"`
+	// ImportWorkers is how many feed import jobs run at the same time
+	ImportWorkers int `yaml:"import_workers"`
+
+	// MaxHeapMB and MaxGoroutines are the runtime limits above which the
+	// health check reports the API as degraded
+	MaxHeapMB     int `yaml:"max_heap_mb"`
+	MaxGoroutines int `yaml:"max_goroutines"`
+	// LongPollSecret signs poll cursors; a random secret is used when empty
+	LongPollSecret string `yaml:"long_poll_secret"`
+
+	// IPAllowlist, when set, limits access to these CIDR ranges
+	IPAllowlist []string `yaml:"ip_allowlist"`
+	// IPBlocklist rejects these CIDR ranges
+	IPBlocklist []string `yaml:"ip_blocklist"`
+	// TrustedProxies are the CIDR ranges allowed to set X-Forwarded-For
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	// RateLimitBackend enables per-IP rate limiting: memory or redis.
+	// Rate limiting is off when empty.
+	RateLimitBackend string `yaml:"rate_limit_backend"`
+	// RateLimitRPS is the sustained number of requests per second per IP
+	RateLimitRPS int `yaml:"rate_limit_rps"`
+	// RateLimitBurst is the number of requests an IP may make at once
+	RateLimitBurst int `yaml:"rate_limit_burst"`
+	// RateLimitPerUser limits authenticated callers per user ID instead of
+	// per IP, with the same rate and burst
+	RateLimitPerUser bool `yaml:"rate_limit_per_user"`
+
+	// ResponseCacheBackend caches GET item and client responses: memory or
+	// redis. Caching is off when empty.
+	ResponseCacheBackend string `yaml:"response_cache_backend"`
+	// ResponseCacheTTL is how long a cached response is served
+	ResponseCacheTTL time.Duration `yaml:"response_cache_ttl"`
+
+	// SessionBackend keeps server-side sessions: memory or redis. The
+	// session routes are off when empty.
+	SessionBackend string `yaml:"session_backend"`
+	// SessionTTL is how long a session lasts after it is created
+	SessionTTL time.Duration `yaml:"session_ttl"`
+	// TokenSecret signs bearer tokens; a random secret is used when empty
+	TokenSecret string `yaml:"token_secret"`
+	// TokenTTL is how long a bearer token is valid after it is issued
+	TokenTTL time.Duration `yaml:"token_ttl"`
+	// TokenBlocklistBackend keeps the IDs of revoked tokens: memory or
+	// redis. Tokens can't be revoked when empty.
+	TokenBlocklistBackend string `yaml:"token_blocklist_backend"`
+
+	// QueueMaxWorkers caps the item and client requests handled at once;
+	// queuing is off when 0
+	QueueMaxWorkers int `yaml:"queue_max_workers"`
+	// QueueMaxSize is how many requests may wait for a worker
+	QueueMaxSize int `yaml:"queue_max_size"`
+	// QueueTimeout is how long a request waits for a worker before 503
+	QueueTimeout time.Duration `yaml:"queue_timeout"`
+
+	// CBFailureThreshold is the number of consecutive store errors that
+	// open the circuit breaker
+	CBFailureThreshold int `yaml:"cb_failure_threshold"`
+	// CBRecoveryTimeout is how long the breaker stays open before a trial call
+	CBRecoveryTimeout time.Duration `yaml:"cb_recovery_timeout"`
+}
+
+// Default returns the configuration used when nothing else is set
+func Default() *Config {
+	return &Config{
+		Port:                8080,
+		Env:                 "production",
+		GRPCPort:            9090,
+		LogLevel:            "info",
+		PIIFields:           []string{"email", "phone"},
+		BodyLogMaxBytes:     4096,
+		ReadinessPath:       "/api/v1/ready",
+		StorageBackend:      "memory",
+		BoltPath:            "data.db",
+		RedisURL:            "redis://localhost:6379/0",
+		StoreEvictionPolicy: "oldest",
+		MaxBodySizeBytes:    1 << 20,
+		APIV2Enabled:        true,
+		ValidationRulesPath: "validation-rules.yaml",
+		WebhookDLQPath:      "webhook_dlq.db",
+		LongPollTimeout:     30 * time.Second,
+		ReservationTTL:      15 * time.Minute,
+		ExportWorkers:       2,
+		ImportWorkers:       2,
+		MaxHeapMB:           512,
+		MaxGoroutines:       10000,
+		RateLimitRPS:        10,
+		RateLimitBurst:      20,
+		ResponseCacheTTL:    5 * time.Second,
+		SessionTTL:          30 * time.Minute,
+		TokenTTL:            time.Hour,
+		QueueMaxSize:        100,
+		QueueTimeout:        5 * time.Second,
+		CBFailureThreshold:  5,
+		CBRecoveryTimeout:   30 * time.Second,
+	}
+}
+
+// Load builds the configuration from defaults, an optional YAML file and
+// environment variables. Precedence: env > file > defaults.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path, err := filePath()
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		if err := mergeFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mergeEnv(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadFromFile parses a YAML file on top of the defaults
+func LoadFromFile(path string) (*Config, error) {
+	cfg := Default()
+	if err := mergeFile(cfg, path); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// filePath returns the config file to use, or "" when there is none
+func filePath() (string, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return path, nil
+	}
+
+	_, err := os.Stat(DefaultFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("config: stat %s: %w", DefaultFile, err)
+	}
+	return DefaultFile, nil
+}
+
+func mergeFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// mergeEnv overrides cfg with the environment, reporting every variable
+// that fails to parse
+func mergeEnv(cfg *Config) error {
+	var errs []error
+	errs = append(errs, envInt("PORT", &cfg.Port))
+	envString("ENV", &cfg.Env)
+	errs = append(errs, envInt("GRPC_PORT", &cfg.GRPCPort))
+	envString("LOG_LEVEL", &cfg.LogLevel)
+	errs = append(errs, envInt64("BODY_LOG_MAX_BYTES", &cfg.BodyLogMaxBytes))
+	envString("TLS_CERT_FILE", &cfg.TLSCertFile)
+	envString("TLS_KEY_FILE", &cfg.TLSKeyFile)
+	envString("READINESS_PATH", &cfg.ReadinessPath)
+	errs = append(errs, envBool("AUTH_ENABLED", &cfg.AuthEnabled))
+	envString("BASIC_AUTH_FILE", &cfg.BasicAuthFile)
+	envString("STORAGE_BACKEND", &cfg.StorageBackend)
+	errs = append(errs, envDuration("REPLICATION_INTERVAL", &cfg.ReplicationInterval))
+	envString("BOLT_PATH", &cfg.BoltPath)
+	errs = append(errs, envBool("READ_REPLICA", &cfg.ReadReplica))
+	envString("REDIS_URL", &cfg.RedisURL)
+	errs = append(errs, envInt("STORE_MAX_ITEMS", &cfg.StoreMaxItems))
+	envString("STORE_EVICTION_POLICY", &cfg.StoreEvictionPolicy)
+	envList("ITEM_INDEXES", &cfg.ItemIndexes)
+	errs = append(errs, envInt("LRU_CACHE_SIZE", &cfg.CacheSize))
+	errs = append(errs, envInt64("MAX_BODY_SIZE_BYTES", &cfg.MaxBodySizeBytes))
+	envString("ADMIN_API_KEY", &cfg.AdminAPIKey)
+	errs = append(errs, envBool("API_V2_ENABLED", &cfg.APIV2Enabled))
+	errs = append(errs, envBool("DELETE_RETURNS_BODY", &cfg.DeleteReturnsBody))
+	errs = append(errs, envBool("STREAM_LISTS", &cfg.StreamLists))
+	envString("VALIDATION_RULES_PATH", &cfg.ValidationRulesPath)
+	errs = append(errs, envBool("DOCS_ENABLED", &cfg.DocsEnabled))
+	envString("FEATURE_FLAGS_FILE", &cfg.FeatureFlagsFile)
+	envList("WEBHOOK_URLS", &cfg.WebhookURLs)
+	envString("WEBHOOK_DLQ_PATH", &cfg.WebhookDLQPath)
+	envList("CORS_ALLOWED_ORIGINS", &cfg.CORSAllowedOrigins)
+	envString("CORS_ORIGIN_PATTERNS", &cfg.CORSOriginPatterns)
+	envString("TRANSFORM_PIPELINE", &cfg.TransformPipeline)
+	envList("PII_FIELDS", &cfg.PIIFields)
+	errs = append(errs, envDurationMap("SLO_LIMITS", &cfg.SLOLimits))
+	errs = append(errs, envDuration("LONG_POLL_TIMEOUT", &cfg.LongPollTimeout))
+	errs = append(errs, envDuration("RESERVATION_TTL", &cfg.ReservationTTL))
+	errs = append(errs, envInt("EXPORT_WORKERS", &cfg.ExportWorkers))
+	errs = append(errs, envInt("IMPORT_WORKERS", &cfg.ImportWorkers))
+	errs = append(errs, envInt("MAX_HEAP_MB", &cfg.MaxHeapMB))
+	errs = append(errs, envInt("MAX_GOROUTINES", &cfg.MaxGoroutines))
+	envString("LONG_POLL_SECRET", &cfg.LongPollSecret)
+	envList("IP_ALLOWLIST", &cfg.IPAllowlist)
+	envList("IP_BLOCKLIST", &cfg.IPBlocklist)
+	envList("TRUSTED_PROXIES", &cfg.TrustedProxies)
+	envString("RATE_LIMIT_BACKEND", &cfg.RateLimitBackend)
+	errs = append(errs, envInt("RATE_LIMIT_RPS", &cfg.RateLimitRPS))
+	errs = append(errs, envInt("RATE_LIMIT_BURST", &cfg.RateLimitBurst))
+	errs = append(errs, envBool("RATE_LIMIT_PER_USER", &cfg.RateLimitPerUser))
+	envString("RESPONSE_CACHE_BACKEND", &cfg.ResponseCacheBackend)
+	errs = append(errs, envDuration("RESPONSE_CACHE_TTL", &cfg.ResponseCacheTTL))
+	envString("SESSION_BACKEND", &cfg.SessionBackend)
+	errs = append(errs, envDuration("SESSION_TTL", &cfg.SessionTTL))
+	envString("TOKEN_SECRET", &cfg.TokenSecret)
+	errs = append(errs, envDuration("TOKEN_TTL", &cfg.TokenTTL))
+	envString("TOKEN_BLOCKLIST_BACKEND", &cfg.TokenBlocklistBackend)
+	errs = append(errs, envInt("QUEUE_MAX_WORKERS", &cfg.QueueMaxWorkers))
+	errs = append(errs, envInt("QUEUE_MAX_SIZE", &cfg.QueueMaxSize))
+	errs = append(errs, envDuration("QUEUE_TIMEOUT", &cfg.QueueTimeout))
+	errs = append(errs, envInt("CB_FAILURE_THRESHOLD", &cfg.CBFailureThreshold))
+	errs = append(errs, envDuration("CB_RECOVERY_TIMEOUT", &cfg.CBRecoveryTimeout))
+	return errors.Join(errs...)
+}
+
+// envString overrides dst when the variable is set
+func envString(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+// envList overrides dst with a comma-separated variable when it is set
+func envList(key string, dst *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	list := make([]string, 0)
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*dst = list
+}
+
+// envDuration overrides dst when the variable is set to a valid duration
+func envDuration(key string, dst *time.Duration) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("config: %s: invalid duration %q", key, v)
+	}
+	*dst = d
+	return nil
+}
+
+// envDurationMap overrides dst with a comma-separated list of key=duration
+// pairs when the variable is set
+func envDurationMap(key string, dst *map[string]time.Duration) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	m := make(map[string]time.Duration)
+	for _, pair := range strings.Split(v, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		k, d, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("config: %s: invalid pair %q, want key=duration", key, pair)
+		}
+		dur, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return fmt.Errorf("config: %s: invalid duration %q", key, d)
+		}
+		m[strings.TrimSpace(k)] = dur
+	}
+	*dst = m
+	return nil
+}
+
+// envInt overrides dst when the variable is set to a valid integer
+func envInt(key string, dst *int) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("config: %s: invalid integer %q", key, v)
+	}
+	*dst = n
+	return nil
+}
+
+// envInt64 overrides dst when the variable is set to a valid integer
+func envInt64(key string, dst *int64) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("config: %s: invalid integer %q", key, v)
+	}
+	*dst = n
+	return nil
+}
+
+// envBool overrides dst when the variable is set to a valid boolean
+func envBool(key string, dst *bool) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("config: %s: invalid boolean %q", key, v)
+	}
+	*dst = b
+	return nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "port: 9000\nlog_level: debug\nlong_poll_timeout: 5s\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("PORT", "9100")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Port != 9100 {
+		t.Errorf("Port = %d, want 9100 from the environment over the file", cfg.Port)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Errorf("LogLevel = %q, want debug from the file", cfg.LogLevel)
+	}
+	if cfg.LongPollTimeout != 5*time.Second {
+		t.Errorf("LongPollTimeout = %s, want 5s from the file", cfg.LongPollTimeout)
+	}
+	if cfg.StorageBackend != "memory" {
+		t.Errorf("StorageBackend = %q, want the default memory", cfg.StorageBackend)
+	}
+}
+
+func TestLoadReportsEveryBadVariable(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := Load(); err == nil {
+		t.Error("Load with a missing CONFIG_FILE succeeded")
+	}
+
+	t.Setenv("CONFIG_FILE", "")
+	t.Chdir(t.TempDir())
+	t.Setenv("PORT", "eighty")
+	t.Setenv("AUTH_ENABLED", "maybe")
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load with bad variables succeeded")
+	}
+	for _, key := range []string{"PORT", "AUTH_ENABLED"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q doesn't name %s", err, key)
+		}
+	}
+}
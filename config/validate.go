@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// LogLevels are the accepted values of LogLevel
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// Validate checks that every setting of c is usable and returns all the
+// problems found, joined into one error, or nil
+func Validate(c *Config) error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("config: "+format, args...))
+		}
+	}
+
+	check(c.Port >= 1 && c.Port <= 65535, "port %d must be between 1 and 65535", c.Port)
+	check(c.GRPCPort >= 0 && c.GRPCPort <= 65535, "grpc_port %d must be between 0 and 65535", c.GRPCPort)
+	check(c.GRPCPort == 0 || c.GRPCPort != c.Port, "grpc_port %d must differ from port", c.GRPCPort)
+	check(slices.Contains(LogLevels, c.LogLevel), "log_level %q must be one of %s", c.LogLevel, strings.Join(LogLevels, ", "))
+	check(c.BodyLogMaxBytes >= 0, "body_log_max_bytes %d must not be negative", c.BodyLogMaxBytes)
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "tls_cert_file and tls_key_file must be set together")
+	check(strings.HasPrefix(c.ReadinessPath, "/"), "readiness_path %q must start with /", c.ReadinessPath)
+
+	check(slices.Contains([]string{"memory", "sharded", "replicated", "eventsource", "bolt", "redis"}, c.StorageBackend), "storage_backend %q must be memory, sharded, replicated, eventsource, bolt or redis", c.StorageBackend)
+	check(c.ReplicationInterval >= 0, "replication_interval %s must not be negative", c.ReplicationInterval)
+	check(c.StorageBackend != "bolt" || c.BoltPath != "", "bolt_path is required by the bolt backend")
+	check(!c.ReadReplica || c.StorageBackend == "bolt", "read_replica needs the bolt backend")
+	check(c.StoreMaxItems >= 0, "store_max_items %d must not be negative", c.StoreMaxItems)
+	check(slices.Contains([]string{"oldest", "lru", "none"}, c.StoreEvictionPolicy), "store_eviction_policy %q must be oldest, lru or none", c.StoreEvictionPolicy)
+	check(c.CacheSize >= 0, "cache_size %d must not be negative", c.CacheSize)
+	check(c.MaxBodySizeBytes > 0, "max_body_size_bytes %d must be positive", c.MaxBodySizeBytes)
+
+	check(c.LongPollTimeout > 0, "long_poll_timeout %s must be positive", c.LongPollTimeout)
+	check(c.ReservationTTL > 0, "reservation_ttl %s must be positive", c.ReservationTTL)
+	check(c.ExportWorkers > 0, "export_workers %d must be positive", c.ExportWorkers)
+	check(c.ImportWorkers > 0, "import_workers %d must be positive", c.ImportWorkers)
+	check(c.MaxHeapMB > 0, "max_heap_mb %d must be positive", c.MaxHeapMB)
+	check(c.MaxGoroutines > 0, "max_goroutines %d must be positive", c.MaxGoroutines)
+	for route, limit := range c.SLOLimits {
+		check(limit > 0, "slo_limits %q: %s must be positive", route, limit)
+	}
+
+	check(slices.Contains([]string{"", "memory", "redis"}, c.RateLimitBackend), "rate_limit_backend %q must be memory, redis or empty", c.RateLimitBackend)
+	check(c.RateLimitRPS > 0, "rate_limit_rps %d must be positive", c.RateLimitRPS)
+	check(c.RateLimitBurst > 0, "rate_limit_burst %d must be positive", c.RateLimitBurst)
+	check(!c.RateLimitPerUser || c.RateLimitBackend != "", "rate_limit_per_user needs a rate_limit_backend for unauthenticated requests")
+	check(slices.Contains([]string{"", "memory", "redis"}, c.ResponseCacheBackend), "response_cache_backend %q must be memory, redis or empty", c.ResponseCacheBackend)
+	check(c.ResponseCacheBackend == "" || c.ResponseCacheTTL > 0, "response_cache_ttl %s must be positive", c.ResponseCacheTTL)
+	check(slices.Contains([]string{"", "memory", "redis"}, c.SessionBackend), "session_backend %q must be memory, redis or empty", c.SessionBackend)
+	check(c.SessionBackend == "" || c.SessionTTL > 0, "session_ttl %s must be positive", c.SessionTTL)
+	check(c.TokenTTL > 0, "token_ttl %s must be positive", c.TokenTTL)
+	check(slices.Contains([]string{"", "memory", "redis"}, c.TokenBlocklistBackend), "token_blocklist_backend %q must be memory, redis or empty", c.TokenBlocklistBackend)
+
+	check(c.QueueMaxWorkers >= 0, "queue_max_workers %d must not be negative", c.QueueMaxWorkers)
+	check(c.QueueMaxSize >= 0, "queue_max_size %d must not be negative", c.QueueMaxSize)
+	check(c.QueueMaxWorkers == 0 || c.QueueTimeout > 0, "queue_timeout %s must be positive", c.QueueTimeout)
+	check(c.CBFailureThreshold > 0, "cb_failure_threshold %d must be positive", c.CBFailureThreshold)
+	check(c.CBRecoveryTimeout >= 0, "cb_recovery_timeout %s must not be negative", c.CBRecoveryTimeout)
+
+	return errors.Join(errs...)
+}
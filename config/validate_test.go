@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	if err := Validate(Default()); err != nil {
+		t.Errorf("the defaults don't validate: %v", err)
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*Config)
+		want   []string
+	}{
+		{"port out of range", func(c *Config) { c.Port = 70000 }, []string{"port 70000"}},
+		{"zero port", func(c *Config) { c.Port = 0 }, []string{"port 0"}},
+		{"unknown log level", func(c *Config) { c.LogLevel = "verbose" }, []string{`log_level "verbose"`}},
+		{"zero rate limit", func(c *Config) { c.RateLimitRPS = 0 }, []string{"rate_limit_rps 0"}},
+		{"half a TLS pair", func(c *Config) { c.TLSCertFile = "cert.pem" }, []string{"tls_cert_file and tls_key_file"}},
+		{"bolt without a path", func(c *Config) { c.StorageBackend, c.BoltPath = "bolt", "" }, []string{"bolt_path"}},
+		{"everything at once", func(c *Config) {
+			c.Port = -1
+			c.LogLevel = ""
+			c.RateLimitRPS = -5
+			c.StorageBackend = "mongo"
+			c.LongPollTimeout = 0
+			c.QueueMaxWorkers, c.QueueTimeout = 4, -time.Second
+		}, []string{"port -1", `log_level ""`, "rate_limit_rps -5", `storage_backend "mongo"`, "long_poll_timeout 0s", "queue_timeout -1s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.change(c)
+			err := Validate(c)
+			if err == nil {
+				t.Fatal("Validate returned nil")
+			}
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.want) {
+				t.Errorf("got %d errors, want %d:\n%v", len(lines), len(tt.want), err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("no error about %s in:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// DefaultWatchInterval is how often a Watcher checks its file
+const DefaultWatchInterval = 5 * time.Second
+
+// Watcher reloads the configuration when its YAML file changes
+type Watcher struct {
+	path     string
+	interval time.Duration
+
+	// Changed receives the configuration each time it is reloaded. Only
+	// the latest is kept if the receiver falls behind; it is closed when
+	// Run returns.
+	Changed chan *Config
+}
+
+// NewWatcher creates a watcher checking the modification time of path
+// every interval
+func NewWatcher(path string, interval time.Duration) *Watcher {
+	return &Watcher{path: path, interval: interval, Changed: make(chan *Config, 1)}
+}
+
+// Run polls the file until ctx is done. A file that fails to load or
+// validate is logged and skipped, keeping the previous configuration.
+func (w *Watcher) Run(ctx context.Context) {
+	defer close(w.Changed)
+
+	last := w.modTime()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mod := w.modTime()
+		if mod.Equal(last) {
+			continue
+		}
+		last = mod
+
+		cfg, err := Load()
+		if err == nil {
+			err = Validate(cfg)
+		}
+		if err != nil {
+			log.Printf("WARN: config: reload %s: %v", w.path, err)
+			continue
+		}
+		// Replace a reload the receiver hasn't picked up yet
+		select {
+		case <-w.Changed:
+		default:
+		}
+		w.Changed <- cfg
+	}
+}
+
+func (w *Watcher) modTime() time.Time {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// FilePath returns the YAML file Load reads, or "" when there is none
+func FilePath() (string, error) {
+	return filePath()
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// rewrite replaces the file at path, moving its modification time forward
+// so the change is seen whatever the file system's time resolution
+func rewrite(t *testing.T, path, contents string, age time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(-age)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	rewrite(t, path, "rate_limit_rps: 5\n", time.Hour)
+	t.Setenv("CONFIG_FILE", path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewWatcher(path, 10*time.Millisecond)
+	go w.Run(ctx)
+
+	// A file that doesn't load is skipped, keeping the previous config
+	rewrite(t, path, "rate_limit_rps: [\n", 2*time.Minute)
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case cfg := <-w.Changed:
+		t.Fatalf("invalid file reloaded as %+v", cfg)
+	default:
+	}
+
+	rewrite(t, path, "rate_limit_rps: 50\n", time.Minute)
+	select {
+	case cfg := <-w.Changed:
+		if cfg.RateLimitRPS != 50 {
+			t.Errorf("RateLimitRPS = %d after reload, want 50", cfg.RateLimitRPS)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no reload after the file changed")
+	}
+
+	cancel()
+	select {
+	case _, open := <-w.Changed:
+		if open {
+			t.Error("reload after the file was unchanged")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Changed not closed after the context was cancelled")
+	}
+}
@@ -0,0 +1,45 @@
+// Package correlation carries the ID that ties together the requests of one
+// operation across services, and forwards it on outbound HTTP calls
+package correlation
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header is the HTTP header carrying the correlation ID
+const Header = "X-Correlation-ID"
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the correlation ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// IDFromContext returns the correlation ID stored by WithID, or "" when there
+// is none
+func IDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Transport is an http.RoundTripper that sets the Header of each outbound
+// request to the correlation ID of the request context
+type Transport struct {
+	// Base sends the requests; http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+// RoundTrip sends req with the correlation ID header added
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := IDFromContext(req.Context()); id != "" && req.Header.Get(Header) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(Header, id)
+	}
+	return base.RoundTrip(req)
+}
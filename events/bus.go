@@ -0,0 +1,139 @@
+package events
+
+import (
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types published for store mutations
+const (
+	Created = "created"
+	Updated = "updated"
+	Deleted = "deleted"
+)
+
+// Event describes a mutation of a stored record
+type Event struct {
+	Type   string    `json:"type"`
+	Entity string    `json:"entity"`
+	ID     string    `json:"id"`
+	Data   any       `json:"data,omitempty"`
+	Time   time.Time `json:"time"`
+	// CorrelationID is the correlation ID of the request that made the
+	// mutation, if any
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// TenantID is the tenant owning the record; subscribers only pass
+	// events on to callers of the same tenant
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// VisibleTo reports whether a caller of tenant tenantID may see e
+func (e Event) VisibleTo(tenantID string) bool {
+	return e.TenantID == tenantID
+}
+
+// CancelFunc ends a subscription and closes its channel
+type CancelFunc func()
+
+// subscriberBuffer is the number of events a slow subscriber can fall
+// behind before new events are dropped for it
+const subscriberBuffer = 16
+
+// Topic returns the topic that events of typ about entity are published
+// on, such as "item.created"
+func Topic(entity, typ string) string {
+	return entity + "." + typ
+}
+
+// subscriber is one subscription and the topic pattern it matches
+type subscriber struct {
+	pattern string
+	ch      chan Event
+}
+
+// matches reports whether the subscriber wants events on topic. Malformed
+// patterns match nothing.
+func (s subscriber) matches(topic string) bool {
+	ok, err := path.Match(s.pattern, topic)
+	return ok && err == nil
+}
+
+// Bus is an in-process publish/subscribe bus. Publishers send events on a
+// topic such as "item.created"; subscribers name a topic or a path.Match
+// pattern such as "item.*". It is safe for concurrent use.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    map[int]subscriber
+	next    int
+	dropped atomic.Uint64
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[int]subscriber),
+	}
+}
+
+// Subscribe registers a new subscriber to the topics matching pattern. The
+// returned CancelFunc must be called once the subscriber is done.
+func (b *Bus) Subscribe(pattern string) (<-chan Event, CancelFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	ch := make(chan Event, subscriberBuffer)
+	b.subs[id] = subscriber{pattern: pattern, ch: ch}
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs, id)
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// Publish delivers an event to every subscriber of topic without blocking.
+// Subscribers whose buffer is full miss the event, and it counts as dropped.
+func (b *Bus) Publish(topic string, e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subs {
+		if !sub.matches(topic) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// SubscriberCount returns how many subscribers receive events on topic
+func (b *Bus) SubscriberCount(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	n := 0
+	for _, sub := range b.subs {
+		if sub.matches(topic) {
+			n++
+		}
+	}
+	return n
+}
+
+// Dropped returns how many events were dropped because a subscriber fell
+// behind
+func (b *Bus) Dropped() uint64 {
+	return b.dropped.Load()
+}
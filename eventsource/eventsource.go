@@ -0,0 +1,105 @@
+// Package eventsource records every change to a resource as an event, and
+// derives the current state by replaying a resource's events in order.
+package eventsource
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrWrongVersion is returned by Append when the stream has moved past the
+// version the caller expected, because someone else appended first
+var ErrWrongVersion = errors.New("eventsource: stream version changed")
+
+// Event is one recorded change to the resource a stream belongs to
+type Event struct {
+	ID       string `json:"id"`
+	StreamID string `json:"stream_id"`
+	Type     string `json:"type"`
+	// Data is the payload of the event, whose shape depends on Type
+	Data json.RawMessage `json:"data"`
+	// Version numbers the events of a stream from 1
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// EventStore keeps append-only streams of events
+type EventStore interface {
+	// Append adds events to the stream, numbering them from
+	// expectedVersion+1. It returns ErrWrongVersion when the stream isn't at
+	// expectedVersion; a new stream is at version 0.
+	Append(streamID string, expectedVersion int, events []Event) error
+	// Load returns the events of the stream in order; an unknown stream
+	// has none
+	Load(streamID string) ([]Event, error)
+	// Streams returns the ID of every stream, in the order they were started
+	Streams() ([]string, error)
+}
+
+// MemoryEventStore implements EventStore in memory
+type MemoryEventStore struct {
+	mu      sync.RWMutex
+	streams map[string][]Event
+	order   []string
+}
+
+// NewMemoryEventStore creates an empty event store
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{streams: make(map[string][]Event)}
+}
+
+// Append implements EventStore. Events without an ID or an OccurredAt get
+// one.
+func (s *MemoryEventStore) Append(streamID string, expectedVersion int, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, exists := s.streams[streamID]
+	if len(stream) != expectedVersion {
+		return fmt.Errorf("%w: %s is at %d, not %d", ErrWrongVersion, streamID, len(stream), expectedVersion)
+	}
+
+	now := time.Now()
+	for i, e := range events {
+		if e.ID == "" {
+			e.ID = uuid.New().String()
+		}
+		if e.OccurredAt.IsZero() {
+			e.OccurredAt = now
+		}
+		e.StreamID = streamID
+		e.Version = expectedVersion + i + 1
+		stream = append(stream, e)
+	}
+	s.streams[streamID] = stream
+	if !exists {
+		s.order = append(s.order, streamID)
+	}
+	return nil
+}
+
+// Load implements EventStore
+func (s *MemoryEventStore) Load(streamID string) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.streams[streamID]), nil
+}
+
+// Streams implements EventStore
+func (s *MemoryEventStore) Streams() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.order), nil
+}
@@ -0,0 +1,127 @@
+package eventsource
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"go-api/models"
+)
+
+// Item event types
+const (
+	// ItemCreated carries the whole new item. It also starts a deleted
+	// item over, as when a snapshot is restored.
+	ItemCreated = "ItemCreated"
+	// ItemUpdated carries the whole item after the update
+	ItemUpdated = "ItemUpdated"
+	// ItemQuantityChanged carries only the new quantity, for updates that
+	// change nothing else
+	ItemQuantityChanged = "ItemQuantityChanged"
+	// ItemDeleted carries no data
+	ItemDeleted = "ItemDeleted"
+)
+
+// quantityChange is the data of an ItemQuantityChanged event
+type quantityChange struct {
+	Quantity  int       `json:"quantity"`
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ItemAggregate is the state of one item, built by replaying its events
+type ItemAggregate struct {
+	Item models.Item
+	// Version is the version of the last event applied, which Append
+	// expects when recording the next one
+	Version int
+	// Exists is set once the item was created, and Deleted once it was
+	// deleted since
+	Exists  bool
+	Deleted bool
+}
+
+// ReplayItem builds the state of an item from its events
+func ReplayItem(events []Event) (*ItemAggregate, error) {
+	agg := &ItemAggregate{}
+	for _, e := range events {
+		if err := agg.Apply(e); err != nil {
+			return nil, err
+		}
+	}
+	return agg, nil
+}
+
+// Live reports whether the item exists and isn't deleted
+func (a *ItemAggregate) Live() bool {
+	return a.Exists && !a.Deleted
+}
+
+// Apply moves the state on by one event
+func (a *ItemAggregate) Apply(e Event) error {
+	switch e.Type {
+	case ItemCreated:
+		var item models.Item
+		if err := json.Unmarshal(e.Data, &item); err != nil {
+			return fmt.Errorf("eventsource: %s %d: %w", e.StreamID, e.Version, err)
+		}
+		a.Item, a.Exists, a.Deleted = item, true, false
+	case ItemUpdated:
+		if !a.Live() {
+			return fmt.Errorf("eventsource: %s %d: %s of a missing item", e.StreamID, e.Version, e.Type)
+		}
+		var item models.Item
+		if err := json.Unmarshal(e.Data, &item); err != nil {
+			return fmt.Errorf("eventsource: %s %d: %w", e.StreamID, e.Version, err)
+		}
+		a.Item = item
+	case ItemQuantityChanged:
+		if !a.Live() {
+			return fmt.Errorf("eventsource: %s %d: %s of a missing item", e.StreamID, e.Version, e.Type)
+		}
+		var change quantityChange
+		if err := json.Unmarshal(e.Data, &change); err != nil {
+			return fmt.Errorf("eventsource: %s %d: %w", e.StreamID, e.Version, err)
+		}
+		a.Item.Quantity, a.Item.Version, a.Item.UpdatedAt = change.Quantity, change.Version, change.UpdatedAt
+	case ItemDeleted:
+		a.Deleted = true
+	default:
+		return fmt.Errorf("eventsource: %s %d: unknown event type %q", e.StreamID, e.Version, e.Type)
+	}
+	a.Version = e.Version
+	return nil
+}
+
+// itemEvent returns an event of type carrying data
+func itemEvent(eventType string, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("eventsource: encode %s: %w", eventType, err)
+	}
+	return Event{Type: eventType, Data: raw}, nil
+}
+
+// updateEvent returns the event recording the update of old to updated: an
+// ItemQuantityChanged when only the quantity changed, or else an ItemUpdated
+func updateEvent(old, updated models.Item) (Event, error) {
+	same := updated
+	same.Quantity, same.Version, same.UpdatedAt = old.Quantity, old.Version, old.UpdatedAt
+	if old.Quantity != updated.Quantity && sameItem(old, same) {
+		return itemEvent(ItemQuantityChanged, quantityChange{
+			Quantity:  updated.Quantity,
+			Version:   updated.Version,
+			UpdatedAt: updated.UpdatedAt,
+		})
+	}
+	return itemEvent(ItemUpdated, updated)
+}
+
+// sameItem reports whether a and b hold the same fields
+func sameItem(a, b models.Item) bool {
+	return a.ID == b.ID && a.Name == b.Name && a.Description == b.Description &&
+		a.Status == b.Status && a.Quantity == b.Quantity && a.ClientID == b.ClientID &&
+		slices.Equal(a.Tags, b.Tags) && a.TenantID == b.TenantID && a.Version == b.Version &&
+		a.CreatedAt.Equal(b.CreatedAt) && a.UpdatedAt.Equal(b.UpdatedAt)
+}
@@ -0,0 +1,118 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+func TestReplayHundredEvents(t *testing.T) {
+	events := NewMemoryEventStore()
+	store := NewEventSourcedItemStore(events)
+
+	created := store.Create(models.Item{Name: "Widget", Quantity: 1})
+	// 98 updates: every other one changes only the quantity
+	for i := range 98 {
+		current, _ := store.GetByID(created.ID)
+		next := current
+		next.Quantity = current.Quantity + 1
+		if i%2 == 1 {
+			next.Name = fmt.Sprintf("Widget %d", i)
+			next.Tags = []string{fmt.Sprintf("round-%d", i)}
+		}
+		if _, err := store.Update(created.ID, next); err != nil {
+			t.Fatalf("update %d: %v", i, err)
+		}
+	}
+
+	stream, err := events.Load(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stream) != 99 {
+		t.Fatalf("%d events recorded, want 99", len(stream))
+	}
+	counts := make(map[string]int)
+	for i, e := range stream {
+		if e.Version != i+1 || e.StreamID != created.ID {
+			t.Fatalf("event %d has version %d of stream %q", i, e.Version, e.StreamID)
+		}
+		counts[e.Type]++
+	}
+	if counts[ItemCreated] != 1 || counts[ItemQuantityChanged] != 49 || counts[ItemUpdated] != 49 {
+		t.Errorf("event types %v, want 1 created, 49 quantity changes and 49 updates", counts)
+	}
+
+	item, ok := store.GetByID(created.ID)
+	if !ok {
+		t.Fatal("item missing after 99 events")
+	}
+	if item.Quantity != 99 || item.Name != "Widget 97" || len(item.Tags) != 1 || item.Tags[0] != "round-97" || item.Version != 99 {
+		t.Errorf("replayed %+v, want quantity 99, name Widget 97, tag round-97 and version 99", item)
+	}
+	if !item.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("created at %v, want %v", item.CreatedAt, created.CreatedAt)
+	}
+
+	// The 100th event deletes the item, and replay agrees
+	if !store.Delete(created.ID) {
+		t.Fatal("delete failed")
+	}
+	stream, _ = events.Load(created.ID)
+	agg, err := ReplayItem(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stream) != 100 || agg.Version != 100 || agg.Live() {
+		t.Errorf("after %d events: version %d, live %v; want 100, 100, false", len(stream), agg.Version, agg.Live())
+	}
+	if _, ok := store.GetByID(created.ID); ok {
+		t.Error("deleted item still read")
+	}
+	if n := len(store.GetAll()); n != 0 {
+		t.Errorf("GetAll returned %d items, want none", n)
+	}
+}
+
+func TestReplayRejectsInvalidStreams(t *testing.T) {
+	created, _ := itemEvent(ItemCreated, models.Item{ID: "a", Name: "Widget"})
+	updated, _ := itemEvent(ItemUpdated, models.Item{ID: "a", Name: "Gadget"})
+	tests := []struct {
+		name   string
+		events []Event
+	}{
+		{"update before create", []Event{updated}},
+		{"update after delete", []Event{created, {Type: ItemDeleted}, updated}},
+		{"unknown type", []Event{created, {Type: "ItemRenamed"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReplayItem(tt.events); err == nil {
+				t.Error("replay succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestAppendExpectsVersion(t *testing.T) {
+	events := NewMemoryEventStore()
+	e := Event{Type: ItemDeleted}
+	if err := events.Append("a", 0, []Event{e, e}); err != nil {
+		t.Fatal(err)
+	}
+	if err := events.Append("a", 1, []Event{e}); !errors.Is(err, ErrWrongVersion) {
+		t.Errorf("append at a stale version: %v, want ErrWrongVersion", err)
+	}
+	if err := events.Append("a", 2, []Event{e}); err != nil {
+		t.Errorf("append at the current version: %v", err)
+	}
+
+	store := NewEventSourcedItemStore(events)
+	item := store.Create(models.Item{Name: "Widget"})
+	if _, err := store.Update(item.ID, models.Item{Name: "Gadget", Version: 7}); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Errorf("update with a stale item version: %v, want ErrVersionConflict", err)
+	}
+}
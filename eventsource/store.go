@@ -0,0 +1,263 @@
+package eventsource
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"go-api/models"
+	"go-api/storage"
+
+	"github.com/google/uuid"
+)
+
+// EventSourcedItemStore implements storage.Store for items on top of an
+// EventStore. Writes append events to the item's stream, named by its ID,
+// and reads replay them, so every past state of an item stays on record.
+// Reads cost a replay of every event of the items they return.
+type EventSourcedItemStore struct {
+	events EventStore
+}
+
+// NewEventSourcedItemStore creates an item store recording in events
+func NewEventSourcedItemStore(events EventStore) *EventSourcedItemStore {
+	return &EventSourcedItemStore{events: events}
+}
+
+// load replays the stream of the item with ID id
+func (s *EventSourcedItemStore) load(id string) (*ItemAggregate, error) {
+	events, err := s.events.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	return ReplayItem(events)
+}
+
+// GetAll returns every item that isn't deleted
+func (s *EventSourcedItemStore) GetAll() []models.Item {
+	ids, err := s.events.Streams()
+	if err != nil {
+		log.Printf("WARN: event store: list streams: %v", err)
+		return []models.Item{}
+	}
+
+	items := make([]models.Item, 0, len(ids))
+	for _, id := range ids {
+		if item, ok := s.GetByID(id); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetSorted replays every live item and orders them by fields
+func (s *EventSourcedItemStore) GetSorted(fields []storage.SortField) []models.Item {
+	items := s.GetAll()
+	storage.SortRecords(items, fields)
+	return items
+}
+
+// GetByID replays the item with ID id
+func (s *EventSourcedItemStore) GetByID(id string) (models.Item, bool) {
+	agg, err := s.load(id)
+	if err != nil {
+		log.Printf("WARN: event store: replay %s: %v", id, err)
+		return models.Item{}, false
+	}
+	if !agg.Live() {
+		return models.Item{}, false
+	}
+	return agg.Item, true
+}
+
+// GetMany replays the items with the given IDs. IDs that don't exist are
+// absent from the result.
+func (s *EventSourcedItemStore) GetMany(ids []string) map[string]models.Item {
+	found := make(map[string]models.Item, len(ids))
+	for _, id := range ids {
+		if item, ok := s.GetByID(id); ok {
+			found[id] = item
+		}
+	}
+	return found
+}
+
+// Create records an ItemCreated event for a new item. A failure is logged
+// and the zero value returned; use TryCreate to get the error.
+func (s *EventSourcedItemStore) Create(data models.Item) models.Item {
+	created, err := s.TryCreate(data)
+	if err != nil {
+		log.Printf("WARN: event store: create: %v", err)
+	}
+	return created
+}
+
+// TryCreate records an ItemCreated event for a new item
+func (s *EventSourcedItemStore) TryCreate(data models.Item) (models.Item, error) {
+	now := time.Now()
+	data.ID = uuid.New().String()
+	if data.Status == "" {
+		data.Status = models.StatusDraft
+	}
+	data.Version = 1
+	data.CreatedAt = now
+	data.UpdatedAt = now
+
+	e, err := itemEvent(ItemCreated, data)
+	if err == nil {
+		err = s.events.Append(data.ID, 0, []Event{e})
+	}
+	if err != nil {
+		return models.Item{}, err
+	}
+	return data, nil
+}
+
+// CreateWithTTL returns storage.ErrTTLUnsupported; an event stream doesn't
+// expire
+func (s *EventSourcedItemStore) CreateWithTTL(data models.Item, ttl time.Duration) (models.Item, error) {
+	return models.Item{}, storage.ErrTTLUnsupported
+}
+
+// CreateMany creates each item in turn. Items that fail are logged and left
+// out of the result.
+func (s *EventSourcedItemStore) CreateMany(data []models.Item) []models.Item {
+	created := make([]models.Item, 0, len(data))
+	for _, item := range data {
+		item, err := s.TryCreate(item)
+		if err != nil {
+			log.Printf("WARN: event store: create many: %v", err)
+			continue
+		}
+		created = append(created, item)
+	}
+	return created
+}
+
+// Update records an ItemUpdated event, or an ItemQuantityChanged event when
+// only the quantity changed. It returns storage.ErrVersionConflict when
+// data carries a non-zero Version that isn't the current one.
+func (s *EventSourcedItemStore) Update(id string, data models.Item) (models.Item, error) {
+	for {
+		agg, err := s.load(id)
+		if err != nil {
+			return models.Item{}, err
+		}
+		if !agg.Live() {
+			return models.Item{}, storage.ErrNotFound
+		}
+		old := agg.Item
+		if data.Version != 0 && data.Version != old.Version {
+			return models.Item{}, storage.ErrVersionConflict
+		}
+
+		updated := data
+		updated.ID = id
+		updated.TenantID = old.TenantID
+		updated.Version = old.Version + 1
+		updated.CreatedAt = old.CreatedAt
+		updated.UpdatedAt = time.Now()
+
+		e, err := updateEvent(old, updated)
+		if err != nil {
+			return models.Item{}, err
+		}
+		err = s.events.Append(id, agg.Version, []Event{e})
+		if errors.Is(err, ErrWrongVersion) {
+			// Someone else wrote first; replay their change and try again
+			continue
+		}
+		if err != nil {
+			return models.Item{}, err
+		}
+		return updated, nil
+	}
+}
+
+// Delete records an ItemDeleted event
+func (s *EventSourcedItemStore) Delete(id string) bool {
+	_, deleted := s.DeleteAndReturn(id)
+	return deleted
+}
+
+// DeleteAndReturn records an ItemDeleted event and returns the item as it
+// was before
+func (s *EventSourcedItemStore) DeleteAndReturn(id string) (models.Item, bool) {
+	for {
+		agg, err := s.load(id)
+		if err != nil {
+			log.Printf("WARN: event store: replay %s: %v", id, err)
+			return models.Item{}, false
+		}
+		if !agg.Live() {
+			return models.Item{}, false
+		}
+		err = s.events.Append(id, agg.Version, []Event{{Type: ItemDeleted, Data: []byte("{}")}})
+		if errors.Is(err, ErrWrongVersion) {
+			continue
+		}
+		if err != nil {
+			log.Printf("WARN: event store: delete %s: %v", id, err)
+			return models.Item{}, false
+		}
+		return agg.Item, true
+	}
+}
+
+// Clear records an ItemDeleted event for every item. Their history is kept.
+func (s *EventSourcedItemStore) Clear() error {
+	return s.Replace(nil)
+}
+
+// Replace makes items the only live items, keeping their IDs and
+// timestamps: items not among them are deleted, and the others recorded
+// as created or updated. Their history is kept. It isn't atomic; a failure
+// part way leaves the items replaced so far.
+func (s *EventSourcedItemStore) Replace(items []models.Item) error {
+	keep := make(map[string]bool, len(items))
+	for _, item := range items {
+		keep[item.ID] = true
+	}
+
+	ids, err := s.events.Streams()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if !keep[id] {
+			s.Delete(id)
+		}
+	}
+
+	for _, item := range items {
+		agg, err := s.load(item.ID)
+		if err != nil {
+			return err
+		}
+		eventType := ItemCreated
+		if agg.Live() {
+			eventType = ItemUpdated
+		}
+		e, err := itemEvent(eventType, item)
+		if err != nil {
+			return err
+		}
+		if err := s.events.Append(item.ID, agg.Version, []Event{e}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export writes every item to w; see storage.ExportRecords
+func (s *EventSourcedItemStore) Export(ctx context.Context, w io.Writer) error {
+	return storage.ExportRecords[models.Item](ctx, s, w)
+}
+
+// Import replaces the items with those read from r; see
+// storage.ImportRecords
+func (s *EventSourcedItemStore) Import(ctx context.Context, r io.Reader) error {
+	return storage.ImportRecords[models.Item](ctx, s, r)
+}
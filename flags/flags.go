@@ -0,0 +1,110 @@
+package flags
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Flags gating experimental endpoints
+const (
+	WebSocket = "websocket"
+)
+
+// Known lists every flag checked by the application
+var Known = []string{WebSocket}
+
+// FlagStore reports whether a feature flag is enabled
+type FlagStore interface {
+	IsEnabled(flag string) bool
+}
+
+// EnvFlagStore reads flags from FEATURE_<FLAG_NAME>=true environment variables
+type EnvFlagStore struct{}
+
+// IsEnabled implements FlagStore
+func (EnvFlagStore) IsEnabled(flag string) bool {
+	return os.Getenv(EnvName(flag)) == "true"
+}
+
+// EnvName returns the environment variable for a flag, e.g.
+// "new_search" becomes FEATURE_NEW_SEARCH
+func EnvName(flag string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, flag)
+	return "FEATURE_" + name
+}
+
+// fileFormat is the layout of a flags file: {"flags": {"new_search": true}}
+type fileFormat struct {
+	Flags map[string]bool `json:"flags"`
+}
+
+// FileStore reads flags from a JSON file, reloading it whenever its
+// modification time changes
+type FileStore struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	flags   map[string]bool
+}
+
+// NewFileStore loads the flags file at path
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// IsEnabled implements FlagStore
+func (s *FileStore) IsEnabled(flag string) bool {
+	if err := s.reload(); err != nil {
+		// Keep serving the last good flags
+		log.Printf("flags: reload %s: %v", s.path, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flags[flag]
+}
+
+// reload rereads the file if it changed since the last load
+func (s *FileStore) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var f fileFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	s.flags = f.Flags
+	s.modTime = info.ModTime()
+	return nil
+}
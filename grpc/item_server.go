@@ -0,0 +1,164 @@
+// Package grpc serves the item store over gRPC, next to the HTTP API
+package grpc
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"go-api/models"
+	"go-api/proto/apipb"
+	"go-api/storage"
+	"go-api/validation"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ItemServer implements apipb.ItemServiceServer over an item store
+type ItemServer struct {
+	apipb.UnimplementedItemServiceServer
+	store storage.Store[models.Item]
+	// validate checks items before they are stored
+	validate func(models.Item) error
+}
+
+// NewItemServer creates an ItemServer
+func NewItemServer(store storage.Store[models.Item]) *ItemServer {
+	return &ItemServer{store: store, validate: validation.Item}
+}
+
+// SetValidator replaces validation.Item as the check of items before they
+// are stored
+func (s *ItemServer) SetValidator(validate func(models.Item) error) {
+	s.validate = validate
+}
+
+// storeFor returns the store view for the caller of ctx
+func (s *ItemServer) storeFor(ctx context.Context) storage.Store[models.Item] {
+	if sc, ok := s.store.(storage.Scoper[models.Item]); ok {
+		return sc.For(ctx)
+	}
+	return s.store
+}
+
+// GetItem returns the item with the requested ID
+func (s *ItemServer) GetItem(ctx context.Context, req *apipb.GetItemRequest) (*apipb.Item, error) {
+	item, exists := s.storeFor(ctx).GetByID(req.GetId())
+	if !exists {
+		return nil, status.Error(codes.NotFound, "item not found")
+	}
+	return toProto(item), nil
+}
+
+// ListItems returns a page of items in creation order
+func (s *ItemServer) ListItems(ctx context.Context, req *apipb.ListItemsRequest) (*apipb.ListItemsResponse, error) {
+	if req.GetOffset() < 0 || req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset and limit must not be negative")
+	}
+
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = math.MaxInt32
+	}
+	page, total := storage.GetPage(s.storeFor(ctx), int(req.GetOffset()), limit)
+	resp := &apipb.ListItemsResponse{Items: make([]*apipb.Item, len(page)), Total: int32(total)}
+	for i, item := range page {
+		resp.Items[i] = toProto(item)
+	}
+	return resp, nil
+}
+
+// CreateItem validates and stores a new item
+func (s *ItemServer) CreateItem(ctx context.Context, req *apipb.CreateItemRequest) (*apipb.Item, error) {
+	item := fromProto(req.GetItem())
+	if err := s.validate(item); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	created, err := s.storeFor(ctx).TryCreate(item)
+	if err != nil {
+		if errors.Is(err, storage.ErrStoreFull) {
+			return nil, status.Error(codes.ResourceExhausted, "store is full")
+		}
+		return nil, status.Error(codes.Internal, "failed to create item")
+	}
+	return toProto(created), nil
+}
+
+// UpdateItem replaces an item, following the same status transitions as
+// PUT /items/{id}
+func (s *ItemServer) UpdateItem(ctx context.Context, req *apipb.UpdateItemRequest) (*apipb.Item, error) {
+	item := fromProto(req.GetItem())
+	if err := s.validate(item); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	store := s.storeFor(ctx)
+	current, exists := store.GetByID(req.GetId())
+	if !exists {
+		return nil, status.Error(codes.NotFound, "item not found")
+	}
+
+	// Status only changes through a valid transition; omitting it keeps the current one
+	if item.Status == "" {
+		item.Status = current.Status
+	} else if item.Status != current.Status {
+		if err := models.ValidateTransition(current.Status, item.Status); err != nil {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+	}
+	// Pinning the version read above makes a concurrent change abort the
+	// update instead of being overwritten
+	if item.Version == 0 {
+		item.Version = current.Version
+	}
+
+	updated, err := store.Update(req.GetId(), item)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return nil, status.Error(codes.NotFound, "item not found")
+	case errors.Is(err, storage.ErrVersionConflict):
+		return nil, status.Error(codes.Aborted, "item was modified by another request")
+	case err != nil:
+		return nil, status.Error(codes.Internal, "failed to update item")
+	}
+	return toProto(updated), nil
+}
+
+// DeleteItem removes an item
+func (s *ItemServer) DeleteItem(ctx context.Context, req *apipb.DeleteItemRequest) (*emptypb.Empty, error) {
+	if !s.storeFor(ctx).Delete(req.GetId()) {
+		return nil, status.Error(codes.NotFound, "item not found")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// toProto converts a stored item to its wire form
+func toProto(item models.Item) *apipb.Item {
+	return &apipb.Item{
+		Id:          item.ID,
+		Name:        item.Name,
+		Description: item.Description,
+		Status:      item.Status,
+		ClientId:    item.ClientID,
+		TenantId:    item.TenantID,
+		Version:     int64(item.Version),
+		CreatedAt:   timestamppb.New(item.CreatedAt),
+		UpdatedAt:   timestamppb.New(item.UpdatedAt),
+	}
+}
+
+// fromProto converts a request item to a models.Item. Server fields other
+// than the version are left for the store to set.
+func fromProto(item *apipb.Item) models.Item {
+	return models.Item{
+		Name:        item.GetName(),
+		Description: item.GetDescription(),
+		Status:      item.GetStatus(),
+		ClientID:    item.GetClientId(),
+		Version:     int(item.GetVersion()),
+	}
+}
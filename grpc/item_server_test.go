@@ -0,0 +1,71 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"go-api/models"
+	"go-api/proto/apipb"
+	"go-api/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// racingStore changes a record right after it is read, as a concurrent
+// call would between UpdateItem's read and its update
+type racingStore struct {
+	storage.Store[models.Item]
+}
+
+func (s racingStore) GetByID(id string) (models.Item, bool) {
+	item, exists := s.Store.GetByID(id)
+	if exists {
+		changed := item
+		changed.Name = "changed concurrently"
+		s.Store.Update(id, changed)
+	}
+	return item, exists
+}
+
+func TestUpdateItemWithoutVersionAborts(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	item := store.Create(models.Item{Name: "widget", Quantity: 1, Status: models.StatusDraft})
+	s := NewItemServer(racingStore{store})
+
+	_, err := s.UpdateItem(context.Background(), &apipb.UpdateItemRequest{
+		Id:   item.ID,
+		Item: &apipb.Item{Name: "gadget"},
+	})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("UpdateItem error = %v, want Aborted", err)
+	}
+	if got, _ := store.GetByID(item.ID); got.Name != "changed concurrently" {
+		t.Errorf("name = %q, want the concurrent change kept", got.Name)
+	}
+}
+
+func TestUpdateItemWithVersion(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	item := store.Create(models.Item{Name: "widget", Quantity: 1, Status: models.StatusDraft})
+	s := NewItemServer(store)
+
+	updated, err := s.UpdateItem(context.Background(), &apipb.UpdateItemRequest{
+		Id:   item.ID,
+		Item: &apipb.Item{Name: "gadget", Version: int64(item.Version)},
+	})
+	if err != nil {
+		t.Fatalf("UpdateItem: %v", err)
+	}
+	if updated.GetName() != "gadget" || updated.GetVersion() != int64(item.Version+1) {
+		t.Errorf("updated = %v, want gadget at version %d", updated, item.Version+1)
+	}
+
+	_, err = s.UpdateItem(context.Background(), &apipb.UpdateItemRequest{
+		Id:   item.ID,
+		Item: &apipb.Item{Name: "stale", Version: int64(item.Version)},
+	})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("stale UpdateItem error = %v, want Aborted", err)
+	}
+}
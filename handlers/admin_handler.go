@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go-api/flags"
+	"go-api/logger"
+	"go-api/middleware"
+	"go-api/models"
+	"go-api/response"
+	"go-api/storage"
+
+	"github.com/google/uuid"
+)
+
+// snapshot is the document produced by Snapshot and accepted by Restore
+type snapshot struct {
+	Items   []models.Item   `json:"items"`
+	Clients []models.Client `json:"clients"`
+}
+
+// AdminHandler handles HTTP requests for administrative operations
+type AdminHandler struct {
+	itemStore   storage.Store[models.Item]
+	clientStore storage.Store[models.Client]
+	flags       flags.FlagStore
+	copier      StoreCopier
+}
+
+// StoreCopyRequest is the body of POST /admin/stores/copy, naming the
+// backend to copy the active stores to
+type StoreCopyRequest struct {
+	Backend  string `json:"backend"`
+	BoltPath string `json:"bolt_path,omitempty"`
+	RedisURL string `json:"redis_url,omitempty"`
+}
+
+// StoreCopier copies every active store to the backend of req, returning
+// the number of records copied per store. Errors wrapping ErrCopyTarget
+// mean the target was refused.
+type StoreCopier func(ctx context.Context, req StoreCopyRequest) (map[string]int, error)
+
+// ErrCopyTarget is returned by a StoreCopier refusing the target backend
+var ErrCopyTarget = errors.New("invalid copy target")
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(itemStore storage.Store[models.Item], clientStore storage.Store[models.Client], flagStore flags.FlagStore) *AdminHandler {
+	return &AdminHandler{itemStore: itemStore, clientStore: clientStore, flags: flagStore}
+}
+
+// SetStoreCopier sets the copier behind POST /admin/stores/copy
+func (h *AdminHandler) SetStoreCopier(copier StoreCopier) {
+	h.copier = copier
+}
+
+// Flags handles GET /admin/flags
+func (h *AdminHandler) Flags(w http.ResponseWriter, r *http.Request) {
+	state := make(map[string]bool, len(flags.Known))
+	for _, flag := range flags.Known {
+		state[flag] = h.flags.IsEnabled(flag)
+	}
+	response.Encode(r.Context(), w, map[string]any{"flags": state})
+}
+
+// entityStats is one entry of the Stats response
+type entityStats struct {
+	Entity string `json:"entity"`
+	storage.Stats
+}
+
+// Stats handles GET /admin/stats
+func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	response.Encode(r.Context(), w, []entityStats{
+		{Entity: "items", Stats: storage.StatsOf(h.itemStore)},
+		{Entity: "clients", Stats: storage.StatsOf(h.clientStore)},
+	})
+}
+
+// ClearItems handles DELETE /admin/items
+func (h *AdminHandler) ClearItems(w http.ResponseWriter, r *http.Request) {
+	clearStore(w, r, "items", h.itemStore)
+}
+
+// ClearClients handles DELETE /admin/clients
+func (h *AdminHandler) ClearClients(w http.ResponseWriter, r *http.Request) {
+	clearStore(w, r, "clients", h.clientStore)
+}
+
+// Snapshot handles GET /admin/snapshot
+func (h *AdminHandler) Snapshot(w http.ResponseWriter, r *http.Request) {
+	filename := fmt.Sprintf("snapshot-%s.json", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Hold both read locks so items and clients come from the same instant.
+	// Locks are always taken items first to avoid deadlocks.
+	storage.View(h.itemStore, func(items []models.Item) {
+		storage.View(h.clientStore, func(clients []models.Client) {
+			response.Encode(r.Context(), w, snapshot{Items: items, Clients: clients})
+		})
+	})
+}
+
+// Restore handles POST /admin/snapshot/restore
+func (h *AdminHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	var snap snapshot
+	if err := json.NewDecoder(r.Body).Decode(&snap); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := validateSnapshot(snap); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Warn("snapshot restored", "by", remoteIP(r), "items", len(snap.Items), "clients", len(snap.Clients))
+
+	previousItems := h.itemStore.GetAll()
+	if err := h.itemStore.Replace(snap.Items); err != nil {
+		log.Error("restore items", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to restore items"})
+		return
+	}
+	if err := h.clientStore.Replace(snap.Clients); err != nil {
+		log.Error("restore clients", "error", err)
+		if err := h.itemStore.Replace(previousItems); err != nil {
+			log.Error("restore: roll back items", "error", err)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to restore clients"})
+		return
+	}
+
+	response.Encode(r.Context(), w, map[string]int{"items": len(snap.Items), "clients": len(snap.Clients)})
+}
+
+// CopyStores handles POST /admin/stores/copy by copying every record of the
+// active stores to another backend, ready to switch STORAGE_BACKEND over.
+// The target's previous contents are replaced.
+func (h *AdminHandler) CopyStores(w http.ResponseWriter, r *http.Request) {
+	var req StoreCopyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	switch {
+	case req.Backend == "bolt" && req.BoltPath == "":
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "bolt_path is required for the bolt backend"})
+		return
+	case req.Backend == "redis" && req.RedisURL == "":
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "redis_url is required for the redis backend"})
+		return
+	case req.Backend != "bolt" && req.Backend != "redis":
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "backend must be bolt or redis"})
+		return
+	}
+
+	log := logger.FromContext(r.Context())
+	log.Warn("copying stores", "backend", req.Backend, "by", remoteIP(r))
+
+	counts, err := h.copier(r.Context(), req)
+	if errors.Is(err, ErrCopyTarget) {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Error("copy stores", "backend", req.Backend, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to copy stores"})
+		return
+	}
+
+	response.Encode(r.Context(), w, map[string]any{"backend": req.Backend, "copied": counts})
+}
+
+// validateSnapshot checks every record has a unique UUID and a creation time
+func validateSnapshot(snap snapshot) error {
+	if err := validateRecords("item", snap.Items, func(i models.Item) (string, time.Time) { return i.ID, i.CreatedAt }); err != nil {
+		return err
+	}
+	return validateRecords("client", snap.Clients, func(c models.Client) (string, time.Time) { return c.ID, c.CreatedAt })
+}
+
+func validateRecords[T any](kind string, records []T, fields func(T) (string, time.Time)) error {
+	seen := make(map[string]bool, len(records))
+	for i, record := range records {
+		id, created := fields(record)
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("%s %d: invalid id %q", kind, i, id)
+		}
+		if seen[id] {
+			return fmt.Errorf("%s %d: duplicate id %q", kind, i, id)
+		}
+		if created.IsZero() {
+			return fmt.Errorf("%s %d: created_at is required", kind, i)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func clearStore[T any](w http.ResponseWriter, r *http.Request, name string, store storage.Store[T]) {
+	log := logger.FromContext(r.Context())
+	log.Warn("store cleared", "store", name, "by", remoteIP(r))
+
+	if err := store.Clear(); err != nil {
+		log.Error("clear store", "store", name, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to clear " + name})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// remoteIP returns the IP address of the peer that sent the request
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// UserRateLimits handles GET /admin/ratelimit/users by listing the active
+// per-user rate limit buckets with the tokens they have left
+func UserRateLimits(limiter *middleware.UserRateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response.Encode(r.Context(), w, map[string]any{"users": limiter.Buckets()})
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go-api/blocklist"
+	"go-api/logger"
+	"go-api/middleware"
+	"go-api/response"
+)
+
+// AuthHandler handles HTTP requests about the caller's own credentials
+type AuthHandler struct {
+	tokens    *middleware.TokenIssuer
+	blocklist blocklist.Blocklist
+}
+
+// NewAuthHandler creates an auth handler issuing tokens with tokens and
+// revoking them in list. Either may be nil, leaving its route unserved.
+func NewAuthHandler(tokens *middleware.TokenIssuer, list blocklist.Blocklist) *AuthHandler {
+	return &AuthHandler{tokens: tokens, blocklist: list}
+}
+
+// tokenResponse is the body of a token issued by Issue
+type tokenResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Issue handles POST /auth/token by minting a bearer token for the
+// authenticated caller, carrying their sub and tenant_id claims and scopes
+func (h *AuthHandler) Issue(w http.ResponseWriter, r *http.Request) {
+	claims, _ := middleware.ClaimsFromContext(r.Context())
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		response.Encode(r.Context(), w, map[string]string{"error": "Authentication required"})
+		return
+	}
+
+	// Only the identity is copied; the new token gets its own jti and exp
+	issued := middleware.Claims{"sub": sub}
+	if tenantID, ok := claims["tenant_id"].(string); ok {
+		issued["tenant_id"] = tenantID
+	}
+	token, exp, err := h.tokens.Issue(issued, middleware.ScopesFromContext(r.Context()))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("issue token", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to issue token"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response.Encode(r.Context(), w, tokenResponse{Token: token, TokenType: "Bearer", ExpiresAt: exp.UTC()})
+}
+
+// expiryOf returns the exp claim of claims, a Unix time in seconds, and
+// false when it is missing
+func expiryOf(claims middleware.Claims) (time.Time, bool) {
+	switch exp := claims["exp"].(type) {
+	case float64:
+		return time.Unix(int64(exp), 0), true
+	case int64:
+		return time.Unix(exp, 0), true
+	case json.Number:
+		if secs, err := exp.Int64(); err == nil {
+			return time.Unix(secs, 0), true
+		}
+	case time.Time:
+		return exp, true
+	}
+	return time.Time{}, false
+}
+
+// Logout handles DELETE /auth/token by revoking the caller's token until it
+// expires
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	jti := middleware.TokenID(r)
+	claims, _ := middleware.ClaimsFromContext(r.Context())
+	exp, hasExp := expiryOf(claims)
+	if jti == "" || !hasExp {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "The credentials are not a token with jti and exp claims"})
+		return
+	}
+
+	if err := h.blocklist.Block(jti, exp); err != nil {
+		logger.FromContext(r.Context()).Error("revoke token", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to revoke token"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go-api/patch"
+)
+
+// maxBatchUpdate is the largest number of records one batch update may change
+const maxBatchUpdate = 10000
+
+// errBatchTooLarge stops a batch update that matches more than maxBatchUpdate records
+var errBatchTooLarge = fmt.Errorf("the filter matches more than %d records; narrow it down", maxBatchUpdate)
+
+// batchUpdateRequest is the body of a batch update: the fields a record must
+// equal to be changed, and the fields to set on it
+type batchUpdateRequest struct {
+	Filter map[string]any `json:"filter"`
+	Patch  map[string]any `json:"patch"`
+}
+
+// batchUpdateResult is the body returned by a batch update
+type batchUpdateResult struct {
+	UpdatedCount int      `json:"updated_count"`
+	IDs          []string `json:"ids"`
+}
+
+// check rejects empty requests and fields T doesn't have
+func (req batchUpdateRequest) check(known map[string]int) error {
+	if len(req.Filter) == 0 {
+		return errors.New("filter must name at least one field")
+	}
+	if len(req.Patch) == 0 {
+		return errors.New("patch must name at least one field")
+	}
+
+	var invalid []string
+	for name := range req.Filter {
+		if _, ok := known[name]; !ok {
+			invalid = append(invalid, name)
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("invalid filter fields: %s", strings.Join(invalid, ", "))
+	}
+
+	for name := range req.Patch {
+		if _, ok := known[name]; !ok || serverFields[name] || name == "version" {
+			invalid = append(invalid, name)
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("invalid patch fields: %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// fieldsOf returns the JSON fields of record, decoded the same way as a
+// request body so they compare equal to filter values
+func fieldsOf(record any) map[string]any {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// matches reports whether every filter field of record equals its filter value
+func matches(record any, filter map[string]any) bool {
+	fields := fieldsOf(record)
+	for name, want := range filter {
+		if !reflect.DeepEqual(fields[name], want) {
+			return false
+		}
+	}
+	return true
+}
+
+// patched returns record with the patch fields merged in as a JSON Merge
+// Patch
+func patched[T any](record T, fields map[string]any) (T, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return patch.Apply(record, data)
+}
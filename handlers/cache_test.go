@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+func TestListNotModified(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	// Keep the stored items a minute old, so a new one is a second later
+	// at HTTP date precision
+	then := time.Now().Add(-time.Minute)
+	store.Replace([]models.Item{{ID: "a", Name: "widget", Version: 1, CreatedAt: then, UpdatedAt: then}})
+	h := NewItemHandler(store, nil)
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		h.GetAll(w, r)
+		return w
+	}
+
+	first := get("", "")
+	lastModified := first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || lastModified != then.UTC().Format(http.TimeFormat) {
+		t.Fatalf("status %d, Last-Modified %q; want 200 at %s", first.Code, lastModified, then.UTC().Format(http.TimeFormat))
+	}
+
+	cached := get("If-Modified-Since", lastModified)
+	if cached.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", cached.Code)
+	}
+	if cached.Body.Len() != 0 {
+		t.Errorf("304 has a body: %q", cached.Body)
+	}
+	for header, want := range map[string]string{
+		"Last-Modified": lastModified,
+		"ETag":          first.Header().Get("ETag"),
+		"Cache-Control": "max-age=0, must-revalidate",
+	} {
+		if got := cached.Header().Get(header); got != want || got == "" {
+			t.Errorf("304 %s = %q, want %q", header, got, want)
+		}
+	}
+	if etag := get("If-None-Match", first.Header().Get("ETag")); etag.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match of the current ETag: status %d, want 304", etag.Code)
+	}
+
+	store.Create(models.Item{Name: "gadget"})
+	if changed := get("If-Modified-Since", lastModified); changed.Code != http.StatusOK || changed.Body.Len() == 0 {
+		t.Errorf("after a create: status %d with %d bytes, want 200 with the list", changed.Code, changed.Body.Len())
+	}
+	if changed := get("If-None-Match", first.Header().Get("ETag")); changed.Code != http.StatusOK {
+		t.Errorf("after a create, If-None-Match of the old ETag: status %d, want 200", changed.Code)
+	}
+}
+
+func TestListETagIsStable(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	for range 50 {
+		store.Create(models.Item{Name: "widget"})
+	}
+	h := NewItemHandler(store, nil)
+
+	etag := ""
+	for i := range 20 {
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		r.Header.Set("If-None-Match", etag)
+		w := httptest.NewRecorder()
+		h.GetAll(w, r)
+		if i == 0 {
+			etag = w.Header().Get("ETag")
+			continue
+		}
+		if w.Code != http.StatusNotModified {
+			t.Fatalf("request %d of an unchanged list: status %d, want 304", i+1, w.Code)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-api/changelog"
+	"go-api/response"
+)
+
+// Changelog handles GET /changelog, optionally with ?since=<version> to only
+// list the versions after it
+func Changelog(log changelog.Changelog) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw := r.URL.Query().Get("since")
+		if raw == "" {
+			response.Encode(r.Context(), w, log)
+			return
+		}
+
+		since, err := changelog.ParseVersion(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			response.Encode(r.Context(), w, map[string]string{"error": "since must be a version such as 1.2.0"})
+			return
+		}
+		response.Encode(r.Context(), w, log.Since(since))
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go-api/models"
+	"go-api/response"
+	"go-api/storage"
+)
+
+// domainCount is one entry of GET /clients/domains
+type domainCount struct {
+	Domain string `json:"domain"`
+	Count  int    `json:"count"`
+}
+
+// parseEmailDomain normalizes the domain of an email_domain query, and
+// returns an error describing why it doesn't look like a domain
+func parseEmailDomain(raw string) (string, error) {
+	domain := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case domain == "":
+		return "", errors.New("email_domain is required")
+	case strings.Contains(domain, "@"):
+		return "", errors.New("email_domain must be a domain such as company.com, without the part before '@'")
+	case !strings.Contains(domain, "."):
+		return "", errors.New("email_domain must contain a dot, such as company.com")
+	case len(domain) > 253:
+		return "", errors.New("email_domain must be at most 253 characters")
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" {
+			return "", errors.New("email_domain must not start or end with a dot or contain '..'")
+		}
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", errors.New("email_domain labels must not start or end with '-'")
+		}
+		if strings.IndexFunc(label, func(c rune) bool {
+			return !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-')
+		}) >= 0 {
+			return "", errors.New("email_domain may only contain letters, digits, '-' and '.'")
+		}
+	}
+	return domain, nil
+}
+
+// Search handles GET /clients/search?email_domain=company.com
+func (h *ClientHandler) Search(w http.ResponseWriter, r *http.Request) {
+	domain, err := parseEmailDomain(r.URL.Query().Get("email_domain"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	clients := storage.ByEmailDomain(h.storeFor(r), domain)
+	w.Header().Set("Accept-Ranges", rangeUnit)
+	if writeRangeOf(w, r, clients) {
+		return
+	}
+
+	page, _ := storage.PageOf(clients, 0, len(clients))
+	if page == nil {
+		page = make([]models.Client, 0)
+	}
+	writeList(w, r, page, storage.LastModified(h.storeFor(r)))
+}
+
+// Domains handles GET /clients/domains
+func (h *ClientHandler) Domains(w http.ResponseWriter, r *http.Request) {
+	counts := storage.EmailDomains(h.storeFor(r))
+	domains := make([]domainCount, 0, len(counts))
+	for domain, count := range counts {
+		domains = append(domains, domainCount{Domain: domain, Count: count})
+	}
+	slices.SortFunc(domains, func(a, b domainCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Domain, b.Domain)
+	})
+	response.Encode(r.Context(), w, domains)
+}
@@ -2,56 +2,220 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
+	"go-api/logger"
 	"go-api/models"
+	"go-api/patch"
+	"go-api/response"
+	"go-api/service"
 	"go-api/storage"
+	"go-api/validation"
 
 	"github.com/gorilla/mux"
 )
 
 // ClientHandler handles HTTP requests for clients
 type ClientHandler struct {
-	store storage.Store[models.Client]
+	store    storage.Store[models.Client]
+	contacts storage.Store[models.Contact]
+	service  *service.ClientService
+	urls     URLBuilder
+	// deleteReturnsBody makes Delete respond with the deleted client
+	deleteReturnsBody bool
+	// streamLists makes GetAll stream unsorted JSON lists
+	streamLists bool
 }
 
-// NewClientHandler creates a new client handler
-func NewClientHandler(store storage.Store[models.Client]) *ClientHandler {
-	return &ClientHandler{store: store}
+// clientWithContacts is a client with its contacts inlined
+type clientWithContacts struct {
+	models.Client
+	Contacts []models.Contact `json:"contacts"`
+}
+
+// NewClientHandler creates a new client handler. svc answers the list
+// filters that depend on items.
+func NewClientHandler(store storage.Store[models.Client], contacts storage.Store[models.Contact], svc *service.ClientService) *ClientHandler {
+	return &ClientHandler{store: store, contacts: contacts, service: svc}
+}
+
+// SetURLBuilder sets the function used to build Location headers
+func (h *ClientHandler) SetURLBuilder(urls URLBuilder) {
+	h.urls = urls
+}
+
+// SetDeleteReturnsBody makes Delete respond 200 with the deleted client
+// instead of 204
+func (h *ClientHandler) SetDeleteReturnsBody(on bool) {
+	h.deleteReturnsBody = on
+}
+
+// SetStreamLists makes GetAll write unsorted JSON lists as it reads the
+// store, without building the whole list or its ETag first
+func (h *ClientHandler) SetStreamLists(on bool) {
+	h.streamLists = on
+}
+
+// storeFor returns the store view for the caller of r
+func (h *ClientHandler) storeFor(r *http.Request) storage.Store[models.Client] {
+	if s, ok := h.store.(storage.Scoper[models.Client]); ok {
+		return s.For(r.Context())
+	}
+	return h.store
 }
 
 // GetAll handles GET /clients
 func (h *ClientHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	clients := h.store.GetAll()
-	json.NewEncoder(w).Encode(clients)
+	query := r.URL.Query()
+	if query.Has("has_items") || query.Has("item_status") {
+		h.getByItems(w, r)
+		return
+	}
+
+	if wantsNDJSON(r) {
+		writeNDJSON(w, r, h.storeFor(r))
+		return
+	}
+	w.Header().Set("Accept-Ranges", rangeUnit)
+	if writeRange(w, r, h.storeFor(r)) {
+		return
+	}
+
+	order, err := parseSort[models.Client](r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if order == nil && h.streamLists && wantsJSONStream(r) {
+		writeJSONStream(w, r, h.storeFor(r))
+		return
+	}
+
+	var clients []models.Client
+	if order != nil {
+		clients = h.storeFor(r).GetSorted(order)
+	} else {
+		clients = h.storeFor(r).GetAll()
+		// A fixed order keeps the ETag of an unchanged list the same
+		storage.SortByID(clients)
+	}
+	if wantsCSV(r) {
+		writeCSV(w, "", clients)
+		return
+	}
+
+	writeList(w, r, clients, storage.LastModified(h.storeFor(r)))
+}
+
+// getByItems handles GET /clients?has_items=true&item_status=active
+func (h *ClientHandler) getByItems(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	hasItems := true
+	if raw := query.Get("has_items"); raw != "" {
+		var err error
+		if hasItems, err = strconv.ParseBool(raw); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			response.Encode(r.Context(), w, map[string]string{"error": "has_items must be true or false"})
+			return
+		}
+	}
+	status := query.Get("item_status")
+	if status != "" && !models.ValidStatus(status) {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "invalid item_status '" + status + "'"})
+		return
+	}
+
+	clients := h.service.ByItems(r.Context(), hasItems, status)
+	w.Header().Set("Accept-Ranges", rangeUnit)
+	if writeRangeOf(w, r, clients) {
+		return
+	}
+
+	page, _ := storage.PageOf(clients, 0, len(clients))
+	if page == nil {
+		page = make([]models.Client, 0)
+	}
+	writeList(w, r, page, storage.LastModified(h.storeFor(r)))
+}
+
+// ExportCSV handles GET /clients/export.csv
+func (h *ClientHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	writeCSV(w, "clients.csv", h.storeFor(r).GetAll())
+}
+
+// ImportCSV handles POST /clients/import
+func (h *ClientHandler) ImportCSV(w http.ResponseWriter, r *http.Request) {
+	importCSV(w, r, h.storeFor(r), validation.Client)
 }
 
 // GetByID handles GET /clients/{id}
 func (h *ClientHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
-	client, exists := h.store.GetByID(id)
+	client, exists := h.storeFor(r).GetByID(id)
 
 	if !exists {
 		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Client not found"})
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
 		return
 	}
 
-	json.NewEncoder(w).Encode(client)
+	// Clients are usually fetched right before their items
+	if h.urls != nil {
+		if itemsURL, err := h.urls("clients.items", "id", id); err == nil {
+			response.Push(w, r, []string{itemsURL})
+		}
+	}
+
+	if r.URL.Query().Get("expand") == "contacts" {
+		response.Encode(r.Context(), w, clientWithContacts{Client: client, Contacts: contactsOf(scoped(h.contacts, r), id)})
+		return
+	}
+	response.Encode(r.Context(), w, client)
+}
+
+// GetBatch handles GET /clients/batch?ids=id1,id2
+func (h *ClientHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseIDs(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	found := h.storeFor(r).GetMany(ids)
+	response.Encode(r.Context(), w, batchResponse(ids, found))
 }
 
 // Create handles POST /clients
 func (h *ClientHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var client models.Client
 	if err := json.NewDecoder(r.Body).Decode(&client); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		writeDecodeError(w, r, err)
 		return
 	}
 
-	created := h.store.Create(client)
+	if err := validation.Client(client); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	// Balances start at zero and only change through the ledger; statuses
+	// start active and only change through the status endpoint
+	client.Balance = 0
+	client.Status = models.ClientActive
+	created, err := h.storeFor(r).TryCreate(client)
+	if err != nil {
+		writeCreateError(w, r, "client", err)
+		return
+	}
+	setLocation(w, h.urls, "clients.get", "id", created.ID)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(created)
+	response.Encode(r.Context(), w, created)
 }
 
 // Update handles PUT /clients/{id}
@@ -60,30 +224,117 @@ func (h *ClientHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	var client models.Client
 	if err := json.NewDecoder(r.Body).Decode(&client); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		writeDecodeError(w, r, err)
 		return
 	}
 
-	updated, exists := h.store.Update(id, client)
+	if err := validation.Client(client); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	// Keep the balance and status; pinning the version makes a concurrent
+	// credit, debit or status change fail the update with 409 instead of
+	// being overwritten
+	current, exists := h.storeFor(r).GetByID(id)
 	if !exists {
 		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Client not found"})
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
+		return
+	}
+	client.Balance = current.Balance
+	client.Status = current.Status
+	if client.Version == 0 {
+		client.Version = current.Version
+	}
+
+	updated, err := h.storeFor(r).Update(id, client)
+	if err != nil {
+		writeClientUpdateError(w, r, id, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(updated)
+	response.Encode(r.Context(), w, updated)
+}
+
+// Patch handles PATCH /clients/{id} with a JSON Merge Patch body. The
+// balance and status can't be patched; they change through the ledger and
+// status routes.
+func (h *ClientHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	body, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	current, exists := h.storeFor(r).GetByID(id)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
+		return
+	}
+
+	client, err := patch.Apply(current, body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "Invalid patch: " + err.Error()})
+		return
+	}
+	client.ID, client.TenantID, client.CreatedAt, client.UpdatedAt = current.ID, current.TenantID, current.CreatedAt, current.UpdatedAt
+	client.Balance, client.Status = current.Balance, current.Status
+	if err := validation.Client(client); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	// The version read above is kept unless the patch sets one, so a
+	// concurrent change fails the patch with 409
+	updated, err := h.storeFor(r).Update(id, client)
+	if err != nil {
+		writeClientUpdateError(w, r, id, err)
+		return
+	}
+
+	response.Encode(r.Context(), w, updated)
+}
+
+// writeClientUpdateError maps a Store.Update error to a response
+func writeClientUpdateError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
+	case errors.Is(err, storage.ErrVersionConflict):
+		w.WriteHeader(http.StatusConflict)
+		response.Encode(r.Context(), w, map[string]string{"error": "Client was modified by another request; fetch the latest version and retry"})
+	default:
+		logger.FromContext(r.Context()).Error("update client", "id", id, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to update client"})
+	}
 }
 
 // Delete handles DELETE /clients/{id}
 func (h *ClientHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 
-	if !h.store.Delete(id) {
+	deleted, exists := h.storeFor(r).DeleteAndReturn(id)
+	if !exists {
 		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Client not found"})
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
 		return
 	}
 
+	// Contacts don't outlive their client
+	contacts := scoped(h.contacts, r)
+	for _, c := range contactsOf(contacts, id) {
+		contacts.Delete(c.ID)
+	}
+
+	if h.deleteReturnsBody {
+		response.Encode(r.Context(), w, deleted)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
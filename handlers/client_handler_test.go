@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/models"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// pushedPaths sends GET path over a raw HTTP/2 connection to server that
+// accepts server push, and returns the paths the server promised to push
+// before the response ended
+func pushedPaths(t *testing.T, server *httptest.Server, path string) []string {
+	t.Helper()
+	conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		t.Fatal(err)
+	}
+	framer := http2.NewFramer(conn, conn)
+	if err := framer.WriteSettings(http2.Setting{ID: http2.SettingEnablePush, Val: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var block bytes.Buffer
+	enc := hpack.NewEncoder(&block)
+	for _, f := range [][2]string{{":method", "GET"}, {":scheme", "https"}, {":authority", server.Listener.Addr().String()}, {":path", path}} {
+		enc.WriteField(hpack.HeaderField{Name: f[0], Value: f[1]})
+	}
+	if err := framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: block.Bytes(), EndStream: true, EndHeaders: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var pushed []string
+	dec := hpack.NewDecoder(4096, nil)
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				framer.WriteSettingsAck()
+			}
+		case *http2.PushPromiseFrame:
+			fields, err := dec.DecodeFull(f.HeaderBlockFragment())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, field := range fields {
+				if field.Name == ":path" {
+					pushed = append(pushed, field.Value)
+				}
+			}
+		case *http2.HeadersFrame:
+			// Keep the decoder's table in step with the server's encoder
+			dec.DecodeFull(f.HeaderBlockFragment())
+			if f.StreamID == 1 && f.StreamEnded() {
+				return pushed
+			}
+		case *http2.DataFrame:
+			if f.StreamID == 1 && f.StreamEnded() {
+				return pushed
+			}
+		case *http2.GoAwayFrame:
+			t.Fatalf("server sent GOAWAY: %v", f.ErrCode)
+		}
+	}
+}
+
+func TestClientGetByIDPushesItems(t *testing.T) {
+	clients := storage.NewMemoryStore[models.Client]()
+	client := clients.Create(models.Client{Name: "Acme", Email: "billing@acme.example"})
+	h := NewClientHandler(clients, storage.NewMemoryStore[models.Contact](), nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/clients/{id}", h.GetByID).Methods("GET").Name("clients.get")
+	router.HandleFunc("/api/v1/clients/{id}/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}).Methods("GET").Name("clients.items")
+	h.SetURLBuilder(func(name string, pairs ...string) (string, error) {
+		u, err := router.Get(name).URL(pairs...)
+		if err != nil {
+			return "", err
+		}
+		return u.String(), nil
+	})
+
+	server := httptest.NewUnstartedServer(router)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	want := "/api/v1/clients/" + client.ID + "/items"
+	if pushed := pushedPaths(t, server, "/api/v1/clients/"+client.ID); len(pushed) != 1 || pushed[0] != want {
+		t.Errorf("pushed %v, want %s", pushed, want)
+	}
+
+	// Go's client refuses pushes; the response is served all the same
+	resp, err := server.Client().Get(server.URL + "/api/v1/clients/" + client.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 || resp.StatusCode != http.StatusOK {
+		t.Errorf("got %s %d, want HTTP/2 200", resp.Proto, resp.StatusCode)
+	}
+
+	// Without HTTP/2 there is nothing to push to
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/clients/"+client.ID, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), client.ID) {
+		t.Errorf("HTTP/1.1: status %d, body %q", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go-api/logger"
+	"go-api/middleware"
+	"go-api/models"
+	"go-api/response"
+	"go-api/service"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// ClientStatusHandler handles HTTP requests for client statuses
+type ClientStatusHandler struct {
+	service *service.ClientStatusService
+}
+
+// NewClientStatusHandler creates a client status handler
+func NewClientStatusHandler(svc *service.ClientStatusService) *ClientStatusHandler {
+	return &ClientStatusHandler{service: svc}
+}
+
+// clientStatusRequest is the body of PATCH /clients/{id}/status
+type clientStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// UpdateStatus handles PATCH /clients/{id}/status
+func (h *ClientStatusHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req clientStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if !models.ValidClientStatus(req.Status) {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "invalid status '" + req.Status + "'"})
+		return
+	}
+
+	// The actor is the authenticated caller, when there is one
+	var actorID string
+	if claims, ok := middleware.ClaimsFromContext(r.Context()); ok {
+		actorID, _ = claims["sub"].(string)
+	}
+
+	client, err := h.service.ChangeStatus(r.Context(), id, req.Status, req.Reason, actorID)
+	var transition *service.TransitionError
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
+		return
+	case errors.As(err, &transition):
+		w.WriteHeader(http.StatusConflict)
+		response.Encode(r.Context(), w, map[string]any{"error": err.Error(), "allowed": transition.Allowed})
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).Error("change client status", "client_id", id, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to change client status"})
+		return
+	}
+
+	response.Encode(r.Context(), w, client)
+}
+
+// History handles GET /clients/{id}/status-history
+func (h *ClientStatusHandler) History(w http.ResponseWriter, r *http.Request) {
+	events, err := h.service.History(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
+		return
+	}
+
+	response.Encode(r.Context(), w, events)
+}
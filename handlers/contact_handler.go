@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-api/logger"
+	"go-api/models"
+	"go-api/response"
+	"go-api/storage"
+	"go-api/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// maxContactsPerClient is the most contacts a client can have
+const maxContactsPerClient = 10
+
+// ContactHandler handles HTTP requests for the contacts of a client
+type ContactHandler struct {
+	store   storage.Store[models.Contact]
+	clients storage.Store[models.Client]
+}
+
+// NewContactHandler creates a new contact handler
+func NewContactHandler(store storage.Store[models.Contact], clients storage.Store[models.Client]) *ContactHandler {
+	return &ContactHandler{store: store, clients: clients}
+}
+
+// GetAll handles GET /clients/{id}/contacts
+func (h *ContactHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	clientID := mux.Vars(r)["id"]
+	if !h.clientExists(w, r, clientID) {
+		return
+	}
+
+	response.Encode(r.Context(), w, contactsOf(scoped(h.store, r), clientID))
+}
+
+// Create handles POST /clients/{id}/contacts
+func (h *ContactHandler) Create(w http.ResponseWriter, r *http.Request) {
+	clientID := mux.Vars(r)["id"]
+
+	var contact models.Contact
+	if err := json.NewDecoder(r.Body).Decode(&contact); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	contact.ClientID = clientID
+
+	if err := validation.Contact(contact); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if !h.clientExists(w, r, clientID) {
+		return
+	}
+
+	store := scoped(h.store, r)
+	if len(contactsOf(store, clientID)) >= maxContactsPerClient {
+		v := &validation.ValidationError{}
+		v.Add("contacts", validation.ErrMaxValue, fmt.Sprintf("a client can have at most %d contacts", maxContactsPerClient))
+		writeValidationError(w, r, v)
+		return
+	}
+
+	created, err := store.TryCreate(contact)
+	if err != nil {
+		writeCreateError(w, r, "contact", err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	response.Encode(r.Context(), w, created)
+}
+
+// Update handles PUT /clients/{id}/contacts/{contact_id}
+func (h *ContactHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clientID, id := vars["id"], vars["contact_id"]
+
+	var contact models.Contact
+	if err := json.NewDecoder(r.Body).Decode(&contact); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if err := validation.Contact(contact); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	store := scoped(h.store, r)
+	current, exists := store.GetByID(id)
+	if !exists || current.ClientID != clientID {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Contact not found"})
+		return
+	}
+	if contact.Version == 0 {
+		contact.Version = current.Version
+	}
+
+	updated, err := store.Update(id, contact)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Contact not found"})
+		return
+	case errors.Is(err, storage.ErrVersionConflict):
+		w.WriteHeader(http.StatusConflict)
+		response.Encode(r.Context(), w, map[string]string{"error": "Contact was modified by another request; fetch the latest version and retry"})
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).Error("update contact", "id", id, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to update contact"})
+		return
+	}
+
+	response.Encode(r.Context(), w, updated)
+}
+
+// Delete handles DELETE /clients/{id}/contacts/{contact_id}
+func (h *ContactHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clientID, id := vars["id"], vars["contact_id"]
+
+	store := scoped(h.store, r)
+	if current, exists := store.GetByID(id); !exists || current.ClientID != clientID || !store.Delete(id) {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Contact not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientExists responds 404 and returns false when the client doesn't exist
+func (h *ContactHandler) clientExists(w http.ResponseWriter, r *http.Request, clientID string) bool {
+	if _, exists := scoped(h.clients, r).GetByID(clientID); !exists {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
+		return false
+	}
+	return true
+}
+
+// contactsOf returns the contacts of a client
+func contactsOf(store storage.Store[models.Contact], clientID string) []models.Contact {
+	contacts := make([]models.Contact, 0)
+	for _, c := range store.GetAll() {
+		if c.ClientID == clientID {
+			contacts = append(contacts, c)
+		}
+	}
+	return contacts
+}
+
+// scoped returns the store view for the caller of r
+func scoped[T any](store storage.Store[T], r *http.Request) storage.Store[T] {
+	if s, ok := store.(storage.Scoper[T]); ok {
+		return s.For(r.Context())
+	}
+	return store
+}
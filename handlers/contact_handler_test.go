@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-api/models"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// contactRouter routes the client and contact handlers as router.Setup
+// does, without the middleware
+func contactRouter(clients storage.Store[models.Client], contacts storage.Store[models.Contact]) *mux.Router {
+	ch := NewClientHandler(clients, contacts, nil)
+	h := NewContactHandler(contacts, clients)
+	router := mux.NewRouter()
+	router.HandleFunc("/clients/{id}", ch.GetByID).Methods("GET")
+	router.HandleFunc("/clients/{id}/contacts", h.GetAll).Methods("GET")
+	router.HandleFunc("/clients/{id}/contacts", h.Create).Methods("POST")
+	router.HandleFunc("/clients/{id}/contacts/{contact_id}", h.Update).Methods("PUT")
+	router.HandleFunc("/clients/{id}/contacts/{contact_id}", h.Delete).Methods("DELETE")
+	return router
+}
+
+// send serves one request through handler, decoding a JSON response into
+// out when it's not nil
+func send(t *testing.T, handler http.Handler, method, path, body string, out any) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if out != nil && w.Code < 300 {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: %v in %q", method, path, err, w.Body)
+		}
+	}
+	return w.Code
+}
+
+func TestContactLifecycle(t *testing.T) {
+	clients := storage.NewMemoryStore[models.Client]()
+	client := clients.Create(models.Client{Name: "Acme"})
+	other := clients.Create(models.Client{Name: "Globex"})
+	router := contactRouter(clients, storage.NewMemoryStore[models.Contact]())
+	base := "/clients/" + client.ID + "/contacts"
+
+	var created models.Contact
+	if code := send(t, router, "POST", base, `{"name":"Ada","email":"ada@acme.example","role":"billing","is_primary":true}`, &created); code != http.StatusCreated {
+		t.Fatalf("create: status %d, want 201", code)
+	}
+	if created.ID == "" || created.ClientID != client.ID || !created.IsPrimary {
+		t.Fatalf("created %+v", created)
+	}
+
+	var listed []models.Contact
+	if send(t, router, "GET", base, "", &listed); len(listed) != 1 || listed[0].ID != created.ID {
+		t.Fatalf("list = %+v, want the created contact", listed)
+	}
+	if send(t, router, "GET", "/clients/"+other.ID+"/contacts", "", &listed); len(listed) != 0 {
+		t.Errorf("another client lists %+v", listed)
+	}
+
+	var expanded struct {
+		models.Client
+		Contacts []models.Contact `json:"contacts"`
+	}
+	if send(t, router, "GET", "/clients/"+client.ID+"?expand=contacts", "", &expanded); expanded.ID != client.ID || len(expanded.Contacts) != 1 {
+		t.Errorf("expand=contacts = %+v", expanded)
+	}
+
+	var updated models.Contact
+	if code := send(t, router, "PUT", base+"/"+created.ID, `{"name":"Ada Lovelace","role":"owner"}`, &updated); code != http.StatusOK {
+		t.Fatalf("update: status %d, want 200", code)
+	}
+	if updated.Name != "Ada Lovelace" || updated.ClientID != client.ID || updated.Version != created.Version+1 {
+		t.Errorf("updated %+v", updated)
+	}
+	if code := send(t, router, "PUT", base+"/"+created.ID, fmt.Sprintf(`{"name":"Ada","version":%d}`, created.Version), nil); code != http.StatusConflict {
+		t.Errorf("update of a stale version: status %d, want 409", code)
+	}
+	if code := send(t, router, "PUT", "/clients/"+other.ID+"/contacts/"+created.ID, `{"name":"Ada"}`, nil); code != http.StatusNotFound {
+		t.Errorf("update through another client: status %d, want 404", code)
+	}
+
+	if code := send(t, router, "DELETE", "/clients/"+other.ID+"/contacts/"+created.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("delete through another client: status %d, want 404", code)
+	}
+	if code := send(t, router, "DELETE", base+"/"+created.ID, "", nil); code != http.StatusNoContent {
+		t.Errorf("delete: status %d, want 204", code)
+	}
+	if send(t, router, "GET", base, "", &listed); len(listed) != 0 {
+		t.Errorf("list after delete = %+v", listed)
+	}
+	if code := send(t, router, "DELETE", base+"/"+created.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("second delete: status %d, want 404", code)
+	}
+}
+
+func TestContactCreateErrors(t *testing.T) {
+	clients := storage.NewMemoryStore[models.Client]()
+	client := clients.Create(models.Client{Name: "Acme"})
+	router := contactRouter(clients, storage.NewMemoryStore[models.Contact]())
+	base := "/clients/" + client.ID + "/contacts"
+
+	for i := range maxContactsPerClient {
+		if code := send(t, router, "POST", base, fmt.Sprintf(`{"name":"contact %d"}`, i), nil); code != http.StatusCreated {
+			t.Fatalf("contact %d: status %d, want 201", i, code)
+		}
+	}
+	tests := []struct {
+		name, path, body string
+		want             int
+	}{
+		{"over the limit", base, `{"name":"one too many"}`, http.StatusUnprocessableEntity},
+		{"unknown client", "/clients/missing/contacts", `{"name":"Ada"}`, http.StatusNotFound},
+		{"invalid email", base, `{"name":"Ada","email":"not an address"}`, http.StatusUnprocessableEntity},
+		{"no name", base, `{"email":"ada@acme.example"}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		if code := send(t, router, "POST", tt.path, tt.body, nil); code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, code, tt.want)
+		}
+	}
+}
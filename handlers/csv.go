@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-api/response"
+	"go-api/storage"
+	"go-api/validation"
+)
+
+// maxImportMemory is how much of a multipart upload is kept in memory
+const maxImportMemory = 32 << 20
+
+// importError describes a CSV row that could not be imported
+type importError struct {
+	Row     int                     `json:"row"`
+	Message string                  `json:"message"`
+	Errors  []validation.FieldError `json:"errors,omitempty"`
+}
+
+// importResult is the body returned by the CSV import endpoints
+type importResult struct {
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []importError `json:"errors"`
+}
+
+// wantsCSV reports whether the client asked for CSV in the Accept header
+func wantsCSV(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/csv")
+}
+
+// csvColumns returns the JSON field names of T with their struct field index
+func csvColumns[T any]() ([]string, []int) {
+	t := reflect.TypeFor[T]()
+	names := make([]string, 0, t.NumField())
+	indexes := make([]int, 0, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		names = append(names, name)
+		indexes = append(indexes, i)
+	}
+	return names, indexes
+}
+
+// writeCSV streams records as RFC 4180 CSV with a header row
+func writeCSV[T any](w http.ResponseWriter, filename string, records []T) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	if filename != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+
+	enc := newCSVEncoder[T](w)
+	for _, record := range records {
+		enc.Encode(record)
+	}
+	enc.Flush()
+}
+
+// csvEncoder writes records of T as CSV rows under a header row
+type csvEncoder[T any] struct {
+	cw      *csv.Writer
+	indexes []int
+	row     []string
+}
+
+// newCSVEncoder writes the header row of T to w and returns an encoder for
+// the records
+func newCSVEncoder[T any](w io.Writer) *csvEncoder[T] {
+	names, indexes := csvColumns[T]()
+	cw := csv.NewWriter(w)
+	cw.Write(names)
+	return &csvEncoder[T]{cw: cw, indexes: indexes, row: make([]string, len(indexes))}
+}
+
+// Encode writes record as one row
+func (e *csvEncoder[T]) Encode(record T) error {
+	v := reflect.ValueOf(record)
+	for i, idx := range e.indexes {
+		e.row[i] = formatCSVValue(v.Field(idx))
+	}
+	return e.cw.Write(e.row)
+}
+
+// Flush writes any buffered rows and reports the first write error
+func (e *csvEncoder[T]) Flush() error {
+	e.cw.Flush()
+	return e.cw.Error()
+}
+
+func formatCSVValue(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64, reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	}
+
+	b, _ := json.Marshal(v.Interface())
+	return string(b)
+}
+
+func parseCSVValue(v reflect.Value, s string) error {
+	if s == "" {
+		return nil
+	}
+	if _, ok := v.Interface().(time.Time); ok {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float64, reflect.Float32:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return json.Unmarshal([]byte(s), v.Addr().Interface())
+	}
+	return nil
+}
+
+// importCSV reads the "file" field of a multipart upload, validates each row
+// and bulk-inserts the valid ones
+func importCSV[T any](w http.ResponseWriter, r *http.Request, store storage.Store[T], validate func(T) error) {
+	if err := r.ParseMultipartForm(maxImportMemory); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "A CSV file is required in the \"file\" field"})
+		return
+	}
+	defer file.Close()
+
+	cr := csv.NewReader(file)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "Could not read CSV header"})
+		return
+	}
+
+	// Map each CSV column to a struct field by JSON name
+	names, indexes := csvColumns[T]()
+	fieldFor := make(map[string]int, len(names))
+	for i, name := range names {
+		fieldFor[name] = indexes[i]
+	}
+	columns := make([]int, len(header))
+	for i, name := range header {
+		idx, ok := fieldFor[strings.TrimSpace(name)]
+		if !ok {
+			idx = -1
+		}
+		columns[i] = idx
+	}
+
+	result := importResult{Errors: make([]importError, 0)}
+	var valid []T
+	for rowNum := 2; ; rowNum++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, importError{Row: rowNum, Message: err.Error()})
+			continue
+		}
+
+		record, err := decodeCSVRow[T](columns, header, row)
+		if err == nil {
+			err = validate(record)
+		}
+		if err != nil {
+			rowErr := importError{Row: rowNum, Message: err.Error()}
+			var verr *validation.ValidationError
+			if errors.As(err, &verr) {
+				rowErr.Errors = verr.Errors
+			}
+			result.Errors = append(result.Errors, rowErr)
+			continue
+		}
+		valid = append(valid, record)
+	}
+
+	result.Imported = len(store.CreateMany(valid))
+	result.Failed = len(result.Errors)
+	response.Encode(r.Context(), w, result)
+}
+
+func decodeCSVRow[T any](columns []int, header, row []string) (T, error) {
+	var record T
+	v := reflect.ValueOf(&record).Elem()
+	for i, value := range row {
+		if i >= len(columns) || columns[i] < 0 {
+			continue
+		}
+		if err := parseCSVValue(v.Field(columns[i]), strings.TrimSpace(value)); err != nil {
+			return record, fmt.Errorf("%s: invalid value %q", header[i], value)
+		}
+	}
+	return record, nil
+}
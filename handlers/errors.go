@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-api/response"
+	"go-api/storage"
+	"go-api/validation"
+)
+
+// writeDecodeError responds to a request body that could not be decoded
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		response.Encode(r.Context(), w, map[string]string{"error": fmt.Sprintf("Request body exceeds %d bytes", maxErr.Limit)})
+		return
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	response.Encode(r.Context(), w, map[string]string{"error": "Invalid request payload"})
+}
+
+// writeValidationError responds with the field errors of a failed validation
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *validation.ValidationError
+	if !errors.As(err, &verr) {
+		verr = &validation.ValidationError{Errors: []validation.FieldError{{Code: validation.CodeForError(err), Message: err.Error()}}}
+	}
+
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	response.Encode(r.Context(), w, verr)
+}
+
+// writeCreateError responds to a create the store refused
+func writeCreateError(w http.ResponseWriter, r *http.Request, resource string, err error) {
+	if errors.Is(err, storage.ErrStoreFull) {
+		w.WriteHeader(http.StatusInsufficientStorage)
+		response.Encode(r.Context(), w, map[string]string{"error": "Store is full"})
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	response.Encode(r.Context(), w, map[string]string{"error": "Failed to create " + resource})
+}
+
+// readMergePatch reads a JSON Merge Patch request body. Only objects are
+// accepted, since any other patch would replace the whole record.
+func readMergePatch(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeDecodeError(w, r, err)
+		return nil, false
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) == 0 || trimmed[0] != '{' {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "A merge patch must be a JSON object"})
+		return nil, false
+	}
+	return body, true
+}
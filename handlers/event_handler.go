@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go-api/events"
+	"go-api/response"
+	"go-api/tenant"
+)
+
+// EventHandler streams store mutations to clients as server-sent events
+type EventHandler struct {
+	bus    *events.Bus
+	topics string
+}
+
+// NewEventHandler creates an event handler streaming the events of bus on
+// the topics matching pattern
+func NewEventHandler(bus *events.Bus, pattern string) *EventHandler {
+	return &EventHandler{bus: bus, topics: pattern}
+}
+
+// Stream handles GET /{resource}/events. Callers only get the events of
+// their own tenant.
+func (h *EventHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	tenantID := tenant.IDFromContext(r.Context())
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	stream, cancel := h.bus.Subscribe(h.topics)
+	defer cancel()
+
+	if err := rc.Flush(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Streaming not supported"})
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-stream:
+			if !ok {
+				return
+			}
+			if !e.VisibleTo(tenantID) {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/events"
+	"go-api/tenant"
+)
+
+// waitForSubscribers waits until topic has n subscribers
+func waitForSubscribers(t *testing.T, bus *events.Bus, topic string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for bus.SubscriberCount(topic) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s has %d subscribers, want %d", topic, bus.SubscriberCount(topic), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStreamOnlyCallersTenant(t *testing.T) {
+	bus := events.NewBus()
+	h := NewEventHandler(bus, "item.*")
+
+	ctx, cancel := context.WithCancel(tenant.WithID(context.Background(), "acme"))
+	r := httptest.NewRequest(http.MethodGet, "/items/events", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Stream(w, r)
+	}()
+	waitForSubscribers(t, bus, "item.created", 1)
+
+	bus.Publish("item.created", events.Event{Type: events.Created, ID: "other", TenantID: "globex"})
+	bus.Publish("item.created", events.Event{Type: events.Created, ID: "mine", TenantID: "acme"})
+	bus.Publish("item.created", events.Event{Type: events.Created, ID: "default"})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	body := w.Body.String()
+	if !strings.Contains(body, `"id":"mine"`) {
+		t.Errorf("stream is missing the caller's event: %s", body)
+	}
+	if strings.Contains(body, `"id":"other"`) || strings.Contains(body, `"id":"default"`) {
+		t.Errorf("stream has other tenants' events: %s", body)
+	}
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api/models"
+	"go-api/response"
+	"go-api/storage"
+	"go-api/tenant"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// exportJobTTL is how long an export job and its file are kept
+	exportJobTTL = time.Hour
+	// exportQueueSize is how many export jobs may wait for a worker
+	exportQueueSize = 100
+	// exportProgressEvery is how many records a job writes between progress
+	// updates
+	exportProgressEvery = 1000
+)
+
+// exportFormats maps each export format to its file extension and media type
+var exportFormats = map[string][2]string{
+	"csv":    {"csv", "text/csv; charset=utf-8"},
+	"ndjson": {"ndjson", ndjsonContentType},
+}
+
+// exportEntity writes the records of one entity for an export job
+type exportEntity struct {
+	// fields are the fields the job filter may name
+	fields map[string]int
+	export func(ctx context.Context, job models.ExportJob, w io.Writer, progress func(exported, total int)) error
+}
+
+// exportEntityOf exports the records of store matching the job filter
+func exportEntityOf[T any](store storage.Store[T]) exportEntity {
+	return exportEntity{
+		fields: storage.FilterableFields[T](),
+		export: func(ctx context.Context, job models.ExportJob, w io.Writer, progress func(exported, total int)) error {
+			view := store
+			if s, ok := store.(storage.Scoper[T]); ok {
+				view = s.For(ctx)
+			}
+			records, err := storage.Filter(view, job.Filter)
+			if err != nil {
+				return err
+			}
+
+			enc := newRecordEncoder[T](job.Format, w)
+			for i, record := range records {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := enc.Encode(record); err != nil {
+					return err
+				}
+				if (i+1)%exportProgressEvery == 0 {
+					progress(i+1, len(records))
+				}
+			}
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			progress(len(records), len(records))
+			return nil
+		},
+	}
+}
+
+// recordEncoder writes records in one export format
+type recordEncoder[T any] interface {
+	Encode(record T) error
+	Flush() error
+}
+
+// newRecordEncoder returns the encoder of format writing to w
+func newRecordEncoder[T any](format string, w io.Writer) recordEncoder[T] {
+	if format == "ndjson" {
+		buf := bufio.NewWriter(w)
+		return &ndjsonEncoder[T]{buf: buf, enc: json.NewEncoder(buf)}
+	}
+	return newCSVEncoder[T](w)
+}
+
+// ndjsonEncoder writes records as one JSON object per line
+type ndjsonEncoder[T any] struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+// Encode writes record as one line
+func (e *ndjsonEncoder[T]) Encode(record T) error {
+	return e.enc.Encode(record)
+}
+
+// Flush writes any buffered lines
+func (e *ndjsonEncoder[T]) Flush() error {
+	return e.buf.Flush()
+}
+
+// ExportJobHandler handles HTTP requests for asynchronous exports and runs
+// the export jobs in the background
+type ExportJobHandler struct {
+	jobs     storage.Store[models.ExportJob]
+	entities map[string]exportEntity
+	queue    chan string
+	urls     URLBuilder
+}
+
+// NewExportJobHandler creates an export job handler keeping its jobs in
+// jobStore, which should expire records created with a TTL
+func NewExportJobHandler(jobStore storage.Store[models.ExportJob], itemStore storage.Store[models.Item], clientStore storage.Store[models.Client]) *ExportJobHandler {
+	return &ExportJobHandler{
+		jobs: jobStore,
+		entities: map[string]exportEntity{
+			"items":   exportEntityOf(itemStore),
+			"clients": exportEntityOf(clientStore),
+		},
+		queue: make(chan string, exportQueueSize),
+	}
+}
+
+// SetURLBuilder sets the function used to build Location headers
+func (h *ExportJobHandler) SetURLBuilder(urls URLBuilder) {
+	h.urls = urls
+}
+
+// Run processes queued jobs with workers goroutines until ctx is cancelled,
+// then waits for the running jobs to stop. Jobs don't outlive the process,
+// so their files are removed on the way out.
+func (h *ExportJobHandler) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-h.queue:
+					h.process(ctx, id)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	for _, job := range h.jobs.GetAll() {
+		removeExport(job.Path)
+	}
+}
+
+// Expire removes the file of an expired job. It is registered as the job
+// store's expiry hook.
+func (h *ExportJobHandler) Expire(job models.ExportJob) {
+	removeExport(job.Path)
+}
+
+// process runs the job with id and records the outcome
+func (h *ExportJobHandler) process(ctx context.Context, id string) {
+	job, exists := h.jobs.GetByID(id)
+	if !exists {
+		return
+	}
+	job.Status = models.ExportRunning
+	if job, exists = h.save(job); !exists {
+		return
+	}
+
+	file, err := os.CreateTemp("", "export-*."+exportFormats[job.Format][0])
+	if err == nil {
+		ctx := tenant.WithID(ctx, job.TenantID)
+		err = h.entities[job.Entity].export(ctx, job, file, func(exported, total int) {
+			job.Exported, job.Total = exported, total
+			h.save(job)
+		})
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			removeExport(file.Name())
+		}
+	}
+
+	if err != nil {
+		log.Printf("WARN: export job %s: %v", id, err)
+		job.Status = models.ExportFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = models.ExportDone
+		job.Path = file.Name()
+	}
+	// A job that expired while running leaves nothing to download
+	if _, exists := h.save(job); !exists {
+		removeExport(job.Path)
+	}
+}
+
+// save stores job and reports whether it still exists
+func (h *ExportJobHandler) save(job models.ExportJob) (models.ExportJob, bool) {
+	saved, err := h.jobs.Update(job.ID, job)
+	if err != nil {
+		return job, false
+	}
+	return saved, true
+}
+
+// removeExport deletes an export file, if there is one
+func removeExport(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("WARN: remove export file %s: %v", path, err)
+	}
+}
+
+// exportJobRequest is the body of POST /export/jobs
+type exportJobRequest struct {
+	Entity string            `json:"entity"`
+	Format string            `json:"format"`
+	Filter map[string]string `json:"filter"`
+}
+
+// check returns an error naming the first invalid part of the request
+func (req exportJobRequest) check(entities map[string]exportEntity) error {
+	entity, ok := entities[req.Entity]
+	if !ok {
+		return fmt.Errorf("entity must be one of: %s", strings.Join(slices.Sorted(maps.Keys(entities)), ", "))
+	}
+	if _, ok := exportFormats[req.Format]; !ok {
+		return fmt.Errorf("format must be one of: %s", strings.Join(slices.Sorted(maps.Keys(exportFormats)), ", "))
+	}
+	for name := range req.Filter {
+		if _, ok := entity.fields[name]; !ok {
+			return fmt.Errorf("cannot filter %s by '%s'", req.Entity, name)
+		}
+	}
+	return nil
+}
+
+// Create handles POST /export/jobs
+func (h *ExportJobHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req exportJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "csv"
+	}
+	if err := req.check(h.entities); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	jobs := scoped(h.jobs, r)
+	job, err := jobs.CreateWithTTL(models.ExportJob{
+		Entity:    req.Entity,
+		Format:    req.Format,
+		Filter:    req.Filter,
+		Status:    models.ExportPending,
+		ExpiresAt: time.Now().Add(exportJobTTL),
+	}, exportJobTTL)
+	if err != nil {
+		writeCreateError(w, r, "export job", err)
+		return
+	}
+
+	select {
+	case h.queue <- job.ID:
+	default:
+		jobs.Delete(job.ID)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		response.Encode(r.Context(), w, map[string]string{"error": "Too many export jobs are waiting; retry later"})
+		return
+	}
+
+	setLocation(w, h.urls, "export.jobs.get", "id", job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	response.Encode(r.Context(), w, map[string]string{"job_id": job.ID, "status": job.Status})
+}
+
+// Get handles GET /export/jobs/{id}
+func (h *ExportJobHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, exists := scoped(h.jobs, r).GetByID(mux.Vars(r)["id"])
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Export job not found"})
+		return
+	}
+
+	response.Encode(r.Context(), w, job)
+}
+
+// Download handles GET /export/jobs/{id}/download
+func (h *ExportJobHandler) Download(w http.ResponseWriter, r *http.Request) {
+	job, exists := scoped(h.jobs, r).GetByID(mux.Vars(r)["id"])
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Export job not found"})
+		return
+	}
+	if job.Status != models.ExportDone {
+		w.WriteHeader(http.StatusConflict)
+		response.Encode(r.Context(), w, map[string]string{"error": "Export job is " + job.Status})
+		return
+	}
+
+	file, err := os.Open(job.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusGone)
+		response.Encode(r.Context(), w, map[string]string{"error": "Export file is no longer available"})
+		return
+	}
+	defer file.Close()
+
+	format := exportFormats[job.Format]
+	w.Header().Set("Content-Type", format[1])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.Entity+"."+format[0]))
+	http.ServeContent(w, r, "", job.UpdatedAt, file)
+}
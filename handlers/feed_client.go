@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// maxFeedRedirects is how many redirects a feed fetch follows
+const maxFeedRedirects = 5
+
+// errFeedAddrBlocked is returned when a feed URL leads to an address the
+// server must not fetch from
+var errFeedAddrBlocked = errors.New("feed address is not allowed")
+
+// publicAddr reports whether addr is an address of the public internet: not
+// loopback, link-local (which includes 169.254.169.254), private, shared,
+// multicast or unspecified
+func publicAddr(addr netip.AddrPort) bool {
+	ip := addr.Addr().Unmap()
+	return ip.IsValid() && !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() && !sharedAddrs.Contains(ip)
+}
+
+// sharedAddrs is the carrier-grade NAT range of RFC 6598, which IsPrivate
+// leaves out
+var sharedAddrs = netip.MustParsePrefix("100.64.0.0/10")
+
+// newFeedClient returns the client fetching import feeds. Every connection,
+// redirects included, is checked against allow after DNS resolution,
+// against the address actually dialed, so a name can't resolve to an
+// internal address between a check and the dial.
+func newFeedClient(allow func(netip.AddrPort) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !allow(addr) {
+				return fmt.Errorf("%w: %s", errFeedAddrBlocked, address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the feed, so the check would be of
+	// the proxy's address
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   importFeedTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFeedRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFeedRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to a %s URL", req.URL.Scheme)
+			}
+			// Names are checked when dialed; addresses can be checked now
+			ip, err := netip.ParseAddr(req.URL.Hostname())
+			if err != nil {
+				return nil
+			}
+			port := map[string]uint16{"http": 80, "https": 443}[req.URL.Scheme]
+			if p, err := strconv.ParseUint(req.URL.Port(), 10, 16); err == nil {
+				port = uint16(p)
+			}
+			if !allow(netip.AddrPortFrom(ip, port)) {
+				return fmt.Errorf("%w: redirect to %s", errFeedAddrBlocked, req.URL.Host)
+			}
+			return nil
+		},
+	}
+}
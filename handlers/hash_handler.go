@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-api/response"
+	"go-api/storage"
+	"go-api/tenant"
+)
+
+// HashHandler serves a fingerprint of every record of a resource, so caches
+// can tell the list changed without fetching it. Fingerprints are computed
+// on first use and kept until Invalidate is called.
+type HashHandler[T any] struct {
+	store storage.Store[T]
+
+	mu     sync.Mutex
+	hashes map[string]contentHash // by tenant
+}
+
+// contentHash is the body returned by Hash
+type contentHash struct {
+	Hash  string `json:"hash"`
+	Count int    `json:"count"`
+}
+
+// NewHashHandler creates a handler fingerprinting the records of store
+func NewHashHandler[T any](store storage.Store[T]) *HashHandler[T] {
+	return &HashHandler[T]{store: store, hashes: make(map[string]contentHash)}
+}
+
+// Invalidate drops the cached fingerprints; call it after every change
+func (h *HashHandler[T]) Invalidate() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clear(h.hashes)
+}
+
+// Hash handles GET /{resource}/hash
+func (h *HashHandler[T]) Hash(w http.ResponseWriter, r *http.Request) {
+	sum, err := h.sum(r)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to hash records"})
+		return
+	}
+
+	etag := `"` + sum.Hash + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "max-age=0, must-revalidate")
+	if notModified(r, etag, time.Time{}) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	response.Encode(r.Context(), w, sum)
+}
+
+// sum returns the fingerprint of the records visible to the caller of r
+func (h *HashHandler[T]) sum(r *http.Request) (contentHash, error) {
+	tenantID := tenant.IDFromContext(r.Context())
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if sum, ok := h.hashes[tenantID]; ok {
+		return sum, nil
+	}
+
+	store := h.store
+	if s, ok := store.(storage.Scoper[T]); ok {
+		store = s.For(r.Context())
+	}
+	records := store.GetAll()
+	storage.SortByID(records)
+
+	hash := sha256.New()
+	enc := json.NewEncoder(hash)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return contentHash{}, err
+		}
+	}
+
+	sum := contentHash{Hash: hex.EncodeToString(hash.Sum(nil)), Count: len(records)}
+	h.hashes[tenantID] = sum
+	return sum, nil
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Head answers HEAD requests with get, the route's GET handler. The body is
+// produced as usual and discarded, so ETag and Last-Modified match GET and
+// Content-Length is the size of the body GET would send.
+func Head(get http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		nb := &noBodyWriter{ResponseWriter: w}
+		get(nb, r)
+		nb.finish()
+	}
+}
+
+// noBodyWriter counts the bytes of a response body instead of sending them.
+// The status is held back until the handler is done so Content-Length can be
+// set first.
+type noBodyWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *noBodyWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *noBodyWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.size += int64(len(b))
+	return len(b), nil
+}
+
+func (w *noBodyWriter) finish() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status != http.StatusNotModified && w.status != http.StatusNoContent {
+		w.Header().Set("Content-Length", strconv.FormatInt(w.size, 10))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"go-api/models"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHeadMatchesGet(t *testing.T) {
+	items := storage.NewMemoryStore[models.Item]()
+	item := items.Create(models.Item{Name: "widget", Quantity: 3})
+	items.Create(models.Item{Name: "gadget", Quantity: 5})
+	h := NewItemHandler(items, nil)
+
+	tests := []struct {
+		name   string
+		get    http.HandlerFunc
+		vars   map[string]string
+		status int
+	}{
+		{"list", h.GetAll, nil, http.StatusOK},
+		{"item", h.GetByID, map[string]string{"id": item.ID}, http.StatusOK},
+		{"missing item", h.GetByID, map[string]string{"id": "missing"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serve := func(handler http.HandlerFunc, method string) *httptest.ResponseRecorder {
+				r := mux.SetURLVars(httptest.NewRequest(method, "/", nil), tt.vars)
+				w := httptest.NewRecorder()
+				handler(w, r)
+				return w
+			}
+			get, head := serve(tt.get, http.MethodGet), serve(Head(tt.get), http.MethodHead)
+
+			if get.Code != tt.status || head.Code != tt.status {
+				t.Fatalf("GET %d, HEAD %d, want %d", get.Code, head.Code, tt.status)
+			}
+			if head.Body.Len() != 0 {
+				t.Errorf("HEAD has a body: %q", head.Body)
+			}
+			for _, header := range []string{"ETag", "Last-Modified", "Accept-Ranges"} {
+				if got, want := head.Header().Get(header), get.Header().Get(header); got != want {
+					t.Errorf("HEAD %s = %q, GET sent %q", header, got, want)
+				}
+			}
+			if got, want := head.Header().Get("Content-Length"), len(get.Body.Bytes()); got != strconv.Itoa(want) {
+				t.Errorf("HEAD Content-Length = %q, GET sent %d bytes", got, want)
+			}
+		})
+	}
+}
+
+func TestHeadNotModifiedHasNoContentLength(t *testing.T) {
+	items := storage.NewMemoryStore[models.Item]()
+	items.Create(models.Item{Name: "widget"})
+	h := NewItemHandler(items, nil)
+
+	w := httptest.NewRecorder()
+	h.GetAll(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	r := httptest.NewRequest(http.MethodHead, "/items", nil)
+	r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	head := httptest.NewRecorder()
+	Head(h.GetAll)(head, r)
+	if head.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", head.Code)
+	}
+	if cl := head.Header().Get("Content-Length"); cl != "" {
+		t.Errorf("304 Content-Length = %q, want none", cl)
+	}
+}
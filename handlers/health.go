@@ -1,15 +1,113 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
+	"runtime"
+	"sync"
 	"time"
+
+	"go-api/response"
 )
 
-// HealthCheck handles GET /health
-func HealthCheck(w http.ResponseWriter, r *http.Request) {
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "ok",
-		"time":   time.Now().Format(time.RFC3339),
+// StoreHealth is a store whose backend health can be reported
+type StoreHealth interface {
+	Ping() error
+	State() string
+}
+
+// memStatsMaxAge is how long a read of the runtime memory statistics is
+// reused; reading them stops the world
+const memStatsMaxAge = time.Second
+
+// RuntimeLimits are the runtime figures above which the API counts as
+// degraded
+type RuntimeLimits struct {
+	MaxHeapBytes  uint64
+	MaxGoroutines int
+}
+
+// HealthHandler reports the health of the API, its runtime and its stores
+type HealthHandler struct {
+	stores  map[string]StoreHealth
+	limits  RuntimeLimits
+	started time.Time
+
+	mu       sync.Mutex
+	memStats runtime.MemStats
+	memRead  time.Time
+}
+
+// NewHealthHandler creates a health handler reporting on stores, keyed by
+// entity, and on the runtime against limits. Uptime counts from now.
+func NewHealthHandler(stores map[string]StoreHealth, limits RuntimeLimits) *HealthHandler {
+	return &HealthHandler{stores: stores, limits: limits, started: time.Now()}
+}
+
+// runtimeHealth is the runtime section of the health response
+type runtimeHealth struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	NumGC        uint32 `json:"num_gc"`
+	GCSys        uint64 `json:"gc_sys"`
+	NumGoroutine int    `json:"num_goroutine"`
+}
+
+// readRuntime returns the current runtime figures, reading the memory
+// statistics at most once per memStatsMaxAge
+func (h *HealthHandler) readRuntime() runtimeHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if time.Since(h.memRead) >= memStatsMaxAge {
+		runtime.ReadMemStats(&h.memStats)
+		h.memRead = time.Now()
+	}
+	return runtimeHealth{
+		HeapAlloc:    h.memStats.HeapAlloc,
+		NumGC:        h.memStats.NumGC,
+		GCSys:        h.memStats.GCSys,
+		NumGoroutine: runtime.NumGoroutine(),
+	}
+}
+
+// Readiness handles the readiness probe: 503 until ready reports true
+func Readiness(ready func() bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			response.Encode(r.Context(), w, map[string]string{"status": "starting"})
+			return
+		}
+		response.Encode(r.Context(), w, map[string]string{"status": "ready"})
+	}
+}
+
+// Check handles GET /health. An unhealthy store makes it 503; a runtime
+// over its limits still serves, so it is 207.
+func (h *HealthHandler) Check(w http.ResponseWriter, r *http.Request) {
+	code := http.StatusOK
+	states := make(map[string]string, len(h.stores))
+	for entity, store := range h.stores {
+		err := store.Ping()
+		states[entity] = store.State()
+		if err != nil || states[entity] != "closed" {
+			code = http.StatusServiceUnavailable
+		}
+	}
+
+	rt := h.readRuntime()
+	if code == http.StatusOK && (rt.HeapAlloc > h.limits.MaxHeapBytes || rt.NumGoroutine > h.limits.MaxGoroutines) {
+		code = http.StatusMultiStatus
+	}
+
+	status := "ok"
+	if code != http.StatusOK {
+		status = "degraded"
+		w.WriteHeader(code)
+	}
+	response.Encode(r.Context(), w, map[string]any{
+		"status":         status,
+		"time":           time.Now().Format(time.RFC3339),
+		"uptime_seconds": time.Since(h.started).Seconds(),
+		"stores":         states,
+		"runtime":        rt,
 	})
 }
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"go-api/middleware"
+)
+
+// fakeStoreHealth is a store whose Ping calls are counted
+type fakeStoreHealth struct {
+	pings atomic.Int64
+	err   error
+}
+
+func (s *fakeStoreHealth) Ping() error {
+	s.pings.Add(1)
+	return s.err
+}
+
+func (s *fakeStoreHealth) State() string { return "closed" }
+
+func TestHeadHealth(t *testing.T) {
+	store := &fakeStoreHealth{}
+	h := NewHealthHandler(map[string]StoreHealth{"items": store}, RuntimeLimits{MaxHeapBytes: 1 << 40, MaxGoroutines: 1 << 20})
+	// Routed as router.Setup does, with the content type negotiated first
+	server := httptest.NewServer(middleware.ContentNegotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			Head(h.Check)(w, r)
+			return
+		}
+		h.Check(w, r)
+	})))
+	defer server.Close()
+	send := func(method string) (*http.Response, []byte) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, body
+	}
+
+	// The body holds the uptime, whose length can change between two
+	// requests, so look for a pair of the same length
+	var head, get *http.Response
+	var headBody, getBody []byte
+	for range 5 {
+		head, headBody = send(http.MethodHead)
+		get, getBody = send(http.MethodGet)
+		if head.ContentLength == int64(len(getBody)) {
+			break
+		}
+	}
+	if head.StatusCode != http.StatusOK {
+		t.Fatalf("HEAD status %d, want 200", head.StatusCode)
+	}
+	if len(headBody) != 0 {
+		t.Errorf("HEAD has a body: %q", headBody)
+	}
+	if got, want := head.Header.Get("Content-Length"), strconv.Itoa(len(getBody)); got != want {
+		t.Errorf("HEAD Content-Length = %q, GET body is %s bytes", got, want)
+	}
+	if got, want := head.Header.Get("Content-Type"), get.Header.Get("Content-Type"); got != want || got == "" {
+		t.Errorf("HEAD Content-Type = %q, GET sent %q", got, want)
+	}
+
+	// HEAD still pings the stores
+	pings := store.pings.Load()
+	store.err = errors.New("connection refused")
+	if head, _ := send(http.MethodHead); head.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("HEAD with a failing store: status %d, want 503", head.StatusCode)
+	}
+	if store.pings.Load() != pings+1 {
+		t.Error("HEAD didn't ping the store")
+	}
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"go-api/models"
+	"go-api/response"
+	"go-api/storage"
+	"go-api/tenant"
+	"go-api/validation"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// importFeedTimeout bounds fetching a feed, from connecting to reading
+	// the last byte
+	importFeedTimeout = 30 * time.Second
+	// maxImportFeedSize is the largest feed read, in bytes
+	maxImportFeedSize = 10 << 20
+	// importAsyncThreshold is how many entries a feed may have to be
+	// imported within the request; larger feeds are imported by a job
+	importAsyncThreshold = 100
+	// importJobTTL is how long an import job is kept
+	importJobTTL = time.Hour
+	// importQueueSize is how many import jobs may wait for a worker
+	importQueueSize = 100
+	// importUserAgent identifies the server to feed hosts
+	importUserAgent = "go-api-feed-importer/1.0"
+)
+
+// errFeedTooLarge is returned for feeds over maxImportFeedSize
+var errFeedTooLarge = fmt.Errorf("feed is larger than %d MiB", maxImportFeedSize>>20)
+
+// ImportJobHandler handles HTTP requests importing items from JSON feeds,
+// and runs the imports of large feeds in the background
+type ImportJobHandler struct {
+	jobs   storage.Store[models.ImportJob]
+	items  storage.Store[models.Item]
+	client *http.Client
+	queue  chan string
+	urls   URLBuilder
+	// validate checks each feed entry before it is stored
+	validate func(models.Item) error
+}
+
+// NewImportJobHandler creates an import handler storing items in itemStore
+// and keeping its jobs in jobStore, which should expire records created
+// with a TTL
+func NewImportJobHandler(jobStore storage.Store[models.ImportJob], itemStore storage.Store[models.Item]) *ImportJobHandler {
+	return &ImportJobHandler{
+		jobs:     jobStore,
+		items:    itemStore,
+		client:   newFeedClient(publicAddr),
+		queue:    make(chan string, importQueueSize),
+		validate: validation.Item,
+	}
+}
+
+// SetURLBuilder sets the function used to build Location headers
+func (h *ImportJobHandler) SetURLBuilder(urls URLBuilder) {
+	h.urls = urls
+}
+
+// SetValidator replaces validation.Item as the check of feed entries
+func (h *ImportJobHandler) SetValidator(validate func(models.Item) error) {
+	h.validate = validate
+}
+
+// Run processes queued jobs with workers goroutines until ctx is cancelled,
+// then waits for the running jobs to stop
+func (h *ImportJobHandler) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-h.queue:
+					h.process(ctx, id)
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// process stores the items of the job with id and records the outcome
+func (h *ImportJobHandler) process(ctx context.Context, id string) {
+	job, exists := h.jobs.GetByID(id)
+	if !exists {
+		return
+	}
+	job.Status = models.ImportRunning
+	if job, exists = h.save(job); !exists {
+		return
+	}
+
+	items := h.items
+	if s, ok := items.(storage.Scoper[models.Item]); ok {
+		items = s.For(tenant.WithID(ctx, job.TenantID))
+	}
+	job.Imported = len(items.CreateMany(job.Items))
+	job.Items = nil
+	job.Status = models.ImportDone
+	h.save(job)
+}
+
+// save stores job and reports whether it still exists
+func (h *ImportJobHandler) save(job models.ImportJob) (models.ImportJob, bool) {
+	saved, err := h.jobs.Update(job.ID, job)
+	if err != nil {
+		return job, false
+	}
+	return saved, true
+}
+
+// importURLRequest is the body of POST /items/import-url
+type importURLRequest struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+}
+
+// check returns an error naming the first invalid part of the request
+func (req importURLRequest) check() error {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if req.Format != "json_array" {
+		return errors.New("format must be one of: json_array")
+	}
+	return nil
+}
+
+// fetch reads the feed at rawURL, up to maxImportFeedSize bytes
+func (h *ImportJobHandler) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", importUserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed responded %s", resp.Status)
+	}
+
+	// One byte past the limit tells a feed of exactly the limit from a larger one
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImportFeedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxImportFeedSize {
+		return nil, errFeedTooLarge
+	}
+	return body, nil
+}
+
+// parseFeed decodes a JSON array of items and validates each one. Entries
+// that don't decode or validate are reported in the result instead.
+func parseFeed(body []byte, validate func(models.Item) error) ([]models.Item, importResult, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(bytes.TrimSpace(body), &entries); err != nil {
+		return nil, importResult{}, errors.New("feed must be a JSON array of items")
+	}
+
+	result := importResult{Errors: make([]importError, 0)}
+	valid := make([]models.Item, 0, len(entries))
+	for i, entry := range entries {
+		var item models.Item
+		err := json.Unmarshal(entry, &item)
+		if err == nil {
+			err = validate(item)
+		}
+		if err != nil {
+			rowErr := importError{Row: i + 1, Message: err.Error()}
+			var verr *validation.ValidationError
+			if errors.As(err, &verr) {
+				rowErr.Errors = verr.Errors
+			}
+			result.Errors = append(result.Errors, rowErr)
+			continue
+		}
+		valid = append(valid, item)
+	}
+	result.Failed = len(result.Errors)
+	return valid, result, nil
+}
+
+// ImportURL handles POST /items/import-url. Feeds of fewer than 100 entries
+// are imported before responding; larger ones respond 202 with the ID of
+// the job importing them.
+func (h *ImportJobHandler) ImportURL(w http.ResponseWriter, r *http.Request) {
+	var req importURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "json_array"
+	}
+	if err := req.check(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	body, err := h.fetch(r.Context(), req.URL)
+	if err != nil {
+		log.Printf("WARN: import feed %s: %v", req.URL, err)
+		// Only the size limit is explained; other causes would tell the
+		// caller what the server can reach
+		message := "Could not fetch feed"
+		if errors.Is(err, errFeedTooLarge) {
+			message += ": " + err.Error()
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		response.Encode(r.Context(), w, map[string]string{"error": message})
+		return
+	}
+	items, result, err := parseFeed(body, h.validate)
+	if err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if len(items)+result.Failed < importAsyncThreshold {
+		result.Imported = len(scoped(h.items, r).CreateMany(items))
+		response.Encode(r.Context(), w, result)
+		return
+	}
+
+	errs := make([]models.ImportError, len(result.Errors))
+	for i, e := range result.Errors {
+		errs[i] = models.ImportError{Row: e.Row, Message: e.Message}
+	}
+	jobs := scoped(h.jobs, r)
+	job, err := jobs.CreateWithTTL(models.ImportJob{
+		URL:       req.URL,
+		Status:    models.ImportPending,
+		Failed:    result.Failed,
+		Total:     len(items) + result.Failed,
+		Errors:    errs,
+		Items:     items,
+		ExpiresAt: time.Now().Add(importJobTTL),
+	}, importJobTTL)
+	if err != nil {
+		writeCreateError(w, r, "import job", err)
+		return
+	}
+
+	select {
+	case h.queue <- job.ID:
+	default:
+		jobs.Delete(job.ID)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		response.Encode(r.Context(), w, map[string]string{"error": "Too many import jobs are waiting; retry later"})
+		return
+	}
+
+	setLocation(w, h.urls, "import.jobs.get", "id", job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	response.Encode(r.Context(), w, map[string]string{"job_id": job.ID, "status": job.Status})
+}
+
+// Get handles GET /import/jobs/{id}
+func (h *ImportJobHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, exists := scoped(h.jobs, r).GetByID(mux.Vars(r)["id"])
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Import job not found"})
+		return
+	}
+
+	response.Encode(r.Context(), w, job)
+}
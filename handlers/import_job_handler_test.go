@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/models"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// feedHandler returns an import handler that may fetch only from the
+// loopback servers of feeds, which the default client refuses
+func feedHandler(items storage.Store[models.Item], feeds ...*httptest.Server) *ImportJobHandler {
+	h := NewImportJobHandler(storage.NewMemoryStore[models.ImportJob](), items)
+	allowed := make(map[string]bool, len(feeds))
+	for _, feed := range feeds {
+		allowed[feed.Listener.Addr().String()] = true
+	}
+	h.client = newFeedClient(func(addr netip.AddrPort) bool { return allowed[addr.String()] })
+	return h
+}
+
+// importURL posts an import of feed to h, returning the response
+func importURL(h *ImportJobHandler, feed string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	body := fmt.Sprintf(`{"url":%q}`, feed)
+	h.ImportURL(w, httptest.NewRequest(http.MethodPost, "/items/import-url", strings.NewReader(body)))
+	return w
+}
+
+func TestImportURLSmallFeed(t *testing.T) {
+	var userAgent string
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Write([]byte(`[{"name":"Widget","quantity":3},{"name":"Gadget","quantity":-1},{"name":"Gizmo"}]`))
+	}))
+	defer feed.Close()
+	items := storage.NewMemoryStore[models.Item]()
+	h := feedHandler(items, feed)
+
+	w := importURL(h, feed.URL)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", w.Code, w.Body)
+	}
+	var result importResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 2 || result.Failed != 1 {
+		t.Errorf("imported %d and failed %d, want 2 and 1", result.Imported, result.Failed)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Row != 2 || len(result.Errors[0].Errors) == 0 {
+		t.Errorf("errors %+v, want one for row 2 with its field errors", result.Errors)
+	}
+	if n := len(items.GetAll()); n != 2 {
+		t.Errorf("store has %d items, want 2", n)
+	}
+	if userAgent != importUserAgent {
+		t.Errorf("feed saw User-Agent %q, want %q", userAgent, importUserAgent)
+	}
+}
+
+func TestImportURLLargeFeedRunsAsJob(t *testing.T) {
+	entries := make([]models.Item, importAsyncThreshold+50)
+	for i := range entries {
+		entries[i] = models.Item{Name: fmt.Sprintf("Item %d", i), Quantity: i}
+	}
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(entries)
+	}))
+	defer feed.Close()
+	items := storage.NewMemoryStore[models.Item]()
+	h := feedHandler(items, feed)
+
+	w := importURL(h, feed.URL)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status %d, want 202: %s", w.Code, w.Body)
+	}
+	var accepted struct {
+		JobID  string `json:"job_id"`
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &accepted); err != nil {
+		t.Fatal(err)
+	}
+	if accepted.JobID == "" || accepted.Status != models.ImportPending {
+		t.Fatalf("accepted %+v, want a pending job", accepted)
+	}
+	if n := len(items.GetAll()); n != 0 {
+		t.Fatalf("%d items stored before the job ran", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.Run(ctx, 1)
+
+	var job models.ImportJob
+	for deadline := time.Now().Add(time.Second); job.Status != models.ImportDone; {
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %q after a second", job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+		w := httptest.NewRecorder()
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/import/jobs/"+accepted.JobID, nil), map[string]string{"id": accepted.JobID})
+		h.Get(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("get job: status %d", w.Code)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if job.Imported != len(entries) || job.Total != len(entries) || job.Failed != 0 {
+		t.Errorf("job imported %d of %d with %d failed, want all %d", job.Imported, job.Total, job.Failed, len(entries))
+	}
+	if n := len(items.GetAll()); n != len(entries) {
+		t.Errorf("store has %d items, want %d", n, len(entries))
+	}
+}
+
+func TestImportURLRejects(t *testing.T) {
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large":
+			w.Write(bytes.Repeat([]byte(" "), maxImportFeedSize+1))
+		case "/object":
+			w.Write([]byte(`{"name":"Widget"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer feed.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"relative url", "/feed.json", http.StatusBadRequest},
+		{"unsupported scheme", "ftp://example.com/feed.json", http.StatusBadRequest},
+		{"feed over 10 MiB", feed.URL + "/large", http.StatusBadGateway},
+		{"feed not found", feed.URL + "/missing", http.StatusBadGateway},
+		{"feed not an array", feed.URL + "/object", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := storage.NewMemoryStore[models.Item]()
+			h := feedHandler(items, feed)
+			if w := importURL(h, tt.url); w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if n := len(items.GetAll()); n != 0 {
+				t.Errorf("store has %d items, want none", n)
+			}
+		})
+	}
+}
+
+func TestImportURLRefusesInternalAddresses(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("internal server fetched at %s", r.URL)
+	}))
+	defer internal.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(internal.URL+"/secret", http.StatusFound))
+	defer redirect.Close()
+
+	tests := []struct {
+		name string
+		h    *ImportJobHandler
+		url  string
+	}{
+		{"loopback", NewImportJobHandler(storage.NewMemoryStore[models.ImportJob](), storage.NewMemoryStore[models.Item]()), internal.URL},
+		{"name of a loopback address", NewImportJobHandler(storage.NewMemoryStore[models.ImportJob](), storage.NewMemoryStore[models.Item]()),
+			"http://localhost:" + internal.URL[strings.LastIndexByte(internal.URL, ':')+1:]},
+		{"redirect to a blocked address", feedHandler(storage.NewMemoryStore[models.Item](), redirect), redirect.URL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := importURL(tt.h, tt.url)
+			if w.Code != http.StatusBadGateway {
+				t.Fatalf("status %d, want 502: %s", w.Code, w.Body)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["error"] != "Could not fetch feed" {
+				t.Errorf("error %q, want the generic message", body["error"])
+			}
+		})
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34:80":        true,
+		"[2606:4700::1111]:443":   true,
+		"127.0.0.1:80":            false,
+		"[::1]:80":                false,
+		"10.1.2.3:80":             false,
+		"172.16.0.1:80":           false,
+		"192.168.1.1:80":          false,
+		"169.254.169.254:80":      false,
+		"100.64.0.1:80":           false,
+		"0.0.0.0:80":              false,
+		"[fe80::1]:80":            false,
+		"[fd00::1]:80":            false,
+		"[::ffff:127.0.0.1]:80":   false,
+		"[::ffff:169.254.1.1]:80": false,
+		"224.0.0.1:80":            false,
+	} {
+		if got := publicAddr(netip.MustParseAddrPort(addr)); got != want {
+			t.Errorf("publicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
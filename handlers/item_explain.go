@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go-api/response"
+	"go-api/storage"
+)
+
+// Explain handles GET /items/explain?status=active&tags=go, describing how
+// the store would answer a filter on the query's fields: which are looked
+// up in an index and which are scanned, how many records each would
+// read, and which indexes would avoid the scan. It spans every tenant, so
+// it is for admins.
+func (h *ItemHandler) Explain(w http.ResponseWriter, r *http.Request) {
+	predicates := make(map[string]string)
+	for field, values := range r.URL.Query() {
+		predicates[field] = values[0]
+	}
+	if len(predicates) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "list at least one field to filter on, such as status=active"})
+		return
+	}
+
+	response.Encode(r.Context(), w, storage.Explain(h.store, predicates))
+}
@@ -2,56 +2,223 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
+	"go-api/logger"
 	"go-api/models"
+	"go-api/patch"
+	"go-api/response"
 	"go-api/storage"
+	"go-api/tenant"
+	"go-api/validation"
 
 	"github.com/gorilla/mux"
 )
 
 // ItemHandler handles HTTP requests for items
 type ItemHandler struct {
-	store storage.Store[models.Item]
+	store      storage.Store[models.Item]
+	tombstones *storage.Tombstones
+	urls       URLBuilder
+	// deleteReturnsBody makes Delete respond with the deleted item
+	deleteReturnsBody bool
+	// streamLists makes GetAll stream unsorted JSON lists
+	streamLists bool
+	// validate checks items before they are stored
+	validate func(models.Item) error
 }
 
-// NewItemHandler creates a new item handler
-func NewItemHandler(store storage.Store[models.Item]) *ItemHandler {
-	return &ItemHandler{store: store}
+// NewItemHandler creates a new item handler. tombstones lists the deleted
+// items reported by Diff.
+func NewItemHandler(store storage.Store[models.Item], tombstones *storage.Tombstones) *ItemHandler {
+	return &ItemHandler{store: store, tombstones: tombstones, validate: validation.Item}
+}
+
+// SetURLBuilder sets the function used to build Location headers
+func (h *ItemHandler) SetURLBuilder(urls URLBuilder) {
+	h.urls = urls
+}
+
+// SetDeleteReturnsBody makes Delete respond 200 with the deleted item
+// instead of 204
+func (h *ItemHandler) SetDeleteReturnsBody(on bool) {
+	h.deleteReturnsBody = on
+}
+
+// SetStreamLists makes GetAll write unsorted JSON lists as it reads the
+// store, without building the whole list or its ETag first
+func (h *ItemHandler) SetStreamLists(on bool) {
+	h.streamLists = on
+}
+
+// SetValidator replaces validation.Item as the check of items before they
+// are stored
+func (h *ItemHandler) SetValidator(validate func(models.Item) error) {
+	h.validate = validate
+}
+
+// storeFor returns the store view for the caller of r
+func (h *ItemHandler) storeFor(r *http.Request) storage.Store[models.Item] {
+	if s, ok := h.store.(storage.Scoper[models.Item]); ok {
+		return s.For(r.Context())
+	}
+	return h.store
 }
 
 // GetAll handles GET /items
 func (h *ItemHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	items := h.store.GetAll()
-	json.NewEncoder(w).Encode(items)
+	if query := r.URL.Query(); query.Has("tags") || query.Has("any_tag") {
+		h.getByTags(w, r)
+		return
+	}
+
+	if wantsNDJSON(r) {
+		writeNDJSON(w, r, h.storeFor(r))
+		return
+	}
+	w.Header().Set("Accept-Ranges", rangeUnit)
+	if writeRange(w, r, h.storeFor(r)) {
+		return
+	}
+
+	order, err := parseSort[models.Item](r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if order == nil && h.streamLists && wantsJSONStream(r) {
+		writeJSONStream(w, r, h.storeFor(r))
+		return
+	}
+
+	var items []models.Item
+	if order != nil {
+		items = h.storeFor(r).GetSorted(order)
+	} else {
+		items = h.storeFor(r).GetAll()
+		// A fixed order keeps the ETag of an unchanged list the same
+		storage.SortByID(items)
+	}
+	if wantsCSV(r) {
+		writeCSV(w, "", items)
+		return
+	}
+
+	writeList(w, r, items, storage.LastModified(h.storeFor(r)))
+}
+
+// itemDiff is the body returned by Diff
+type itemDiff struct {
+	Items      []models.Item `json:"items"`
+	DeletedIDs []string      `json:"deleted_ids"`
+	ServerTime time.Time     `json:"server_time"`
+}
+
+// Diff handles GET /items/diff?since=<RFC 3339 time>
+func (h *ItemHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	since, err := time.Parse(time.RFC3339, r.URL.Query().Get("since"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "since must be an RFC 3339 timestamp"})
+		return
+	}
+
+	// Taken before reading, so a change made while the response is built is
+	// sent again on the next sync instead of being missed
+	serverTime := time.Now()
+
+	deleted, ok := h.tombstones.Since(tenant.IDFromContext(r.Context()), since)
+	if !ok {
+		w.WriteHeader(http.StatusGone)
+		response.Encode(r.Context(), w, map[string]string{"error": "Deletions that old are no longer known; fetch the full list"})
+		return
+	}
+
+	response.Encode(r.Context(), w, itemDiff{
+		Items:      storage.UpdatedSince(h.storeFor(r), since),
+		DeletedIDs: deleted,
+		ServerTime: serverTime,
+	})
+}
+
+// ExportCSV handles GET /items/export.csv
+func (h *ItemHandler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	writeCSV(w, "items.csv", h.storeFor(r).GetAll())
+}
+
+// ImportCSV handles POST /items/import
+func (h *ItemHandler) ImportCSV(w http.ResponseWriter, r *http.Request) {
+	importCSV(w, r, h.storeFor(r), h.validate)
+}
+
+// GetByClient handles GET /clients/{id}/items
+func (h *ItemHandler) GetByClient(w http.ResponseWriter, r *http.Request) {
+	clientID := mux.Vars(r)["id"]
+
+	items, err := storage.Filter(h.storeFor(r), map[string]string{"client_id": clientID})
+	if err != nil {
+		logger.FromContext(r.Context()).Error("filter items by client", "client_id", clientID, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to list items"})
+		return
+	}
+
+	storage.SortByID(items)
+	writeList(w, r, items, storage.LastModified(h.storeFor(r)))
 }
 
 // GetByID handles GET /items/{id}
 func (h *ItemHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
-	item, exists := h.store.GetByID(id)
+	item, exists := h.storeFor(r).GetByID(id)
 
 	if !exists {
 		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Item not found"})
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
 		return
 	}
 
-	json.NewEncoder(w).Encode(item)
+	response.Encode(r.Context(), w, item)
+}
+
+// GetBatch handles GET /items/batch?ids=id1,id2
+func (h *ItemHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseIDs(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	found := h.storeFor(r).GetMany(ids)
+	response.Encode(r.Context(), w, batchResponse(ids, found))
 }
 
 // Create handles POST /items
 func (h *ItemHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var item models.Item
 	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := h.validate(item); err != nil {
+		writeValidationError(w, r, err)
 		return
 	}
 
-	created := h.store.Create(item)
+	created, err := h.storeFor(r).TryCreate(item)
+	if err != nil {
+		writeCreateError(w, r, "item", err)
+		return
+	}
+	setLocation(w, h.urls, "items.get", "id", created.ID)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(created)
+	response.Encode(r.Context(), w, created)
 }
 
 // Update handles PUT /items/{id}
@@ -60,30 +227,237 @@ func (h *ItemHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	var item models.Item
 	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	if err := h.validate(item); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+
+	current, exists := h.storeFor(r).GetByID(id)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
+		return
+	}
+
+	// Status only changes through a valid transition; omitting it keeps the current one
+	if item.Status == "" {
+		item.Status = current.Status
+	} else if item.Status != current.Status {
+		if err := models.ValidateTransition(current.Status, item.Status); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	// Pinning the version read above makes a concurrent change fail the
+	// update with 409 instead of being overwritten
+	if item.Version == 0 {
+		item.Version = current.Version
+	}
+
+	updated, err := h.storeFor(r).Update(id, item)
+	if err != nil {
+		writeItemUpdateError(w, r, id, err)
+		return
+	}
+
+	response.Encode(r.Context(), w, updated)
+}
+
+// Patch handles PATCH /items/{id} with a JSON Merge Patch body. A changed
+// status must be a valid transition.
+func (h *ItemHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	body, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	current, exists := h.storeFor(r).GetByID(id)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
+		return
+	}
+
+	item, err := patch.Apply(current, body)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request payload"})
+		response.Encode(r.Context(), w, map[string]string{"error": "Invalid patch: " + err.Error()})
 		return
 	}
+	item.ID, item.TenantID, item.CreatedAt, item.UpdatedAt = current.ID, current.TenantID, current.CreatedAt, current.UpdatedAt
+	if err := h.validate(item); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	if item.Status != current.Status {
+		if err := models.ValidateTransition(current.Status, item.Status); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+			return
+		}
+	}
 
-	updated, exists := h.store.Update(id, item)
+	// The version read above is kept unless the patch sets one, so a
+	// concurrent change fails the patch with 409
+	updated, err := h.storeFor(r).Update(id, item)
+	if err != nil {
+		writeItemUpdateError(w, r, id, err)
+		return
+	}
+
+	response.Encode(r.Context(), w, updated)
+}
+
+// UpdateStatus handles PATCH /items/{id}/status
+func (h *ItemHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	item, exists := h.storeFor(r).GetByID(id)
 	if !exists {
 		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Item not found"})
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
+		return
+	}
+
+	if err := models.ValidateTransition(item.Status, req.Status); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Passing the version we read makes a concurrent change fail with 409
+	item.Status = req.Status
+	updated, err := h.storeFor(r).Update(id, item)
+	if err != nil {
+		writeItemUpdateError(w, r, id, err)
+		return
+	}
+
+	response.Encode(r.Context(), w, updated)
+}
+
+// BatchUpdate handles PATCH /items/batch. Every item equal to the filter
+// gets the patch fields; if any of them fails validation none are changed.
+func (h *ItemHandler) BatchUpdate(w http.ResponseWriter, r *http.Request) {
+	var req batchUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if err := req.check(storage.SortableFields[models.Item]()); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	}
+	if _, err := patched(models.Item{}, req.Patch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "Invalid patch: " + err.Error()})
+		return
+	}
+
+	matched := 0
+	updated, err := storage.UpdateWhere(h.storeFor(r),
+		func(item models.Item) bool { return matches(item, req.Filter) },
+		func(item models.Item) (models.Item, error) {
+			if matched++; matched > maxBatchUpdate {
+				return item, errBatchTooLarge
+			}
+			next, err := patched(item, req.Patch)
+			if err != nil {
+				return item, err
+			}
+			if err := h.validate(next); err != nil {
+				return item, err
+			}
+			if next.Status != item.Status {
+				if err := models.ValidateTransition(item.Status, next.Status); err != nil {
+					return item, err
+				}
+			}
+			return next, nil
+		})
+	if err != nil {
+		writeItemBatchError(w, r, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(updated)
+	storage.SortByID(updated)
+	ids := make([]string, len(updated))
+	for i, item := range updated {
+		ids[i] = item.ID
+	}
+	response.Encode(r.Context(), w, batchUpdateResult{UpdatedCount: len(ids), IDs: ids})
+}
+
+// writeItemBatchError maps a storage.UpdateWhere error to a response
+func writeItemBatchError(w http.ResponseWriter, r *http.Request, err error) {
+	var rerr *storage.RecordError
+	var verr *validation.ValidationError
+	switch {
+	case errors.Is(err, errBatchTooLarge):
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+	case !errors.As(err, &rerr):
+		logger.FromContext(r.Context()).Error("batch update items", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to update items"})
+	case errors.As(err, &verr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		response.Encode(r.Context(), w, map[string]any{"id": rerr.ID, "errors": verr.Errors})
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrVersionConflict):
+		writeItemUpdateError(w, r, rerr.ID, rerr.Err)
+	default:
+		// A status transition the item can't make
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		response.Encode(r.Context(), w, map[string]string{"id": rerr.ID, "error": rerr.Err.Error()})
+	}
+}
+
+// writeItemUpdateError maps a Store.Update error to a response
+func writeItemUpdateError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
+	case errors.Is(err, storage.ErrVersionConflict):
+		w.WriteHeader(http.StatusConflict)
+		response.Encode(r.Context(), w, map[string]string{"error": "Item was modified by another request; fetch the latest version and retry"})
+	default:
+		logger.FromContext(r.Context()).Error("update item", "id", id, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to update item"})
+	}
 }
 
 // Delete handles DELETE /items/{id}
 func (h *ItemHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 
-	if !h.store.Delete(id) {
+	deleted, exists := h.storeFor(r).DeleteAndReturn(id)
+	if !exists {
 		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Item not found"})
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
 		return
 	}
 
+	if h.deleteReturnsBody {
+		response.Encode(r.Context(), w, deleted)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-api/models"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// racingStore changes a record right after it is read, as a concurrent
+// request would between a handler's read and its update
+type racingStore struct {
+	storage.Store[models.Item]
+}
+
+func (s racingStore) GetByID(id string) (models.Item, bool) {
+	item, exists := s.Store.GetByID(id)
+	if exists {
+		changed := item
+		changed.Name = "changed concurrently"
+		s.Store.Update(id, changed)
+	}
+	return item, exists
+}
+
+// put sends a PUT of body for id to handle
+func put(handle http.HandlerFunc, id, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPut, "/items/"+id, strings.NewReader(body))
+	r = mux.SetURLVars(r, map[string]string{"id": id})
+	w := httptest.NewRecorder()
+	handle(w, r)
+	return w
+}
+
+func TestItemUpdateWithoutVersionConflicts(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	item := store.Create(models.Item{Name: "widget", Quantity: 1, Status: models.StatusDraft})
+	h := NewItemHandler(racingStore{store}, nil)
+
+	w := put(h.Update, item.ID, `{"name":"gadget","quantity":2}`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", w.Code, w.Body)
+	}
+	if got, _ := store.GetByID(item.ID); got.Name != "changed concurrently" {
+		t.Errorf("name = %q, want the concurrent change kept", got.Name)
+	}
+}
+
+func TestConcurrentItemUpdatesConflictOnce(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	item := store.Create(models.Item{Name: "widget", Quantity: 1, Status: models.StatusDraft})
+	h := NewItemHandler(store, nil)
+
+	for range 50 {
+		current, _ := store.GetByID(item.ID)
+		body := fmt.Sprintf(`{"name":"gadget","quantity":2,"version":%d}`, current.Version)
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		codes := make([]int, 2)
+		for i := range codes {
+			wg.Go(func() {
+				<-start
+				codes[i] = put(h.Update, item.ID, body).Code
+			})
+		}
+		close(start)
+		wg.Wait()
+
+		slices.Sort(codes)
+		if codes[0] != http.StatusOK || codes[1] != http.StatusConflict {
+			t.Fatalf("statuses = %v, want one 200 and one 409", codes)
+		}
+	}
+}
+
+func TestItemListSort(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	store.Replace([]models.Item{
+		{ID: "1", Name: "bolt", Status: "published", Quantity: 5},
+		{ID: "2", Name: "axle", Status: "draft", Quantity: 1},
+		{ID: "3", Name: "cog", Status: "published", Quantity: 9},
+	})
+	h := NewItemHandler(store, nil)
+	list := func(sort string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.GetAll(w, httptest.NewRequest(http.MethodGet, "/items?sort="+sort, nil))
+		return w
+	}
+
+	w := list("status,-quantity")
+	var items []models.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("status %d: %v", w.Code, err)
+	}
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	if want := []string{"2", "3", "1"}; !slices.Equal(ids, want) {
+		t.Errorf("sort=status,-quantity: order %v, want %v", ids, want)
+	}
+
+	w = list("name,-price,colour")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown fields: status %d, want 400", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "-price") || !strings.Contains(body, "colour") || strings.Contains(body, "name") {
+		t.Errorf("error %s, want both unknown fields and only them", body)
+	}
+}
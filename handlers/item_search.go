@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-api/models"
+	"go-api/response"
+	"go-api/storage"
+)
+
+// searchLimit is the most results a search returns
+const searchLimit = 20
+
+// searchResult is one entry of GET /items/search
+type searchResult struct {
+	Item  models.Item `json:"item"`
+	Score float64     `json:"score"`
+}
+
+// Search handles GET /items/search?q=widget. With fuzzy=true items are
+// ranked by the trigram similarity of their name and description to q, so
+// typos still match. min_score sets the lowest similarity returned; by
+// default any item sharing a trigram with q is. Otherwise items containing
+// q, ignoring case, are returned with a score of 1.
+func (h *ItemHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "q is required"})
+		return
+	}
+	fuzzy, err := strconv.ParseBool(query.Get("fuzzy"))
+	if err != nil && query.Has("fuzzy") {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "fuzzy must be true or false"})
+		return
+	}
+	var minScore float64
+	if raw := query.Get("min_score"); raw != "" {
+		minScore, err = strconv.ParseFloat(raw, 64)
+		if err != nil || minScore < 0 || minScore > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			response.Encode(r.Context(), w, map[string]string{"error": "min_score must be a number between 0 and 1"})
+			return
+		}
+	}
+
+	results := make([]searchResult, 0)
+	if fuzzy {
+		for _, match := range storage.Search(h.storeFor(r), q, minScore) {
+			results = append(results, searchResult{Item: match.Record, Score: match.Score})
+		}
+	} else {
+		q = strings.ToLower(q)
+		for _, item := range h.storeFor(r).GetAll() {
+			if strings.Contains(strings.ToLower(item.Name+" "+item.Description), q) {
+				results = append(results, searchResult{Item: item, Score: 1})
+			}
+		}
+	}
+	if len(results) > searchLimit {
+		results = results[:searchLimit]
+	}
+	response.Encode(r.Context(), w, results)
+}
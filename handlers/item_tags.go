@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go-api/logger"
+	"go-api/models"
+	"go-api/response"
+	"go-api/storage"
+	"go-api/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// tagCount is one entry of GET /tags
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// parseTags splits a comma-separated tag list, dropping empty entries
+func parseTags(raw string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// getByTags handles GET /items?tags=go,api (every tag) and
+// GET /items?any_tag=go,api (at least one)
+func (h *ItemHandler) getByTags(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Has("tags") && query.Has("any_tag") {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "use either tags or any_tag, not both"})
+		return
+	}
+	all := query.Has("tags")
+	tags := parseTags(query.Get("any_tag"))
+	if all {
+		tags = parseTags(query.Get("tags"))
+	}
+	if len(tags) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "list at least one tag"})
+		return
+	}
+
+	items := storage.ByTags(h.storeFor(r), tags, all)
+	w.Header().Set("Accept-Ranges", rangeUnit)
+	if writeRangeOf(w, r, items) {
+		return
+	}
+
+	page, _ := storage.PageOf(items, 0, len(items))
+	if page == nil {
+		page = make([]models.Item, 0)
+	}
+	writeList(w, r, page, storage.LastModified(h.storeFor(r)))
+}
+
+// Tags handles GET /tags
+func (h *ItemHandler) Tags(w http.ResponseWriter, r *http.Request) {
+	counts := storage.TagCounts(h.storeFor(r))
+	tags := make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, tagCount{Tag: tag, Count: count})
+	}
+	slices.SortFunc(tags, func(a, b tagCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	response.Encode(r.Context(), w, tags)
+}
+
+// AddTag handles POST /items/{id}/tags/{tag}. Adding a tag the item already
+// has changes nothing.
+func (h *ItemHandler) AddTag(w http.ResponseWriter, r *http.Request) {
+	tag := mux.Vars(r)["tag"]
+	if err := validation.Tag(tag); err != nil {
+		writeValidationError(w, r, err)
+		return
+	}
+	h.retag(w, r, func(item models.Item) (models.Item, error) {
+		if slices.Contains(item.Tags, tag) {
+			return item, nil
+		}
+		item.Tags = append(slices.Clone(item.Tags), tag)
+		return item, h.validate(item)
+	})
+}
+
+// RemoveTag handles DELETE /items/{id}/tags/{tag}. Removing a tag the item
+// doesn't have changes nothing.
+func (h *ItemHandler) RemoveTag(w http.ResponseWriter, r *http.Request) {
+	tag := mux.Vars(r)["tag"]
+	h.retag(w, r, func(item models.Item) (models.Item, error) {
+		item.Tags = slices.DeleteFunc(slices.Clone(item.Tags), func(t string) bool { return t == tag })
+		return item, nil
+	})
+}
+
+// retag applies change to the tags of the item in one atomic update, so a
+// concurrent tag change isn't lost
+func (h *ItemHandler) retag(w http.ResponseWriter, r *http.Request, change func(models.Item) (models.Item, error)) {
+	id := mux.Vars(r)["id"]
+	updated, err := storage.UpdateWhere(h.storeFor(r), func(item models.Item) bool {
+		return item.ID == id
+	}, change)
+	var verr *validation.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeValidationError(w, r, verr)
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).Error("change item tags", "id", id, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to update item"})
+		return
+	case len(updated) == 0:
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
+		return
+	}
+
+	response.Encode(r.Context(), w, updated[0])
+}
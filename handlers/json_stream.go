@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+
+	"go-api/logger"
+	"go-api/response"
+	"go-api/storage"
+)
+
+// streamBufferSize is how much of a streamed list is batched per write
+const streamBufferSize = 8 << 10
+
+// wantsJSONStream reports whether a list for r can be streamed as a JSON
+// array rather than CSV or MessagePack
+func wantsJSONStream(r *http.Request) bool {
+	return response.ContentType(r.Context()) == response.JSON && !wantsCSV(r)
+}
+
+// writeJSONStream writes every record of store as a JSON array, encoding
+// each as it is visited instead of marshalling the whole list first. The
+// body is sent chunked, without the ETag and Last-Modified of writeList,
+// since it is never held whole. An encoding error ends the array early.
+func writeJSONStream[T any](w http.ResponseWriter, r *http.Request, store storage.Store[T]) {
+	bw := bufio.NewWriterSize(w, streamBufferSize)
+	enc := json.NewEncoder(bw)
+	first := true
+	// Encoding through one pointer saves boxing every record
+	current := new(T)
+
+	bw.WriteByte('[')
+	storage.Range(store, func(record T) bool {
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+		*current = record
+		if err := enc.Encode(current); err != nil {
+			logger.FromContext(r.Context()).Error("stream list", "error", err)
+			return false
+		}
+		return true
+	})
+	bw.WriteString("]\n")
+	bw.Flush()
+}
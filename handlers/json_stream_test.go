@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+// itemStore returns a memory store holding n items
+func itemStore(n int) *storage.MemoryStore[models.Item] {
+	store := storage.NewMemoryStore[models.Item]()
+	batch := make([]models.Item, n)
+	for i := range batch {
+		batch[i] = models.Item{Name: fmt.Sprintf("item %d", i), Quantity: i, Tags: []string{"bulk"}}
+	}
+	store.CreateMany(batch)
+	return store
+}
+
+func TestGetAllStreamsJSONArray(t *testing.T) {
+	for _, n := range []int{0, 1, 500} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			store := itemStore(n)
+			h := NewItemHandler(store, nil)
+			h.SetStreamLists(true)
+
+			w := httptest.NewRecorder()
+			h.GetAll(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d", w.Code)
+			}
+			if w.Header().Get("ETag") != "" {
+				t.Error("streamed list has an ETag")
+			}
+			var items []models.Item
+			if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+				t.Fatalf("body isn't a JSON array: %v", err)
+			}
+			if items == nil || len(items) != n {
+				t.Fatalf("streamed %d items, want %d", len(items), n)
+			}
+			for _, item := range items {
+				if stored, _ := store.GetByID(item.ID); stored.Name != item.Name || stored.Quantity != item.Quantity {
+					t.Fatalf("streamed %+v, stored %+v", item, stored)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAllSortedDoesNotStream(t *testing.T) {
+	h := NewItemHandler(itemStore(3), nil)
+	h.SetStreamLists(true)
+
+	w := httptest.NewRecorder()
+	h.GetAll(w, httptest.NewRequest(http.MethodGet, "/items?sort=name", nil))
+	if w.Header().Get("ETag") == "" {
+		t.Error("sorted list has no ETag; it was streamed")
+	}
+	var items []models.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[0].Name != "item 0" || items[2].Name != "item 2" {
+		t.Errorf("sorted list %+v", items)
+	}
+}
+
+// discardWriter is a ResponseWriter dropping the body, so benchmarks
+// measure the handler and not a growing recorder
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// benchmarkGetAll lists 100,000 items with streaming on or off
+func benchmarkGetAll(b *testing.B, stream bool) {
+	h := NewItemHandler(itemStore(100000), nil)
+	h.SetStreamLists(stream)
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		h.GetAll(&discardWriter{header: make(http.Header)}, r)
+	}
+}
+
+func BenchmarkGetAllSlice(b *testing.B) {
+	benchmarkGetAll(b, false)
+}
+
+func BenchmarkGetAllStream(b *testing.B) {
+	benchmarkGetAll(b, true)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-api/logger"
+	"go-api/models"
+	"go-api/response"
+	"go-api/service"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultLedgerLimit is how many entries the ledger history returns
+	// without ?limit=
+	defaultLedgerLimit = 50
+	// maxLedgerLimit is the largest accepted ?limit=
+	maxLedgerLimit = 1000
+)
+
+// LedgerHandler handles HTTP requests for client balances
+type LedgerHandler struct {
+	service *service.LedgerService
+}
+
+// NewLedgerHandler creates a ledger handler
+func NewLedgerHandler(svc *service.LedgerService) *LedgerHandler {
+	return &LedgerHandler{service: svc}
+}
+
+// ledgerRequest is the body of a credit or debit
+type ledgerRequest struct {
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+}
+
+// Credit handles POST /clients/{id}/credits
+func (h *LedgerHandler) Credit(w http.ResponseWriter, r *http.Request) {
+	h.post(w, r, h.service.Credit)
+}
+
+// Debit handles POST /clients/{id}/debits
+func (h *LedgerHandler) Debit(w http.ResponseWriter, r *http.Request) {
+	h.post(w, r, h.service.Debit)
+}
+
+func (h *LedgerHandler) post(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, clientID string, amount float64, reference string) (models.Ledger, error)) {
+	id := mux.Vars(r)["id"]
+
+	var req ledgerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if req.Amount <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": "amount must be a positive number"})
+		return
+	}
+
+	entry, err := apply(r.Context(), id, req.Amount, req.Reference)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
+		return
+	case errors.Is(err, service.ErrInsufficientBalance):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).Error("post ledger entry", "client_id", id, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to update balance"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response.Encode(r.Context(), w, entry)
+}
+
+// History handles GET /clients/{id}/ledger?offset=0&limit=50, newest first.
+// A Range header pages the history like the other lists.
+func (h *LedgerHandler) History(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.service.History(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Client not found"})
+		return
+	}
+	w.Header().Set("Accept-Ranges", rangeUnit)
+	if writeRangeWith(w, r, func(offset, limit int) ([]models.Ledger, int) {
+		return storage.Window(entries, offset, limit)
+	}) {
+		return
+	}
+
+	offset, limit := 0, defaultLedgerLimit
+	if raw := r.URL.Query().Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			response.Encode(r.Context(), w, map[string]string{"error": "offset must be a non-negative integer"})
+			return
+		}
+		offset = n
+	}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxLedgerLimit {
+			w.WriteHeader(http.StatusBadRequest)
+			response.Encode(r.Context(), w, map[string]string{"error": "limit must be between 1 and " + strconv.Itoa(maxLedgerLimit)})
+			return
+		}
+		limit = n
+	}
+
+	page, _ := storage.Window(entries, offset, limit)
+	if page == nil {
+		page = []models.Ledger{}
+	}
+	response.Encode(r.Context(), w, page)
+}
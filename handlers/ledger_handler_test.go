@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-api/models"
+	"go-api/service"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLedgerHistoryPages(t *testing.T) {
+	clients := storage.NewMemoryStore[models.Client]()
+	svc := service.NewLedgerService(clients, storage.NewMemoryStore[models.Ledger]())
+	client := clients.Create(models.Client{Name: "Acme", Email: "billing@acme.example"})
+	for i := range 5 {
+		if _, err := svc.Credit(context.Background(), client.ID, float64(i+1), fmt.Sprintf("ref-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := NewLedgerHandler(svc)
+
+	get := func(query, rangeHeader string) (*httptest.ResponseRecorder, []models.Ledger) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, "/clients/"+client.ID+"/ledger"+query, nil)
+		r = mux.SetURLVars(r, map[string]string{"id": client.ID})
+		if rangeHeader != "" {
+			r.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		h.History(w, r)
+		var entries []models.Ledger
+		if w.Code < 300 {
+			if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w, entries
+	}
+	refs := func(entries []models.Ledger) []string {
+		refs := make([]string, len(entries))
+		for i, e := range entries {
+			refs[i] = e.Reference
+		}
+		return refs
+	}
+
+	tests := []struct {
+		query, rangeHeader string
+		status             int
+		want               string
+	}{
+		{"", "", http.StatusOK, "[ref-4 ref-3 ref-2 ref-1 ref-0]"},
+		{"?limit=2", "", http.StatusOK, "[ref-4 ref-3]"},
+		{"?offset=2&limit=2", "", http.StatusOK, "[ref-2 ref-1]"},
+		{"?offset=4&limit=2", "", http.StatusOK, "[ref-0]"},
+		{"?offset=9", "", http.StatusOK, "[]"},
+		{"", "items=1-2", http.StatusPartialContent, "[ref-3 ref-2]"},
+		{"", "items=3-", http.StatusPartialContent, "[ref-1 ref-0]"},
+		{"", "items=5-", http.StatusRequestedRangeNotSatisfiable, "[]"},
+		{"?offset=-1", "", http.StatusBadRequest, "[]"},
+		{"?limit=0", "", http.StatusBadRequest, "[]"},
+	}
+	for _, tt := range tests {
+		w, entries := get(tt.query, tt.rangeHeader)
+		if w.Code != tt.status || fmt.Sprint(refs(entries)) != tt.want {
+			t.Errorf("%q with Range %q: status %d, %v; want %d, %s", tt.query, tt.rangeHeader, w.Code, refs(entries), tt.status, tt.want)
+		}
+	}
+	if w, _ := get("", "items=0-1"); w.Header().Get("Content-Range") != "items 0-1/5" {
+		t.Errorf("Content-Range = %q, want items 0-1/5", w.Header().Get("Content-Range"))
+	}
+}
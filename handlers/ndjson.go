@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go-api/storage"
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON
+const ndjsonContentType = "application/x-ndjson"
+
+// wantsNDJSON reports whether the client asked for newline-delimited JSON
+// in the Accept header
+func wantsNDJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), ndjsonContentType)
+}
+
+// writeNDJSON streams every record of store as one JSON object per line,
+// flushing after each so large lists are never held in memory
+func writeNDJSON[T any](w http.ResponseWriter, r *http.Request, store storage.Store[T]) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	records := make(chan T, 64)
+	go storage.StreamAll(ctx, store, records)
+
+	// Without a Content-Length, flushing sends the body chunked
+	w.Header().Set("Content-Type", ndjsonContentType)
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	for record := range records {
+		if err := enc.Encode(record); err != nil {
+			cancel()
+			continue
+		}
+		rc.Flush()
+	}
+}
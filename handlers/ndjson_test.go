@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+func TestGetAllStreamsNDJSON(t *testing.T) {
+	const count = 10000
+	store := storage.NewMemoryStore[models.Item]()
+	batch := make([]models.Item, count)
+	for i := range batch {
+		batch[i] = models.Item{Name: fmt.Sprintf("item %d", i), Quantity: i}
+	}
+	store.CreateMany(batch)
+	server := httptest.NewServer(http.HandlerFunc(NewItemHandler(store, nil).GetAll))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept", ndjsonContentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != ndjsonContentType {
+		t.Errorf("Content-Type = %q, want %s", ct, ndjsonContentType)
+	}
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Transfer-Encoding = %v, want chunked", resp.TransferEncoding)
+	}
+
+	seen := make(map[string]bool, count)
+	n := 0
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		n++
+		var item models.Item
+		if err := json.Unmarshal(lines.Bytes(), &item); err != nil {
+			t.Fatalf("line %d: %v", n, err)
+		}
+		seen[item.ID] = true
+	}
+	if err := lines.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != count || len(seen) != count {
+		t.Errorf("streamed %d lines of %d distinct items, want %d", n, len(seen), count)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// OpenAPI handles GET /openapi.json with an OpenAPI 3.0 document describing
+// every named route of router. Request bodies use the same models as the
+// Postman collection.
+func OpenAPI(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		paths := make(map[string]map[string]any)
+		router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			name := route.GetName()
+			methods, err := route.GetMethods()
+			if name == "" || err != nil {
+				return nil
+			}
+			pattern, _ := route.GetPathTemplate()
+			path, params := openAPIPath(pattern)
+			if paths[path] == nil {
+				paths[path] = make(map[string]any)
+			}
+			for _, method := range methods {
+				paths[path][strings.ToLower(method)] = openAPIOperation(name, method, path, params)
+			}
+			return nil
+		})
+
+		response.Encode(r.Context(), w, map[string]any{
+			"openapi": "3.0.3",
+			"info":    map[string]string{"title": "go-api", "version": "1.0.0"},
+			"servers": []map[string]string{{"url": baseURL(r)}},
+			"paths":   paths,
+		})
+	}
+}
+
+// openAPIPath turns a mux path template into an OpenAPI path, dropping the
+// patterns of its variables, and returns the variable names
+func openAPIPath(pattern string) (string, []string) {
+	var params []string
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if v, ok := strings.CutPrefix(segment, "{"); ok {
+			v, _, _ = strings.Cut(strings.TrimSuffix(v, "}"), ":")
+			params = append(params, v)
+			segments[i] = "{" + v + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func openAPIOperation(name, method, path string, params []string) map[string]any {
+	op := map[string]any{
+		"operationId": name,
+		"responses": map[string]any{
+			"2XX": map[string]string{"description": "Success"},
+			"default": map[string]any{
+				"description": "Error",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"error": map[string]string{"type": "string"}},
+					}},
+				},
+			},
+		},
+	}
+	if tag := openAPITag(path); tag != "" {
+		op["tags"] = []string{tag}
+	}
+
+	if len(params) > 0 {
+		parameters := make([]map[string]any, len(params))
+		for i, p := range params {
+			parameters[i] = map[string]any{
+				"name":     p,
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]string{"type": "string"},
+			}
+		}
+		op["parameters"] = parameters
+	}
+
+	if model, ok := postmanBodies[name]; ok && (method == http.MethodPost || method == http.MethodPut) {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(model))},
+			},
+		}
+	}
+	return op
+}
+
+// openAPITag groups routes by the first path segment after the API
+// version, such as /api/v1
+func openAPITag(path string) string {
+	rest, ok := strings.CutPrefix(path, "/api/")
+	if !ok {
+		return ""
+	}
+	_, rest, _ = strings.Cut(rest, "/")
+	tag, _, _ := strings.Cut(rest, "/")
+	return tag
+}
+
+// schemaOf returns the JSON schema of values of type t. Server fields are
+// marked read-only.
+func schemaOf(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t == reflect.TypeFor[time.Time]() {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		properties := make(map[string]any)
+		for i := range t.NumField() {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			schema := schemaOf(t.Field(i).Type)
+			if serverFields[name] {
+				schema["readOnly"] = true
+			}
+			properties[name] = schema
+		}
+		return map[string]any{"type": "object", "properties": properties}
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-api/storage"
+
+	"github.com/google/uuid"
+)
+
+// maxBatchIDs is the largest number of IDs accepted by a batch lookup
+const maxBatchIDs = 100
+
+// parseIDs reads the comma-separated ?ids= query parameter, dropping duplicates
+func parseIDs(r *http.Request) ([]string, error) {
+	raw := r.URL.Query().Get("ids")
+	if raw == "" {
+		return nil, errors.New("ids query parameter is required")
+	}
+
+	parts := strings.Split(raw, ",")
+	ids := make([]string, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, id := range parts {
+		id = strings.TrimSpace(id)
+		if seen[id] {
+			continue
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("invalid id %q", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if len(ids) > maxBatchIDs {
+		return nil, fmt.Errorf("at most %d ids are allowed per request", maxBatchIDs)
+	}
+	return ids, nil
+}
+
+// batchResponse builds the GetBatch body: found records keyed by ID, plus
+// the IDs that were not found under "not_found"
+func batchResponse[T any](ids []string, found map[string]T) map[string]any {
+	resp := make(map[string]any, len(found)+1)
+	notFound := make([]string, 0)
+	for _, id := range ids {
+		if item, ok := found[id]; ok {
+			resp[id] = item
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+	resp["not_found"] = notFound
+	return resp
+}
+
+// parseSort reads the comma-separated ?sort= query parameter, such as
+// "status,-created_at"; a leading "-" sorts that field descending. It
+// returns nil when the parameter is absent.
+func parseSort[T any](r *http.Request) ([]storage.SortField, error) {
+	raw := r.URL.Query().Get("sort")
+	if raw == "" {
+		return nil, nil
+	}
+
+	sortable := storage.SortableFields[T]()
+	var fields []storage.SortField
+	var invalid []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		name, desc := strings.CutPrefix(part, "-")
+		if _, ok := sortable[name]; !ok {
+			invalid = append(invalid, part)
+			continue
+		}
+		fields = append(fields, storage.SortField{Field: name, Desc: desc})
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid sort fields: %s", strings.Join(invalid, ", "))
+	}
+	return fields, nil
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-api/events"
+	"go-api/response"
+	"go-api/tenant"
+)
+
+// pollHistory is the number of recent events kept for long-polling clients
+const pollHistory = 1000
+
+// errCursorExpired is returned for cursors older than the retained history,
+// or made before the handler started
+var errCursorExpired = errors.New("cursor expired")
+
+// PollHandler serves store mutations to long-polling clients that cannot
+// use server-sent events. Each recorded event gets the next sequence
+// number, and a cursor holds the number of the last event its client has
+// seen, so no event is skipped or sent twice.
+type PollHandler struct {
+	secret  []byte
+	timeout time.Duration
+	cancel  events.CancelFunc
+	done    chan struct{}
+	once    sync.Once
+	// epoch tells cursors of this handler from those of an earlier
+	// process, whose sequence numbers meant other events
+	epoch uint64
+
+	mu      sync.Mutex
+	history []events.Event // the events numbered seq-len(history)+1 to seq
+	seq     uint64         // the sequence number of the last event recorded
+	changed chan struct{}  // closed and replaced whenever an event arrives
+}
+
+// pollResponse is the body of a long-poll response
+type pollResponse struct {
+	Events []events.Event `json:"events"`
+	Cursor string         `json:"cursor"`
+}
+
+// NewPollHandler creates a handler that keeps recent events of bus on the
+// topics matching pattern and signs its cursors with secret. Requests wait up
+// to timeout for a change.
+func NewPollHandler(bus *events.Bus, pattern string, secret []byte, timeout time.Duration) *PollHandler {
+	stream, cancel := bus.Subscribe(pattern)
+	h := &PollHandler{
+		secret:  secret,
+		timeout: timeout,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		epoch:   uint64(time.Now().UnixNano()),
+		changed: make(chan struct{}),
+	}
+	go h.record(stream)
+	return h
+}
+
+func (h *PollHandler) record(stream <-chan events.Event) {
+	for e := range stream {
+		h.mu.Lock()
+		h.history = append(h.history, e)
+		h.seq++
+		if len(h.history) > pollHistory {
+			h.history = h.history[len(h.history)-pollHistory:]
+		}
+		close(h.changed)
+		h.changed = make(chan struct{})
+		h.mu.Unlock()
+	}
+}
+
+// Close stops recording events and releases waiting requests
+func (h *PollHandler) Close() {
+	h.once.Do(func() {
+		h.cancel()
+		close(h.done)
+	})
+}
+
+// since returns the recorded events of tenantID after the one numbered
+// seen, the sequence number of the last event recorded, and a channel closed
+// when the next event of any tenant arrives. It returns errCursorExpired
+// when events after seen have been dropped from the history.
+func (h *PollHandler) since(tenantID string, seen uint64) (found []events.Event, last uint64, changed <-chan struct{}, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	oldest := h.seq - uint64(len(h.history)) + 1
+	if seen+1 < oldest || seen > h.seq {
+		return nil, 0, nil, errCursorExpired
+	}
+	for _, e := range h.history[seen+1-oldest:] {
+		if e.VisibleTo(tenantID) {
+			found = append(found, e)
+		}
+	}
+	return found, h.seq, h.changed, nil
+}
+
+// last returns the sequence number of the last event recorded
+func (h *PollHandler) last() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.seq
+}
+
+// Poll handles GET /{resource}/poll?since=<cursor>
+func (h *PollHandler) Poll(w http.ResponseWriter, r *http.Request) {
+	seen := h.last()
+	if cursor := r.URL.Query().Get("since"); cursor != "" {
+		var err error
+		if seen, err = h.decodeCursor(cursor); err != nil {
+			writeCursorError(w, r, err)
+			return
+		}
+	}
+
+	tenantID := tenant.IDFromContext(r.Context())
+	timer := time.NewTimer(h.timeout)
+	defer timer.Stop()
+
+	for {
+		found, last, changed, err := h.since(tenantID, seen)
+		if err != nil {
+			writeCursorError(w, r, err)
+			return
+		}
+		// Events of other tenants up to last are skipped along with the
+		// caller's
+		if len(found) > 0 {
+			response.Encode(r.Context(), w, pollResponse{Events: found, Cursor: h.encodeCursor(last)})
+			return
+		}
+		seen = last
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			response.Encode(r.Context(), w, pollResponse{Events: []events.Event{}, Cursor: h.encodeCursor(seen)})
+			return
+		case <-h.done:
+			response.Encode(r.Context(), w, pollResponse{Events: []events.Event{}, Cursor: h.encodeCursor(seen)})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeCursorError maps a decodeCursor or since error to a response
+func writeCursorError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errCursorExpired) {
+		w.WriteHeader(http.StatusGone)
+		response.Encode(r.Context(), w, map[string]string{"error": "Events after this cursor are no longer kept; fetch the full list and poll without a cursor"})
+		return
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	response.Encode(r.Context(), w, map[string]string{"error": "Invalid cursor"})
+}
+
+// encodeCursor returns the handler's epoch and seq as an HMAC-signed opaque
+// token
+func (h *PollHandler) encodeCursor(seq uint64) string {
+	buf := binary.BigEndian.AppendUint64(nil, h.epoch)
+	buf = binary.BigEndian.AppendUint64(buf, seq)
+	buf = append(buf, h.sign(buf)...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// decodeCursor returns the sequence number in a cursor made by
+// encodeCursor. A cursor of an earlier process, signed with the same
+// secret, gets errCursorExpired.
+func (h *PollHandler) decodeCursor(cursor string) (uint64, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(buf) != 16+sha256.Size {
+		return 0, errors.New("malformed cursor")
+	}
+	if !hmac.Equal(buf[16:], h.sign(buf[:16])) {
+		return 0, errors.New("cursor signature mismatch")
+	}
+	if binary.BigEndian.Uint64(buf[:8]) != h.epoch {
+		return 0, errCursorExpired
+	}
+	return binary.BigEndian.Uint64(buf[8:16]), nil
+}
+
+func (h *PollHandler) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, h.secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-api/events"
+	"go-api/tenant"
+)
+
+// sendPoll sends a long-poll request as tenantID
+func sendPoll(h *PollHandler, tenantID, cursor string) *httptest.ResponseRecorder {
+	target := "/items/poll"
+	if cursor != "" {
+		target += "?since=" + cursor
+	}
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r = r.WithContext(tenant.WithID(r.Context(), tenantID))
+	w := httptest.NewRecorder()
+	h.Poll(w, r)
+	return w
+}
+
+// poll sends a long-poll request as tenantID and decodes the response
+func poll(t *testing.T, h *PollHandler, tenantID, cursor string) pollResponse {
+	t.Helper()
+	w := sendPoll(h, tenantID, cursor)
+	if w.Code != http.StatusOK {
+		t.Fatalf("poll: status %d: %s", w.Code, w.Body)
+	}
+	var resp pollResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	return resp
+}
+
+func TestPollOnlyCallersTenant(t *testing.T) {
+	bus := events.NewBus()
+	h := NewPollHandler(bus, "item.*", []byte("secret"), 100*time.Millisecond)
+	defer h.Close()
+	waitForSubscribers(t, bus, "item.created", 1)
+
+	start := h.encodeCursor(h.last())
+	bus.Publish("item.created", events.Event{Type: events.Created, ID: "other", TenantID: "globex", Time: time.Now()})
+	bus.Publish("item.created", events.Event{Type: events.Created, ID: "mine", TenantID: "acme", Time: time.Now()})
+	time.Sleep(20 * time.Millisecond)
+
+	resp := poll(t, h, "acme", start)
+	if len(resp.Events) != 1 || resp.Events[0].ID != "mine" {
+		t.Fatalf("history = %+v, want only the caller's event", resp.Events)
+	}
+
+	// Another tenant's event doesn't end the wait
+	done := make(chan pollResponse)
+	go func() { done <- poll(t, h, "acme", resp.Cursor) }()
+	time.Sleep(20 * time.Millisecond)
+	bus.Publish("item.updated", events.Event{Type: events.Updated, ID: "other", TenantID: "globex", Time: time.Now()})
+	if resp := <-done; len(resp.Events) != 0 {
+		t.Errorf("wait returned %+v, want no events", resp.Events)
+	}
+}
+
+func TestPollCursorRejectsTampering(t *testing.T) {
+	h := NewPollHandler(events.NewBus(), "item.*", []byte("secret"), time.Millisecond)
+	defer h.Close()
+
+	other := NewPollHandler(events.NewBus(), "item.*", []byte("other"), time.Millisecond)
+	defer other.Close()
+
+	r := httptest.NewRequest(http.MethodGet, "/items/poll?since="+other.encodeCursor(0), nil)
+	w := httptest.NewRecorder()
+	h.Poll(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestPollReturnsOnNewEvent(t *testing.T) {
+	bus := events.NewBus()
+	h := NewPollHandler(bus, "item.*", []byte("secret"), 5*time.Second)
+	defer h.Close()
+	waitForSubscribers(t, bus, "item.created", 1)
+
+	start := time.Now()
+	done := make(chan pollResponse)
+	go func() { done <- poll(t, h, "", "") }()
+	time.Sleep(20 * time.Millisecond)
+	bus.Publish("item.created", events.Event{Type: events.Created, ID: "i1", Time: time.Now()})
+
+	resp := <-done
+	if len(resp.Events) != 1 || resp.Events[0].ID != "i1" {
+		t.Fatalf("events = %+v, want i1", resp.Events)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("poll took %s, want it to return as soon as the event arrived", elapsed)
+	}
+
+	// The cursor skips the events already returned
+	h.timeout = 10 * time.Millisecond
+	if next := poll(t, h, "", resp.Cursor); len(next.Events) != 0 {
+		t.Errorf("polling from the cursor returned %+v again", next.Events)
+	}
+}
+
+func TestPollTimesOut(t *testing.T) {
+	h := NewPollHandler(events.NewBus(), "item.*", []byte("secret"), 30*time.Millisecond)
+	defer h.Close()
+
+	start := time.Now()
+	resp := poll(t, h, "", "")
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("poll returned after %s, before the timeout", elapsed)
+	}
+	if resp.Events == nil || len(resp.Events) != 0 || resp.Cursor == "" {
+		t.Errorf("response = %+v, want no events and a new cursor", resp)
+	}
+	if _, err := h.decodeCursor(resp.Cursor); err != nil {
+		t.Errorf("timeout cursor: %v", err)
+	}
+}
+
+func TestPollCursorKeepsEventsOfTheSameTime(t *testing.T) {
+	bus := events.NewBus()
+	h := NewPollHandler(bus, "item.*", []byte("secret"), 10*time.Millisecond)
+	defer h.Close()
+	waitForSubscribers(t, bus, "item.created", 1)
+
+	at := time.Now()
+	start := h.encodeCursor(h.last())
+	bus.Publish("item.created", events.Event{Type: events.Created, ID: "i1", Time: at})
+	time.Sleep(20 * time.Millisecond)
+	first := poll(t, h, "", start)
+	if len(first.Events) != 1 || first.Events[0].ID != "i1" {
+		t.Fatalf("events = %+v, want i1", first.Events)
+	}
+
+	bus.Publish("item.created", events.Event{Type: events.Created, ID: "i2", Time: at})
+	time.Sleep(20 * time.Millisecond)
+	if next := poll(t, h, "", first.Cursor); len(next.Events) != 1 || next.Events[0].ID != "i2" {
+		t.Errorf("events = %+v, want i2 though it has the time of i1", next.Events)
+	}
+}
+
+func TestPollExpiredCursorIsGone(t *testing.T) {
+	h := NewPollHandler(events.NewBus(), "item.*", []byte("secret"), 10*time.Millisecond)
+	defer h.Close()
+
+	// Recorded without the bus, which drops events sent faster than a
+	// subscriber reads them
+	behind := h.encodeCursor(h.last())
+	stream := make(chan events.Event, pollHistory+1)
+	for range pollHistory + 1 {
+		stream <- events.Event{Type: events.Created, ID: "i", Time: time.Now()}
+	}
+	close(stream)
+	h.record(stream)
+
+	if w := sendPoll(h, "", behind); w.Code != http.StatusGone {
+		t.Errorf("cursor behind the history: status %d, want 410", w.Code)
+	}
+	if resp := poll(t, h, "", h.encodeCursor(1)); len(resp.Events) != pollHistory {
+		t.Errorf("cursor at the oldest kept event: %d events, want %d", len(resp.Events), pollHistory)
+	}
+
+	// A restarted handler signing with the same secret counts from 0 again
+	restarted := NewPollHandler(events.NewBus(), "item.*", []byte("secret"), 10*time.Millisecond)
+	defer restarted.Close()
+	if w := sendPoll(restarted, "", h.encodeCursor(1)); w.Code != http.StatusGone {
+		t.Errorf("cursor of an earlier process: status %d, want 410", w.Code)
+	}
+}
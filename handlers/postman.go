@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"go-api/models"
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// postmanSchema is the Postman collection format produced by Postman
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanBodies are the models whose example payloads fill the POST and PUT
+// requests of each route
+var postmanBodies = map[string]any{
+	"items.create":            models.Item{},
+	"items.update":            models.Item{},
+	"v2.items.create":         models.ItemV2{},
+	"v2.items.update":         models.ItemV2{},
+	"clients.create":          models.Client{},
+	"clients.update":          models.Client{},
+	"clients.contacts.create": models.Contact{},
+	"clients.contacts.update": models.Contact{},
+}
+
+// serverFields are set by the server and left out of example payloads
+var serverFields = map[string]bool{"id": true, "tenant_id": true, "balance": true, "created_at": true, "updated_at": true}
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Header []postmanHeader `json:"header"`
+	URL    postmanURL      `json:"url"`
+	Body   *postmanBody    `json:"body,omitempty"`
+}
+
+type postmanHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string         `json:"mode"`
+	Raw     string         `json:"raw"`
+	Options map[string]any `json:"options"`
+}
+
+type postmanVariable struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Enabled bool   `json:"enabled,omitempty"`
+}
+
+type postmanEnvironment struct {
+	Name   string            `json:"name"`
+	Values []postmanVariable `json:"values"`
+	Scope  string            `json:"_postman_variable_scope"`
+}
+
+// Postman handles GET /admin/postman with a Postman collection holding a
+// request for every named route of router
+func Postman(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collection := postmanCollection{
+			Info:     postmanInfo{Name: "go-api", Schema: postmanSchema},
+			Item:     make([]postmanItem, 0),
+			Variable: []postmanVariable{{Key: "base_url", Value: baseURL(r)}},
+		}
+		router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			name := route.GetName()
+			if name == "" {
+				return nil
+			}
+			pattern, _ := route.GetPathTemplate()
+			methods, _ := route.GetMethods()
+			for _, method := range methods {
+				collection.Item = append(collection.Item, postmanItem{
+					Name:    name,
+					Request: postmanRequestFor(name, method, pattern),
+				})
+			}
+			return nil
+		})
+		response.Encode(r.Context(), w, collection)
+	}
+}
+
+// PostmanEnvironment handles GET /admin/postman/environment with a Postman
+// environment defining the base_url the collection uses
+func PostmanEnvironment(w http.ResponseWriter, r *http.Request) {
+	response.Encode(r.Context(), w, postmanEnvironment{
+		Name:   "go-api",
+		Values: []postmanVariable{{Key: "base_url", Value: baseURL(r), Enabled: true}},
+		Scope:  "environment",
+	})
+}
+
+// baseURL returns the scheme and host r was sent to
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func postmanRequestFor(name, method, pattern string) postmanRequest {
+	var path []string
+	var vars []postmanVariable
+	for _, segment := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if v, ok := strings.CutPrefix(segment, "{"); ok {
+			v, _, _ = strings.Cut(strings.TrimSuffix(v, "}"), ":")
+			vars = append(vars, postmanVariable{Key: v})
+			segment = ":" + v
+		}
+		path = append(path, segment)
+	}
+
+	req := postmanRequest{
+		Method: method,
+		Header: []postmanHeader{{Key: "Accept", Value: "application/json"}},
+		URL: postmanURL{
+			Raw:      "{{base_url}}/" + strings.Join(path, "/"),
+			Host:     []string{"{{base_url}}"},
+			Path:     path,
+			Variable: vars,
+		},
+	}
+
+	if model, ok := postmanBodies[name]; ok && (method == http.MethodPost || method == http.MethodPut) {
+		raw, _ := json.MarshalIndent(examplePayload(reflect.ValueOf(model)), "", "  ")
+		req.Header = append(req.Header, postmanHeader{Key: "Content-Type", Value: "application/json"})
+		req.Body = &postmanBody{
+			Mode:    "raw",
+			Raw:     string(raw),
+			Options: map[string]any{"raw": map[string]string{"language": "json"}},
+		}
+	}
+	return req
+}
+
+// examplePayload builds an example JSON object for a model struct, with
+// placeholder values for its zero fields
+func examplePayload(v reflect.Value) map[string]any {
+	payload := make(map[string]any)
+	t := v.Type()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		switch {
+		case name == "" || name == "-" || serverFields[name]:
+		case name == "email" && v.Field(i).IsZero():
+			payload[name] = "user@example.com"
+		default:
+			payload[name] = exampleValue(v.Field(i))
+		}
+	}
+	return payload
+}
+
+func exampleValue(v reflect.Value) any {
+	if !v.IsZero() {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return "example"
+	case reflect.Bool:
+		return false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return 0
+	case reflect.Float32, reflect.Float64:
+		return 0.0
+	case reflect.Slice:
+		return []any{}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeFor[time.Time]() {
+			return time.Now().UTC().Format(time.RFC3339)
+		}
+		return examplePayload(v)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPostmanHasAnItemPerRoute(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/items", noop).Methods("GET").Name("items.list")
+	api.HandleFunc("/items", noop).Methods("POST").Name("items.create")
+	api.HandleFunc("/items/{id}", noop).Methods("GET", "HEAD").Name("items.get")
+	api.HandleFunc("/items/{id}", noop).Methods("PUT").Name("items.update")
+	api.HandleFunc("/clients/{id}/contacts/{contact_id:[a-z0-9-]+}", noop).Methods("DELETE").Name("clients.contacts.delete")
+	// Unnamed routes, such as the static files, aren't part of the API
+	router.PathPrefix("/static/").HandlerFunc(noop)
+	router.HandleFunc("/admin/postman", Postman(router)).Methods("GET").Name("admin.postman")
+
+	routes := 0
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if methods, err := route.GetMethods(); err == nil && route.GetName() != "" {
+			routes += len(methods)
+		}
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/postman", nil))
+	var collection postmanCollection
+	if err := json.Unmarshal(w.Body.Bytes(), &collection); err != nil {
+		t.Fatalf("status %d: %v", w.Code, err)
+	}
+	if collection.Info.Schema != postmanSchema {
+		t.Errorf("schema = %q, want %s", collection.Info.Schema, postmanSchema)
+	}
+	if len(collection.Item) != routes || routes != 7 {
+		t.Fatalf("%d items for %d route methods, want 7 of each", len(collection.Item), routes)
+	}
+
+	requests := make(map[string]postmanRequest)
+	for _, item := range collection.Item {
+		requests[item.Request.Method+" "+item.Name] = item.Request
+	}
+	create := requests["POST items.create"]
+	var body map[string]any
+	if create.Body == nil || json.Unmarshal([]byte(create.Body.Raw), &body) != nil {
+		t.Fatalf("items.create body = %+v, want an example item", create.Body)
+	}
+	if body["name"] != "example" || body["id"] != nil {
+		t.Errorf("items.create example = %v, want a name and no server-set id", body)
+	}
+	if requests["GET items.list"].Body != nil {
+		t.Error("a GET request has a body")
+	}
+	del := requests["DELETE clients.contacts.delete"]
+	if want := "{{base_url}}/api/v1/clients/:id/contacts/:contact_id"; del.URL.Raw != want {
+		t.Errorf("URL = %q, want %q", del.URL.Raw, want)
+	}
+	if len(del.URL.Variable) != 2 || del.URL.Variable[1].Key != "contact_id" {
+		t.Errorf("URL variables = %+v, want id and contact_id", del.URL.Variable)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-api/response"
+	"go-api/storage"
+)
+
+// rangeUnit is the unit of Range requests on list routes, counted in records
+const rangeUnit = "items"
+
+// parseRange parses a Range header such as "items=0-99" or "items=100-".
+// It reports false for headers in any other form, which are ignored.
+func parseRange(header string) (start, end int, ok bool) {
+	spec, found := strings.CutPrefix(header, rangeUnit+"=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	from, to, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	if to == "" {
+		return start, -1, true
+	}
+	end, err = strconv.Atoi(to)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// writeRange answers a list request carrying a Range header with 206 and
+// the requested records, or 416 when the range starts past the last record.
+// It reports false when r has no usable Range header.
+func writeRange[T any](w http.ResponseWriter, r *http.Request, store storage.Store[T]) bool {
+	return writeRangeWith(w, r, func(offset, limit int) ([]T, int) {
+		return storage.GetPage(store, offset, limit)
+	})
+}
+
+// writeRangeOf is writeRange over records already read
+func writeRangeOf[T any](w http.ResponseWriter, r *http.Request, records []T) bool {
+	return writeRangeWith(w, r, func(offset, limit int) ([]T, int) {
+		return storage.PageOf(records, offset, limit)
+	})
+}
+
+// writeRangeWith is writeRange reading the requested page with getPage
+func writeRangeWith[T any](w http.ResponseWriter, r *http.Request, getPage func(offset, limit int) ([]T, int)) bool {
+	start, end, ok := parseRange(r.Header.Get("Range"))
+	if !ok {
+		return false
+	}
+
+	// An open-ended range, or one ending past math.MaxInt-1, reads to the
+	// last record; end-start+1 would overflow for it
+	limit := math.MaxInt
+	if end >= 0 && end-start < math.MaxInt-1 {
+		limit = end - start + 1
+	}
+	page, total := getPage(start, limit)
+	if start >= total {
+		w.Header().Set("Content-Range", fmt.Sprintf("%s */%d", rangeUnit, total))
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		response.Encode(r.Context(), w, map[string]string{"error": "Range not satisfiable"})
+		return true
+	}
+
+	w.Header().Set("Content-Range", fmt.Sprintf("%s %d-%d/%d", rangeUnit, start, start+len(page)-1, total))
+	w.WriteHeader(http.StatusPartialContent)
+	response.Encode(r.Context(), w, page)
+	return true
+}
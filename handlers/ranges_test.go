@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+func TestWriteRangeLargeEnds(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	for _, name := range []string{"a", "b", "c"} {
+		store.Create(models.Item{Name: name})
+	}
+
+	tests := []struct {
+		header      string
+		wantRange   string
+		wantRecords int
+	}{
+		{"items=1-", "items 1-2/3", 2},
+		{"items=0-9223372036854775807", "items 0-2/3", 3},
+		{"items=2-9223372036854775807", "items 2-2/3", 1},
+		{"items=0-1", "items 0-1/3", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items", nil)
+			r.Header.Set("Range", tt.header)
+			w := httptest.NewRecorder()
+			if !writeRange(w, r, storage.Store[models.Item](store)) {
+				t.Fatal("writeRange ignored the header")
+			}
+			if w.Code != http.StatusPartialContent {
+				t.Fatalf("status = %d, want 206", w.Code)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.wantRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantRange)
+			}
+			var page []models.Item
+			if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+			if len(page) != tt.wantRecords {
+				t.Errorf("got %d records, want %d", len(page), tt.wantRecords)
+			}
+		})
+	}
+}
+
+func TestGetAllRange(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	for range 250 {
+		store.Create(models.Item{Name: "widget"})
+	}
+	h := NewItemHandler(store, nil)
+
+	tests := []struct {
+		name, header string
+		wantStatus   int
+		wantRange    string
+		wantRecords  int
+	}{
+		{"no range", "", http.StatusOK, "", 250},
+		{"first page", "items=0-99", http.StatusPartialContent, "items 0-99/250", 100},
+		{"partial last page", "items=200-299", http.StatusPartialContent, "items 200-249/250", 50},
+		{"unsatisfiable", "items=250-299", http.StatusRequestedRangeNotSatisfiable, "items */250", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/items", nil)
+			if tt.header != "" {
+				r.Header.Set("Range", tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.GetAll(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Accept-Ranges"); got != "items" {
+				t.Errorf("Accept-Ranges = %q, want items", got)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.wantRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantRange)
+			}
+			if tt.wantRecords == 0 {
+				return
+			}
+			var page []models.Item
+			if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+			if len(page) != tt.wantRecords {
+				t.Errorf("got %d records, want %d", len(page), tt.wantRecords)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"go-api/response"
+	"go-api/service"
+	"go-api/storage"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ReservationHandler handles HTTP requests for item reservations
+type ReservationHandler struct {
+	service *service.ReservationService
+	ttl     time.Duration
+}
+
+// NewReservationHandler creates a reservation handler. Reservations last
+// ttl unless the request asks for another lifetime.
+func NewReservationHandler(svc *service.ReservationService, ttl time.Duration) *ReservationHandler {
+	return &ReservationHandler{service: svc, ttl: ttl}
+}
+
+// reserveRequest is the body of POST /items/{id}/reserve
+type reserveRequest struct {
+	Quantity      int    `json:"quantity"`
+	ReservationID string `json:"reservation_id"`
+	// TTLSeconds overrides the default lifetime of the reservation
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+// Reserve handles POST /items/{id}/reserve
+func (h *ReservationHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req reserveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	var invalid string
+	switch {
+	case req.Quantity <= 0:
+		invalid = "quantity must be positive"
+	case req.TTLSeconds < 0:
+		invalid = "ttl_seconds must not be negative"
+	default:
+		if _, err := uuid.Parse(req.ReservationID); err != nil {
+			invalid = "reservation_id must be a UUID"
+		}
+	}
+	if invalid != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Encode(r.Context(), w, map[string]string{"error": invalid})
+		return
+	}
+
+	ttl := h.ttl
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	reservation, err := h.service.Reserve(r.Context(), id, req.ReservationID, req.Quantity, ttl)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
+		return
+	case errors.Is(err, service.ErrInsufficientQuantity), errors.Is(err, service.ErrReservationExists):
+		w.WriteHeader(http.StatusConflict)
+		response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		writeCreateError(w, r, "reservation", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	response.Encode(r.Context(), w, reservation)
+}
+
+// Release handles POST /items/{id}/release
+func (h *ReservationHandler) Release(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req struct {
+		ReservationID string `json:"reservation_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	err := h.service.Release(r.Context(), id, req.ReservationID)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Reservation not found"})
+		return
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to release reservation"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List handles GET /items/{id}/reservations
+func (h *ReservationHandler) List(w http.ResponseWriter, r *http.Request) {
+	reservations, err := h.service.List(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Item not found"})
+		return
+	}
+
+	response.Encode(r.Context(), w, reservations)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// URLBuilder returns the URL of a named route
+type URLBuilder func(name string, pairs ...string) (string, error)
+
+// routeInfo describes a named route in the ListRoutes response
+type routeInfo struct {
+	Name    string   `json:"name"`
+	Pattern string   `json:"pattern"`
+	Methods []string `json:"methods"`
+}
+
+// ListRoutes handles GET /routes by listing every named route of router
+func ListRoutes(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routes := make([]routeInfo, 0)
+		router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+			name := route.GetName()
+			if name == "" {
+				return nil
+			}
+			pattern, _ := route.GetPathTemplate()
+			methods, _ := route.GetMethods()
+			routes = append(routes, routeInfo{Name: name, Pattern: pattern, Methods: methods})
+			return nil
+		})
+		response.Encode(r.Context(), w, routes)
+	}
+}
+
+// setLocation points the Location header at the named route, logging
+// instead of failing the request when the URL can't be built
+func setLocation(w http.ResponseWriter, urls URLBuilder, name string, pairs ...string) {
+	if urls == nil {
+		return
+	}
+	u, err := urls(name, pairs...)
+	if err != nil {
+		log.Printf("build URL for route %s(%s): %v", name, strings.Join(pairs, ","), err)
+		return
+	}
+	w.Header().Set("Location", u)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"go-api/logger"
+	"go-api/middleware"
+	"go-api/response"
+	"go-api/session"
+
+	"github.com/google/uuid"
+)
+
+// SessionHandler handles HTTP requests for server-side sessions
+type SessionHandler struct {
+	store session.Store
+	ttl   time.Duration
+}
+
+// NewSessionHandler creates a session handler whose sessions last ttl
+func NewSessionHandler(store session.Store, ttl time.Duration) *SessionHandler {
+	return &SessionHandler{store: store, ttl: ttl}
+}
+
+// Create handles POST /sessions. The body may set the initial data of the
+// session; an authenticated caller's session belongs to them.
+func (h *SessionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeDecodeError(w, r, err)
+		return
+	}
+	if req.Data == nil {
+		req.Data = make(map[string]any)
+	}
+
+	s := session.Session{
+		ID:        uuid.NewString(),
+		Data:      req.Data,
+		UserID:    middleware.UserID(r),
+		ExpiresAt: time.Now().Add(h.ttl),
+	}
+	if err := h.store.Save(r.Context(), s); err != nil {
+		logger.FromContext(r.Context()).Error("create session", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to create session"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     session.CookieName,
+		Value:    s.ID,
+		Path:     "/",
+		Expires:  s.ExpiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.WriteHeader(http.StatusCreated)
+	response.Encode(r.Context(), w, s)
+}
+
+// Me handles GET /sessions/me
+func (h *SessionHandler) Me(w http.ResponseWriter, r *http.Request) {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Session not found"})
+		return
+	}
+
+	response.Encode(r.Context(), w, s)
+}
+
+// Delete handles DELETE /sessions/me by invalidating the session and
+// clearing its cookie
+func (h *SessionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	s, ok := session.FromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Session not found"})
+		return
+	}
+
+	if err := h.store.Delete(r.Context(), s.ID); err != nil {
+		logger.FromContext(r.Context()).Error("delete session", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		response.Encode(r.Context(), w, map[string]string{"error": "Failed to delete session"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     session.CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+// Package v2 implements the handlers of the v2 API. Every v2 response is
+// wrapped in an Envelope, and models use their v2 field names.
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-api/response"
+	"go-api/validation"
+)
+
+// Error codes of v2 error responses
+const (
+	CodeInvalidPayload   = "invalid_payload"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeValidation       = "validation_failed"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeStoreFull        = "store_full"
+	CodeInternal         = "internal_error"
+)
+
+// Envelope wraps every v2 response body: Data on success, Error otherwise
+type Envelope struct {
+	Data  any    `json:"data,omitempty"`
+	Meta  *Meta  `json:"meta,omitempty"`
+	Error *Error `json:"error,omitempty"`
+}
+
+// Meta describes the page of a list response
+type Meta struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// Error describes why a request failed. Fields lists the field errors of a
+// failed validation, named as in the v2 models.
+type Error struct {
+	Code    string                  `json:"code"`
+	Message string                  `json:"message"`
+	Fields  []validation.FieldError `json:"fields,omitempty"`
+}
+
+// writeData responds with data, and meta for a list
+func writeData(w http.ResponseWriter, r *http.Request, status int, data any, meta *Meta) {
+	w.WriteHeader(status)
+	response.Encode(r.Context(), w, Envelope{Data: data, Meta: meta})
+}
+
+// writeError responds with an error of code
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	w.WriteHeader(status)
+	response.Encode(r.Context(), w, Envelope{Error: &Error{Code: code, Message: message}})
+}
+
+// writeDecodeError responds to a request body that could not be decoded
+func writeDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		writeError(w, r, http.StatusRequestEntityTooLarge, CodeInvalidPayload, fmt.Sprintf("Request body exceeds %d bytes", maxErr.Limit))
+		return
+	}
+	writeError(w, r, http.StatusBadRequest, CodeInvalidPayload, "Invalid request payload")
+}
+
+// writeValidationError responds with the field errors of a failed
+// validation, renaming the fields, and messages starting with them, with
+// renames
+func writeValidationError(w http.ResponseWriter, r *http.Request, err error, renames map[string]string) {
+	var verr *validation.ValidationError
+	if !errors.As(err, &verr) {
+		writeError(w, r, http.StatusUnprocessableEntity, CodeValidation, err.Error())
+		return
+	}
+
+	fields := make([]validation.FieldError, len(verr.Errors))
+	for i, fe := range verr.Errors {
+		if name, ok := renames[fe.Field]; ok {
+			if rest, ok := strings.CutPrefix(fe.Message, fe.Field+" "); ok {
+				fe.Message = name + " " + rest
+			}
+			fe.Field = name
+		}
+		fields[i] = fe
+	}
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	response.Encode(r.Context(), w, Envelope{Error: &Error{Code: CodeValidation, Message: "Validation failed", Fields: fields}})
+}
@@ -0,0 +1,236 @@
+package v2
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"go-api/handlers"
+	"go-api/logger"
+	"go-api/models"
+	"go-api/pagination"
+	"go-api/storage"
+	"go-api/validation"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultLimit is how many items a list returns without ?limit=
+	defaultLimit = 50
+	// maxLimit is the largest accepted ?limit=
+	maxLimit = 1000
+)
+
+// itemFields maps the v1 item fields named by validation errors to their
+// v2 names
+var itemFields = map[string]string{
+	"name":     "title",
+	"quantity": "stock",
+}
+
+// V2ItemHandler handles HTTP requests for items in the v2 API. It shares
+// the item store with the v1 handlers and translates between models.Item
+// and models.ItemV2.
+type V2ItemHandler struct {
+	store storage.Store[models.Item]
+	urls  handlers.URLBuilder
+	// validate checks items before they are stored
+	validate func(models.Item) error
+}
+
+// NewV2ItemHandler creates a v2 item handler over the item store
+func NewV2ItemHandler(store storage.Store[models.Item]) *V2ItemHandler {
+	return &V2ItemHandler{store: store, validate: validation.Item}
+}
+
+// SetURLBuilder sets the function used to build Location headers
+func (h *V2ItemHandler) SetURLBuilder(urls handlers.URLBuilder) {
+	h.urls = urls
+}
+
+// SetValidator replaces validation.Item as the check of items before they
+// are stored
+func (h *V2ItemHandler) SetValidator(validate func(models.Item) error) {
+	h.validate = validate
+}
+
+// storeFor returns the store view for the caller of r
+func (h *V2ItemHandler) storeFor(r *http.Request) storage.Store[models.Item] {
+	if s, ok := h.store.(storage.Scoper[models.Item]); ok {
+		return s.For(r.Context())
+	}
+	return h.store
+}
+
+// List handles GET /items?limit=&offset=, oldest first. ?page=, counted
+// from 1, may be given instead of offset. The Link header points at the
+// next, previous, last and first pages; an offset between pages links
+// from the page it falls in.
+func (h *V2ItemHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit, ok := intParam(w, r, "limit", defaultLimit, 1, maxLimit)
+	if !ok {
+		return
+	}
+	offset, ok := intParam(w, r, "offset", 0, 0, -1)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Has("page") {
+		if r.URL.Query().Has("offset") {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "use either page or offset, not both")
+			return
+		}
+		page, ok := intParam(w, r, "page", 1, 1, -1)
+		if !ok {
+			return
+		}
+		offset = (page - 1) * limit
+	}
+
+	items := h.storeFor(r).GetAll()
+	slices.SortFunc(items, func(a, b models.Item) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	page := items[min(offset, len(items)):min(offset+limit, len(items))]
+
+	data := make([]models.ItemV2, len(page))
+	for i, item := range page {
+		data[i] = models.ItemToV2(item)
+	}
+	w.Header().Set("Link", pagination.LinkHeader(r.URL.Path, offset/limit+1, pagination.TotalPages(len(items), limit), limit))
+	writeData(w, r, http.StatusOK, data, &Meta{Total: len(items), Limit: limit, Offset: offset})
+}
+
+// intParam reads the integer query parameter name, def when absent. It
+// responds with 400 and returns false when the value is outside
+// [lo, hi]; hi < 0 means unbounded.
+func intParam(w http.ResponseWriter, r *http.Request, name string, def, lo, hi int) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < lo || (hi >= 0 && n > hi) {
+		message := name + " must be an integer of at least " + strconv.Itoa(lo)
+		if hi >= 0 {
+			message = name + " must be between " + strconv.Itoa(lo) + " and " + strconv.Itoa(hi)
+		}
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, message)
+		return 0, false
+	}
+	return n, true
+}
+
+// Get handles GET /items/{id}
+func (h *V2ItemHandler) Get(w http.ResponseWriter, r *http.Request) {
+	item, exists := h.storeFor(r).GetByID(mux.Vars(r)["id"])
+	if !exists {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Item not found")
+		return
+	}
+
+	writeData(w, r, http.StatusOK, models.ItemToV2(item), nil)
+}
+
+// Create handles POST /items
+func (h *V2ItemHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var body models.ItemV2
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	item := models.ItemFromV2(body)
+	if err := h.validate(item); err != nil {
+		writeValidationError(w, r, err, itemFields)
+		return
+	}
+
+	created, err := h.storeFor(r).TryCreate(item)
+	if errors.Is(err, storage.ErrStoreFull) {
+		writeError(w, r, http.StatusInsufficientStorage, CodeStoreFull, "Store is full")
+		return
+	}
+	if err != nil {
+		logger.FromContext(r.Context()).Error("create item", "error", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to create item")
+		return
+	}
+
+	if h.urls != nil {
+		if u, err := h.urls("v2.items.get", "id", created.ID); err != nil {
+			log.Printf("build URL for route v2.items.get(id,%s): %v", created.ID, err)
+		} else {
+			w.Header().Set("Location", u)
+		}
+	}
+	writeData(w, r, http.StatusCreated, models.ItemToV2(created), nil)
+}
+
+// Update handles PUT /items/{id}. As in v1, omitting the status keeps the
+// current one, and a changed status must be a valid transition.
+func (h *V2ItemHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var body models.ItemV2
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeDecodeError(w, r, err)
+		return
+	}
+
+	item := models.ItemFromV2(body)
+	if err := h.validate(item); err != nil {
+		writeValidationError(w, r, err, itemFields)
+		return
+	}
+
+	current, exists := h.storeFor(r).GetByID(id)
+	if !exists {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Item not found")
+		return
+	}
+	if item.Status == "" {
+		item.Status = current.Status
+	} else if item.Status != current.Status {
+		if err := models.ValidateTransition(current.Status, item.Status); err != nil {
+			writeError(w, r, http.StatusUnprocessableEntity, CodeValidation, err.Error())
+			return
+		}
+	}
+	// Pinning the version read above makes a concurrent change fail the
+	// update with 409 instead of being overwritten
+	if item.Version == 0 {
+		item.Version = current.Version
+	}
+
+	updated, err := h.storeFor(r).Update(id, item)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Item not found")
+		return
+	case errors.Is(err, storage.ErrVersionConflict):
+		writeError(w, r, http.StatusConflict, CodeConflict, "Item was modified by another request; fetch the latest version and retry")
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).Error("update item", "id", id, "error", err)
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to update item")
+		return
+	}
+
+	writeData(w, r, http.StatusOK, models.ItemToV2(updated), nil)
+}
+
+// Delete handles DELETE /items/{id}
+func (h *V2ItemHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !h.storeFor(r).Delete(mux.Vars(r)["id"]) {
+		writeError(w, r, http.StatusNotFound, CodeNotFound, "Item not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
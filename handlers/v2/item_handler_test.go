@@ -0,0 +1,112 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-api/handlers"
+	"go-api/models"
+	"go-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// racingStore changes a record right after it is read, as a concurrent
+// request would between a handler's read and its update
+type racingStore struct {
+	storage.Store[models.Item]
+}
+
+func (s racingStore) GetByID(id string) (models.Item, bool) {
+	item, exists := s.Store.GetByID(id)
+	if exists {
+		changed := item
+		changed.Name = "changed concurrently"
+		s.Store.Update(id, changed)
+	}
+	return item, exists
+}
+
+func TestUpdateWithoutVersionConflicts(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	item := store.Create(models.Item{Name: "widget", Quantity: 1, Status: models.StatusDraft})
+	h := NewV2ItemHandler(racingStore{store})
+
+	r := httptest.NewRequest(http.MethodPut, "/api/v2/items/"+item.ID, strings.NewReader(`{"title":"gadget","stock":2}`))
+	r = mux.SetURLVars(r, map[string]string{"id": item.ID})
+	w := httptest.NewRecorder()
+	h.Update(w, r)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", w.Code, w.Body)
+	}
+	if got, _ := store.GetByID(item.ID); got.Name != "changed concurrently" {
+		t.Errorf("name = %q, want the concurrent change kept", got.Name)
+	}
+}
+
+func TestV1AndV2ShareStore(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	v1 := handlers.NewItemHandler(store, nil)
+	v2 := NewV2ItemHandler(store)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/items", v1.Create).Methods("POST")
+	router.HandleFunc("/api/v2/items/{id}", v2.Get).Methods("GET")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/items", "application/json", strings.NewReader(`{"name":"widget","quantity":3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created models.Item
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("v1 create: status %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/api/v2/items/" + created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got struct {
+		Data models.ItemV2 `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&got)
+	if resp.StatusCode != http.StatusOK || got.Data.Title != "widget" || got.Data.Stock != 3 {
+		t.Errorf("v2 get: status %d, item %+v; want widget with stock 3", resp.StatusCode, got.Data)
+	}
+}
+
+func TestListLinksPages(t *testing.T) {
+	store := storage.NewMemoryStore[models.Item]()
+	for range 45 {
+		store.Create(models.Item{Name: "widget"})
+	}
+	h := NewV2ItemHandler(store)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"page=1&limit=20", `</api/v2/items?page=2&limit=20>; rel="next", </api/v2/items?page=3&limit=20>; rel="last", </api/v2/items?page=1&limit=20>; rel="first"`},
+		{"page=2&limit=20", `</api/v2/items?page=3&limit=20>; rel="next", </api/v2/items?page=1&limit=20>; rel="prev", </api/v2/items?page=3&limit=20>; rel="last", </api/v2/items?page=1&limit=20>; rel="first"`},
+		{"page=3&limit=20", `</api/v2/items?page=2&limit=20>; rel="prev", </api/v2/items?page=3&limit=20>; rel="last", </api/v2/items?page=1&limit=20>; rel="first"`},
+		{"offset=25&limit=20", `</api/v2/items?page=3&limit=20>; rel="next", </api/v2/items?page=1&limit=20>; rel="prev", </api/v2/items?page=3&limit=20>; rel="last", </api/v2/items?page=1&limit=20>; rel="first"`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.List(w, httptest.NewRequest(http.MethodGet, "/api/v2/items?"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", tt.query, w.Code)
+		}
+		if got := w.Header().Get("Link"); got != tt.want {
+			t.Errorf("%s: Link = %s\nwant %s", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"go-api/response"
+	"go-api/webhook"
+
+	"github.com/gorilla/mux"
+)
+
+// WebhookHandler handles HTTP requests for webhook administration
+type WebhookHandler struct {
+	dispatcher *webhook.Dispatcher
+	dlq        *webhook.DLQ
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(dispatcher *webhook.Dispatcher, dlq *webhook.DLQ) *WebhookHandler {
+	return &WebhookHandler{dispatcher: dispatcher, dlq: dlq}
+}
+
+// ListDLQ handles GET /admin/webhooks/dlq
+func (h *WebhookHandler) ListDLQ(w http.ResponseWriter, r *http.Request) {
+	response.Encode(r.Context(), w, h.dlq.List())
+}
+
+// RetryDLQ handles POST /admin/webhooks/dlq/{id}/retry
+func (h *WebhookHandler) RetryDLQ(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	letter, err := h.dlq.Retry(r.Context(), h.dispatcher, id)
+	if errors.Is(err, webhook.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		response.Encode(r.Context(), w, map[string]string{"error": "Dead letter not found"})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		response.Encode(r.Context(), w, map[string]any{"error": "Delivery failed: " + err.Error(), "dead_letter": letter})
+		return
+	}
+
+	response.Encode(r.Context(), w, map[string]any{"delivered": true, "dead_letter": letter})
+}
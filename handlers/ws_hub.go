@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"go-api/events"
+	"go-api/response"
+	"go-api/tenant"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	wsSendBuffer = 32
+)
+
+// wsMessage is a command sent by a WebSocket client
+type wsMessage struct {
+	Action string `json:"action"`
+	Entity string `json:"entity"`
+}
+
+// wsClient is a single WebSocket connection registered with the hub
+type wsClient struct {
+	conn *websocket.Conn
+	send chan events.Event
+	once sync.Once
+	// tenant is the caller's tenant; only its events are sent
+	tenant string
+
+	mu       sync.Mutex
+	entities map[string]bool // nil means every entity
+}
+
+// wants reports whether the client is subscribed to the entity
+func (c *wsClient) wants(entity string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entities == nil || c.entities[entity]
+}
+
+func (c *wsClient) subscribe(entity string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entities == nil {
+		c.entities = make(map[string]bool)
+	}
+	c.entities[entity] = true
+}
+
+func (c *wsClient) unsubscribe(entity string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entities == nil {
+		c.entities = make(map[string]bool)
+	}
+	delete(c.entities, entity)
+}
+
+// WSHub fans out store mutation events to connected WebSocket clients
+type WSHub struct {
+	upgrader  websocket.Upgrader
+	broadcast chan events.Event
+	cancels   []events.CancelFunc
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+// NewWSHub creates a hub that relays the events of bus on the topics
+// matching any of patterns
+func NewWSHub(bus *events.Bus, patterns ...string) *WSHub {
+	h := &WSHub{
+		upgrader: websocket.Upgrader{
+			// CORS already allows every origin
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		broadcast: make(chan events.Event),
+		done:      make(chan struct{}),
+		clients:   make(map[*wsClient]struct{}),
+	}
+
+	for _, pattern := range patterns {
+		stream, cancel := bus.Subscribe(pattern)
+		h.cancels = append(h.cancels, cancel)
+		go h.forward(stream)
+	}
+	go h.run()
+
+	return h
+}
+
+// ServeWS handles GET /ws
+func (h *WSHub) ServeWS(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-h.done:
+		w.WriteHeader(http.StatusServiceUnavailable)
+		response.Encode(r.Context(), w, map[string]string{"error": "Server is shutting down"})
+		return
+	default:
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		return
+	}
+
+	c := &wsClient{conn: conn, send: make(chan events.Event, wsSendBuffer), tenant: tenant.IDFromContext(r.Context())}
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	go h.writePump(c)
+	h.readPump(c)
+}
+
+// Close disconnects every client and stops relaying events. It is safe to
+// call more than once and is registered as a server shutdown hook.
+func (h *WSHub) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+		for _, cancel := range h.cancels {
+			cancel()
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for c := range h.clients {
+			h.removeLocked(c)
+		}
+	})
+}
+
+// forward moves events from a bus subscription onto the broadcast channel
+func (h *WSHub) forward(stream <-chan events.Event) {
+	for e := range stream {
+		select {
+		case h.broadcast <- e:
+		case <-h.done:
+			return
+		}
+	}
+}
+
+func (h *WSHub) run() {
+	for {
+		select {
+		case e := <-h.broadcast:
+			h.mu.Lock()
+			for c := range h.clients {
+				if !e.VisibleTo(c.tenant) || !c.wants(e.Entity) {
+					continue
+				}
+				select {
+				case c.send <- e:
+				default:
+					// Client can't keep up; drop the event for it
+				}
+			}
+			h.mu.Unlock()
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// readPump processes client commands until the connection fails
+func (h *WSHub) readPump(c *wsClient) {
+	defer h.remove(c)
+
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("websocket: read: %v", err)
+			}
+			return
+		}
+
+		switch msg.Action {
+		case "subscribe":
+			c.subscribe(msg.Entity)
+		case "unsubscribe":
+			c.unsubscribe(msg.Entity)
+		}
+	}
+}
+
+// writePump sends events and keepalive pings until the send channel closes
+func (h *WSHub) writePump(c *wsClient) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case e, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server closing"))
+				return
+			}
+			if err := c.conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *WSHub) remove(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c)
+}
+
+func (h *WSHub) removeLocked(c *wsClient) {
+	delete(h.clients, c)
+	c.once.Do(func() { close(c.send) })
+}
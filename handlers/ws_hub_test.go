@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/events"
+	"go-api/tenant"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialHub serves hub on a test server, with each connection in tenantID,
+// and connects a client to it
+func dialHub(t *testing.T, hub *WSHub, tenantID string) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.ServeWS(w, r.WithContext(tenant.WithID(r.Context(), tenantID)))
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitForHub waits until every client of hub satisfies ready
+func waitForHub(t *testing.T, hub *WSHub, clients int, ready func(c *wsClient) bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		hub.mu.Lock()
+		n := 0
+		for c := range hub.clients {
+			if ready(c) {
+				n++
+			}
+		}
+		hub.mu.Unlock()
+		if n == clients {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d websocket clients ready", n, clients)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// readEvent reads the next event sent to conn
+func readEvent(t *testing.T, conn *websocket.Conn) events.Event {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var e events.Event
+	if err := conn.ReadJSON(&e); err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	return e
+}
+
+func TestWSHubSubscribeFilters(t *testing.T) {
+	bus := events.NewBus()
+	hub := NewWSHub(bus, "item.*", "client.*")
+	defer hub.Close()
+	conn := dialHub(t, hub, "")
+
+	if err := conn.WriteJSON(wsMessage{Action: "subscribe", Entity: "items"}); err != nil {
+		t.Fatal(err)
+	}
+	waitForHub(t, hub, 1, func(c *wsClient) bool { return c.wants("items") && !c.wants("clients") })
+
+	bus.Publish("client.created", events.Event{Type: events.Created, Entity: "clients", ID: "c1"})
+	bus.Publish("item.created", events.Event{Type: events.Created, Entity: "items", ID: "i1"})
+	if e := readEvent(t, conn); e.Entity != "items" || e.ID != "i1" {
+		t.Errorf("got %s %s, want the item event only", e.Entity, e.ID)
+	}
+
+	if err := conn.WriteJSON(wsMessage{Action: "unsubscribe", Entity: "items"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteJSON(wsMessage{Action: "subscribe", Entity: "clients"}); err != nil {
+		t.Fatal(err)
+	}
+	waitForHub(t, hub, 1, func(c *wsClient) bool { return c.wants("clients") && !c.wants("items") })
+	bus.Publish("item.updated", events.Event{Type: events.Updated, Entity: "items", ID: "i1"})
+	bus.Publish("client.updated", events.Event{Type: events.Updated, Entity: "clients", ID: "c1"})
+	if e := readEvent(t, conn); e.Entity != "clients" || e.ID != "c1" {
+		t.Errorf("got %s %s, want the client event only", e.Entity, e.ID)
+	}
+}
+
+func TestWSHubOnlyCallersTenant(t *testing.T) {
+	bus := events.NewBus()
+	hub := NewWSHub(bus, "item.*")
+	defer hub.Close()
+	conn := dialHub(t, hub, "acme")
+	waitForHub(t, hub, 1, func(*wsClient) bool { return true })
+
+	bus.Publish("item.created", events.Event{Type: events.Created, Entity: "items", ID: "other", TenantID: "globex"})
+	bus.Publish("item.created", events.Event{Type: events.Created, Entity: "items", ID: "mine", TenantID: "acme"})
+	if e := readEvent(t, conn); e.ID != "mine" {
+		t.Errorf("got event %s, want only the caller's tenant's", e.ID)
+	}
+}
+
+func TestWSHubCloseDisconnectsClients(t *testing.T) {
+	hub := NewWSHub(events.NewBus(), "item.*")
+	conn := dialHub(t, hub, "")
+	waitForHub(t, hub, 1, func(*wsClient) bool { return true })
+
+	hub.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("read after Close: %v, want a going away close", err)
+	}
+
+	w := httptest.NewRecorder()
+	hub.ServeWS(w, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("ServeWS after Close: status %d, want 503", w.Code)
+	}
+}
@@ -0,0 +1,81 @@
+// Package lock serializes operations across server instances sharing a
+// backend, such as an upsert followed by its webhook, so that they run once.
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLeaseLost is returned by Lease.Unlock when the lease expired, and the
+// lock may have been taken by someone else, before it was released
+var ErrLeaseLost = errors.New("lock: lease expired before unlock")
+
+// Locker hands out exclusive locks on keys
+type Locker interface {
+	// Lock blocks until the lock on key is acquired or ctx is done. The lock
+	// is released after ttl even if it is never unlocked, so a crashed
+	// holder doesn't keep it forever.
+	Lock(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+}
+
+// Lease is a held lock
+type Lease interface {
+	Unlock() error
+}
+
+// MemoryLocker is a Locker local to this process, for a single instance and
+// for tests. Each key is a channel holding one token while it is locked.
+type MemoryLocker struct {
+	// locks maps each key to its chan struct{}
+	locks sync.Map
+}
+
+// NewMemoryLocker creates an in-memory locker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{}
+}
+
+// Lock implements Locker
+func (l *MemoryLocker) Lock(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	v, _ := l.locks.LoadOrStore(key, make(chan struct{}, 1))
+	held := v.(chan struct{})
+	select {
+	case held <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	lease := &memoryLease{held: held}
+	lease.timer = time.AfterFunc(ttl, func() { lease.release() })
+	return lease, nil
+}
+
+// memoryLease is a lock held on a MemoryLocker
+type memoryLease struct {
+	held  chan struct{}
+	timer *time.Timer
+	once  sync.Once
+}
+
+// release takes the token back out of the channel, and reports whether this
+// call did so
+func (l *memoryLease) release() bool {
+	released := false
+	l.once.Do(func() {
+		<-l.held
+		released = true
+	})
+	return released
+}
+
+// Unlock implements Lease
+func (l *memoryLease) Unlock() error {
+	l.timer.Stop()
+	if !l.release() {
+		return ErrLeaseLost
+	}
+	return nil
+}
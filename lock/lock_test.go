@@ -0,0 +1,87 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testExclusion has n goroutines take the same lock of locker, checking
+// that no two hold it at once
+func testExclusion(t *testing.T, locker Locker, n int) {
+	t.Helper()
+	var active, overlaps, done atomic.Int64
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			lease, err := locker.Lock(context.Background(), "upsert:42", 10*time.Second)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if active.Add(1) != 1 {
+				overlaps.Add(1)
+			}
+			time.Sleep(100 * time.Microsecond)
+			active.Add(-1)
+			done.Add(1)
+			if err := lease.Unlock(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := overlaps.Load(); got != 0 {
+		t.Errorf("the lock was taken %d times while already held", got)
+	}
+	if got := done.Load(); got != int64(n) {
+		t.Errorf("%d of %d goroutines got the lock", got, n)
+	}
+}
+
+func TestMemoryLockerExclusion(t *testing.T) {
+	testExclusion(t, NewMemoryLocker(), 100)
+}
+
+func TestRedisLockerExclusion(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	// Waiters poll every 50ms, so fewer of them keep the test quick
+	testExclusion(t, NewRedisLocker(client), 20)
+}
+
+func TestMemoryLockerLeaseExpires(t *testing.T) {
+	locker := NewMemoryLocker()
+	lease, err := locker.Lock(context.Background(), "k", 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock is free again once the lease expires
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	next, err := locker.Lock(ctx, "k", time.Second)
+	if err != nil {
+		t.Fatalf("lock not released by the TTL: %v", err)
+	}
+	if err := lease.Unlock(); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Unlock of an expired lease = %v, want ErrLeaseLost", err)
+	}
+
+	// The expired lease's Unlock didn't release the new holder
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := locker.Lock(ctx, "k", time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock of a held key = %v, want the context's deadline", err)
+	}
+	if err := next.Unlock(); err != nil {
+		t.Errorf("Unlock = %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisRetryInterval is how long RedisLocker waits between attempts to take
+// a held lock
+const redisRetryInterval = 50 * time.Millisecond
+
+// redisUnlock deletes the lock only if it still holds the lease's token, so
+// an expired lease can't release a lock someone else has taken since
+var redisUnlock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLocker is a Locker shared by every instance using the same Redis,
+// keeping each lock under a "lock:<key>" key. It follows the Redlock
+// algorithm on a single node: a lock is acquired with SET NX PX holding a
+// random token, and released only by the holder of that token. With one
+// node there is no quorum, so a Redis failover before the key is
+// replicated can hand the same lock to two holders; the TTL should also
+// exceed the time the locked operation takes, or the lock expires under it.
+type RedisLocker struct {
+	client redis.Cmdable
+}
+
+// NewRedisLocker creates a locker on client
+func NewRedisLocker(client redis.Cmdable) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+// Lock implements Locker, trying again every 50ms while the lock is held
+func (l *RedisLocker) Lock(ctx context.Context, key string, ttl time.Duration) (Lease, error) {
+	lease := &redisLease{client: l.client, key: "lock:" + key, token: uuid.NewString()}
+	for {
+		err := l.client.SetArgs(ctx, lease.key, lease.token, redis.SetArgs{Mode: "NX", TTL: ttl}).Err()
+		if err == nil {
+			return lease, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("lock: acquire %s: %w", key, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(redisRetryInterval):
+		}
+	}
+}
+
+// redisLease is a lock held on a RedisLocker
+type redisLease struct {
+	client redis.Cmdable
+	key    string
+	token  string
+}
+
+// Unlock implements Lease
+func (l *redisLease) Unlock() error {
+	// The lease must be released even when the caller's context is done
+	deleted, err := redisUnlock.Run(context.Background(), l.client, []string{l.key}, l.token).Int()
+	if err != nil {
+		return fmt.Errorf("lock: release %s: %w", l.key, err)
+	}
+	if deleted == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
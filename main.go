@@ -1,42 +1,980 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"go-api/blocklist"
+	"go-api/config"
+	"go-api/events"
+	"go-api/eventsource"
+	"go-api/flags"
+	itemgrpc "go-api/grpc"
 	"go-api/handlers"
+	v2 "go-api/handlers/v2"
+	"go-api/metrics"
+	"go-api/middleware"
+	"go-api/migration"
 	"go-api/models"
+	"go-api/proto/apipb"
 	"go-api/router"
+	"go-api/service"
+	"go-api/session"
 	"go-api/storage"
+	"go-api/telemetry"
+	"go-api/transform"
+	"go-api/validation"
+	"go-api/webhook"
+
+	"github.com/redis/go-redis/v9"
+	"go.etcd.io/bbolt"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
+	enumerateRoutes := flag.Bool("enumerate-routes", false, "print the method and path template of every route at startup")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err == nil {
+		err = config.Validate(cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+	slog.SetLogLoggerLevel(logLevel(cfg.LogLevel))
+
+	// Tracing
+	shutdownTracing, err := telemetry.Setup()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize stores
-	itemStore := storage.NewMemoryStore[models.Item]()
-	clientStore := storage.NewMemoryStore[models.Client]()
+	backend, err := openStores(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer backend.close()
+	itemStore, clientStore, contactStore := backend.items, backend.clients, backend.contacts
+
+	// Record the latency of every backend call
+	itemStore = storage.NewInstrumentedStore(itemStore, "items", cfg.StorageBackend, metrics.Registry)
+	clientStore = storage.NewInstrumentedStore(clientStore, "clients", cfg.StorageBackend, metrics.Registry)
+
+	// Fail fast while a backend is unhealthy
+	itemBreaker := storage.NewCircuitBreaker(itemStore, cfg.CBFailureThreshold, cfg.CBRecoveryTimeout)
+	clientBreaker := storage.NewCircuitBreaker(clientStore, cfg.CBFailureThreshold, cfg.CBRecoveryTimeout)
+	itemStore, clientStore = itemBreaker, clientBreaker
+
+	// Coalesce concurrent reads of the same record
+	itemFlight := storage.NewSingleFlightStore(itemStore)
+	clientFlight := storage.NewSingleFlightStore(clientStore)
+	metrics.RegisterDedup("items", itemFlight.DedupStats)
+	metrics.RegisterDedup("clients", clientFlight.DedupStats)
+	itemStore, clientStore = itemFlight, clientFlight
+
+	// Cache reads of single records
+	if cfg.CacheSize > 0 {
+		itemCache := storage.NewLRUStore(itemStore, cfg.CacheSize)
+		clientCache := storage.NewLRUStore(clientStore, cfg.CacheSize)
+		metrics.RegisterCache("items", itemCache.CacheStats)
+		metrics.RegisterCache("clients", clientCache.CacheStats)
+		itemStore, clientStore = itemCache, clientCache
+	}
+
+	// Keep each tenant's records apart
+	itemStore = storage.NewTenantStore(itemStore)
+	clientStore = storage.NewTenantStore(clientStore)
+	contactStore = storage.NewTenantStore(contactStore)
+
+	// Publish store mutations to the event bus from store hooks, on the
+	// item.* and client.* topics
+	bus := events.NewBus()
+	itemHooks := storage.NewHookedStore(itemStore)
+	clientHooks := storage.NewHookedStore(clientStore)
+	storage.PublishHooks(itemHooks, bus, "items", "item")
+	storage.PublishHooks(clientHooks, bus, "clients", "client")
+	var topics []string
+	for _, prefix := range []string{"item", "client"} {
+		for _, typ := range []string{events.Created, events.Updated, events.Deleted} {
+			topics = append(topics, events.Topic(prefix, typ))
+		}
+	}
+	metrics.RegisterEvents(bus.Dropped, bus.SubscriberCount, topics)
+	itemStore, clientStore = itemHooks, clientHooks
+
+	// Remember deleted items for differential sync
+	tombstones := storage.NewTombstones(storage.DefaultTombstoneRetention)
+	itemHooks.AddDeleteHook(tombstones.Record)
+
+	// Trace store operations as part of each request
+	itemStore = storage.NewTracedStore(itemStore, telemetry.Tracer(), "items")
+	clientStore = storage.NewTracedStore(clientStore, telemetry.Tracer(), "clients")
+	contactStore = storage.NewTracedStore(contactStore, telemetry.Tracer(), "contacts")
+
+	// Reservations hold item quantities until released or expired; the
+	// janitor gives expired ones back
+	reservationStore := storage.NewMemoryStore[models.Reservation]()
+	reservationService := service.NewReservationService(itemStore, storage.NewTenantStore[models.Reservation](reservationStore))
+	reservationStore.OnExpire(reservationService.Expire)
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	defer stopJanitor()
+	reservationStore.StartJanitor(janitorCtx)
+
+	// Export jobs run in the background and expire with their files
+	exportJobStore := storage.NewMemoryStore[models.ExportJob]()
+	exportJobHandler := handlers.NewExportJobHandler(storage.NewTenantStore[models.ExportJob](exportJobStore), itemStore, clientStore)
+	exportJobStore.OnExpire(exportJobHandler.Expire)
+	exportJobStore.StartJanitor(janitorCtx)
+	exportCtx, stopExports := context.WithCancel(context.Background())
+	exportsDone := make(chan struct{})
+	go func() {
+		defer close(exportsDone)
+		exportJobHandler.Run(exportCtx, cfg.ExportWorkers)
+	}()
+
+	// Items pass the rules file on top of the built-in checks
+	validateItem := validation.Item
+	if cfg.ValidationRulesPath != "" {
+		itemValidator, err := validation.NewItemValidator(cfg.ValidationRulesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		validateItem = itemValidator.Validate
+	}
+
+	// Large feed imports run in the background
+	importJobStore := storage.NewMemoryStore[models.ImportJob]()
+	importJobHandler := handlers.NewImportJobHandler(storage.NewTenantStore[models.ImportJob](importJobStore), itemStore)
+	importJobHandler.SetValidator(validateItem)
+	importJobStore.StartJanitor(janitorCtx)
+	importsDone := make(chan struct{})
+	go func() {
+		defer close(importsDone)
+		importJobHandler.Run(exportCtx, cfg.ImportWorkers)
+	}()
+
+	// Feature flags
+	var flagStore flags.FlagStore = flags.EnvFlagStore{}
+	if cfg.FeatureFlagsFile != "" {
+		fileFlags, err := flags.NewFileStore(cfg.FeatureFlagsFile)
+		if err != nil {
+			log.Fatalf("load feature flags: %v", err)
+		}
+		flagStore = fileFlags
+	}
+
+	// Webhooks, with failed deliveries kept in a dead letter queue
+	dlqStore, closeDLQ, err := openDLQStore(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeDLQ()
+	dlq := webhook.NewDLQ(dlqStore)
+	subscriptions := make([]webhook.Subscription, len(cfg.WebhookURLs))
+	for i, s := range cfg.WebhookURLs {
+		subscriptions[i] = webhook.ParseSubscription(s)
+	}
+	dispatcher := webhook.NewDispatcher(subscriptions, dlq)
+
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	dispatchDone := make(chan struct{})
+	go func() {
+		defer close(dispatchDone)
+		if len(cfg.WebhookURLs) > 0 {
+			dispatcher.Run(dispatchCtx, bus, "item.*", "client.*")
+		}
+	}()
 
 	// Initialize handlers
-	itemHandler := handlers.NewItemHandler(itemStore)
-	clientHandler := handlers.NewClientHandler(clientStore)
+	healthHandler := handlers.NewHealthHandler(map[string]handlers.StoreHealth{
+		"items":   itemBreaker,
+		"clients": clientBreaker,
+	}, handlers.RuntimeLimits{
+		MaxHeapBytes:  uint64(cfg.MaxHeapMB) * 1024 * 1024,
+		MaxGoroutines: cfg.MaxGoroutines,
+	})
+	itemHandler := handlers.NewItemHandler(itemStore, tombstones)
+	itemV2Handler := v2.NewV2ItemHandler(itemStore)
+	reservationHandler := handlers.NewReservationHandler(reservationService, cfg.ReservationTTL)
+	clientService := service.NewClientService(clientStore, itemStore)
+	clientHandler := handlers.NewClientHandler(clientStore, contactStore, clientService)
+	itemHandler.SetDeleteReturnsBody(cfg.DeleteReturnsBody)
+	clientHandler.SetDeleteReturnsBody(cfg.DeleteReturnsBody)
+	itemHandler.SetStreamLists(cfg.StreamLists)
+	itemHandler.SetValidator(validateItem)
+	itemV2Handler.SetValidator(validateItem)
+	clientHandler.SetStreamLists(cfg.StreamLists)
+	ledgerHandler := handlers.NewLedgerHandler(service.NewLedgerService(clientStore, backend.ledger))
+	clientStatusHandler := handlers.NewClientStatusHandler(service.NewClientStatusService(clientStore, backend.statusEvents))
+	contactHandler := handlers.NewContactHandler(contactStore, clientStore)
+	itemEvents := handlers.NewEventHandler(bus, "item.*")
+	clientEvents := handlers.NewEventHandler(bus, "client.*")
+	wsHub := handlers.NewWSHub(bus, "item.*", "client.*")
+	itemHash := handlers.NewHashHandler(itemStore)
+	clientHash := handlers.NewHashHandler(clientStore)
+	itemHooks.OnChange(itemHash.Invalidate)
+	clientHooks.OnChange(clientHash.Invalidate)
+	itemPoll := handlers.NewPollHandler(bus, "item.*", pollSecret(cfg), cfg.LongPollTimeout)
+	adminHandler := handlers.NewAdminHandler(itemStore, clientStore, flagStore)
+	adminHandler.SetStoreCopier(storeCopier(cfg, backend))
+	webhookHandler := handlers.NewWebhookHandler(dispatcher, dlq)
+
+	// Not ready until the stores have loaded
+	readiness := &ReadinessGate{}
 
 	// Setup router
-	r := router.Setup(itemHandler, clientHandler)
+	corsConfig, err := loadCORSConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load CORS origin patterns: %v", err)
+	}
+	cors := new(atomic.Pointer[middleware.CORSConfig])
+	cors.Store(corsConfig)
+
+	transforms, err := transform.Parse(cfg.TransformPipeline)
+	if err != nil {
+		log.Fatalf("Failed to load transform pipeline: %v", err)
+	}
+
+	ipFilter, err := ipFilterConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load IP filter: %v", err)
+	}
+
+	var credentials middleware.CredentialChecker
+	envCredentials, err := middleware.NewEnvCredentialChecker()
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch {
+	case cfg.BasicAuthFile != "" && envCredentials != nil:
+		log.Fatal("Set either BASIC_AUTH_FILE or BASIC_AUTH_USERS, not both")
+	case cfg.BasicAuthFile != "":
+		fileCredentials, err := middleware.NewFileCredentialChecker(cfg.BasicAuthFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		credentials = fileCredentials
+	case envCredentials != nil:
+		credentials = envCredentials
+	}
+
+	rateLimiter, err := openRateLimiter(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	limiters := []middleware.RateLimiter{rateLimiter}
+
+	// Per-user buckets are local to this instance, whatever the backend
+	var userRateLimiter *middleware.UserRateLimiter
+	if cfg.RateLimitPerUser {
+		userRateLimiter = middleware.NewUserRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
+		limiterCtx, stopLimiter := context.WithCancel(context.Background())
+		defer stopLimiter()
+		go userRateLimiter.Run(limiterCtx)
+		limiters = append(limiters, userRateLimiter)
+	}
+
+	responseCache, err := openResponseCache(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sessionStore, err := openSessionStore(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var sessionHandler *handlers.SessionHandler
+	if sessionStore != nil {
+		sessionHandler = handlers.NewSessionHandler(sessionStore, cfg.SessionTTL)
+	}
+
+	tokenBlocklist, err := openBlocklist(janitorCtx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Tokens are issued to users who sign in with their basic credentials
+	var tokens *middleware.TokenIssuer
+	if credentials != nil {
+		tokens = middleware.NewTokenIssuer(tokenSecret(cfg), cfg.TokenTTL)
+	}
+	var authHandler *handlers.AuthHandler
+	if tokens != nil || tokenBlocklist != nil {
+		authHandler = handlers.NewAuthHandler(tokens, tokenBlocklist)
+	}
+
+	r := router.Setup(cfg, router.Handlers{
+		Health:          healthHandler,
+		Ready:           readiness.Ready,
+		Items:           itemHandler,
+		Reservations:    reservationHandler,
+		Clients:         clientHandler,
+		Ledger:          ledgerHandler,
+		ClientStatus:    clientStatusHandler,
+		ExportJobs:      exportJobHandler,
+		ImportJobs:      importJobHandler,
+		Contacts:        contactHandler,
+		ItemEvents:      itemEvents,
+		ClientEvents:    clientEvents,
+		WSHub:           wsHub,
+		ItemPoll:        itemPoll,
+		ItemHash:        itemHash,
+		ClientHash:      clientHash,
+		Admin:           adminHandler,
+		Webhooks:        webhookHandler,
+		ItemsV2:         itemV2Handler,
+		Flags:           flagStore,
+		CORS:            cors,
+		IPFilter:        ipFilter,
+		Transforms:      transforms,
+		Credentials:     credentials,
+		ResponseCache:   responseCache,
+		Sessions:        sessionHandler,
+		SessionStore:    sessionStore,
+		Tokens:          tokens,
+		Blocklist:       tokenBlocklist,
+		Auth:            authHandler,
+		RateLimiter:     rateLimiter,
+		UserRateLimiter: userRateLimiter,
+		ItemsGate:       itemBreaker,
+		ClientsGate:     clientBreaker,
+	})
+
+	// Apply CORS and rate limit changes to the config file without a restart
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if path, err := config.FilePath(); err == nil && path != "" {
+		watcher := config.NewWatcher(path, config.DefaultWatchInterval)
+		go watcher.Run(watchCtx)
+		go applyReloads(watcher.Changed, cors, limiters...)
+	}
+
+	if *enumerateRoutes {
+		for _, route := range router.Routes(r) {
+			fmt.Printf("%s %s\n", route.Method, route.Path)
+		}
+	}
 
 	// Start server
-	port := ":8080"
+	port := fmt.Sprintf(":%d", cfg.Port)
 	log.Printf("Server starting on http://localhost%s", port)
 	log.Printf("API endpoints:")
 	log.Printf("  - GET    /api/v1/health")
+	log.Printf("  - GET    %s", cfg.ReadinessPath)
+	log.Printf("  - GET    /api/v1/ws")
 	log.Printf("  - GET    /api/v1/items")
 	log.Printf("  - POST   /api/v1/items")
+	log.Printf("  - GET    /api/v1/items/batch?ids=")
+	log.Printf("  - PATCH  /api/v1/items/batch")
+	log.Printf("  - GET    /api/v1/items/export.csv")
+	log.Printf("  - POST   /api/v1/items/import")
+	log.Printf("  - GET    /api/v1/items/events")
+	log.Printf("  - GET    /api/v1/items/hash")
+	log.Printf("  - GET    /api/v1/items/diff?since=")
+	log.Printf("  - GET    /api/v1/items/poll?since=")
 	log.Printf("  - GET    /api/v1/items/{id}")
 	log.Printf("  - PUT    /api/v1/items/{id}")
 	log.Printf("  - DELETE /api/v1/items/{id}")
+	log.Printf("  - PATCH  /api/v1/items/{id}/status")
+	log.Printf("  - POST   /api/v1/items/{id}/reserve")
+	log.Printf("  - POST   /api/v1/items/{id}/release")
+	log.Printf("  - GET    /api/v1/items/{id}/reservations")
 	log.Printf("  - GET    /api/v1/clients")
 	log.Printf("  - POST   /api/v1/clients")
+	log.Printf("  - GET    /api/v1/clients/batch?ids=")
+	log.Printf("  - GET    /api/v1/clients/export.csv")
+	log.Printf("  - POST   /api/v1/clients/import")
+	log.Printf("  - GET    /api/v1/clients/hash")
+	log.Printf("  - GET    /api/v1/clients/events")
 	log.Printf("  - GET    /api/v1/clients/{id}")
 	log.Printf("  - PUT    /api/v1/clients/{id}")
 	log.Printf("  - DELETE /api/v1/clients/{id}")
-	
-	log.Fatal(http.ListenAndServe(port, r))
+	log.Printf("  - GET    /api/v1/clients/{id}/items")
+	log.Printf("  - POST   /api/v1/clients/{id}/credits")
+	log.Printf("  - POST   /api/v1/clients/{id}/debits")
+	log.Printf("  - GET    /api/v1/clients/{id}/ledger")
+	log.Printf("  - PATCH  /api/v1/clients/{id}/status")
+	log.Printf("  - GET    /api/v1/clients/{id}/status-history")
+	log.Printf("  - POST   /api/v1/export/jobs")
+	log.Printf("  - GET    /api/v1/export/jobs/{id}")
+	log.Printf("  - GET    /api/v1/export/jobs/{id}/download")
+	log.Printf("  - GET    /api/v1/clients/{id}/contacts")
+	log.Printf("  - POST   /api/v1/clients/{id}/contacts")
+	log.Printf("  - PUT    /api/v1/clients/{id}/contacts/{contact_id}")
+	log.Printf("  - DELETE /api/v1/clients/{id}/contacts/{contact_id}")
+	log.Printf("  - DELETE /api/v1/admin/items")
+	log.Printf("  - DELETE /api/v1/admin/clients")
+	log.Printf("  - GET    /api/v1/admin/stats")
+	log.Printf("  - GET    /api/v1/admin/flags")
+	log.Printf("  - GET    /api/v1/admin/webhooks/dlq")
+	log.Printf("  - POST   /api/v1/admin/webhooks/dlq/{id}/retry")
+	log.Printf("  - GET    /api/v1/admin/postman")
+	log.Printf("  - GET    /api/v1/admin/snapshot")
+	log.Printf("  - POST   /api/v1/admin/snapshot/restore")
+
+	srv := &http.Server{Addr: port, Handler: r}
+	// Hijacked WebSocket connections are not tracked by Shutdown
+	srv.RegisterOnShutdown(wsHub.Close)
+	srv.RegisterOnShutdown(itemPoll.Close)
+
+	// gRPC shares the stores with the HTTP API
+	var grpcSrv *grpc.Server
+	var grpcListener net.Listener
+	if cfg.GRPCPort != 0 {
+		grpcListener, err = net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			log.Fatalf("listen for gRPC: %v", err)
+		}
+		grpcSrv = grpc.NewServer()
+		itemServer := itemgrpc.NewItemServer(itemStore)
+		itemServer.SetValidator(validateItem)
+		apipb.RegisterItemServiceServer(grpcSrv, itemServer)
+		reflection.Register(grpcSrv)
+		log.Printf("gRPC server starting on localhost:%d", cfg.GRPCPort)
+	}
+
+	// Serve until an interrupt, or until either server fails
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		// HTTP/2, and with it server push, needs TLS
+		serve := srv.ListenAndServe
+		if cfg.TLSCertFile != "" {
+			serve = func() error { return srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile) }
+		}
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
+	if grpcSrv != nil {
+		g.Go(func() error {
+			return grpcSrv.Serve(grpcListener)
+		})
+	}
+	g.Go(func() error {
+		// Drain in-flight requests and calls
+		<-gctx.Done()
+		log.Printf("Shutting down...")
+		stopWatch()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+		if grpcSrv != nil {
+			grpcSrv.GracefulStop()
+		}
+		return nil
+	})
+
+	// Load the stores while the server already answers probes
+	if backend.migrate != nil {
+		readiness.Add(1)
+		go func() {
+			defer readiness.Done()
+			if err := backend.migrate(context.Background()); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+	readiness.Open()
+
+	serveErr := g.Wait()
+
+	// Run the hooks of the last mutations before the buses lose subscribers
+	itemHooks.Close()
+	clientHooks.Close()
+
+	// Pending webhook deliveries go to the dead letter queue
+	stopDispatch()
+	<-dispatchDone
+
+	// Running exports are cancelled
+	stopExports()
+	<-exportsDone
+	<-importsDone
+
+	// Flush pending spans
+	shutdownTracing()
+
+	if serveErr != nil {
+		log.Fatal(serveErr)
+	}
+}
+
+// ReadinessGate reports ready once every startup task added to it is done
+type ReadinessGate struct {
+	pending sync.WaitGroup
+	ready   atomic.Bool
+}
+
+// Add registers n startup tasks; each calls Done when it finishes
+func (g *ReadinessGate) Add(n int) {
+	g.pending.Add(n)
+}
+
+// Done marks one startup task finished
+func (g *ReadinessGate) Done() {
+	g.pending.Done()
+}
+
+// Open marks the server ready once the added tasks are done. No task may be
+// added after Open.
+func (g *ReadinessGate) Open() {
+	go func() {
+		g.pending.Wait()
+		g.ready.Store(true)
+		log.Printf("Ready to serve traffic")
+	}()
+}
+
+// Ready reports whether every startup task is done
+func (g *ReadinessGate) Ready() bool {
+	return g.ready.Load()
+}
+
+// logLevel maps a validated LOG_LEVEL to its slog level
+func logLevel(name string) slog.Level {
+	switch name {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// loadCORSConfig builds the CORS settings of cfg
+func loadCORSConfig(cfg *config.Config) (*middleware.CORSConfig, error) {
+	patterns, err := middleware.ParseOriginPatterns(cfg.CORSOriginPatterns)
+	if err != nil {
+		return nil, err
+	}
+	return &middleware.CORSConfig{
+		AllowedOrigins:        cfg.CORSAllowedOrigins,
+		AllowedOriginPatterns: patterns,
+	}, nil
+}
+
+// applyReloads applies every reloaded config to the settings that can change
+// while the server runs: CORS origins and rate limits. Other settings need a
+// restart.
+func applyReloads(changed <-chan *config.Config, cors *atomic.Pointer[middleware.CORSConfig], limiters ...middleware.RateLimiter) {
+	for cfg := range changed {
+		corsConfig, err := loadCORSConfig(cfg)
+		if err != nil {
+			log.Printf("WARN: config reload: %v; keeping the previous CORS settings", err)
+		} else {
+			cors.Store(corsConfig)
+		}
+		for _, limiter := range limiters {
+			if ls, ok := limiter.(middleware.LimitSetter); ok {
+				ls.SetLimits(cfg.RateLimitRPS, cfg.RateLimitBurst)
+			}
+		}
+		log.Printf("Config reloaded; CORS origins and rate limits updated")
+	}
+}
+
+// openRateLimiter creates the rate limiter for the configured backend, or nil
+// when rate limiting is off
+func openRateLimiter(cfg *config.Config) (middleware.RateLimiter, error) {
+	switch cfg.RateLimitBackend {
+	case "":
+		return nil, nil
+	case "memory":
+		return middleware.NewMemoryRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst), nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse redis url: %w", err)
+		}
+		log.Printf("Using redis rate limiter at %s", opts.Addr)
+		return middleware.NewRedisRateLimiter(redis.NewClient(opts), cfg.RateLimitRPS, cfg.RateLimitBurst), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.RateLimitBackend)
+	}
+}
+
+// openResponseCache creates the response cache for the configured backend,
+// or nil when caching is off
+func openResponseCache(cfg *config.Config) (middleware.CacheStore, error) {
+	switch cfg.ResponseCacheBackend {
+	case "":
+		return nil, nil
+	case "memory":
+		return middleware.NewMemoryCacheStore(middleware.DefaultCacheEntries), nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse redis url: %w", err)
+		}
+		log.Printf("Using redis response cache at %s", opts.Addr)
+		return middleware.NewRedisCacheStore(redis.NewClient(opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown response cache backend %q", cfg.ResponseCacheBackend)
+	}
+}
+
+// ipFilterConfig parses the IP allowlist, blocklist and trusted proxies
+func ipFilterConfig(cfg *config.Config) (middleware.IPFilterConfig, error) {
+	var ipf middleware.IPFilterConfig
+	var err error
+	if ipf.Allowlist, err = middleware.ParseCIDRs(cfg.IPAllowlist); err != nil {
+		return ipf, err
+	}
+	if ipf.Blocklist, err = middleware.ParseCIDRs(cfg.IPBlocklist); err != nil {
+		return ipf, err
+	}
+	if ipf.TrustedProxies, err = middleware.ParseCIDRs(cfg.TrustedProxies); err != nil {
+		return ipf, err
+	}
+	return ipf, nil
+}
+
+// pollSecret returns the key signing long-poll cursors. Without a configured
+// secret, cursors only stay valid until the next restart.
+func pollSecret(cfg *config.Config) []byte {
+	if cfg.LongPollSecret != "" {
+		return []byte(cfg.LongPollSecret)
+	}
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+// tokenSecret returns the key signing bearer tokens. Without a configured
+// secret, tokens only stay valid until the next restart.
+func tokenSecret(cfg *config.Config) []byte {
+	if cfg.TokenSecret != "" {
+		return []byte(cfg.TokenSecret)
+	}
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+// backendStores holds the stores of every resource on the configured backend
+type backendStores struct {
+	items    storage.Store[models.Item]
+	clients  storage.Store[models.Client]
+	contacts storage.Store[models.Contact]
+	ledger   storage.Store[models.Ledger]
+	// statusEvents holds the history of client status changes
+	statusEvents storage.Store[models.ClientStatusEvent]
+	// migrate, when set, brings stored data up to date; the server is not
+	// ready until it returns
+	migrate func(ctx context.Context) error
+	// close releases any resources held by the backend
+	close func()
+}
+
+// openStores creates the stores for the configured backend
+func openStores(cfg *config.Config) (*backendStores, error) {
+	switch cfg.StorageBackend {
+	case "memory":
+		if cfg.StoreMaxItems > 0 {
+			return boundedStores(cfg)
+		}
+		var items storage.Store[models.Item] = storage.NewMemoryStore[models.Item]()
+		if len(cfg.ItemIndexes) > 0 {
+			indexed, err := storage.NewIndexedMemoryStore[models.Item](cfg.ItemIndexes)
+			if err != nil {
+				return nil, fmt.Errorf("item indexes: %w", err)
+			}
+			items = indexed
+		}
+		return &backendStores{
+			items:        items,
+			clients:      storage.NewMemoryStore[models.Client](),
+			contacts:     storage.NewMemoryStore[models.Contact](),
+			ledger:       storage.NewMemoryStore[models.Ledger](),
+			statusEvents: storage.NewMemoryStore[models.ClientStatusEvent](),
+			close:        func() {},
+		}, nil
+
+	case "eventsource":
+		log.Printf("Recording items as events in memory")
+		return &backendStores{
+			items:        eventsource.NewEventSourcedItemStore(eventsource.NewMemoryEventStore()),
+			clients:      storage.NewMemoryStore[models.Client](),
+			contacts:     storage.NewMemoryStore[models.Contact](),
+			ledger:       storage.NewMemoryStore[models.Ledger](),
+			statusEvents: storage.NewMemoryStore[models.ClientStatusEvent](),
+			close:        func() {},
+		}, nil
+
+	case "sharded":
+		return &backendStores{
+			items:        storage.NewShardedMemoryStore[models.Item](storage.DefaultShards),
+			clients:      storage.NewShardedMemoryStore[models.Client](storage.DefaultShards),
+			contacts:     storage.NewShardedMemoryStore[models.Contact](storage.DefaultShards),
+			ledger:       storage.NewShardedMemoryStore[models.Ledger](storage.DefaultShards),
+			statusEvents: storage.NewShardedMemoryStore[models.ClientStatusEvent](storage.DefaultShards),
+			close:        func() {},
+		}, nil
+
+	case "replicated":
+		items := storage.NewReplicatedStore[models.Item](cfg.ReplicationInterval)
+		clients := storage.NewReplicatedStore[models.Client](cfg.ReplicationInterval)
+		contacts := storage.NewReplicatedStore[models.Contact](cfg.ReplicationInterval)
+		metrics.RegisterReplication("items", items.Lag)
+		metrics.RegisterReplication("clients", clients.Lag)
+		metrics.RegisterReplication("contacts", contacts.Lag)
+		return &backendStores{
+			items:        items,
+			clients:      clients,
+			contacts:     contacts,
+			ledger:       storage.NewMemoryStore[models.Ledger](),
+			statusEvents: storage.NewMemoryStore[models.ClientStatusEvent](),
+			close:        func() {},
+		}, nil
+
+	case "bolt":
+		db, err := bbolt.Open(cfg.BoltPath, 0o600, &bbolt.Options{Timeout: time.Second})
+		if err != nil {
+			return nil, fmt.Errorf("open bolt database %s: %w", cfg.BoltPath, err)
+		}
+		runner := migration.NewRunner(migration.NewBoltVersions(db), migration.Bolt(db)...)
+		itemStore, err := storage.NewBoltStore[models.Item](db, "items")
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		clientStore, err := storage.NewBoltStore[models.Client](db, "clients")
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		contactStore, err := storage.NewBoltStore[models.Contact](db, "contacts")
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		ledgerStore, err := storage.NewBoltStore[models.Ledger](db, "ledger")
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		statusEventStore, err := storage.NewBoltStore[models.ClientStatusEvent](db, "client_status_events")
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		log.Printf("Using bolt storage at %s", cfg.BoltPath)
+		stores := &backendStores{
+			items:        itemStore,
+			clients:      clientStore,
+			contacts:     contactStore,
+			ledger:       ledgerStore,
+			statusEvents: statusEventStore,
+			migrate:      runner.Run,
+			close:        func() { db.Close() },
+		}
+		if cfg.ReadReplica {
+			withReadReplicas(stores)
+		}
+		return stores, nil
+
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse redis url: %w", err)
+		}
+		client := redis.NewClient(opts)
+		log.Printf("Using redis storage at %s", opts.Addr)
+		return &backendStores{
+			items:        storage.NewRedisStore[models.Item](client, "items"),
+			clients:      storage.NewRedisStore[models.Client](client, "clients"),
+			contacts:     storage.NewRedisStore[models.Contact](client, "contacts"),
+			ledger:       storage.NewRedisStore[models.Ledger](client, "ledger"),
+			statusEvents: storage.NewRedisStore[models.ClientStatusEvent](client, "client_status_events"),
+			close:        func() { client.Close() },
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+}
+
+// storeCopier returns the copier behind POST /admin/stores/copy, copying
+// every store of active to a freshly opened target backend. The target is
+// migrated first, so it's ready to serve once the copy is done.
+func storeCopier(cfg *config.Config, active *backendStores) handlers.StoreCopier {
+	return func(ctx context.Context, req handlers.StoreCopyRequest) (map[string]int, error) {
+		if req.Backend == cfg.StorageBackend && (req.Backend == "bolt" && req.BoltPath == cfg.BoltPath || req.Backend == "redis" && req.RedisURL == cfg.RedisURL) {
+			return nil, fmt.Errorf("%w: the target is the active store", handlers.ErrCopyTarget)
+		}
+
+		targetCfg := *cfg
+		targetCfg.StorageBackend, targetCfg.ReadReplica = req.Backend, false
+		targetCfg.BoltPath, targetCfg.RedisURL = req.BoltPath, req.RedisURL
+		target, err := openStores(&targetCfg)
+		if err != nil {
+			return nil, err
+		}
+		defer target.close()
+		if target.migrate != nil {
+			if err := target.migrate(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		counts := make(map[string]int, 5)
+		if counts["items"], err = migration.CopyStore(ctx, active.items, target.items); err != nil {
+			return nil, fmt.Errorf("copy items: %w", err)
+		}
+		if counts["clients"], err = migration.CopyStore(ctx, active.clients, target.clients); err != nil {
+			return nil, fmt.Errorf("copy clients: %w", err)
+		}
+		if counts["contacts"], err = migration.CopyStore(ctx, active.contacts, target.contacts); err != nil {
+			return nil, fmt.Errorf("copy contacts: %w", err)
+		}
+		if counts["ledger"], err = migration.CopyStore(ctx, active.ledger, target.ledger); err != nil {
+			return nil, fmt.Errorf("copy ledger: %w", err)
+		}
+		if counts["client_status_events"], err = migration.CopyStore(ctx, active.statusEvents, target.statusEvents); err != nil {
+			return nil, fmt.Errorf("copy client status events: %w", err)
+		}
+		return counts, nil
+	}
+}
+
+// withReadReplicas serves the reads of the items, clients and contacts from
+// in-memory copies, filled once the backend's migrations have run
+func withReadReplicas(stores *backendStores) {
+	items := storage.NewCompositeStore(stores.items, storage.NewMemoryStore[models.Item]())
+	clients := storage.NewCompositeStore(stores.clients, storage.NewMemoryStore[models.Client]())
+	contacts := storage.NewCompositeStore(stores.contacts, storage.NewMemoryStore[models.Contact]())
+	stores.items, stores.clients, stores.contacts = items, clients, contacts
+
+	migrate := stores.migrate
+	stores.migrate = func(ctx context.Context) error {
+		if migrate != nil {
+			if err := migrate(ctx); err != nil {
+				return err
+			}
+		}
+		return errors.Join(items.Sync(ctx), clients.Sync(ctx), contacts.Sync(ctx))
+	}
+	log.Printf("Serving reads from in-memory replicas")
+}
+
+// boundedStores creates memory stores capped at cfg.StoreMaxItems records.
+// Ledger entries and status events are never evicted, so their stores are
+// unbounded.
+func boundedStores(cfg *config.Config) (*backendStores, error) {
+	switch cfg.StoreEvictionPolicy {
+	case "oldest":
+		return &backendStores{
+			items:        storage.NewBoundedMemoryStore[models.Item](cfg.StoreMaxItems, storage.OldestFirst[models.Item]{}),
+			clients:      storage.NewBoundedMemoryStore[models.Client](cfg.StoreMaxItems, storage.OldestFirst[models.Client]{}),
+			contacts:     storage.NewBoundedMemoryStore[models.Contact](cfg.StoreMaxItems, storage.OldestFirst[models.Contact]{}),
+			ledger:       storage.NewMemoryStore[models.Ledger](),
+			statusEvents: storage.NewMemoryStore[models.ClientStatusEvent](),
+			close:        func() {},
+		}, nil
+	case "lru":
+		return &backendStores{
+			items:        storage.NewBoundedMemoryStore[models.Item](cfg.StoreMaxItems, storage.NewLeastRecentlyUsed[models.Item]()),
+			clients:      storage.NewBoundedMemoryStore[models.Client](cfg.StoreMaxItems, storage.NewLeastRecentlyUsed[models.Client]()),
+			contacts:     storage.NewBoundedMemoryStore[models.Contact](cfg.StoreMaxItems, storage.NewLeastRecentlyUsed[models.Contact]()),
+			ledger:       storage.NewMemoryStore[models.Ledger](),
+			statusEvents: storage.NewMemoryStore[models.ClientStatusEvent](),
+			close:        func() {},
+		}, nil
+	case "none":
+		return &backendStores{
+			items:        storage.NewBoundedMemoryStore[models.Item](cfg.StoreMaxItems, nil),
+			clients:      storage.NewBoundedMemoryStore[models.Client](cfg.StoreMaxItems, nil),
+			contacts:     storage.NewBoundedMemoryStore[models.Contact](cfg.StoreMaxItems, nil),
+			ledger:       storage.NewMemoryStore[models.Ledger](),
+			statusEvents: storage.NewMemoryStore[models.ClientStatusEvent](),
+			close:        func() {},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown store eviction policy %q", cfg.StoreEvictionPolicy)
+}
+
+// openDLQStore creates the store for failed webhook deliveries. It is
+// persisted in its own bolt file when webhooks are configured.
+func openDLQStore(cfg *config.Config) (storage.Store[models.DeadLetter], func(), error) {
+	if len(cfg.WebhookURLs) == 0 {
+		return storage.NewMemoryStore[models.DeadLetter](), func() {}, nil
+	}
+
+	db, err := bbolt.Open(cfg.WebhookDLQPath, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, nil, fmt.Errorf("open webhook dead letter queue %s: %w", cfg.WebhookDLQPath, err)
+	}
+	store, err := storage.NewBoltStore[models.DeadLetter](db, "dead_letters")
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return store, func() { db.Close() }, nil
+}
+
+// openSessionStore creates the session store for the configured backend, or
+// nil when sessions are off
+func openSessionStore(cfg *config.Config) (session.Store, error) {
+	switch cfg.SessionBackend {
+	case "":
+		return nil, nil
+	case "memory":
+		return session.NewMemorySessionStore(), nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse redis url: %w", err)
+		}
+		log.Printf("Using redis session store at %s", opts.Addr)
+		return session.NewRedisSessionStore(redis.NewClient(opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown session backend %q", cfg.SessionBackend)
+	}
+}
+
+// openBlocklist creates the token blocklist for the configured backend, or
+// nil when tokens can't be revoked. The memory blocklist is swept until ctx
+// is cancelled.
+func openBlocklist(ctx context.Context, cfg *config.Config) (blocklist.Blocklist, error) {
+	switch cfg.TokenBlocklistBackend {
+	case "":
+		return nil, nil
+	case "memory":
+		list := blocklist.NewMemoryBlocklist()
+		go list.Run(ctx)
+		return list, nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse redis url: %w", err)
+		}
+		log.Printf("Using redis token blocklist at %s", opts.Addr)
+		return blocklist.NewRedisBlocklist(redis.NewClient(opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown token blocklist backend %q", cfg.TokenBlocklistBackend)
+	}
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-api/config"
+	"go-api/handlers"
+	"go-api/middleware"
+)
+
+func TestReloadChangesRateLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(contents string, mod time.Time) {
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("rate_limit_rps: 1\nrate_limit_burst: 1\n", time.Now().Add(-time.Hour))
+	t.Setenv("CONFIG_FILE", path)
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := middleware.NewMemoryRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst)
+	handler := middleware.RateLimit(limiter, func(r *http.Request) string { return "client" })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/items", nil))
+		return w
+	}
+	if request().Code != http.StatusOK || request().Code != http.StatusTooManyRequests {
+		t.Fatal("a burst of 1 didn't reject the second request")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher := config.NewWatcher(path, 10*time.Millisecond)
+	go watcher.Run(ctx)
+
+	// Keep changing the file until the watcher, which may not have taken
+	// its first look yet, sees a change
+	var reloaded *config.Config
+	deadline := time.After(time.Second)
+	for mod := time.Now(); reloaded == nil; mod = mod.Add(time.Second) {
+		writeConfig("rate_limit_rps: 50\nrate_limit_burst: 50\n", mod)
+		select {
+		case reloaded = <-watcher.Changed:
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("the changed file wasn't reloaded")
+		}
+	}
+	// Apply the reload as the server does, returning once it's applied
+	changed := make(chan *config.Config, 1)
+	changed <- reloaded
+	close(changed)
+	applyReloads(changed, &atomic.Pointer[middleware.CORSConfig]{}, limiter)
+
+	w := request()
+	if w.Code != http.StatusOK {
+		t.Errorf("after the reload: status %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("RateLimit-Policy"); got != "50;w=1" {
+		t.Errorf("RateLimit-Policy = %q after the reload, want 50;w=1", got)
+	}
+}
+
+func TestReadinessWaitsForStartup(t *testing.T) {
+	gate := &ReadinessGate{}
+	gate.Add(2)
+	gate.Open()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/ready", handlers.Readiness(gate.Ready))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	status := func() int {
+		t.Helper()
+		resp, err := http.Get(server.URL + "/api/v1/ready")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := status(); code != http.StatusServiceUnavailable {
+		t.Fatalf("before the stores load: status %d, want 503", code)
+	}
+	gate.Done()
+	if code := status(); code != http.StatusServiceUnavailable {
+		t.Fatalf("with one store loaded: status %d, want 503", code)
+	}
+	gate.Done()
+	deadline := time.Now().Add(time.Second)
+	for status() != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("still not ready a second after the stores loaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,107 @@
+// Package masking hides personal data, such as email addresses and phone
+// numbers, in response bodies served outside production.
+package masking
+
+import (
+	"context"
+	"strings"
+
+	"go-api/transform"
+)
+
+// masked replaces values that can't be masked more precisely
+const masked = "***"
+
+// MaskEmail keeps the first letter of the address and the top-level
+// domain, so "alice@example.com" becomes "a*@*.com". Anything that isn't an
+// address is masked whole; "" stays "".
+func MaskEmail(s string) string {
+	if s == "" {
+		return ""
+	}
+	local, domain, ok := strings.Cut(s, "@")
+	if !ok || local == "" || domain == "" {
+		return masked
+	}
+	var first string
+	for _, r := range local {
+		first = string(r)
+		break
+	}
+	tld := ""
+	if i := strings.LastIndexByte(domain, '.'); i >= 0 && i < len(domain)-1 {
+		tld = domain[i:]
+	}
+	return first + "*@*" + tld
+}
+
+// MaskPhone keeps the last four digits of the number, so "+1 555-867-1234"
+// becomes "***-***-1234". Numbers of fewer than seven digits are masked
+// whole; "" stays "".
+func MaskPhone(s string) string {
+	if s == "" {
+		return ""
+	}
+	var digits []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			digits = append(digits, s[i])
+		}
+	}
+	if len(digits) < 7 {
+		return "***-***-****"
+	}
+	return "***-***-" + string(digits[len(digits)-4:])
+}
+
+// Mask masks a string as an email address when it holds an @, and as a
+// phone number otherwise
+func Mask(s string) string {
+	if strings.Contains(s, "@") {
+		return MaskEmail(s)
+	}
+	return MaskPhone(s)
+}
+
+// fields masks the named fields at any depth of a body
+type fields map[string]bool
+
+// PII returns a transformer masking the values of the named fields
+// wherever they appear in a body, in nested objects and lists too. String
+// values go through Mask, and values of other types but null become "***".
+func PII(names []string) transform.Transformer {
+	f := make(fields, len(names))
+	for _, name := range names {
+		f[name] = true
+	}
+	return f
+}
+
+func (f fields) Transform(_ context.Context, body map[string]any) map[string]any {
+	f.walk(body)
+	return body
+}
+
+// walk masks the named fields of v and of everything nested in it
+func (f fields) walk(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if !f[key] {
+				f.walk(value)
+				continue
+			}
+			switch value := value.(type) {
+			case nil:
+			case string:
+				v[key] = Mask(value)
+			default:
+				v[key] = masked
+			}
+		}
+	case []any:
+		for _, elem := range v {
+			f.walk(elem)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package masking
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestMaskEmail(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"alice@example.com", "a*@*.com"},
+		{"a@b.co.uk", "a*@*.uk"},
+		{"élodie@example.fr", "é*@*.fr"},
+		{"root@localhost", "r*@*"},
+		{"trailing@dot.", "t*@*"},
+		{"", ""},
+		{"not an address", "***"},
+		{"@example.com", "***"},
+		{"alice@", "***"},
+	}
+	for _, tt := range tests {
+		if got := MaskEmail(tt.in); got != tt.want {
+			t.Errorf("MaskEmail(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMaskPhone(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"+1 555-867-1234", "***-***-1234"},
+		{"(555) 867 5309", "***-***-5309"},
+		{"5558671234", "***-***-1234"},
+		{"867-5309", "***-***-5309"},
+		{"", ""},
+		{"12345", "***-***-****"},
+		{"call me", "***-***-****"},
+	}
+	for _, tt := range tests {
+		if got := MaskPhone(tt.in); got != tt.want {
+			t.Errorf("MaskPhone(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPIIMasksNestedFields(t *testing.T) {
+	var body map[string]any
+	err := json.Unmarshal([]byte(`{
+		"name": "Acme",
+		"email": "billing@acme.example",
+		"phone": null,
+		"contacts": [
+			{"name": "Ada", "email": "ada@acme.example", "phone": "+44 20 7946 0958"},
+			{"name": "Bob", "phone": 5550100}
+		],
+		"owner": {"profile": {"email": "owner@acme.example"}}
+	}`), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := json.Marshal(PII([]string{"email", "phone"}).Transform(context.Background(), body))
+	want := `{"contacts":[{"email":"a*@*.example","name":"Ada","phone":"***-***-0958"},{"name":"Bob","phone":"***"}],` +
+		`"email":"b*@*.example","name":"Acme","owner":{"profile":{"email":"o*@*.example"}},"phone":null}`
+	if string(got) != want {
+		t.Errorf("masked body\n%s\nwant\n%s", got, want)
+	}
+}
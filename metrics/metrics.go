@@ -0,0 +1,184 @@
+// Package metrics holds the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every collector of the API
+var Registry = prometheus.NewRegistry()
+
+// HTTPRequests counts the requests of each route by response status. Route
+// is the path template, such as /api/v1/items/{id}, so every item shares
+// one series.
+var HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "api_http_requests_total",
+	Help: "Requests handled, by method, route template and status.",
+}, []string{"method", "route", "status"})
+
+// HTTPDuration is the latency of each route
+var HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "api_http_request_duration_seconds",
+	Help:    "Latency of requests, by method and route template.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route"})
+
+// sizeBuckets are the body sizes, in bytes, the size histograms count up to
+var sizeBuckets = []float64{128, 512, 2048, 8192, 32768, 131072, 524288}
+
+// HTTPRequestSize is the number of request body bytes each route read
+var HTTPRequestSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "api_http_request_body_bytes",
+	Help:    "Request body bytes read, by method and route template.",
+	Buckets: sizeBuckets,
+}, []string{"method", "route"})
+
+// HTTPResponseSize is the number of response body bytes each route wrote
+var HTTPResponseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "api_http_response_body_bytes",
+	Help:    "Response body bytes written, by method and route template.",
+	Buckets: sizeBuckets,
+}, []string{"method", "route"})
+
+// HTTPInFlight is the number of requests of each route being handled
+var HTTPInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "api_http_requests_in_flight",
+	Help: "Requests being handled, by route template.",
+}, []string{"route"})
+
+// SLOViolations counts requests that exceeded their route's SLO
+var SLOViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "api_slo_violations_total",
+	Help: "Requests that took longer than the SLO of their route.",
+}, []string{"method", "path"})
+
+// QueueDepth is the number of requests waiting for a worker
+var QueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "api_queue_depth",
+	Help: "Requests waiting in the queue for a worker.",
+})
+
+// QueueActive is the number of requests holding a worker
+var QueueActive = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "api_queue_active_workers",
+	Help: "Requests currently being handled by a queue worker.",
+})
+
+// QueueRejected counts requests turned away by the queue, by reason
+var QueueRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "api_queue_rejected_requests_total",
+	Help: "Requests rejected because the queue was full or the wait timed out.",
+}, []string{"reason"})
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		HTTPRequestSize,
+		HTTPResponseSize,
+		SLOViolations,
+		QueueDepth,
+		QueueActive,
+		QueueRejected,
+	)
+}
+
+// InitRoute creates the latency, size and in-flight series of a route at
+// zero, so dashboards show the route before its first request
+func InitRoute(method, route string) {
+	HTTPDuration.WithLabelValues(method, route)
+	HTTPRequestSize.WithLabelValues(method, route)
+	HTTPResponseSize.WithLabelValues(method, route)
+	HTTPInFlight.WithLabelValues(route)
+}
+
+// RegisterCache exposes the hit and miss counts of an entity's cache
+func RegisterCache(entity string, stats func() (hits, misses int64)) {
+	labels := prometheus.Labels{"entity": entity}
+	Registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "api_store_cache_hits_total",
+			Help:        "Store reads served from the cache.",
+			ConstLabels: labels,
+		}, func() float64 {
+			hits, _ := stats()
+			return float64(hits)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "api_store_cache_misses_total",
+			Help:        "Store reads that missed the cache.",
+			ConstLabels: labels,
+		}, func() float64 {
+			_, misses := stats()
+			return float64(misses)
+		}),
+	)
+}
+
+// RegisterDedup exposes how many store reads of an entity were coalesced
+// with a concurrent read of the same record
+func RegisterDedup(entity string, stats func() (calls, deduped int64)) {
+	labels := prometheus.Labels{"entity": entity}
+	Registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "api_store_reads_total",
+			Help:        "Store reads of single records.",
+			ConstLabels: labels,
+		}, func() float64 {
+			calls, _ := stats()
+			return float64(calls)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "api_store_reads_deduped_total",
+			Help:        "Store reads answered by a concurrent read of the same record.",
+			ConstLabels: labels,
+		}, func() float64 {
+			_, deduped := stats()
+			return float64(deduped)
+		}),
+	)
+}
+
+// RegisterReplication exposes how far an entity's read replica is behind
+// its primary
+func RegisterReplication(entity string, lag func() time.Duration) {
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "api_store_replication_lag_seconds",
+		Help:        "Age of the oldest write not yet copied to the read replica.",
+		ConstLabels: prometheus.Labels{"entity": entity},
+	}, func() float64 {
+		return lag().Seconds()
+	}))
+}
+
+// RegisterEvents exports the event bus counters: the events dropped because
+// a subscriber fell behind, and the subscribers of each of topics
+func RegisterEvents(dropped func() uint64, subscribers func(topic string) int, topics []string) {
+	Registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "api_events_dropped_total",
+		Help: "Events dropped because a subscriber's buffer was full.",
+	}, func() float64 {
+		return float64(dropped())
+	}))
+	for _, topic := range topics {
+		Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "api_event_subscribers",
+			Help:        "Subscribers receiving the events of a topic.",
+			ConstLabels: prometheus.Labels{"topic": topic},
+		}, func() float64 {
+			return float64(subscribers(topic))
+		}))
+	}
+}
+
+// Handler serves the collectors in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminKey only lets through requests whose X-Admin-Key header matches key.
+// With an empty key every request is rejected, disabling the routes.
+func AdminKey(key string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key == "" {
+				w.WriteHeader(http.StatusForbidden)
+				response.Encode(r.Context(), w, map[string]string{"error": "Admin API is disabled"})
+				return
+			}
+
+			given := r.Header.Get("X-Admin-Key")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(key)) != 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				response.Encode(r.Context(), w, map[string]string{"error": "Invalid admin key"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
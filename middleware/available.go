@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// Gate reports whether a dependency is currently accepting calls
+type Gate interface {
+	Allow() error
+}
+
+// Available responds 503 without calling the handler while gate rejects
+// calls, so requests fail fast instead of waiting on an unhealthy backend
+func Available(gate Gate) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := gate.Allow(); err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				response.Encode(r.Context(), w, map[string]string{"error": err.Error()})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"go-api/logger"
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// Identity is what a CredentialChecker knows about a verified user
+type Identity struct {
+	Roles []string
+	// TenantID is the tenant the user belongs to; "" is the default tenant
+	TenantID string
+}
+
+// CredentialChecker verifies a username and password and returns the
+// identity of that user
+type CredentialChecker interface {
+	Check(username, password string) (identity Identity, ok bool)
+}
+
+// BasicAuth authenticates requests with HTTP basic credentials. The roles
+// returned by checker become the caller's scopes, the username its sub
+// claim and the user's tenant its tenant_id claim. Missing or wrong
+// credentials get 401 with a WWW-Authenticate challenge. Requests already
+// authenticated by BearerToken go through.
+func BasicAuth(checker CredentialChecker) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := ClaimsFromContext(r.Context()); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			username, password, ok := r.BasicAuth()
+			var identity Identity
+			if ok {
+				identity, ok = checker.Check(username, password)
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="go-api"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				response.Encode(r.Context(), w, map[string]string{"error": "Invalid username or password"})
+				return
+			}
+
+			claims := Claims{"sub": username}
+			if identity.TenantID != "" {
+				claims["tenant_id"] = identity.TenantID
+			}
+			ctx := WithScopes(r.Context(), identity.Roles)
+			ctx = WithClaims(ctx, claims)
+			ctx = logger.With(ctx, "user_id", username)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// constantTimeCompare compares password digests; a variable so tests can
+// see it is the comparison used
+var constantTimeCompare = subtle.ConstantTimeCompare
+
+// dummyDigest is compared against for unknown users, so they cost as much
+// as known ones
+var dummyDigest = sha256.Sum256([]byte("unknown user"))
+
+// envCredential is one user read by EnvCredentialChecker
+type envCredential struct {
+	digest [sha256.Size]byte
+	roles  []string
+	tenant string
+}
+
+// EnvCredentialChecker checks credentials against the BASIC_AUTH_USERS and
+// BASIC_AUTH_TENANTS environment variables
+type EnvCredentialChecker struct {
+	users map[string]envCredential
+}
+
+// NewEnvCredentialChecker reads BASIC_AUTH_USERS, a comma-separated list of
+// user:password entries such as "alice:secret,bob:pass". An entry may add
+// its roles after a third colon, joined by "+":
+// "alice:secret:items:read+items:write". Passwords can't contain colons.
+// BASIC_AUTH_TENANTS puts users in a tenant with user=tenant entries, such
+// as "alice=acme,bob=globex"; other users are in the default tenant. It
+// returns nil when BASIC_AUTH_USERS is empty.
+func NewEnvCredentialChecker() (*EnvCredentialChecker, error) {
+	raw := os.Getenv("BASIC_AUTH_USERS")
+	if raw == "" {
+		return nil, nil
+	}
+
+	c := &EnvCredentialChecker{users: make(map[string]envCredential)}
+	for _, entry := range strings.Split(raw, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("BASIC_AUTH_USERS: entry %q is not user:password", entry)
+		}
+		cred := envCredential{digest: sha256.Sum256([]byte(parts[1]))}
+		if len(parts) == 3 && parts[2] != "" {
+			cred.roles = strings.Split(parts[2], "+")
+		}
+		c.users[parts[0]] = cred
+	}
+
+	if raw := os.Getenv("BASIC_AUTH_TENANTS"); raw != "" {
+		for _, entry := range strings.Split(raw, ",") {
+			user, tenant, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || user == "" || tenant == "" {
+				return nil, fmt.Errorf("BASIC_AUTH_TENANTS: entry %q is not user=tenant", entry)
+			}
+			cred, known := c.users[user]
+			if !known {
+				return nil, fmt.Errorf("BASIC_AUTH_TENANTS: user %q is not in BASIC_AUTH_USERS", user)
+			}
+			cred.tenant = tenant
+			c.users[user] = cred
+		}
+	}
+	return c, nil
+}
+
+// Check implements CredentialChecker. The SHA-256 digests of the passwords
+// are compared in constant time, so the comparison costs the same whatever
+// the password's length, and unknown users are compared against a dummy
+// digest, so response times don't reveal which usernames exist.
+func (c *EnvCredentialChecker) Check(username, password string) (Identity, bool) {
+	digest := sha256.Sum256([]byte(password))
+	cred, known := c.users[username]
+	if !known {
+		constantTimeCompare(digest[:], dummyDigest[:])
+		return Identity{}, false
+	}
+	if constantTimeCompare(digest[:], cred.digest[:]) != 1 {
+		return Identity{}, false
+	}
+	return Identity{Roles: cred.roles, TenantID: cred.tenant}, true
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// MaxBodySize rejects request bodies larger than limit bytes with 413.
+// Bodies with a declared Content-Length over the limit are rejected before
+// the handler runs; others are cut off by http.MaxBytesReader while reading.
+func MaxBodySize(limit int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				response.Encode(r.Context(), w, map[string]string{"error": fmt.Sprintf("Request body exceeds %d bytes", limit)})
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
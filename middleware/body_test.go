@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMaxBodySizeRejectsBeforeHandler(t *testing.T) {
+	called := false
+	handler := MaxBodySize(1 << 20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", bytes.NewReader(make([]byte, 2<<20))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413", w.Code)
+	}
+	if called {
+		t.Error("handler ran for an oversized body")
+	}
+}
+
+func TestMaxBodySizeCutsOffUndeclaredLength(t *testing.T) {
+	var readErr error
+	handler := MaxBodySize(1 << 20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/items", bytes.NewReader(make([]byte, 2<<20)))
+	r.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) || maxErr.Limit != 1<<20 {
+		t.Errorf("read error = %v, want a MaxBytesError at 1 MiB", readErr)
+	}
+}
+
+func TestMaxBodySizeAllowsSmallBodies(t *testing.T) {
+	var body []byte
+	handler := MaxBodySize(1 << 20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", bytes.NewReader([]byte(`{"name":"widget"}`))))
+	if w.Code != http.StatusOK || string(body) != `{"name":"widget"}` {
+		t.Errorf("status %d, body %q; want the body passed through", w.Code, body)
+	}
+}
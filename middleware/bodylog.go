@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// redactedHeaders are logged as [REDACTED]
+var redactedHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+	"X-Api-Key":     true,
+	"X-Admin-Key":   true,
+}
+
+// bufferPool recycles the buffers holding captured bodies
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// BodyLogger logs the headers and up to maxBytes of the request and response
+// bodies of every request, at debug level. Credentials are redacted.
+func BodyLogger(maxBytes int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqBuf := bufferPool.Get().(*bytes.Buffer)
+			respBuf := bufferPool.Get().(*bytes.Buffer)
+			defer func() {
+				reqBuf.Reset()
+				respBuf.Reset()
+				bufferPool.Put(reqBuf)
+				bufferPool.Put(respBuf)
+			}()
+
+			if r.Body != nil && r.Body != http.NoBody {
+				// Put the captured prefix back in front of the unread rest
+				io.CopyN(reqBuf, r.Body, maxBytes)
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(reqBuf.Bytes()), r.Body), r.Body}
+			}
+
+			rc := &responseCapture{ResponseWriter: w, buf: respBuf, max: maxBytes, status: http.StatusOK}
+			next.ServeHTTP(rc, r)
+
+			log.Printf("DEBUG: %s %s headers=%s body=%q -> %d headers=%s body=%q",
+				r.Method, r.URL.RequestURI(), formatHeaders(r.Header), reqBuf.Bytes(),
+				rc.status, formatHeaders(w.Header()), respBuf.Bytes())
+		})
+	}
+}
+
+// formatHeaders renders headers sorted by name, with credentials redacted
+func formatHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			value = "[REDACTED]"
+		}
+		parts = append(parts, name+": "+value)
+	}
+	return "{" + strings.Join(parts, "; ") + "}"
+}
+
+// responseCapture copies up to max bytes of the response body into buf
+type responseCapture struct {
+	http.ResponseWriter
+	buf    *bytes.Buffer
+	max    int64
+	status int
+	wrote  bool
+}
+
+func (w *responseCapture) WriteHeader(status int) {
+	if !w.wrote {
+		w.status = status
+		w.wrote = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseCapture) Write(b []byte) (int, error) {
+	w.wrote = true
+	if room := w.max - int64(w.buf.Len()); room > 0 {
+		w.buf.Write(b[:min(int64(len(b)), room)])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *responseCapture) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets WebSocket upgrades take over the connection
+func (w *responseCapture) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.status = http.StatusSwitchingProtocols
+	w.wrote = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Push keeps HTTP/2 server push available to handlers
+func (w *responseCapture) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
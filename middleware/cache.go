@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api/tenant"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// CachedResponse is a response kept by a CacheStore
+type CachedResponse struct {
+	Status int `json:"status"`
+	// Header holds the headers the handler set, such as ETag, Last-Modified
+	// and Link, to be sent again with the cached body
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// CacheStore keeps cached responses
+type CacheStore interface {
+	Get(key string) (CachedResponse, bool)
+	Set(key string, resp CachedResponse, ttl time.Duration)
+}
+
+// maxCachedBodyBytes is the largest response body Cache stores
+const maxCachedBodyBytes = 1 << 20
+
+// Cache serves repeated GET requests from store for ttl. Entries are keyed
+// by tenant, Accept header, path and sorted query, and answered with the
+// headers the handler set and X-Cache: HIT; responses produced by the
+// handler get X-Cache: MISS.
+//
+// Requests sent with Cache-Control: no-cache skip the cached entry, as do
+// conditional and Range requests. Only 200 responses in the negotiated
+// content type and up to 1 MiB are stored, so CSV downloads and streams are never cached, and
+// neither is anything marked Cache-Control: no-store. Writes don't invalidate
+// entries; a cached list may be up to ttl old.
+func Cache(ttl time.Duration, store CacheStore) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.Header.Get("Range") != "" ||
+				r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := cacheKey(r)
+			if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+				if cached, ok := store.Get(key); ok {
+					for name, values := range cached.Header {
+						w.Header()[name] = slices.Clone(values)
+					}
+					w.Header().Set("X-Cache", "HIT")
+					w.WriteHeader(cached.Status)
+					w.Write(cached.Body)
+					return
+				}
+			}
+
+			buf := bufferPool.Get().(*bytes.Buffer)
+			defer func() {
+				buf.Reset()
+				bufferPool.Put(buf)
+			}()
+
+			contentType := w.Header().Get("Content-Type")
+			w.Header().Set("X-Cache", "MISS")
+			before := w.Header().Clone()
+			rc := &responseCapture{ResponseWriter: w, buf: buf, max: maxCachedBodyBytes + 1, status: http.StatusOK}
+			next.ServeHTTP(rc, r)
+
+			if rc.status == http.StatusOK && buf.Len() <= maxCachedBodyBytes &&
+				w.Header().Get("Content-Type") == contentType &&
+				!strings.Contains(w.Header().Get("Cache-Control"), "no-store") {
+				store.Set(key, CachedResponse{Status: rc.status, Header: handlerHeaders(before, w.Header()), Body: buf.Bytes()}, ttl)
+			}
+		})
+	}
+}
+
+// handlerHeaders returns the headers of after that aren't in before, which
+// the handler and the middleware after Cache set. Headers set before, such
+// as the request ID, belong to the request that filled the cache.
+func handlerHeaders(before, after http.Header) http.Header {
+	set := make(http.Header)
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			set[name] = slices.Clone(values)
+		}
+	}
+	return set
+}
+
+// cacheKey identifies a request by everything that changes its response
+func cacheKey(r *http.Request) string {
+	return strings.Join([]string{
+		r.Method,
+		tenant.IDFromContext(r.Context()),
+		r.Header.Get("Accept"),
+		r.URL.Path,
+		r.URL.Query().Encode(), // sorted by key
+	}, "\n")
+}
+
+// DefaultCacheEntries is the number of responses a MemoryCacheStore keeps
+// when no limit is given
+const DefaultCacheEntries = 10000
+
+type cacheEntry struct {
+	resp    CachedResponse
+	expires time.Time
+}
+
+// MemoryCacheStore keeps cached responses in this instance's memory
+type MemoryCacheStore struct {
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	maxEntries int
+}
+
+// NewMemoryCacheStore creates a cache holding at most maxEntries responses.
+// maxEntries <= 0 uses DefaultCacheEntries.
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheEntries
+	}
+	return &MemoryCacheStore{entries: make(map[string]cacheEntry), maxEntries: maxEntries}
+}
+
+// Get implements CacheStore
+func (s *MemoryCacheStore) Get(key string) (CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return CachedResponse{}, false
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(s.entries, key)
+		return CachedResponse{}, false
+	}
+	return entry.resp, true
+}
+
+// Set implements CacheStore. When the cache is full, expired entries are
+// dropped first, then arbitrary ones.
+func (s *MemoryCacheStore) Set(key string, resp CachedResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= s.maxEntries {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		for k := range s.entries {
+			if len(s.entries) < s.maxEntries {
+				break
+			}
+			delete(s.entries, k)
+		}
+	}
+	resp.Header, resp.Body = resp.Header.Clone(), bytes.Clone(resp.Body)
+	s.entries[key] = cacheEntry{resp: resp, expires: now.Add(ttl)}
+}
+
+// RedisCacheStore keeps cached responses in Redis, shared by every instance
+type RedisCacheStore struct {
+	client redis.Cmdable
+}
+
+// NewRedisCacheStore creates a cache storing responses under "cache:" keys
+func NewRedisCacheStore(client redis.Cmdable) *RedisCacheStore {
+	return &RedisCacheStore{client: client}
+}
+
+// Get implements CacheStore. Redis errors count as misses.
+func (s *RedisCacheStore) Get(key string) (CachedResponse, bool) {
+	raw, err := s.client.Get(context.Background(), "cache:"+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("WARN: response cache: get: %v", err)
+		}
+		return CachedResponse{}, false
+	}
+	var resp CachedResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return CachedResponse{}, false
+	}
+	return resp, true
+}
+
+// Set implements CacheStore. The response is stored as JSON.
+func (s *RedisCacheStore) Set(key string, resp CachedResponse, ttl time.Duration) {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return
+	}
+	if err := s.client.Set(context.Background(), "cache:"+key, raw, ttl).Err(); err != nil {
+		log.Printf("WARN: response cache: set: %v", err)
+	}
+}
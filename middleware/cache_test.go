@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCacheHitReplaysHeaders(t *testing.T) {
+	stores := map[string]CacheStore{
+		"memory": NewMemoryCacheStore(0),
+		"redis":  NewRedisCacheStore(redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			cached := Cache(time.Minute, store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("ETag", `"abc"`)
+				w.Header().Set("Last-Modified", "Wed, 14 Oct 2026 08:00:00 GMT")
+				w.Header().Set("Accept-Ranges", "items")
+				w.Header().Add("Link", `</items?page=2>; rel="next"`)
+				w.Header().Add("Link", `</items?page=1>; rel="first"`)
+				w.Write([]byte(`[{"id":"a"}]`))
+			}))
+			// Headers set before Cache belong to each request
+			n := 0
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n++
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Request-ID", fmt.Sprint("req-", n))
+				cached.ServeHTTP(w, r)
+			})
+
+			get := func() *httptest.ResponseRecorder {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+				return w
+			}
+			miss, hit := get(), get()
+			if calls != 1 {
+				t.Fatalf("handler ran %d times, want once", calls)
+			}
+			if miss.Header().Get("X-Cache") != "MISS" || hit.Header().Get("X-Cache") != "HIT" {
+				t.Fatalf("X-Cache %q then %q, want MISS then HIT", miss.Header().Get("X-Cache"), hit.Header().Get("X-Cache"))
+			}
+			for _, header := range []string{"ETag", "Last-Modified", "Accept-Ranges", "Content-Type"} {
+				if got, want := hit.Header().Get(header), miss.Header().Get(header); got != want {
+					t.Errorf("hit %s = %q, want %q", header, got, want)
+				}
+			}
+			if got := hit.Header().Values("Link"); len(got) != 2 || got[0] != miss.Header().Values("Link")[0] {
+				t.Errorf("hit Link = %q, want %q", got, miss.Header().Values("Link"))
+			}
+			if got := hit.Header().Get("X-Request-ID"); got != "req-2" {
+				t.Errorf("hit X-Request-ID = %q, want the second request's", got)
+			}
+			if hit.Code != http.StatusOK || hit.Body.String() != miss.Body.String() {
+				t.Errorf("hit %d %q, want %d %q", hit.Code, hit.Body, miss.Code, miss.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Chain is an ordered list of middleware applied to a single route
+type Chain []mux.MiddlewareFunc
+
+// New creates a chain from the given middleware
+func New(mw ...mux.MiddlewareFunc) Chain {
+	return append(Chain{}, mw...)
+}
+
+// Append returns a new chain with mw added after the existing middleware
+func (c Chain) Append(mw ...mux.MiddlewareFunc) Chain {
+	out := make(Chain, 0, len(c)+len(mw))
+	out = append(out, c...)
+	return append(out, mw...)
+}
+
+// Then wraps h with every middleware in the chain. The first middleware
+// is the outermost, so it sees the request first.
+func (c Chain) Then(h http.HandlerFunc) http.HandlerFunc {
+	var handler http.Handler = h
+	for i := len(c) - 1; i >= 0; i-- {
+		handler = c[i](handler)
+	}
+	return handler.ServeHTTP
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// recordCalls returns a middleware appending name to calls when it runs
+func recordCalls(calls *[]string, name string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	handler := func(w http.ResponseWriter, r *http.Request) { calls = append(calls, "handler") }
+
+	read := New(recordCalls(&calls, "auth"), recordCalls(&calls, "ratelimit"))
+	write := read.Append(recordCalls(&calls, "maxbody"))
+
+	write.Then(handler)(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items", nil))
+	if want := []string{"auth", "ratelimit", "maxbody", "handler"}; !slices.Equal(calls, want) {
+		t.Errorf("write chain ran %v, want %v", calls, want)
+	}
+
+	// Append leaves the chain it extends unchanged
+	calls = nil
+	read.Then(handler)(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+	if want := []string{"auth", "ratelimit", "handler"}; !slices.Equal(calls, want) {
+		t.Errorf("read chain ran %v, want %v", calls, want)
+	}
+
+	calls = nil
+	New().Then(handler)(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if want := []string{"handler"}; !slices.Equal(calls, want) {
+		t.Errorf("empty chain ran %v, want %v", calls, want)
+	}
+}
+
+func TestChainStopsAtRejectingMiddleware(t *testing.T) {
+	var calls []string
+	reject := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "reject")
+			w.WriteHeader(http.StatusForbidden)
+		})
+	}
+	chain := New(recordCalls(&calls, "auth"), reject, recordCalls(&calls, "ratelimit"))
+
+	w := httptest.NewRecorder()
+	chain.Then(func(w http.ResponseWriter, r *http.Request) { calls = append(calls, "handler") })(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if want := []string{"auth", "reject"}; !slices.Equal(calls, want) || w.Code != http.StatusForbidden {
+		t.Errorf("ran %v with status %d, want %v with 403", calls, w.Code, want)
+	}
+}
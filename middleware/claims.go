@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"go-api/logger"
+	"go-api/tenant"
+)
+
+const claimsKey contextKey = "claims"
+
+// Claims are the attributes of an authenticated caller, such as token claims
+type Claims map[string]any
+
+// WithClaims returns a copy of ctx carrying the caller's claims.
+// Authentication middleware calls this once the credentials are verified.
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns the claims stored by WithClaims
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(Claims)
+	return claims, ok
+}
+
+// Tenant copies the tenant_id claim into the request context for the store
+// layer. Requests without the claim belong to the default ("") tenant.
+func Tenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := ClaimsFromContext(r.Context()); ok {
+			if id, ok := claims["tenant_id"].(string); ok {
+				ctx := tenant.WithID(r.Context(), id)
+				r = r.WithContext(logger.With(ctx, "tenant_id", id))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
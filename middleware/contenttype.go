@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// RequireContentType rejects requests whose Content-Type is missing or not
+// one of types with 415 Unsupported Media Type. Parameters such as charset
+// are ignored. The accepted types are listed in the Accept-Patch header for
+// PATCH routes.
+func RequireContentType(types ...string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || !slices.Contains(types, contentType) {
+				if r.Method == http.MethodPatch {
+					w.Header().Set("Accept-Patch", strings.Join(types, ", "))
+				}
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				response.Encode(r.Context(), w, map[string]string{"error": "Content-Type must be " + strings.Join(types, " or ")})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
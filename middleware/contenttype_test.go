@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireContentType(t *testing.T) {
+	const mergePatch = "application/merge-patch+json"
+	handler := RequireContentType(mergePatch)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		contentType string
+		want        int
+	}{
+		{mergePatch, http.StatusOK},
+		{mergePatch + "; charset=utf-8", http.StatusOK},
+		{"", http.StatusUnsupportedMediaType},
+		{"application/json", http.StatusUnsupportedMediaType},
+		{"not a media type", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPatch, "/items/1", nil)
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("Content-Type %q: status %d, want %d", tt.contentType, w.Code, tt.want)
+		}
+		if w.Code == http.StatusUnsupportedMediaType && w.Header().Get("Accept-Patch") != mergePatch {
+			t.Errorf("Content-Type %q: Accept-Patch = %q, want %s", tt.contentType, w.Header().Get("Accept-Patch"), mergePatch)
+		}
+	}
+}
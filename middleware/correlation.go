@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+
+	"go-api/correlation"
+	"go-api/logger"
+
+	"github.com/google/uuid"
+)
+
+// uuidV4 matches a version 4 UUID in its canonical form
+var uuidV4 = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// CorrelationID keeps the X-Correlation-ID a calling service sent, or
+// generates one when it is missing or not a version 4 UUID, and echoes it in
+// the response. Unlike the request ID, which names this request alone, it
+// is shared by every request of one operation across services. The ID is
+// stored in the request context, added to its logger as correlation_id and
+// sent on outbound calls made with a correlation.Transport.
+func CorrelationID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(correlation.Header)
+		if !uuidV4.MatchString(id) {
+			id = uuid.New().String()
+			r.Header.Set(correlation.Header, id)
+		}
+		w.Header().Set(correlation.Header, id)
+
+		ctx := correlation.WithID(r.Context(), id)
+		ctx = logger.With(ctx, "correlation_id", id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// CORSConfig lists the origins allowed to call the API from a browser. With
+// no origins and no patterns every origin is allowed.
+type CORSConfig struct {
+	// AllowedOrigins are matched exactly; "*" allows any origin
+	AllowedOrigins []string
+	// AllowedOriginPatterns are tried when no exact origin matches
+	AllowedOriginPatterns []*regexp.Regexp
+}
+
+// ParseOriginPatterns compiles a comma-separated list of origin regexes.
+// Each pattern must match the whole origin, e.g. `https://.*\.company\.com`.
+func ParseOriginPatterns(s string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("cors: invalid origin pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// allowAll reports whether origin checks are skipped
+func (c CORSConfig) allowAll() bool {
+	return slices.Contains(c.AllowedOrigins, "*") ||
+		(len(c.AllowedOrigins) == 0 && len(c.AllowedOriginPatterns) == 0)
+}
+
+// allows reports whether origin matches an allowed origin or pattern
+func (c CORSConfig) allows(origin string) bool {
+	if slices.Contains(c.AllowedOrigins, origin) {
+		return true
+	}
+	for _, re := range c.AllowedOriginPatterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS enables CORS headers for the origins allowed by cfg. cfg is read on
+// every request, so storing a new config takes effect immediately; a nil
+// or empty pointer allows every origin.
+func CORS(live *atomic.Pointer[CORSConfig]) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var cfg CORSConfig
+			if live != nil {
+				if c := live.Load(); c != nil {
+					cfg = *c
+				}
+			}
+
+			origin := r.Header.Get("Origin")
+			switch {
+			case cfg.allowAll():
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case origin == "":
+			case cfg.allows(origin):
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			default:
+				log.Printf("WARN: CORS origin %q matches no allowed origin or pattern", origin)
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCORSSubdomainPatterns(t *testing.T) {
+	patterns, err := ParseOriginPatterns(`https://.*\.company\.com, http://localhost:\d+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var live atomic.Pointer[CORSConfig]
+	live.Store(&CORSConfig{AllowedOrigins: []string{"https://partner.example"}, AllowedOriginPatterns: patterns})
+	handler := CORS(&live)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.company.com", true},
+		{"https://eu.app.company.com", true},
+		{"https://partner.example", true},
+		{"http://localhost:3000", true},
+		{"https://company.com", false},
+		{"http://app.company.com", false},
+		{"https://app.company.com.evil.test", false},
+		{"https://evilcompany.com", false},
+		{"https://app.company.com:8443", false},
+		{"http://localhost", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		if tt.allowed && (got != tt.origin || w.Header().Get("Vary") != "Origin") {
+			t.Errorf("%s: Allow-Origin %q, Vary %q; want it allowed", tt.origin, got, w.Header().Get("Vary"))
+		}
+		if !tt.allowed && got != "" {
+			t.Errorf("%s: Allow-Origin %q, want none", tt.origin, got)
+		}
+	}
+}
+
+func TestCORSAllowsEveryOriginByDefault(t *testing.T) {
+	handler := CORS(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(http.MethodOptions, "/items", nil)
+	r.Header.Set("Origin", "https://anywhere.test")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" || w.Code != http.StatusOK {
+		t.Errorf("status %d, Allow-Origin %q; want 200 with *", w.Code, got)
+	}
+}
+
+func TestParseOriginPatternsRejectsBadRegex(t *testing.T) {
+	if _, err := ParseOriginPatterns(`https://(unclosed`); err == nil {
+		t.Error("ParseOriginPatterns accepted an invalid regex")
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// Decompress inflates request bodies sent with Content-Encoding gzip or
+// deflate, so handlers read them as plain JSON. The inflated body is cut
+// off at limit bytes by http.MaxBytesReader, so a small compressed body
+// can't expand without bound; the compressed body is limited separately
+// by MaxBodySize. Other encodings get 415 and bodies that aren't valid in
+// their encoding get 400.
+func Decompress(limit int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var inflated io.ReadCloser
+			var err error
+			switch encoding {
+			case "gzip", "x-gzip":
+				inflated, err = gzip.NewReader(r.Body)
+			case "deflate":
+				inflated, err = zlib.NewReader(r.Body)
+			default:
+				w.Header().Set("Accept-Encoding", "gzip, deflate")
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				response.Encode(r.Context(), w, map[string]string{"error": fmt.Sprintf("Unsupported Content-Encoding %q; use gzip or deflate", encoding)})
+				return
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				response.Encode(r.Context(), w, map[string]string{"error": fmt.Sprintf("Request body is not valid %s", encoding)})
+				return
+			}
+
+			compressed := r.Body
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{http.MaxBytesReader(w, inflated, limit), closers{inflated, compressed}}
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// closers closes each of its closers, returning the first error
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var first error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
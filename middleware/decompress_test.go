@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-api/models"
+)
+
+// compress returns body encoded with encoding
+func compress(t *testing.T, encoding string, body []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser = gzip.NewWriter(&buf)
+	if encoding == "deflate" {
+		w = zlib.NewWriter(&buf)
+	}
+	if _, err := w.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressDecodesItems(t *testing.T) {
+	items := make([]models.Item, 200)
+	for i := range items {
+		items[i] = models.Item{Name: "Widget", Description: strings.Repeat("a sturdy widget ", 10), Quantity: i}
+	}
+	plain, err := json.Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			var decoded []models.Item
+			var contentLength int64
+			var header http.Header
+			handler := Decompress(1 << 20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentLength, header = r.ContentLength, r.Header
+				if err := json.NewDecoder(r.Body).Decode(&decoded); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			body := compress(t, encoding, plain)
+			if len(body) >= len(plain) {
+				t.Fatalf("%s body of %d bytes isn't smaller than %d", encoding, len(body), len(plain))
+			}
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/items", bytes.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("Content-Encoding", encoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status %d, want 201", w.Code)
+			}
+			if len(decoded) != len(items) || decoded[199].Quantity != 199 || decoded[0].Description != items[0].Description {
+				t.Errorf("decoded %d items, want the %d sent", len(decoded), len(items))
+			}
+			if contentLength != -1 || header.Get("Content-Encoding") != "" || header.Get("Content-Length") != "" {
+				t.Errorf("handler saw length %d and headers %v, want an unknown length and no encoding", contentLength, header)
+			}
+		})
+	}
+}
+
+func TestDecompressLimitsInflatedSize(t *testing.T) {
+	var readErr error
+	handler := Decompress(1 << 20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	// 8 MiB of zeros compress to a few KiB
+	body := compress(t, "gzip", make([]byte, 8<<20))
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/items", bytes.NewReader(body))
+	r.Header.Set("Content-Encoding", "gzip")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) || maxErr.Limit != 1<<20 {
+		t.Errorf("read error = %v, want a MaxBytesError at 1 MiB", readErr)
+	}
+}
+
+func TestDecompressRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		want     int
+	}{
+		{"not gzip", "gzip", []byte(`[{"name":"Widget"}]`), http.StatusBadRequest},
+		{"not deflate", "deflate", []byte(`[{"name":"Widget"}]`), http.StatusBadRequest},
+		{"unsupported encoding", "br", []byte{0x0b, 0x02}, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := Decompress(1 << 20)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/items", bytes.NewReader(tt.body))
+			r.Header.Set("Content-Encoding", tt.encoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want || called {
+				t.Errorf("status %d with handler called %v, want %d before the handler", w.Code, called, tt.want)
+			}
+		})
+	}
+}
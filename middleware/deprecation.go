@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"go-api/logger"
+
+	"github.com/gorilla/mux"
+)
+
+// Deprecate marks every response of a route scheduled for removal with the
+// Deprecation, Sunset and Link headers of draft-ietf-httpapi-deprecation-header.
+// link is the route replacing it. Each call is logged so the remaining
+// callers can be found before sunset.
+func Deprecate(sunset time.Time, link string) mux.MiddlewareFunc {
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	successor := "<" + link + `>; rel="successor-version"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Sunset", sunsetDate)
+			w.Header().Add("Link", successor)
+
+			logger.FromContext(r.Context()).Warn("deprecated route called",
+				"path", r.URL.Path, "client_ip", ClientIP(r, nil).String(), "sunset", sunsetDate)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-api/logger"
+)
+
+func TestDeprecateHeaders(t *testing.T) {
+	sunset := time.Date(2027, time.March, 31, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	deprecated := Deprecate(sunset, "/api/v1/items")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", `</api/v1/items?page=2>; rel="next"`)
+	}))
+
+	var logs bytes.Buffer
+	ctx := logger.WithContext(context.Background(), slog.New(slog.NewJSONHandler(&logs, nil)))
+	ctx = logger.With(ctx, "request_id", "req-1")
+	r := httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/items/export.csv", nil)
+	r.RemoteAddr = "192.0.2.7:4711"
+	w := httptest.NewRecorder()
+	deprecated.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation = %q, want true", got)
+	}
+	if got, want := w.Header().Get("Sunset"), "Tue, 30 Mar 2027 22:00:00 GMT"; got != want {
+		t.Errorf("Sunset = %q, want %q", got, want)
+	}
+	links := w.Header().Values("Link")
+	if len(links) != 2 || links[0] != `</api/v1/items>; rel="successor-version"` {
+		t.Errorf("Link = %q, want the successor and the handler's own link", links)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log %q: %v", logs.String(), err)
+	}
+	if entry["level"] != "WARN" || entry["request_id"] != "req-1" || entry["client_ip"] != "192.0.2.7" {
+		t.Errorf("logged %v, want a warning with the request ID and client IP", entry)
+	}
+}
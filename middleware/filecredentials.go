@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// credentialReloadInterval is how often FileCredentialChecker looks at the
+// file's modification time
+const credentialReloadInterval = 30 * time.Second
+
+// credentialFile is the document read by FileCredentialChecker
+type credentialFile struct {
+	Users []struct {
+		Username   string   `json:"username"`
+		BcryptHash string   `json:"bcrypt_hash"`
+		Roles      []string `json:"roles"`
+		TenantID   string   `json:"tenant_id"`
+	} `json:"users"`
+}
+
+// fileCredential is one user read by FileCredentialChecker
+type fileCredential struct {
+	hash   []byte
+	roles  []string
+	tenant string
+}
+
+// dummyHash is compared against for unknown users, so they cost as much as
+// known ones
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("unknown user"), bcrypt.DefaultCost)
+	return hash
+})
+
+// FileCredentialChecker checks credentials against bcrypt hashes in a JSON
+// file. The file is read again when its modification time changes, looked
+// at no more than every 30 seconds, so credentials rotate without a
+// restart.
+type FileCredentialChecker struct {
+	path string
+
+	mu      sync.RWMutex
+	users   map[string]fileCredential
+	modTime time.Time
+	checked time.Time
+}
+
+// NewFileCredentialChecker reads the credentials file at path, such as
+// {"users":[{"username":"alice","bcrypt_hash":"$2a$10$...","roles":["items:read"],"tenant_id":"acme"}]}.
+// Hashes can be made with cmd/hashpw.
+func NewFileCredentialChecker(path string) (*FileCredentialChecker, error) {
+	c := &FileCredentialChecker{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("credentials file: %w", err)
+	}
+	users, err := readCredentialFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c.users, c.modTime, c.checked = users, info.ModTime(), time.Now()
+	return c, nil
+}
+
+// readCredentialFile parses the credentials file at path
+func readCredentialFile(path string) (map[string]fileCredential, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("credentials file: %w", err)
+	}
+	var file credentialFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("credentials file %s: %w", path, err)
+	}
+
+	users := make(map[string]fileCredential, len(file.Users))
+	for i, user := range file.Users {
+		if user.Username == "" {
+			return nil, fmt.Errorf("credentials file %s: user %d has no username", path, i)
+		}
+		if _, err := bcrypt.Cost([]byte(user.BcryptHash)); err != nil {
+			return nil, fmt.Errorf("credentials file %s: user %s: %w", path, user.Username, err)
+		}
+		users[user.Username] = fileCredential{hash: []byte(user.BcryptHash), roles: user.Roles, tenant: user.TenantID}
+	}
+	return users, nil
+}
+
+// reload reads the file again if it changed since it was last read. A file
+// that fails to load is logged and the previous credentials are kept.
+func (c *FileCredentialChecker) reload() {
+	c.mu.RLock()
+	due := time.Since(c.checked) >= credentialReloadInterval
+	c.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.checked) < credentialReloadInterval {
+		return
+	}
+	c.checked = time.Now()
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		log.Printf("WARN: credentials file: %v; keeping the previous credentials", err)
+		return
+	}
+	if info.ModTime().Equal(c.modTime) {
+		return
+	}
+	users, err := readCredentialFile(c.path)
+	if err != nil {
+		log.Printf("WARN: %v; keeping the previous credentials", err)
+		return
+	}
+	c.users, c.modTime = users, info.ModTime()
+	log.Printf("Credentials reloaded from %s", c.path)
+}
+
+// Check implements CredentialChecker. bcrypt compares in constant time, and
+// unknown users are compared against a dummy hash, so response times don't
+// reveal which usernames exist.
+func (c *FileCredentialChecker) Check(username, password string) (Identity, bool) {
+	c.reload()
+
+	c.mu.RLock()
+	cred, known := c.users[username]
+	c.mu.RUnlock()
+	if !known {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return Identity{}, false
+	}
+	if bcrypt.CompareHashAndPassword(cred.hash, []byte(password)) != nil {
+		return Identity{}, false
+	}
+	return Identity{Roles: cred.roles, TenantID: cred.tenant}, true
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-api/flags"
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// FeatureFlag hides a route behind a feature flag, responding 404 while
+// the flag is disabled
+func FeatureFlag(store flags.FlagStore, flag string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !store.IsEnabled(flag) {
+				w.WriteHeader(http.StatusNotFound)
+				response.Encode(r.Context(), w, map[string]string{"error": "not available"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
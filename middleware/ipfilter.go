@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// IPFilterConfig restricts which client IPs may call the API
+type IPFilterConfig struct {
+	// Allowlist, when not empty, is the only networks allowed in
+	Allowlist []net.IPNet
+	// Blocklist networks are always rejected
+	Blocklist []net.IPNet
+	// TrustedProxies may set X-Forwarded-For and X-Real-IP
+	TrustedProxies []net.IPNet
+}
+
+// ParseCIDRs parses CIDR ranges; a bare IP is taken as a single address
+func ParseCIDRs(list []string) ([]net.IPNet, error) {
+	nets := make([]net.IPNet, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("ipfilter: invalid IP %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("ipfilter: invalid CIDR %q: %w", s, err)
+		}
+		nets = append(nets, *n)
+	}
+	return nets, nil
+}
+
+// IPFilter responds 403 to clients outside the allowlist or inside the
+// blocklist. Forwarding headers are only honoured from trusted proxies.
+func IPFilter(cfg IPFilterConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if len(cfg.Allowlist) == 0 && len(cfg.Blocklist) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := cfg.clientIP(r)
+			if ip == nil || !cfg.allowed(ip) {
+				log.Printf("WARN: blocked request from %s: %s %s", ip, r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusForbidden)
+				response.Encode(r.Context(), w, map[string]string{"error": "Forbidden"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowed reports whether ip passes the allowlist and blocklist
+func (c IPFilterConfig) allowed(ip net.IP) bool {
+	if contains(c.Blocklist, ip) {
+		return false
+	}
+	return len(c.Allowlist) == 0 || contains(c.Allowlist, ip)
+}
+
+// clientIP returns the IP of the client, trusting only the configured proxies
+func (c IPFilterConfig) clientIP(r *http.Request) net.IP {
+	return ClientIP(r, c.TrustedProxies)
+}
+
+// ClientIP returns the IP of the client. When the peer is a trusted proxy
+// the X-Forwarded-For chain is walked from the right, skipping trusted
+// proxies, falling back to X-Real-IP.
+func ClientIP(r *http.Request, trusted []net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil || !contains(trusted, peer) {
+		return peer
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				return peer
+			}
+			if !contains(trusted, ip) {
+				return ip
+			}
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip
+	}
+	return peer
+}
+
+func contains(nets []net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// mustCIDRs parses CIDRs, failing the test on an invalid one
+func mustCIDRs(t *testing.T, list ...string) []net.IPNet {
+	t.Helper()
+	nets, err := ParseCIDRs(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nets
+}
+
+func TestIPFilterOnlyTrustedProxiesForward(t *testing.T) {
+	handler := IPFilter(IPFilterConfig{
+		Allowlist:      mustCIDRs(t, "10.0.0.0/8"),
+		TrustedProxies: mustCIDRs(t, "192.168.1.1"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       int
+	}{
+		{"internal client", "10.1.2.3:5000", nil, http.StatusOK},
+		{"external client", "203.0.113.7:5000", nil, http.StatusForbidden},
+		{"external client spoofing X-Forwarded-For", "203.0.113.7:5000", map[string]string{"X-Forwarded-For": "10.1.2.3"}, http.StatusForbidden},
+		{"external client spoofing X-Real-IP", "203.0.113.7:5000", map[string]string{"X-Real-IP": "10.1.2.3"}, http.StatusForbidden},
+		{"trusted proxy forwarding an internal client", "192.168.1.1:5000", map[string]string{"X-Forwarded-For": "10.1.2.3"}, http.StatusOK},
+		{"trusted proxy forwarding an external client", "192.168.1.1:5000", map[string]string{"X-Forwarded-For": "203.0.113.7"}, http.StatusForbidden},
+		// The client wrote the left entry itself; the proxy appended the real one
+		{"spoofed entry before a trusted proxy", "192.168.1.1:5000", map[string]string{"X-Forwarded-For": "10.1.2.3, 203.0.113.7"}, http.StatusForbidden},
+		{"trusted proxy with X-Real-IP", "192.168.1.1:5000", map[string]string{"X-Real-IP": "10.1.2.3"}, http.StatusOK},
+		{"trusted proxy without headers", "192.168.1.1:5000", nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestIPFilterBlocklist(t *testing.T) {
+	handler := IPFilter(IPFilterConfig{
+		Blocklist: mustCIDRs(t, "203.0.113.0/24", "2001:db8::1"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for addr, want := range map[string]int{
+		"203.0.113.7:5000":  http.StatusForbidden,
+		"198.51.100.1:5000": http.StatusOK,
+		"[2001:db8::1]:443": http.StatusForbidden,
+		"[2001:db8::2]:443": http.StatusOK,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		r.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("%s: status %d, want %d", addr, w.Code, want)
+		}
+	}
+}
+
+func TestParseCIDRsRejectsInvalid(t *testing.T) {
+	for _, s := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if _, err := ParseCIDRs([]string{s}); err == nil {
+			t.Errorf("ParseCIDRs(%q) succeeded", s)
+		}
+	}
+}
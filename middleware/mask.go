@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"go-api/masking"
+
+	"github.com/gorilla/mux"
+)
+
+// MaskPII masks the values of the named fields, at any depth, in JSON and
+// MessagePack response bodies. It runs before BodyLogger sees the
+// response, so logged responses are masked too. Like Transform, it must
+// run after ContentNegotiation.
+func MaskPII(fields []string) mux.MiddlewareFunc {
+	return Transform(masking.PII(fields))
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-api/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute labels requests outside every route, so unknown paths
+// don't each get a series
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count, latency, body sizes and in-flight requests of
+// each route, labelled with the route's path template rather than the
+// request path. Body sizes count the bytes the handler read and wrote.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := unmatchedRoute
+		if current := mux.CurrentRoute(r); current != nil {
+			if tmpl, err := current.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+
+		inFlight := metrics.HTTPInFlight.WithLabelValues(route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		var body *countingReader
+		if r.Body != nil && r.Body != http.NoBody {
+			body = &countingReader{ReadCloser: r.Body}
+			r.Body = body
+		}
+
+		start := time.Now()
+		cw := &countingWriter{statusWriter: statusWriter{ResponseWriter: w, status: http.StatusOK}}
+		next.ServeHTTP(cw, r)
+
+		metrics.HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(cw.status)).Inc()
+		metrics.HTTPRequestSize.WithLabelValues(r.Method, route).Observe(float64(body.count()))
+		metrics.HTTPResponseSize.WithLabelValues(r.Method, route).Observe(float64(cw.n))
+	})
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.n += int64(n)
+	return n, err
+}
+
+// count returns the bytes read so far; a nil reader, standing in for an
+// empty body, has read none
+func (r *countingReader) count() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.n
+}
+
+// countingWriter counts the response body bytes written
+type countingWriter struct {
+	statusWriter
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.statusWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
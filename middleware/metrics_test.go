@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// histogram is the state of one body size series
+type histogram struct {
+	count uint64
+	sum   float64
+	// buckets holds the cumulative count of each upper bound
+	buckets map[float64]uint64
+}
+
+// since returns the observations h has on top of earlier
+func (h histogram) since(earlier histogram) histogram {
+	delta := histogram{count: h.count - earlier.count, sum: h.sum - earlier.sum, buckets: make(map[float64]uint64)}
+	for bound, n := range h.buckets {
+		delta.buckets[bound] = n - earlier.buckets[bound]
+	}
+	return delta
+}
+
+// bodyHistogram returns the body size histogram named name for method and
+// route from the shared registry; a series not yet observed is empty
+func bodyHistogram(t *testing.T, name, method, route string) histogram {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["method"] != method || labels["route"] != route {
+				continue
+			}
+			h := m.GetHistogram()
+			buckets := make(map[float64]uint64)
+			for _, b := range h.GetBucket() {
+				buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+			}
+			return histogram{count: h.GetSampleCount(), sum: h.GetSampleSum(), buckets: buckets}
+		}
+	}
+	return histogram{buckets: map[float64]uint64{}}
+}
+
+func TestMetricsRecordsBodySizes(t *testing.T) {
+	const route = "/metrics-test/{id}"
+	router := mux.NewRouter()
+	router.Use(Metrics)
+	router.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(strings.Repeat("x", 3000)))
+		w.Write([]byte(strings.Repeat("y", 2000)))
+	}).Methods("POST")
+
+	// The registry is shared by the whole process, so only what this test
+	// adds is checked
+	requestsBefore := bodyHistogram(t, "api_http_request_body_bytes", "POST", route)
+	responsesBefore := bodyHistogram(t, "api_http_response_body_bytes", "POST", route)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/metrics-test/1", strings.NewReader(strings.Repeat("z", 1000))))
+	if w.Body.Len() != 5000 {
+		t.Fatalf("response has %d bytes, want 5000", w.Body.Len())
+	}
+
+	requests := bodyHistogram(t, "api_http_request_body_bytes", "POST", route).since(requestsBefore)
+	if requests.count != 1 || requests.sum != 1000 {
+		t.Errorf("request bodies: %d observed summing %v, want 1 of 1000 bytes", requests.count, requests.sum)
+	}
+	if requests.buckets[512] != 0 || requests.buckets[2048] != 1 {
+		t.Errorf("request buckets %v, want the 1000 bytes in le=2048", requests.buckets)
+	}
+	responses := bodyHistogram(t, "api_http_response_body_bytes", "POST", route).since(responsesBefore)
+	if responses.count != 1 || responses.sum != 5000 {
+		t.Errorf("response bodies: %d observed summing %v, want 1 of 5000 bytes", responses.count, responses.sum)
+	}
+	if responses.buckets[2048] != 0 || responses.buckets[8192] != 1 {
+		t.Errorf("response buckets %v, want the 5000 bytes in le=8192", responses.buckets)
+	}
+
+	// A request without a body counts as zero bytes, under the path template
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/metrics-test/2", nil))
+	requests = bodyHistogram(t, "api_http_request_body_bytes", "POST", route).since(requestsBefore)
+	if requests.count != 2 || requests.sum != 1000 {
+		t.Errorf("after an empty request: %d observed summing %v, want 2 of 1000 bytes", requests.count, requests.sum)
+	}
+}
+
+// BenchmarkCountingOverhead measures what counting adds to reading and
+// writing a 4 KiB chunk, which must stay under 50ns
+func BenchmarkCountingOverhead(b *testing.B) {
+	chunk := make([]byte, 4096)
+	src := strings.NewReader(strings.Repeat("x", len(chunk)))
+	plainReader := io.NopCloser(src)
+	reader := &countingReader{ReadCloser: io.NopCloser(src)}
+	plainWriter := httptest.NewRecorder()
+	writer := &countingWriter{statusWriter: statusWriter{ResponseWriter: plainWriter, status: http.StatusOK}}
+
+	b.ReportAllocs()
+	start := time.Now()
+	for range b.N {
+		src.Seek(0, io.SeekStart)
+		plainReader.Read(chunk)
+		plainWriter.Body.Reset()
+		plainWriter.Write(chunk)
+	}
+	base := time.Since(start)
+	b.ResetTimer()
+	for range b.N {
+		src.Seek(0, io.SeekStart)
+		reader.Read(chunk)
+		plainWriter.Body.Reset()
+		writer.Write(chunk)
+	}
+	b.StopTimer()
+
+	overhead := (b.Elapsed() - base) / time.Duration(b.N)
+	b.ReportMetric(float64(overhead.Nanoseconds()), "overhead-ns/op")
+	if b.N >= 1000 && overhead >= 50*time.Nanosecond {
+		b.Errorf("counting adds %s per 4 KiB chunk, want under 50ns", overhead)
+	}
+}
@@ -1,14 +1,16 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
+
+	"go-api/logger"
 )
 
-// Logging logs HTTP requests
+// Logging logs HTTP requests with the request logger, so each line carries
+// the request and correlation IDs
 func Logging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("%s %s", r.Method, r.URL.Path)
+		logger.FromContext(r.Context()).Info(r.Method + " " + r.URL.Path)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -20,19 +22,3 @@ func JSON(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
-
-// CORS enables CORS headers
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-api/response"
+)
+
+// handlerTypes are negotiated by the handlers that produce them, such as CSV
+// exports, event streams and streamed lists
+var handlerTypes = []string{"text/csv", "text/event-stream", "application/x-ndjson"}
+
+// ContentNegotiation picks the response content type from the Accept header,
+// stores it in the request context for response.Encode and sets the
+// Content-Type header. Requests accepting no supported type get 406.
+func ContentNegotiation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType, ok := negotiate(r.Header.Get("Accept"))
+		w.Header().Add("Vary", "Accept")
+		w.Header().Set("Content-Type", contentType)
+		if !ok {
+			w.WriteHeader(http.StatusNotAcceptable)
+			json.NewEncoder(w).Encode(map[string]any{
+				"error":     "Not Acceptable",
+				"supported": response.Supported,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(response.WithContentType(r.Context(), contentType)))
+	})
+}
+
+// negotiate returns the supported type the Accept header prefers. It reports
+// false when the header names only types nobody here can produce.
+func negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return response.JSON, true
+	}
+
+	best, bestQ := "", 0.0
+	handled := false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		for _, supported := range response.Supported {
+			if matchesMediaRange(mediaType, supported) && q > bestQ {
+				best, bestQ = supported, q
+			}
+		}
+		for _, t := range handlerTypes {
+			if mediaType == t {
+				handled = true
+			}
+		}
+	}
+
+	switch {
+	case best != "":
+		return best, true
+	case handled:
+		return response.JSON, true
+	}
+	return response.JSON, false
+}
+
+// matchesMediaRange reports whether contentType is within a media range
+// such as */*, application/* or application/json
+func matchesMediaRange(mediaRange, contentType string) bool {
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(contentType, prefix+"/")
+}
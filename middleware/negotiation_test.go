@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-api/response"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestContentNegotiation(t *testing.T) {
+	handler := ContentNegotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.Encode(r.Context(), w, map[string]string{"name": "widget"})
+	}))
+
+	tests := []struct {
+		accept string
+		status int
+		want   string
+	}{
+		{"", http.StatusOK, response.JSON},
+		{"application/json", http.StatusOK, response.JSON},
+		{"application/msgpack", http.StatusOK, response.MsgPack},
+		{"*/*", http.StatusOK, response.JSON},
+		{"application/*", http.StatusOK, response.JSON},
+		{"application/json;q=0.5, application/msgpack", http.StatusOK, response.MsgPack},
+		{"application/msgpack;q=0, application/json;q=0.1", http.StatusOK, response.JSON},
+		{"text/csv", http.StatusOK, response.JSON},
+		{"text/html", http.StatusNotAcceptable, response.JSON},
+		{"application/msgpack;q=0", http.StatusNotAcceptable, response.JSON},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.status || w.Header().Get("Content-Type") != tt.want {
+			t.Errorf("Accept %q: status %d, Content-Type %q; want %d, %q", tt.accept, w.Code, w.Header().Get("Content-Type"), tt.status, tt.want)
+			continue
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: Vary = %q, want Accept", tt.accept, w.Header().Get("Vary"))
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+
+		var body map[string]string
+		var err error
+		if tt.want == response.MsgPack {
+			err = msgpack.Unmarshal(w.Body.Bytes(), &body)
+		} else {
+			err = json.Unmarshal(w.Body.Bytes(), &body)
+		}
+		if err != nil || body["name"] != "widget" {
+			t.Errorf("Accept %q: body %q doesn't decode as %s: %v", tt.accept, w.Body, tt.want, err)
+		}
+	}
+}
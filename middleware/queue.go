@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go-api/metrics"
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// Queue runs at most maxWorkers requests at once. Up to maxQueue more wait
+// for a free worker, for at most timeout; requests beyond that, or that
+// time out waiting, get 503 with a Retry-After header. All routes wrapped by
+// the returned middleware share the same workers.
+func Queue(maxQueue, maxWorkers int, timeout time.Duration) mux.MiddlewareFunc {
+	q := &queue{workers: make(chan struct{}, maxWorkers), maxQueue: int64(maxQueue), timeout: timeout}
+	retryAfter := strconv.Itoa(max(1, int(timeout.Seconds())))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if reason := q.acquire(r); reason != "" {
+				metrics.QueueRejected.WithLabelValues(reason).Inc()
+				w.Header().Set("Retry-After", retryAfter)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				response.Encode(r.Context(), w, map[string]string{"error": "Server is busy; retry later"})
+				return
+			}
+			defer q.release()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type queue struct {
+	workers  chan struct{}
+	waiting  atomic.Int64
+	maxQueue int64
+	timeout  time.Duration
+}
+
+// acquire takes a worker for r, waiting in the queue when none is free. It
+// returns why r was rejected, or "" once it holds a worker.
+func (q *queue) acquire(r *http.Request) string {
+	select {
+	case q.workers <- struct{}{}:
+		metrics.QueueActive.Inc()
+		return ""
+	default:
+	}
+
+	if q.waiting.Add(1) > q.maxQueue {
+		q.waiting.Add(-1)
+		return "full"
+	}
+	metrics.QueueDepth.Inc()
+	defer func() {
+		q.waiting.Add(-1)
+		metrics.QueueDepth.Dec()
+	}()
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+	select {
+	case q.workers <- struct{}{}:
+		metrics.QueueActive.Inc()
+		return ""
+	case <-timer.C:
+		return "timeout"
+	case <-r.Context().Done():
+		return "canceled"
+	}
+}
+
+func (q *queue) release() {
+	<-q.workers
+	metrics.QueueActive.Dec()
+}
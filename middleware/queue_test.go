@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueueRejectsWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	queued := Queue(1, 1, time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		queued.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+		return w
+	}
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	// One request holds the worker and the next waits in the queue
+	wg.Go(func() { codes[0] = serve().Code })
+	<-started
+	wg.Go(func() { codes[1] = serve().Code })
+	time.Sleep(20 * time.Millisecond)
+
+	rejected := serve()
+	if rejected.Code != http.StatusServiceUnavailable || rejected.Header().Get("Retry-After") != "1" {
+		t.Errorf("third request: status %d, Retry-After %q; want 503 and 1", rejected.Code, rejected.Header().Get("Retry-After"))
+	}
+	close(release)
+	wg.Wait()
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK {
+		t.Errorf("running and queued requests got %v, want 200s", codes)
+	}
+}
+
+func TestQueueTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	queued := Queue(1, 1, 20*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	go queued.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+	<-started
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	queued.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+	if waited := time.Since(start); waited < 20*time.Millisecond {
+		t.Errorf("rejected after %s, want the 20ms timeout", waited)
+	}
+}
+
+// benchmarkOverload offers twice as many concurrent requests as a backend
+// of 8 workers serves at full speed, with each request slowed in
+// proportion to the overload, and reports the p99 latency of the requests
+// that succeeded
+func benchmarkOverload(b *testing.B, wrap func(http.Handler) http.Handler) time.Duration {
+	const capacity, service = 8, 2 * time.Millisecond
+	var active atomic.Int64
+	backend := wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		time.Sleep(service * time.Duration(max(capacity, n)) / capacity)
+	}))
+
+	var mu sync.Mutex
+	var latencies []time.Duration
+	var rejected atomic.Int64
+	var next atomic.Int64
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for range 2 * capacity {
+		wg.Go(func() {
+			for next.Add(1) <= int64(b.N) {
+				start := time.Now()
+				w := httptest.NewRecorder()
+				backend.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+				if w.Code != http.StatusOK {
+					// Back off as a client would before retrying
+					rejected.Add(1)
+					time.Sleep(service)
+					continue
+				}
+				mu.Lock()
+				latencies = append(latencies, time.Since(start))
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	b.StopTimer()
+
+	if len(latencies) == 0 {
+		b.Fatal("every request was rejected")
+	}
+	slices.Sort(latencies)
+	p99 := latencies[(len(latencies)*99)/100]
+	b.ReportMetric(float64(p99.Microseconds())/1e3, "p99-ms")
+	b.ReportMetric(float64(rejected.Load())/float64(b.N), "rejected/op")
+	return p99
+}
+
+func BenchmarkNoQueueOverload(b *testing.B) {
+	benchmarkOverload(b, func(next http.Handler) http.Handler { return next })
+}
+
+func BenchmarkQueueOverload(b *testing.B) {
+	const timeout = 5 * time.Millisecond
+	p99 := benchmarkOverload(b, Queue(8, 8, timeout))
+	// A request waits at most the timeout for a worker, then runs at full
+	// speed; allow as much again for scheduling
+	if limit := 2 * (timeout + 2*time.Millisecond); p99 > limit {
+		b.Errorf("p99 %s, want at most %s", p99, limit)
+	}
+}
@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitResult is the outcome of a rate limit check
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the caller gets its full allowance back
+	Reset time.Time
+	// RetryAfter is how long a rejected caller must wait for its next request
+	RetryAfter time.Duration
+	// Policy describes the limit as <limit>;w=<window seconds>, e.g.
+	// "20;w=1" for 20 requests per second
+	Policy string
+}
+
+// RateLimiter decides whether the caller identified by key may proceed
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+}
+
+// RateLimit responds 429 once the caller, identified by key, is over the
+// limit. Limiter errors let the request through.
+func RateLimit(limiter RateLimiter, key func(r *http.Request) string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := limiter.Allow(r.Context(), key(r))
+			if err != nil {
+				log.Printf("WARN: rate limiter unavailable, allowing request: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(res.Reset.Unix(), 10))
+			w.Header().Set("RateLimit-Policy", res.Policy)
+			w.Header().Set("X-RateLimit-Policy", res.Policy)
+			if !res.Allowed {
+				// Whole seconds, rounded up so a client waiting that long gets through
+				w.Header().Set("Retry-After", strconv.FormatInt(int64(max(math.Ceil(res.RetryAfter.Seconds()), 1)), 10))
+				w.WriteHeader(http.StatusTooManyRequests)
+				response.Encode(r.Context(), w, map[string]string{"error": "Rate limit exceeded"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RedisRateLimit limits each remote IP with a limiter shared through Redis
+func RedisRateLimit(client *redis.Client, rps int, burst int) mux.MiddlewareFunc {
+	return RateLimit(NewRedisRateLimiter(client, rps, burst), remoteHost)
+}
+
+// policy returns the Policy of limit requests per window
+func policy(limit int, window time.Duration) string {
+	return fmt.Sprintf("%d;w=%d", limit, int(window.Seconds()))
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// LimitSetter is implemented by rate limiters whose limits can change while
+// the server runs
+type LimitSetter interface {
+	SetLimits(rps int, burst int)
+}
+
+// maxBuckets is the number of keys tracked before full buckets are dropped
+const maxBuckets = 10000
+
+// MemoryRateLimiter is a token bucket per key, local to this process
+type MemoryRateLimiter struct {
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewMemoryRateLimiter allows rps requests per second per key, with bursts
+// of up to burst requests
+func NewMemoryRateLimiter(rps int, burst int) *MemoryRateLimiter {
+	if burst < rps {
+		burst = rps
+	}
+	return &MemoryRateLimiter{rate: float64(rps), burst: burst, buckets: make(map[string]*bucket)}
+}
+
+// SetLimits changes the rate and burst of every bucket
+func (l *MemoryRateLimiter) SetLimits(rps int, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate, l.burst = float64(rps), max(rps, burst)
+}
+
+// Allow takes a token from the bucket of key
+func (l *MemoryRateLimiter) Allow(_ context.Context, key string) (RateLimitResult, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.sweep(now)
+		}
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	var retryAfter time.Duration
+	if allowed {
+		b.tokens--
+	} else {
+		retryAfter = l.refill(1 - b.tokens)
+	}
+	return RateLimitResult{
+		Allowed:    allowed,
+		Limit:      l.burst,
+		Remaining:  int(b.tokens),
+		Reset:      now.Add(l.refill(float64(l.burst) - b.tokens)),
+		RetryAfter: retryAfter,
+		Policy:     policy(int(l.rate), time.Second),
+	}, nil
+}
+
+// refill returns how long the bucket takes to gain tokens
+func (l *MemoryRateLimiter) refill(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep drops the buckets that have refilled; they behave like new ones
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RedisRateLimiter counts requests per key in one-second windows stored in
+// Redis, so every instance behind a load balancer shares the same limit
+type RedisRateLimiter struct {
+	client redis.Cmdable
+	limit  atomic.Int64
+}
+
+// NewRedisRateLimiter allows up to max(rps, burst) requests per key in each
+// one-second window
+func NewRedisRateLimiter(client redis.Cmdable, rps int, burst int) *RedisRateLimiter {
+	l := &RedisRateLimiter{client: client}
+	l.SetLimits(rps, burst)
+	return l
+}
+
+// SetLimits changes the requests allowed per window to max(rps, burst)
+func (l *RedisRateLimiter) SetLimits(rps int, burst int) {
+	l.limit.Store(int64(max(rps, burst)))
+}
+
+// Allow counts a request against the current window of key
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	window := time.Now().Unix()
+	redisKey := fmt.Sprintf("ratelimit:%s:%d", key, window)
+
+	var incr *redis.IntCmd
+	_, err := l.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, redisKey)
+		pipe.Expire(ctx, redisKey, 2*time.Second)
+		return nil
+	})
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("ratelimit: %w", err)
+	}
+
+	count := int(incr.Val())
+	limit := int(l.limit.Load())
+	reset := time.Unix(window+1, 0)
+	return RateLimitResult{
+		Allowed:    count <= limit,
+		Limit:      limit,
+		Remaining:  max(limit-count, 0),
+		Reset:      reset,
+		RetryAfter: time.Until(reset),
+		Policy:     policy(limit, time.Second),
+	}, nil
+}
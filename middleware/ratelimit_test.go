@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	limited := RateLimit(NewMemoryRateLimiter(20, 20), remoteHost)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rejected := 0
+	for range 25 {
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		if got := w.Header().Get("RateLimit-Policy"); got != "20;w=1" {
+			t.Fatalf("RateLimit-Policy = %q, want 20;w=1", got)
+		}
+		if w.Code != http.StatusTooManyRequests {
+			continue
+		}
+		rejected++
+		if seconds, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || seconds < 1 {
+			t.Errorf("429 has Retry-After %q, want a positive number of seconds", w.Header().Get("Retry-After"))
+		}
+	}
+	if rejected != 5 {
+		t.Errorf("%d requests rejected, want 5", rejected)
+	}
+}
+
+func TestRateLimitPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter RateLimiter
+		want    string
+	}{
+		{"memory", NewMemoryRateLimiter(20, 40), "20;w=1"},
+		{"user", NewUserRateLimiter(5, 10), "5;w=1"},
+	}
+	for _, tt := range tests {
+		res, err := tt.limiter.Allow(context.Background(), "caller")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Policy != tt.want {
+			t.Errorf("%s: Policy = %q, want %q", tt.name, res.Policy, tt.want)
+		}
+	}
+}
+
+// fakeLimiter is a RateLimiter returning a fixed result and recording keys
+type fakeLimiter struct {
+	result RateLimitResult
+	err    error
+	keys   []string
+}
+
+func (f *fakeLimiter) Allow(_ context.Context, key string) (RateLimitResult, error) {
+	f.keys = append(f.keys, key)
+	return f.result, f.err
+}
+
+func TestRateLimitWithInjectedLimiter(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	serve := func(limiter RateLimiter) *httptest.ResponseRecorder {
+		called = false
+		r := httptest.NewRequest(http.MethodGet, "/items", nil)
+		r.RemoteAddr = "198.51.100.1:5000"
+		w := httptest.NewRecorder()
+		RateLimit(limiter, remoteHost)(next).ServeHTTP(w, r)
+		return w
+	}
+
+	denied := &fakeLimiter{result: RateLimitResult{Limit: 5, RetryAfter: 300 * time.Millisecond, Policy: "5;w=1"}}
+	if w := serve(denied); w.Code != http.StatusTooManyRequests || called || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("denied: status %d, Retry-After %q, handler called %v; want 429 after 1s without the handler", w.Code, w.Header().Get("Retry-After"), called)
+	}
+	if len(denied.keys) != 1 || denied.keys[0] != "198.51.100.1" {
+		t.Errorf("limiter keys = %v, want the remote IP", denied.keys)
+	}
+
+	if w := serve(&fakeLimiter{result: RateLimitResult{Allowed: true, Limit: 5, Remaining: 4}}); w.Code != http.StatusOK || !called || w.Header().Get("X-RateLimit-Remaining") != "4" {
+		t.Errorf("allowed: status %d, Remaining %q, handler called %v", w.Code, w.Header().Get("X-RateLimit-Remaining"), called)
+	}
+
+	// A limiter failure lets the request through
+	if w := serve(&fakeLimiter{err: errors.New("redis down")}); w.Code != http.StatusOK || !called {
+		t.Errorf("failing limiter: status %d, handler called %v; want the request let through", w.Code, called)
+	}
+}
+
+func TestRedisRateLimiterIncrementsWindowKey(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	limiter := NewRedisRateLimiter(client, 2, 0)
+
+	// Retry when the calls straddle a window, which splits the count
+	for attempt := 0; ; attempt++ {
+		server.FlushAll()
+		window := time.Now().Unix()
+		var results []RateLimitResult
+		for range 3 {
+			res, err := limiter.Allow(context.Background(), "198.51.100.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			results = append(results, res)
+		}
+		if time.Now().Unix() != window {
+			if attempt > 3 {
+				t.Fatal("could not fit three calls in one window")
+			}
+			continue
+		}
+
+		keys := server.Keys()
+		if len(keys) != 1 || !strings.HasPrefix(keys[0], "ratelimit:198.51.100.1:") {
+			t.Fatalf("Redis keys = %v, want one ratelimit:198.51.100.1:<window> key", keys)
+		}
+		if count, _ := server.Get(keys[0]); count != "3" {
+			t.Errorf("%s = %s, want 3 increments", keys[0], count)
+		}
+		if ttl := server.TTL(keys[0]); ttl <= 0 || ttl > 2*time.Second {
+			t.Errorf("%s TTL = %s, want it to expire within 2s", keys[0], ttl)
+		}
+		if !results[0].Allowed || !results[1].Allowed || results[2].Allowed {
+			t.Errorf("allowed = %v, %v, %v; want the third call over the limit of 2", results[0].Allowed, results[1].Allowed, results[2].Allowed)
+		}
+		if results[1].Remaining != 0 || results[2].Policy != "2;w=1" {
+			t.Errorf("results = %+v, want none remaining and policy 2;w=1", results)
+		}
+		return
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"go-api/logger"
+
+	"github.com/google/uuid"
+)
+
+const requestIDKey contextKey = "request_id"
+
+// RequestID gives every request an ID, taken from the X-Request-ID header or
+// generated, and echoes it in the response. The ID and a logger carrying
+// request_id, remote_addr and method are stored in the request context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = uuid.New().String()
+			r.Header.Set("X-Request-ID", id)
+		}
+		w.Header().Set("X-Request-ID", id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey, id)
+		ctx = logger.WithContext(ctx, slog.Default().With(
+			"request_id", id,
+			"remote_addr", r.RemoteAddr,
+			"method", r.Method,
+		))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the ID stored by RequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
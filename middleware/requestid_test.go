@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-api/logger"
+)
+
+func TestRequestIDSharedByLogLines(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	handler := RequestID(Logging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := logger.With(r.Context(), "user_id", "alice")
+		logger.FromContext(ctx).Warn("slow store")
+		logger.FromContext(ctx).Error("update item", "id", "42")
+	})))
+
+	for _, incoming := range []string{"", "from-the-proxy"} {
+		logs.Reset()
+		r := httptest.NewRequest(http.MethodPut, "/api/v1/items/42", nil)
+		if incoming != "" {
+			r.Header.Set("X-Request-ID", incoming)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		id := w.Header().Get("X-Request-ID")
+		if id == "" || (incoming != "" && id != incoming) {
+			t.Fatalf("X-Request-ID = %q with %q sent", id, incoming)
+		}
+		lines := 0
+		scanner := bufio.NewScanner(&logs)
+		for scanner.Scan() {
+			lines++
+			var entry map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatalf("line %q: %v", scanner.Text(), err)
+			}
+			if entry["request_id"] != id || entry["method"] != http.MethodPut || entry["remote_addr"] != r.RemoteAddr {
+				t.Errorf("line %v doesn't carry request %s's fields", entry, id)
+			}
+		}
+		if lines != 3 {
+			t.Errorf("%d log lines, want 3:\n%s", lines, logs.String())
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go-api/blocklist"
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// TokenID returns the jti claim of the authenticated caller of r, or "" when
+// the request carries no token ID
+func TokenID(r *http.Request) string {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	jti, _ := claims["jti"].(string)
+	return jti
+}
+
+// Revocation responds 401 to callers whose token ID, the jti claim, is in
+// the blocklist. It must run after the middleware that verifies the token
+// and sets its claims; requests without a jti go through.
+func Revocation(list blocklist.Blocklist) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if jti := TokenID(r); jti != "" && list.IsBlocked(jti) {
+				w.WriteHeader(http.StatusUnauthorized)
+				response.Encode(r.Context(), w, map[string]string{"error": "Token has been revoked"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"go-api/response"
+
+	"github.com/gorilla/mux"
+)
+
+// Scopes checked by RequireScope
+const (
+	ScopeItemsRead    = "items:read"
+	ScopeItemsWrite   = "items:write"
+	ScopeClientsRead  = "clients:read"
+	ScopeClientsWrite = "clients:write"
+	ScopeAdmin        = "admin"
+)
+
+type contextKey string
+
+const scopesKey contextKey = "scopes"
+
+// ScopeChecker reports whether a request has been granted a scope
+type ScopeChecker interface {
+	HasScope(r *http.Request, scope string) bool
+}
+
+// WithScopes returns a copy of ctx carrying the scopes granted to the caller.
+// Authentication middleware calls this once the credentials are verified.
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// ScopesFromContext returns the scopes stored by WithScopes
+func ScopesFromContext(ctx context.Context) []string {
+	scopes, _ := ctx.Value(scopesKey).([]string)
+	return scopes
+}
+
+// ContextScopeChecker checks the scopes stored in the request context.
+// The admin scope grants every other scope.
+type ContextScopeChecker struct{}
+
+// HasScope implements ScopeChecker
+func (ContextScopeChecker) HasScope(r *http.Request, scope string) bool {
+	scopes := ScopesFromContext(r.Context())
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+// RequireScope responds 403 unless the caller has the given scope
+func RequireScope(scope string) mux.MiddlewareFunc {
+	return RequireScopeWith(ContextScopeChecker{}, scope)
+}
+
+// RequireScopeWith is RequireScope with a custom ScopeChecker
+func RequireScopeWith(checker ScopeChecker, scope string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !checker.HasScope(r, scope) {
+				w.WriteHeader(http.StatusForbidden)
+				response.Encode(r.Context(), w, map[string]string{"error": "Missing required scope: " + scope})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// grantedScopes is a ScopeChecker granting a fixed set of scopes
+type grantedScopes map[string]bool
+
+func (g grantedScopes) HasScope(_ *http.Request, scope string) bool {
+	return g[scope]
+}
+
+func TestRequireScopeWith(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	checker := grantedScopes{ScopeItemsRead: true}
+
+	for scope, want := range map[string]int{ScopeItemsRead: http.StatusOK, ScopeItemsWrite: http.StatusForbidden} {
+		called = false
+		w := httptest.NewRecorder()
+		RequireScopeWith(checker, scope)(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+		if w.Code != want || called != (want == http.StatusOK) {
+			t.Errorf("%s: status %d, handler called %v; want %d", scope, w.Code, called, want)
+		}
+	}
+}
+
+func TestRequireScopeBoundaries(t *testing.T) {
+	tests := []struct {
+		granted []string
+		scope   string
+		allowed bool
+	}{
+		{[]string{ScopeItemsRead}, ScopeItemsRead, true},
+		{[]string{ScopeItemsRead}, ScopeItemsWrite, false},
+		{[]string{ScopeItemsWrite}, ScopeItemsRead, false},
+		{[]string{ScopeItemsRead, ScopeItemsWrite}, ScopeClientsRead, false},
+		{[]string{ScopeClientsRead}, ScopeClientsRead, true},
+		{[]string{ScopeClientsRead}, ScopeClientsWrite, false},
+		{[]string{ScopeClientsWrite}, ScopeItemsWrite, false},
+		{[]string{ScopeAdmin}, ScopeItemsWrite, true},
+		{[]string{ScopeAdmin}, ScopeClientsWrite, true},
+		{nil, ScopeItemsRead, false},
+		{nil, ScopeAdmin, false},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(WithScopes(r.Context(), tt.granted))
+		w := httptest.NewRecorder()
+		RequireScope(tt.scope)(next).ServeHTTP(w, r)
+		if allowed := w.Code == http.StatusOK; allowed != tt.allowed {
+			t.Errorf("scopes %v, requiring %s: status %d, want allowed %v", tt.granted, tt.scope, w.Code, tt.allowed)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"go-api/logger"
+	"go-api/session"
+
+	"github.com/gorilla/mux"
+)
+
+// Session loads the session named by the session cookie into the request
+// context, where session.FromContext finds it. A session created for a user
+// is only loaded for that user, so this must run after authentication.
+// Unknown sessions are ignored, and so are store errors, which are logged.
+func Session(store session.Store) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(session.CookieName)
+			if err != nil || cookie.Value == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			s, err := store.Get(r.Context(), cookie.Value)
+			switch {
+			case errors.Is(err, session.ErrNotFound):
+			case err != nil:
+				logger.FromContext(r.Context()).Warn("load session", "error", err)
+			case s.UserID == "" || s.UserID == UserID(r):
+				r = r.WithContext(session.WithSession(r.Context(), s))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
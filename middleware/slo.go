@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"bufio"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-api/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// SLO adds an X-Response-Time-Ms header to every response. Routes listed in
+// limits, keyed by "METHOD /path/template", also get X-SLO-Violated: true
+// when they respond slower than their limit.
+func SLO(limits map[string]time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if tmpl, err := route.GetPathTemplate(); err == nil {
+					path = tmpl
+				}
+			}
+			limit, ok := limits[r.Method+" "+path]
+
+			sw := &sloWriter{ResponseWriter: w, start: time.Now(), method: r.Method, path: path}
+			if ok {
+				sw.limit = limit
+			}
+			next.ServeHTTP(sw, r)
+			sw.writeHeaders()
+		})
+	}
+}
+
+// sloWriter sets the timing headers just before the response headers are
+// sent, which is the last moment they can be changed
+type sloWriter struct {
+	http.ResponseWriter
+	start  time.Time
+	method string
+	path   string
+	limit  time.Duration
+	done   bool
+}
+
+func (w *sloWriter) writeHeaders() {
+	if w.done {
+		return
+	}
+	w.done = true
+
+	elapsed := time.Since(w.start)
+	w.Header().Set("X-Response-Time-Ms", strconv.FormatInt(elapsed.Milliseconds(), 10))
+	if w.limit > 0 && elapsed > w.limit {
+		w.Header().Set("X-SLO-Violated", "true")
+		metrics.SLOViolations.WithLabelValues(w.method, w.path).Inc()
+		log.Printf("WARN: SLO violated: %s %s took %s, limit %s", w.method, w.path, elapsed, w.limit)
+	}
+}
+
+func (w *sloWriter) WriteHeader(status int) {
+	w.writeHeaders()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sloWriter) Write(b []byte) (int, error) {
+	w.writeHeaders()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *sloWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets WebSocket upgrades take over the connection
+func (w *sloWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.writeHeaders()
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Push keeps HTTP/2 server push available to handlers
+func (w *sloWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+
+	"go-api/response"
+	"go-api/transform"
+
+	"github.com/gorilla/mux"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Transform runs pipeline over JSON and MessagePack response bodies: over
+// the object of a single resource, or over each object of a list. Other
+// bodies, such as CSV exports and event streams, are passed through, as are
+// bodies that fail to decode. It must run after ContentNegotiation.
+func Transform(pipeline ...transform.Transformer) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if len(pipeline) == 0 {
+			return next
+		}
+		steps := transform.Pipeline(pipeline)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			buf := bufferPool.Get().(*bytes.Buffer)
+			defer func() {
+				buf.Reset()
+				bufferPool.Put(buf)
+			}()
+
+			tw := &transformWriter{ResponseWriter: w, buf: buf, status: http.StatusOK}
+			next.ServeHTTP(tw, r)
+			if !tw.buffering {
+				return
+			}
+
+			body := buf.Bytes()
+			if transformed, ok := transformBody(r, steps, body); ok {
+				body = transformed
+			}
+			w.Header().Del("Content-Length")
+			w.WriteHeader(tw.status)
+			w.Write(body)
+		})
+	}
+}
+
+// transformBody decodes body in the negotiated content type of r, runs
+// steps over it and encodes it again. It reports false when body isn't an
+// object or a list.
+func transformBody(r *http.Request, steps transform.Pipeline, body []byte) ([]byte, bool) {
+	if len(body) == 0 {
+		return nil, false
+	}
+
+	var v any
+	var err error
+	if response.ContentType(r.Context()) == response.MsgPack {
+		err = msgpack.Unmarshal(body, &v)
+	} else {
+		err = json.Unmarshal(body, &v)
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		out, err := response.Marshal(r.Context(), steps.Transform(r.Context(), v))
+		return out, err == nil
+	case []any:
+		for i, elem := range v {
+			if obj, ok := elem.(map[string]any); ok {
+				v[i] = steps.Transform(r.Context(), obj)
+			}
+		}
+		out, err := response.Marshal(r.Context(), v)
+		return out, err == nil
+	}
+	return nil, false
+}
+
+// transformWriter holds back JSON and MessagePack bodies so Transform can
+// rewrite them, and passes every other body straight through
+type transformWriter struct {
+	http.ResponseWriter
+	buf    *bytes.Buffer
+	status int
+
+	// decided is set by the first WriteHeader or Write, which looks at the
+	// Content-Type to choose whether to buffer
+	decided   bool
+	buffering bool
+}
+
+// decide chooses whether to buffer from the Content-Type set so far
+func (w *transformWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = mediaType == response.JSON || mediaType == response.MsgPack
+}
+
+func (w *transformWriter) WriteHeader(status int) {
+	w.decide()
+	if w.buffering {
+		w.status = status
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *transformWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// FlushError flushes passed-through bodies; buffered ones are written
+// whole once the handler returns
+func (w *transformWriter) FlushError() error {
+	w.decide()
+	if w.buffering {
+		return nil
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *transformWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets WebSocket upgrades take over the connection
+func (w *transformWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided, w.buffering = true, false
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// userBucketIdle is how long a user bucket is kept without requests
+const userBucketIdle = 10 * time.Minute
+
+// UserID returns the ID of the authenticated caller of r, the sub claim,
+// or "" when the request carries no claims
+func UserID(r *http.Request) string {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+	id, _ := claims["sub"].(string)
+	return id
+}
+
+// UserRateLimit limits authenticated callers per user ID rather than per
+// IP, so users sharing an address behind NAT don't share a limit. Requests
+// without a user go through fallback, usually the per-IP limit. It must run
+// after the middleware that authenticates the caller.
+func UserRateLimit(limiter *UserRateLimiter, fallback mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		byUser := RateLimit(limiter, UserID)(next)
+		byIP := fallback(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if UserID(r) != "" {
+				byUser.ServeHTTP(w, r)
+				return
+			}
+			byIP.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UserRateLimiter is a token bucket per user, local to this process.
+// Buckets idle for 10 minutes are dropped by Run.
+type UserRateLimiter struct {
+	mu    sync.RWMutex
+	rate  float64
+	burst int
+
+	// buckets maps each user ID to its *userBucket
+	buckets sync.Map
+}
+
+type userBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// UserBucket is the state of one user's bucket
+type UserBucket struct {
+	UserID   string    `json:"user_id"`
+	Tokens   float64   `json:"tokens"`
+	Burst    int       `json:"burst"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// NewUserRateLimiter allows rps requests per second per user, with bursts
+// of up to burst requests
+func NewUserRateLimiter(rps int, burst int) *UserRateLimiter {
+	l := &UserRateLimiter{}
+	l.SetLimits(rps, burst)
+	return l
+}
+
+// SetLimits changes the rate and burst of every bucket
+func (l *UserRateLimiter) SetLimits(rps int, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate, l.burst = float64(rps), max(rps, burst)
+}
+
+// limits returns the current rate and burst
+func (l *UserRateLimiter) limits() (float64, int) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.rate, l.burst
+}
+
+// Allow takes a token from the bucket of the user with ID key
+func (l *UserRateLimiter) Allow(_ context.Context, key string) (RateLimitResult, error) {
+	now := time.Now()
+	rate, burst := l.limits()
+
+	v, _ := l.buckets.LoadOrStore(key, &userBucket{tokens: float64(burst), last: now})
+	b := v.(*userBucket)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = tokensAt(b, now, rate, burst)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	var retryAfter time.Duration
+	if allowed {
+		b.tokens--
+	} else {
+		retryAfter = refillTime(1-b.tokens, rate)
+	}
+	return RateLimitResult{
+		Allowed:    allowed,
+		Limit:      burst,
+		Remaining:  int(b.tokens),
+		Reset:      now.Add(refillTime(float64(burst)-b.tokens, rate)),
+		RetryAfter: retryAfter,
+		Policy:     policy(int(rate), time.Second),
+	}, nil
+}
+
+// Tokens returns the tokens left in the bucket of userID, and false when
+// the user has no bucket
+func (l *UserRateLimiter) Tokens(userID string) (float64, bool) {
+	v, ok := l.buckets.Load(userID)
+	if !ok {
+		return 0, false
+	}
+	rate, burst := l.limits()
+	b := v.(*userBucket)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return tokensAt(b, time.Now(), rate, burst), true
+}
+
+// Buckets returns every active user bucket, ordered by user ID
+func (l *UserRateLimiter) Buckets() []UserBucket {
+	now := time.Now()
+	rate, burst := l.limits()
+
+	buckets := make([]UserBucket, 0)
+	l.buckets.Range(func(key, v any) bool {
+		b := v.(*userBucket)
+		b.mu.Lock()
+		buckets = append(buckets, UserBucket{
+			UserID:   key.(string),
+			Tokens:   tokensAt(b, now, rate, burst),
+			Burst:    burst,
+			LastSeen: b.last,
+		})
+		b.mu.Unlock()
+		return true
+	})
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].UserID < buckets[j].UserID })
+	return buckets
+}
+
+// Run drops the buckets idle for 10 minutes until ctx is cancelled; a
+// returning user starts again with a full bucket
+func (l *UserRateLimiter) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.sweep(now)
+		}
+	}
+}
+
+// sweep drops the buckets last used more than userBucketIdle before now
+func (l *UserRateLimiter) sweep(now time.Time) {
+	l.buckets.Range(func(key, v any) bool {
+		b := v.(*userBucket)
+		b.mu.Lock()
+		idle := now.Sub(b.last) > userBucketIdle
+		b.mu.Unlock()
+		if idle {
+			l.buckets.CompareAndDelete(key, v)
+		}
+		return true
+	})
+}
+
+// tokensAt returns the tokens of b refilled up to now
+func tokensAt(b *userBucket, now time.Time, rate float64, burst int) float64 {
+	return min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+}
+
+// refillTime returns how long a bucket takes to gain tokens at rate
+func refillTime(tokens, rate float64) time.Duration {
+	return time.Duration(tokens / rate * float64(time.Second))
+}
@@ -0,0 +1,125 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket = []byte("meta")
+	versionKey = []byte("schema_version")
+)
+
+// Transform changes one record, decoded from its stored JSON
+type Transform func(record map[string]any) error
+
+// BoltVersions keeps the schema version in the meta bucket of a Bolt database
+type BoltVersions struct {
+	db *bbolt.DB
+}
+
+// NewBoltVersions creates a version store in db
+func NewBoltVersions(db *bbolt.DB) *BoltVersions {
+	return &BoltVersions{db: db}
+}
+
+// Current returns the recorded schema version
+func (v *BoltVersions) Current() (int, error) {
+	var version int
+	err := v.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(metaBucket)
+		if b == nil {
+			return nil
+		}
+		raw := b.Get(versionKey)
+		if raw == nil {
+			return nil
+		}
+		n, err := strconv.Atoi(string(raw))
+		if err != nil {
+			return fmt.Errorf("invalid schema version %q", raw)
+		}
+		version = n
+		return nil
+	})
+	return version, err
+}
+
+// Set records the schema version
+func (v *BoltVersions) Set(version int) error {
+	return v.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(versionKey, []byte(strconv.Itoa(version)))
+	})
+}
+
+// BoltMigrator rewrites every record of a Bolt bucket through a list of
+// transforms, in a single transaction
+type BoltMigrator struct {
+	db         *bbolt.DB
+	version    int
+	bucket     []byte
+	transforms []Transform
+}
+
+// NewBoltMigrator creates the migration to version that applies transforms
+// to every record in bucket
+func NewBoltMigrator(db *bbolt.DB, version int, bucket string, transforms ...Transform) *BoltMigrator {
+	return &BoltMigrator{db: db, version: version, bucket: []byte(bucket), transforms: transforms}
+}
+
+// Version returns the schema version the migration brings the store to
+func (m *BoltMigrator) Version() int {
+	return m.version
+}
+
+// Apply transforms and rewrites every record in the bucket. Nothing is
+// written unless every record is transformed.
+func (m *BoltMigrator) Apply(ctx context.Context) error {
+	return m.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(m.bucket)
+		if b == nil {
+			return nil
+		}
+
+		updated := make(map[string][]byte)
+		err := b.ForEach(func(k, v []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var record map[string]any
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("%s/%s: %w", m.bucket, k, err)
+			}
+			for _, transform := range m.transforms {
+				if err := transform(record); err != nil {
+					return fmt.Errorf("%s/%s: %w", m.bucket, k, err)
+				}
+			}
+			data, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("%s/%s: %w", m.bucket, k, err)
+			}
+			updated[string(k)] = data
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Bolt doesn't allow writes while iterating a bucket
+		for k, data := range updated {
+			if err := b.Put([]byte(k), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"go-api/storage"
+)
+
+// CopyStore replaces the contents of dst with the records of src, keeping
+// their IDs and timestamps, and returns the number of records copied. The
+// records are streamed through an io.Pipe from src's Export to dst's
+// Import, so src is never encoded into one buffer.
+func CopyStore[T any](ctx context.Context, src, dst storage.Store[T]) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	exported := make(chan error, 1)
+	go func() {
+		err := src.Export(ctx, pw)
+		pw.CloseWithError(err)
+		exported <- err
+	}()
+
+	counter := &lineCounter{r: pr}
+	importErr := dst.Import(ctx, counter)
+	// Unblock the export if the import stopped reading early
+	pr.CloseWithError(errors.New("import stopped"))
+	cancel()
+	exportErr := <-exported
+
+	// The import sees the export's error through the pipe, so report the
+	// export's own error when there is one
+	if exportErr != nil && !errors.Is(exportErr, context.Canceled) {
+		return 0, fmt.Errorf("migration: export: %w", exportErr)
+	}
+	if importErr != nil {
+		return 0, fmt.Errorf("migration: import: %w", importErr)
+	}
+	return counter.lines, nil
+}
+
+// lineCounter counts the newlines read through it, one per exported record
+type lineCounter struct {
+	r     io.Reader
+	lines int
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.lines += bytes.Count(p[:n], []byte{'\n'})
+	return n, err
+}
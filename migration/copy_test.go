@@ -0,0 +1,90 @@
+package migration
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-api/models"
+	"go-api/storage"
+	"go-api/tenant"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// decorated wraps store in the decorators main.go puts around the item
+// store, in the same order, returning the chain and its HookedStore
+func decorated(store storage.Store[models.Item]) (storage.Store[models.Item], *storage.HookedStore[models.Item]) {
+	store = storage.NewInstrumentedStore(store, "items", "test", prometheus.NewRegistry())
+	store = storage.NewCircuitBreaker(store, 5, time.Minute)
+	store = storage.NewSingleFlightStore(store)
+	store = storage.NewLRUStore(store, 100)
+	store = storage.NewTenantStore(store)
+	hooks := storage.NewHookedStore(store)
+	return storage.NewTracedStore[models.Item](hooks, noop.NewTracerProvider().Tracer("test"), "items"), hooks
+}
+
+func TestCopyStoreThroughStoreChain(t *testing.T) {
+	src, _ := decorated(storage.NewMemoryStore[models.Item]())
+	acme := tenant.WithID(context.Background(), "acme")
+	for _, name := range []string{"Widget", "Gadget"} {
+		src.(storage.Scoper[models.Item]).For(acme).Create(models.Item{Name: name})
+	}
+	src.Create(models.Item{Name: "Gizmo"})
+
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "api.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	bolt, err := storage.NewBoltStore[models.Item](db, "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, hooks := decorated(bolt)
+	var resets atomic.Int32
+	hooks.AddResetHook(func(context.Context) { resets.Add(1) })
+	// Cached by the LRU, which the import has to empty
+	stale := dst.Create(models.Item{Name: "Stale"})
+	dst.GetByID(stale.ID)
+
+	n, err := CopyStore(context.Background(), src, dst)
+	if err != nil {
+		t.Fatalf("CopyStore: %v", err)
+	}
+	hooks.Close()
+	if n != 3 {
+		t.Errorf("copied %d records, want 3", n)
+	}
+	if _, ok := dst.GetByID(stale.ID); ok {
+		t.Error("record replaced by the import still served from the cache")
+	}
+	ids := func(items []models.Item) []string {
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.ID+"/"+item.TenantID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	if got, want := ids(dst.GetAll()), ids(src.GetAll()); !slices.Equal(got, want) {
+		t.Errorf("target holds %v, want %v with their IDs and tenants", got, want)
+	}
+	if n := resets.Load(); n != 1 {
+		t.Errorf("reset hooks ran %d times, want once", n)
+	}
+
+	// A tenant's view exports only its records and can't be imported into
+	scoped := dst.(storage.Scoper[models.Item]).For(acme)
+	if n, err := CopyStore(context.Background(), src.(storage.Scoper[models.Item]).For(acme), storage.NewMemoryStore[models.Item]()); err != nil || n != 2 {
+		t.Errorf("copying acme's view: %d records, %v; want 2", n, err)
+	}
+	if _, err := CopyStore(context.Background(), src, scoped); err == nil {
+		t.Error("CopyStore into a tenant view succeeded")
+	}
+}
@@ -0,0 +1,65 @@
+// Package migration evolves the schema of stored records as models change.
+package migration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Migrator applies one schema change
+type Migrator interface {
+	// Apply rewrites the stored records to the new schema
+	Apply(ctx context.Context) error
+	// Version is the schema version the store is at once Apply succeeds
+	Version() int
+}
+
+// VersionStore records the schema version a store was last migrated to
+type VersionStore interface {
+	// Current returns the recorded version, or 0 for a store never migrated
+	Current() (int, error)
+	Set(version int) error
+}
+
+// Runner applies the migrations a store hasn't had yet, in version order
+type Runner struct {
+	versions   VersionStore
+	migrations []Migrator
+}
+
+// NewRunner creates a runner for migrations, recording progress in versions
+func NewRunner(versions VersionStore, migrations ...Migrator) *Runner {
+	sorted := append([]Migrator(nil), migrations...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Version() < sorted[j].Version() })
+	return &Runner{versions: versions, migrations: sorted}
+}
+
+// Run applies every migration with a version above the recorded one. The
+// version is recorded after each migration, so a failed run resumes from
+// the migration that failed.
+func (r *Runner) Run(ctx context.Context) error {
+	current, err := r.versions.Current()
+	if err != nil {
+		return fmt.Errorf("migration: read version: %w", err)
+	}
+
+	for _, m := range r.migrations {
+		if m.Version() <= current {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.Apply(ctx); err != nil {
+			return fmt.Errorf("migration: apply version %d: %w", m.Version(), err)
+		}
+		if err := r.versions.Set(m.Version()); err != nil {
+			return fmt.Errorf("migration: record version %d: %w", m.Version(), err)
+		}
+		current = m.Version()
+		log.Printf("Migrated schema to version %d", current)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"go-api/models"
+
+	"go.etcd.io/bbolt"
+)
+
+// memoryVersions keeps the schema version in memory
+type memoryVersions struct {
+	version int
+}
+
+func (v *memoryVersions) Current() (int, error) { return v.version, nil }
+
+func (v *memoryVersions) Set(version int) error {
+	v.version = version
+	return nil
+}
+
+// countingMigrator counts its runs, failing while fail is set
+type countingMigrator struct {
+	version int
+	runs    int
+	fail    bool
+}
+
+func (m *countingMigrator) Version() int { return m.version }
+
+func (m *countingMigrator) Apply(ctx context.Context) error {
+	m.runs++
+	if m.fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestRunnerIsIdempotent(t *testing.T) {
+	versions := &memoryVersions{}
+	first, second := &countingMigrator{version: 1}, &countingMigrator{version: 2}
+	// Given out of order, they still run in version order
+	runner := NewRunner(versions, second, first)
+
+	second.fail = true
+	if err := runner.Run(context.Background()); err == nil {
+		t.Fatal("Run succeeded with a failing migration")
+	}
+	if versions.version != 1 {
+		t.Fatalf("version = %d after migration 2 failed, want 1", versions.version)
+	}
+
+	second.fail = false
+	for range 2 {
+		if err := runner.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if first.runs != 1 || second.runs != 2 {
+		t.Errorf("migrations ran %d and %d times, want 1 and 2", first.runs, second.runs)
+	}
+	if versions.version != 2 {
+		t.Errorf("version = %d, want 2", versions.version)
+	}
+}
+
+func TestBoltMigrationsAreIdempotent(t *testing.T) {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "api.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	records := map[string]string{
+		"old":       `{"id":"old","name":"widget"}`,
+		"published": `{"id":"published","name":"gadget","status":"published"}`,
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucket([]byte("items"))
+		if err != nil {
+			return err
+		}
+		for k, v := range records {
+			if err := b.Put([]byte(k), []byte(v)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := func(id string) string {
+		t.Helper()
+		var item models.Item
+		db.View(func(tx *bbolt.Tx) error {
+			return json.Unmarshal(tx.Bucket([]byte("items")).Get([]byte(id)), &item)
+		})
+		return item.Status
+	}
+
+	versions := NewBoltVersions(db)
+	for run := range 2 {
+		if err := NewRunner(versions, Bolt(db)...).Run(context.Background()); err != nil {
+			t.Fatalf("run %d: %v", run+1, err)
+		}
+		if got := status("old"); got != models.StatusDraft {
+			t.Errorf("run %d: status of an old item = %q, want %q", run+1, got, models.StatusDraft)
+		}
+		if got := status("published"); got != "published" {
+			t.Errorf("run %d: status of a published item = %q, want published", run+1, got)
+		}
+	}
+	if current, err := versions.Current(); err != nil || current != len(Bolt(db)) {
+		t.Errorf("Current = %d, %v; want %d", current, err, len(Bolt(db)))
+	}
+
+	// Once recorded, a migration isn't applied again even when it would
+	// change records
+	db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte("items")).Put([]byte("old"), []byte(records["old"]))
+	})
+	if err := NewRunner(versions, Bolt(db)...).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := status("old"); got != "" {
+		t.Errorf("status = %q after a third run, want the record untouched", got)
+	}
+}
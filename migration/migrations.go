@@ -0,0 +1,26 @@
+package migration
+
+import (
+	"go-api/models"
+
+	"go.etcd.io/bbolt"
+)
+
+// Bolt returns the migrations of the bolt backend. New migrations take the
+// next version; released ones must never change.
+func Bolt(db *bbolt.DB) []Migrator {
+	return []Migrator{
+		// Items stored before statuses were introduced start as drafts
+		NewBoltMigrator(db, 1, "items", setDefault("status", models.StatusDraft)),
+	}
+}
+
+// setDefault sets field to value on records where it is missing or empty
+func setDefault(field string, value any) Transform {
+	return func(record map[string]any) error {
+		if v, ok := record[field]; !ok || v == nil || v == "" {
+			record[field] = value
+		}
+		return nil
+	}
+}
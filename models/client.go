@@ -4,10 +4,29 @@ import "time"
 
 // Client represents a client in the system
 type Client struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Phone     string    `json:"phone"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	// Balance only changes through ledger credits and debits
+	Balance float64 `json:"balance"`
+	// Status only changes through PATCH /clients/{id}/status, which keeps
+	// a history of every change
+	Status    string    `json:"status"`
+	TenantID  string    `json:"tenant_id"`
+	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// ClientStatusEvent records one change of a client's status
+type ClientStatusEvent struct {
+	ID        string `json:"id"`
+	ClientID  string `json:"client_id"`
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+	Reason    string `json:"reason"`
+	// ActorID is the authenticated caller that made the change, if any
+	ActorID    string    `json:"actor_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Contact is a person to reach at a client
+type Contact struct {
+	ID        string    `json:"id"`
+	ClientID  string    `json:"client_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	Role      string    `json:"role"`
+	IsPrimary bool      `json:"is_primary"`
+	TenantID  string    `json:"tenant_id"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go-api/events"
+)
+
+// DeadLetter is a webhook delivery that failed after every retry
+type DeadLetter struct {
+	ID        string       `json:"id"`
+	Event     events.Event `json:"event"`
+	URL       string       `json:"url"`
+	Attempts  int          `json:"attempts"`
+	LastError string       `json:"last_error"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Export job statuses
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+// ExportJob is an export of one entity that runs in the background
+type ExportJob struct {
+	ID       string            `json:"id"`
+	TenantID string            `json:"tenant_id"`
+	Entity   string            `json:"entity"`
+	Format   string            `json:"format"`
+	Filter   map[string]string `json:"filter,omitempty"`
+	Status   string            `json:"status"`
+	// Exported counts the records written so far out of Total
+	Exported int    `json:"exported"`
+	Total    int    `json:"total"`
+	Error    string `json:"error,omitempty"`
+	// Path is the file holding the finished export
+	Path      string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
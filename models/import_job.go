@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Import job statuses
+const (
+	ImportPending = "pending"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// ImportJob is an import of a large item feed that runs in the background
+type ImportJob struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+	// Imported and Failed count the feed entries out of Total
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Total    int           `json:"total"`
+	Errors   []ImportError `json:"errors"`
+	Error    string        `json:"error,omitempty"`
+	// Items are the valid entries waiting to be stored
+	Items     []Item    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ImportError describes a feed entry that could not be imported. Row is
+// the 1-based position of the entry in the feed.
+type ImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
@@ -7,6 +7,12 @@ type Item struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Quantity    int       `json:"quantity"`
+	ClientID    string    `json:"client_id"`
+	Tags        []string  `json:"tags"`
+	TenantID    string    `json:"tenant_id"`
+	Version     int       `json:"version"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// ItemV2 is an item as the v2 API presents it. It is stored as an Item and
+// translated at the handler layer.
+type ItemV2 struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Stock       int       `json:"stock"`
+	ClientID    string    `json:"client_id"`
+	Tags        []string  `json:"tags"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ItemToV2 returns item as the v2 API presents it
+func ItemToV2(item Item) ItemV2 {
+	return ItemV2{
+		ID:          item.ID,
+		Title:       item.Name,
+		Description: item.Description,
+		Status:      item.Status,
+		Stock:       item.Quantity,
+		ClientID:    item.ClientID,
+		Tags:        item.Tags,
+		Version:     item.Version,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+	}
+}
+
+// ItemFromV2 returns the Item stored for a v2 item. The tenant is left
+// for the store to assign.
+func ItemFromV2(item ItemV2) Item {
+	return Item{
+		ID:          item.ID,
+		Name:        item.Title,
+		Description: item.Description,
+		Status:      item.Status,
+		Quantity:    item.Stock,
+		ClientID:    item.ClientID,
+		Tags:        item.Tags,
+		Version:     item.Version,
+		CreatedAt:   item.CreatedAt,
+		UpdatedAt:   item.UpdatedAt,
+	}
+}
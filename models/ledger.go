@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Ledger entry types
+const (
+	LedgerCredit = "credit"
+	LedgerDebit  = "debit"
+)
+
+// Ledger is one credit or debit of a client's balance
+type Ledger struct {
+	ID       string  `json:"id"`
+	ClientID string  `json:"client_id"`
+	Type     string  `json:"type"`
+	Amount   float64 `json:"amount"`
+	// Balance is the client's balance once the entry was applied
+	Balance   float64   `json:"balance"`
+	Reference string    `json:"reference"`
+	CreatedAt time.Time `json:"created_at"`
+}
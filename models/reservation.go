@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Reservation holds Quantity units of an item until it is released or
+// expires at ExpiresAt
+type Reservation struct {
+	ID        string    `json:"id"`
+	ItemID    string    `json:"item_id"`
+	TenantID  string    `json:"tenant_id"`
+	Quantity  int       `json:"quantity"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "fmt"
+
+// Item statuses
+const (
+	StatusDraft        = "draft"
+	StatusActive       = "active"
+	StatusInactive     = "inactive"
+	StatusDiscontinued = "discontinued"
+)
+
+// itemTransitions lists the statuses each status may move to
+var itemTransitions = map[string][]string{
+	StatusDraft:        {StatusActive},
+	StatusActive:       {StatusInactive, StatusDiscontinued},
+	StatusInactive:     {StatusActive},
+	StatusDiscontinued: {},
+}
+
+// ValidStatus reports whether status is a known item status
+func ValidStatus(status string) bool {
+	_, ok := itemTransitions[status]
+	return ok
+}
+
+// ValidateTransition checks that an item may move from one status to another
+func ValidateTransition(from, to string) error {
+	if !ValidStatus(to) {
+		return fmt.Errorf("invalid status '%s'", to)
+	}
+	for _, next := range itemTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("transition from '%s' to '%s' is not allowed", from, to)
+}
+
+// Client statuses
+const (
+	ClientActive    = "active"
+	ClientInactive  = "inactive"
+	ClientSuspended = "suspended"
+)
+
+// clientTransitions lists the statuses each client status may move to
+var clientTransitions = map[string][]string{
+	ClientActive:    {ClientInactive, ClientSuspended},
+	ClientInactive:  {ClientActive},
+	ClientSuspended: {ClientActive},
+}
+
+// ValidClientStatus reports whether status is a known client status
+func ValidClientStatus(status string) bool {
+	_, ok := clientTransitions[status]
+	return ok
+}
+
+// NextClientStatuses returns the statuses a client may move to from status
+func NextClientStatuses(status string) []string {
+	return clientTransitions[status]
+}
+
+// ValidateClientTransition checks that a client may move from one status to
+// another
+func ValidateClientTransition(from, to string) error {
+	if !ValidClientStatus(to) {
+		return fmt.Errorf("invalid status '%s'", to)
+	}
+	for _, next := range clientTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("transition from '%s' to '%s' is not allowed", from, to)
+}
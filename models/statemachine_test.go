@@ -0,0 +1,36 @@
+package models
+
+import "testing"
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{StatusDraft, StatusActive, true},
+		{StatusActive, StatusInactive, true},
+		{StatusInactive, StatusActive, true},
+		{StatusActive, StatusDiscontinued, true},
+
+		{StatusDraft, StatusInactive, false},
+		{StatusDraft, StatusDiscontinued, false},
+		{StatusActive, StatusDraft, false},
+		{StatusInactive, StatusDraft, false},
+		{StatusInactive, StatusDiscontinued, false},
+		{StatusDiscontinued, StatusActive, false},
+		{StatusDiscontinued, StatusDraft, false},
+		{StatusActive, StatusActive, false},
+		{StatusDraft, "archived", false},
+	}
+	for _, tt := range tests {
+		err := ValidateTransition(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateTransition(%q, %q) = %v, want ok %v", tt.from, tt.to, err, tt.ok)
+		}
+	}
+
+	err := ValidateTransition(StatusDraft, StatusDiscontinued)
+	if want := "transition from 'draft' to 'discontinued' is not allowed"; err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
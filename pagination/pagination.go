@@ -0,0 +1,42 @@
+// Package pagination builds the links between the pages of list responses.
+package pagination
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TotalPages returns how many pages of limit records total records fill;
+// an empty list still has one, empty, page
+func TotalPages(total, limit int) int {
+	if limit <= 0 || total <= 0 {
+		return 1
+	}
+	return (total + limit - 1) / limit
+}
+
+// LinkHeader returns the value of an RFC 8288 Link header pointing from
+// page, counted from 1, to the next, previous, last and first of
+// totalPages pages of limit records at baseURL, such as
+// </api/v2/items?page=3&limit=20>; rel="next". prev is left out on the
+// first page and next on the last.
+func LinkHeader(baseURL string, page, totalPages, limit int) string {
+	totalPages = max(totalPages, 1)
+	sep := "?"
+	if strings.Contains(baseURL, "?") {
+		sep = "&"
+	}
+	link := func(p int, rel string) string {
+		return fmt.Sprintf(`<%s%spage=%d&limit=%d>; rel="%s"`, baseURL, sep, p, limit, rel)
+	}
+
+	links := make([]string, 0, 4)
+	if page < totalPages {
+		links = append(links, link(page+1, "next"))
+	}
+	if page > 1 {
+		links = append(links, link(min(page-1, totalPages), "prev"))
+	}
+	links = append(links, link(totalPages, "last"), link(1, "first"))
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,96 @@
+package pagination
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// linkPattern matches one link of a Link header
+var linkPattern = regexp.MustCompile(`<([^>]*)>;\s*rel="([^"]*)"`)
+
+// parseLinks returns the page and limit each rel of header points at
+func parseLinks(t *testing.T, header string) map[string][2]int {
+	t.Helper()
+	links := make(map[string][2]int)
+	for _, m := range linkPattern.FindAllStringSubmatch(header, -1) {
+		u, err := url.Parse(m[1])
+		if err != nil {
+			t.Fatalf("link %q: %v", m[1], err)
+		}
+		page, err1 := strconv.Atoi(u.Query().Get("page"))
+		limit, err2 := strconv.Atoi(u.Query().Get("limit"))
+		if err1 != nil || err2 != nil {
+			t.Fatalf("link %q has no page and limit", m[1])
+		}
+		if _, dup := links[m[2]]; dup {
+			t.Fatalf("rel %q appears twice in %q", m[2], header)
+		}
+		links[m[2]] = [2]int{page, limit}
+	}
+	return links
+}
+
+func TestLinkHeader(t *testing.T) {
+	tests := []struct {
+		name              string
+		page, totalPages  int
+		next, prev        int
+		last, first, want int
+	}{
+		{name: "only page", page: 1, totalPages: 1, last: 1, first: 1, want: 2},
+		{name: "first page", page: 1, totalPages: 10, next: 2, last: 10, first: 1, want: 3},
+		{name: "middle page", page: 3, totalPages: 10, next: 4, prev: 2, last: 10, first: 1, want: 4},
+		{name: "last page", page: 10, totalPages: 10, prev: 9, last: 10, first: 1, want: 3},
+		{name: "past the end", page: 12, totalPages: 10, prev: 10, last: 10, first: 1, want: 3},
+		{name: "empty list", page: 1, totalPages: 0, last: 1, first: 1, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := LinkHeader("/api/v2/items", tt.page, tt.totalPages, 20)
+			links := parseLinks(t, header)
+			if len(links) != tt.want {
+				t.Errorf("%d links in %q, want %d", len(links), header, tt.want)
+			}
+			for rel, page := range map[string]int{"next": tt.next, "prev": tt.prev, "last": tt.last, "first": tt.first} {
+				link, ok := links[rel]
+				switch {
+				case page == 0 && ok:
+					t.Errorf("rel=%s points at page %d, want it left out", rel, link[0])
+				case page != 0 && !ok:
+					t.Errorf("no rel=%s in %q", rel, header)
+				case ok && (link[0] != page || link[1] != 20):
+					t.Errorf("rel=%s points at page %d limit %d, want page %d limit 20", rel, link[0], link[1], page)
+				}
+			}
+		})
+	}
+}
+
+func TestLinkHeaderKeepsQuery(t *testing.T) {
+	header := LinkHeader("/api/v2/items?status=active", 2, 3, 5)
+	want := `</api/v2/items?status=active&page=3&limit=5>; rel="next", ` +
+		`</api/v2/items?status=active&page=1&limit=5>; rel="prev", ` +
+		`</api/v2/items?status=active&page=3&limit=5>; rel="last", ` +
+		`</api/v2/items?status=active&page=1&limit=5>; rel="first"`
+	if header != want {
+		t.Errorf("LinkHeader = %s\nwant %s", header, want)
+	}
+}
+
+func TestTotalPages(t *testing.T) {
+	tests := []struct{ total, limit, want int }{
+		{0, 20, 1},
+		{1, 20, 1},
+		{20, 20, 1},
+		{21, 20, 2},
+		{200, 20, 10},
+		{5, 0, 1},
+	}
+	for _, tt := range tests {
+		if got := TotalPages(tt.total, tt.limit); got != tt.want {
+			t.Errorf("TotalPages(%d, %d) = %d, want %d", tt.total, tt.limit, got, tt.want)
+		}
+	}
+}
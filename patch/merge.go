@@ -0,0 +1,81 @@
+// Package patch applies JSON Merge Patch documents (RFC 7396) to records.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ContentType is the media type of a JSON Merge Patch document
+const ContentType = "application/merge-patch+json"
+
+// MergePatch applies the JSON Merge Patch document patch to the JSON
+// document target and returns the result. Members of a patch object
+// replace those of the target, objects are merged recursively and null
+// members remove the target's member. A patch that isn't an object
+// replaces the whole target.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	patchValue, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("patch: invalid patch: %w", err)
+	}
+	var targetValue any
+	if len(bytes.TrimSpace(target)) > 0 {
+		if targetValue, err = decode(target); err != nil {
+			return nil, fmt.Errorf("patch: invalid target: %w", err)
+		}
+	}
+	return json.Marshal(merge(targetValue, patchValue))
+}
+
+// Apply merges patch into record, decoding the result as a new T
+func Apply[T any](record T, patch []byte) (T, error) {
+	var next T
+	target, err := json.Marshal(record)
+	if err != nil {
+		return next, fmt.Errorf("patch: %w", err)
+	}
+	merged, err := MergePatch(target, patch)
+	if err != nil {
+		return next, err
+	}
+	if err := json.Unmarshal(merged, &next); err != nil {
+		return next, fmt.Errorf("patch: %w", err)
+	}
+	return next, nil
+}
+
+// decode parses one JSON value, keeping numbers as written
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the document")
+	}
+	return v, nil
+}
+
+// merge is the MergePatch algorithm of RFC 7396, section 2
+func merge(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = make(map[string]any, len(patchObject))
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,91 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go-api/models"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name, target, patch, want string
+	}{
+		// The examples of RFC 7396, appendix A
+		{"replace a member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add a member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null removes", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"null removes one of several", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"arrays are replaced", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"by a value", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested objects merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"arrays aren't merged", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"a non-object patch replaces", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"an object replaces a non-object", `["a"]`, `{"a":"b"}`, `{"a":"b"}`},
+		{"a scalar replaces an object", `{"a":"b"}`, `"c"`, `"c"`},
+		{"null in the target stays", `{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{"a new nested object drops its nulls", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{"an empty patch changes nothing", `{"a":"b","c":{"d":1}}`, `{}`, `{"a":"b","c":{"d":1}}`},
+		{"removing a missing member changes nothing", `{"a":"b"}`, `{"z":null}`, `{"a":"b"}`},
+		{"large numbers keep their digits", `{"n":1}`, `{"n":12345678901234567890}`, `{"n":12345678901234567890}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.target), []byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("MergePatch(%s, %s) = %s, want %s", tt.target, tt.patch, got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range [][2]string{{`{}`, `{"a":`}, {`{"a":`, `{}`}, {`{}`, `{} {}`}} {
+		if _, err := MergePatch([]byte(bad[0]), []byte(bad[1])); err == nil {
+			t.Errorf("MergePatch(%s, %s) succeeded, want an error", bad[0], bad[1])
+		}
+	}
+}
+
+// jsonEqual reports whether a and b hold the same JSON value
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("%s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("%s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestApply(t *testing.T) {
+	item := models.Item{ID: "1", Name: "widget", Description: "blue", Quantity: 3, Tags: []string{"a"}, Version: 2}
+
+	tests := []struct {
+		name, patch string
+		want        models.Item
+	}{
+		{"no-op", `{}`, item},
+		{"set a field", `{"quantity":7}`, models.Item{ID: "1", Name: "widget", Description: "blue", Quantity: 7, Tags: []string{"a"}, Version: 2}},
+		{"null resets a field", `{"description":null,"tags":null}`, models.Item{ID: "1", Name: "widget", Quantity: 3, Version: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(item, []byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply(%s) = %+v, want %+v", tt.patch, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Apply(item, []byte(`{"quantity":"many"}`)); err == nil {
+		t.Error("a patch of the wrong type applied")
+	}
+}
@@ -0,0 +1,4 @@
+// Package apipb holds the Go code generated from proto/api.proto
+package apipb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=module=go-api --go-grpc_out=../.. --go-grpc_opt=module=go-api ../api.proto
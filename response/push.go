@@ -0,0 +1,31 @@
+package response
+
+import (
+	"errors"
+	"log"
+	"net/http"
+)
+
+// Push asks an HTTP/2 client to fetch targets along with the current
+// response. It does nothing when the connection doesn't support push.
+func Push(w http.ResponseWriter, r *http.Request, targets []string) {
+	pusher, ok := w.(http.Pusher)
+	if !ok {
+		return
+	}
+
+	opts := &http.PushOptions{Header: http.Header{}}
+	for _, h := range []string{"Accept", "Authorization", "X-Admin-Key"} {
+		if v := r.Header.Get(h); v != "" {
+			opts.Header.Set(h, v)
+		}
+	}
+	for _, target := range targets {
+		if err := pusher.Push(target, opts); err != nil {
+			if !errors.Is(err, http.ErrNotSupported) {
+				log.Printf("push %s: %v", target, err)
+			}
+			return
+		}
+	}
+}
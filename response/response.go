@@ -0,0 +1,56 @@
+// Package response encodes response bodies in the content type negotiated
+// for the request.
+package response
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Supported content types
+const (
+	JSON    = "application/json"
+	MsgPack = "application/msgpack"
+)
+
+// Supported lists the content types that bodies can be encoded in, in
+// order of preference
+var Supported = []string{JSON, MsgPack}
+
+type contextKey struct{}
+
+// WithContentType returns a copy of ctx carrying the negotiated content type
+func WithContentType(ctx context.Context, contentType string) context.Context {
+	return context.WithValue(ctx, contextKey{}, contentType)
+}
+
+// ContentType returns the content type negotiated for ctx, JSON by default
+func ContentType(ctx context.Context) string {
+	if ct, ok := ctx.Value(contextKey{}).(string); ok {
+		return ct
+	}
+	return JSON
+}
+
+// Encode writes v to w in the content type negotiated for ctx
+func Encode(ctx context.Context, w io.Writer, v any) error {
+	if ContentType(ctx) == MsgPack {
+		enc := msgpack.NewEncoder(w)
+		enc.SetCustomStructTag("json")
+		return enc.Encode(v)
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+// Marshal returns v encoded in the content type negotiated for ctx
+func Marshal(ctx context.Context, v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encode(ctx, &buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
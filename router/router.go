@@ -1,40 +1,413 @@
 package router
 
 import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go-api/blocklist"
+	"go-api/changelog"
+	"go-api/config"
+	"go-api/flags"
 	"go-api/handlers"
+	v2 "go-api/handlers/v2"
+	"go-api/metrics"
 	"go-api/middleware"
+	"go-api/models"
+	"go-api/patch"
+	"go-api/session"
+	"go-api/static"
+	"go-api/telemetry"
+	"go-api/transform"
 
 	"github.com/gorilla/mux"
 )
 
-// Setup configures all routes and middleware
-func Setup(itemHandler *handlers.ItemHandler, clientHandler *handlers.ClientHandler) *mux.Router {
+// Handlers groups the HTTP handlers wired into the router
+type Handlers struct {
+	Health       *handlers.HealthHandler
+	Items        *handlers.ItemHandler
+	Reservations *handlers.ReservationHandler
+	Clients      *handlers.ClientHandler
+	Ledger       *handlers.LedgerHandler
+	ClientStatus *handlers.ClientStatusHandler
+	ExportJobs   *handlers.ExportJobHandler
+	ImportJobs   *handlers.ImportJobHandler
+	Contacts     *handlers.ContactHandler
+	ItemEvents   *handlers.EventHandler
+	ClientEvents *handlers.EventHandler
+	WSHub        *handlers.WSHub
+	ItemPoll     *handlers.PollHandler
+	ItemHash     *handlers.HashHandler[models.Item]
+	ClientHash   *handlers.HashHandler[models.Client]
+	Admin        *handlers.AdminHandler
+	Webhooks     *handlers.WebhookHandler
+
+	// ItemsV2 serves the v2 item routes when cfg.APIV2Enabled is set
+	ItemsV2 *v2.V2ItemHandler
+
+	// Ready reports whether startup has finished, for the readiness probe
+	Ready func() bool
+
+	// Flags gates experimental routes
+	Flags flags.FlagStore
+	// CORS lists the origins allowed to call the API from a browser; it may
+	// be replaced while the server runs
+	CORS *atomic.Pointer[middleware.CORSConfig]
+	// IPFilter restricts which client IPs may call the API
+	IPFilter middleware.IPFilterConfig
+	// Transforms rewrite every JSON response body, such as to strip fields
+	// external clients must not see
+	Transforms transform.Pipeline
+	// Credentials checks HTTP basic credentials on every item and client
+	// route; nil disables basic authentication
+	Credentials middleware.CredentialChecker
+	// ResponseCache keeps GET item and client responses for
+	// cfg.ResponseCacheTTL; nil disables response caching
+	ResponseCache middleware.CacheStore
+	// RateLimiter limits requests per client IP; nil disables rate limiting
+	RateLimiter middleware.RateLimiter
+	// UserRateLimiter, when set, limits authenticated callers per user
+	// instead of per IP on the routes that authenticate them
+	UserRateLimiter *middleware.UserRateLimiter
+	// Sessions serves the session routes, and SessionStore loads the
+	// caller's session on every authenticated route; nil disables sessions
+	Sessions     *handlers.SessionHandler
+	SessionStore session.Store
+	// Tokens verifies bearer tokens on every item and client route, next to
+	// Credentials; nil disables bearer tokens
+	Tokens *middleware.TokenIssuer
+	// Blocklist rejects revoked tokens on every authenticated route; nil
+	// disables token revocation
+	Blocklist blocklist.Blocklist
+	// Auth issues tokens when Tokens is set and serves the logout route
+	// when Blocklist is
+	Auth *handlers.AuthHandler
+	// ItemsGate and ClientsGate reject requests while a store is unavailable
+	ItemsGate   middleware.Gate
+	ClientsGate middleware.Gate
+}
+
+// csvExportSunset is when the export.csv routes are removed; listing with
+// Accept: text/csv replaces them
+var csvExportSunset = time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+// Setup configures all routes and middleware. It panics if a route the
+// changelog lists as added is not registered.
+func Setup(cfg *config.Config, h Handlers) *mux.Router {
 	router := mux.NewRouter()
+	changes, err := changelog.Load()
+	if err != nil {
+		panic(err)
+	}
 
 	// API v1 routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 
+	// Requests are rate limited per client IP for every route. With per-user
+	// limits the check moves into the route chains: routes that authenticate
+	// the caller limit them per user once the claims are known, and the
+	// others, through open, per IP.
+	limitIP := func(next http.Handler) http.Handler { return next }
+	if h.RateLimiter != nil {
+		limitIP = middleware.RateLimit(h.RateLimiter, func(r *http.Request) string {
+			return middleware.ClientIP(r, h.IPFilter.TrustedProxies).String()
+		})
+	}
+	open := middleware.New()
+	if h.UserRateLimiter != nil {
+		open = middleware.New(limitIP)
+	}
+
+	// Per-route middleware chains. Tenant runs per route, after the caller's
+	// claims are known.
+	base := middleware.New()
+	if h.Tokens != nil {
+		base = base.Append(middleware.BearerToken(h.Tokens))
+	}
+	if h.Credentials != nil {
+		base = base.Append(middleware.BasicAuth(h.Credentials))
+	}
+	base = base.Append(middleware.Tenant)
+	if h.Blocklist != nil {
+		base = base.Append(middleware.Revocation(h.Blocklist))
+	}
+	if h.UserRateLimiter != nil {
+		base = base.Append(middleware.UserRateLimit(h.UserRateLimiter, limitIP))
+	}
+	if h.SessionStore != nil {
+		base = base.Append(middleware.Session(h.SessionStore))
+	}
+	scope := func(scopes ...string) middleware.Chain {
+		chain := base
+		if !cfg.AuthEnabled {
+			return chain
+		}
+		for _, s := range scopes {
+			chain = chain.Append(middleware.RequireScope(s))
+		}
+		return chain
+	}
+	guard := func(chain middleware.Chain, gate middleware.Gate) middleware.Chain {
+		if gate == nil {
+			return chain
+		}
+		return chain.Append(middleware.Available(gate))
+	}
+	queue := func(chain middleware.Chain) middleware.Chain { return chain }
+	if cfg.QueueMaxWorkers > 0 {
+		workers := middleware.Queue(cfg.QueueMaxSize, cfg.QueueMaxWorkers, cfg.QueueTimeout)
+		queue = func(chain middleware.Chain) middleware.Chain { return chain.Append(workers) }
+	}
+	cacheReads := func(chain middleware.Chain) middleware.Chain { return chain }
+	if h.ResponseCache != nil {
+		cache := middleware.Cache(cfg.ResponseCacheTTL, h.ResponseCache)
+		cacheReads = func(chain middleware.Chain) middleware.Chain { return chain.Append(cache) }
+	}
+	limitBody := middleware.MaxBodySize(cfg.MaxBodySizeBytes)
+	decompress := middleware.Decompress(cfg.MaxBodySizeBytes)
+
+	// Streams stay open for a long time, so they don't take queue workers
+	itemsStream := guard(scope(middleware.ScopeItemsRead), h.ItemsGate)
+	itemsRead := cacheReads(queue(itemsStream))
+	itemsWrite := queue(guard(scope(middleware.ScopeItemsWrite), h.ItemsGate))
+	itemsBody := itemsWrite.Append(limitBody, decompress)
+	clientsStream := guard(scope(middleware.ScopeClientsRead), h.ClientsGate)
+	clientsRead := cacheReads(queue(clientsStream))
+	clientsWrite := queue(guard(scope(middleware.ScopeClientsWrite), h.ClientsGate))
+	clientsBody := clientsWrite.Append(limitBody, decompress)
+	adminOnly := open.Append(middleware.AdminKey(cfg.AdminAPIKey))
+	mergePatch := middleware.RequireContentType(patch.ContentType)
+
 	// Health check
-	api.HandleFunc("/health", handlers.HealthCheck).Methods("GET")
+	api.HandleFunc("/health", open.Then(h.Health.Check)).Methods("GET").Name("health")
+	api.HandleFunc("/health", open.Then(handlers.Head(h.Health.Check))).Methods("HEAD").Name("health.head")
+	router.HandleFunc(cfg.ReadinessPath, open.Then(handlers.Readiness(h.Ready))).Methods("GET").Name("ready")
+	router.HandleFunc(cfg.ReadinessPath, open.Then(handlers.Head(handlers.Readiness(h.Ready)))).Methods("HEAD").Name("ready.head")
+
+	// Real-time updates for every resource (experimental)
+	ws := scope(middleware.ScopeItemsRead, middleware.ScopeClientsRead).
+		Append(middleware.FeatureFlag(h.Flags, flags.WebSocket))
+	api.HandleFunc("/ws", ws.Then(h.WSHub.ServeWS)).Methods("GET").Name("ws")
 
 	// Item routes
-	api.HandleFunc("/items", itemHandler.GetAll).Methods("GET")
-	api.HandleFunc("/items", itemHandler.Create).Methods("POST")
-	api.HandleFunc("/items/{id}", itemHandler.GetByID).Methods("GET")
-	api.HandleFunc("/items/{id}", itemHandler.Update).Methods("PUT")
-	api.HandleFunc("/items/{id}", itemHandler.Delete).Methods("DELETE")
+	api.HandleFunc("/items", itemsRead.Then(h.Items.GetAll)).Methods("GET").Name("items.list")
+	api.HandleFunc("/items", itemsRead.Then(handlers.Head(h.Items.GetAll))).Methods("HEAD").Name("items.list.head")
+	api.HandleFunc("/items", itemsBody.Then(h.Items.Create)).Methods("POST").Name("items.create")
+	api.HandleFunc("/items/batch", itemsRead.Then(h.Items.GetBatch)).Methods("GET").Name("items.batch")
+	api.HandleFunc("/items/batch", itemsRead.Then(handlers.Head(h.Items.GetBatch))).Methods("HEAD").Name("items.batch.head")
+	api.HandleFunc("/items/batch", itemsBody.Then(h.Items.BatchUpdate)).Methods("PATCH").Name("items.batch.update")
+	itemsExport := itemsRead.Append(middleware.Deprecate(csvExportSunset, "/api/v1/items"))
+	api.HandleFunc("/items/export.csv", itemsExport.Then(h.Items.ExportCSV)).Methods("GET").Name("items.export")
+	api.HandleFunc("/items/export.csv", itemsExport.Then(handlers.Head(h.Items.ExportCSV))).Methods("HEAD").Name("items.export.head")
+	api.HandleFunc("/items/import", itemsBody.Then(h.Items.ImportCSV)).Methods("POST").Name("items.import")
+	api.HandleFunc("/items/import-url", itemsBody.Then(h.ImportJobs.ImportURL)).Methods("POST").Name("items.import_url")
+	api.HandleFunc("/items/events", itemsStream.Then(h.ItemEvents.Stream)).Methods("GET").Name("items.events")
+	api.HandleFunc("/items/hash", itemsRead.Then(h.ItemHash.Hash)).Methods("GET").Name("items.hash")
+	api.HandleFunc("/items/hash", itemsRead.Then(handlers.Head(h.ItemHash.Hash))).Methods("HEAD").Name("items.hash.head")
+	api.HandleFunc("/items/diff", itemsRead.Then(h.Items.Diff)).Methods("GET").Name("items.diff")
+	api.HandleFunc("/items/poll", itemsStream.Then(h.ItemPoll.Poll)).Methods("GET").Name("items.poll")
+	api.HandleFunc("/items/search", itemsRead.Then(h.Items.Search)).Methods("GET").Name("items.search")
+	api.HandleFunc("/items/explain", adminOnly.Then(h.Items.Explain)).Methods("GET").Name("items.explain")
+	api.HandleFunc("/items/{id}", itemsRead.Then(h.Items.GetByID)).Methods("GET").Name("items.get")
+	api.HandleFunc("/items/{id}", itemsRead.Then(handlers.Head(h.Items.GetByID))).Methods("HEAD").Name("items.get.head")
+	api.HandleFunc("/items/{id}", itemsBody.Then(h.Items.Update)).Methods("PUT").Name("items.update")
+	api.HandleFunc("/items/{id}", itemsBody.Append(mergePatch).Then(h.Items.Patch)).Methods("PATCH").Name("items.patch")
+	api.HandleFunc("/items/{id}", itemsWrite.Then(h.Items.Delete)).Methods("DELETE").Name("items.delete")
+	api.HandleFunc("/items/{id}/status", itemsBody.Then(h.Items.UpdateStatus)).Methods("PATCH").Name("items.status")
+	api.HandleFunc("/items/{id}/reserve", itemsBody.Then(h.Reservations.Reserve)).Methods("POST").Name("items.reserve")
+	api.HandleFunc("/items/{id}/release", itemsBody.Then(h.Reservations.Release)).Methods("POST").Name("items.release")
+	api.HandleFunc("/items/{id}/reservations", itemsRead.Then(h.Reservations.List)).Methods("GET").Name("items.reservations")
+	api.HandleFunc("/items/{id}/tags/{tag}", itemsWrite.Then(h.Items.AddTag)).Methods("POST").Name("items.tags.add")
+	api.HandleFunc("/items/{id}/tags/{tag}", itemsWrite.Then(h.Items.RemoveTag)).Methods("DELETE").Name("items.tags.remove")
+	api.HandleFunc("/tags", itemsRead.Then(h.Items.Tags)).Methods("GET").Name("tags.list")
 
 	// Client routes
-	api.HandleFunc("/clients", clientHandler.GetAll).Methods("GET")
-	api.HandleFunc("/clients", clientHandler.Create).Methods("POST")
-	api.HandleFunc("/clients/{id}", clientHandler.GetByID).Methods("GET")
-	api.HandleFunc("/clients/{id}", clientHandler.Update).Methods("PUT")
-	api.HandleFunc("/clients/{id}", clientHandler.Delete).Methods("DELETE")
+	api.HandleFunc("/clients", clientsRead.Then(h.Clients.GetAll)).Methods("GET").Name("clients.list")
+	api.HandleFunc("/clients", clientsRead.Then(handlers.Head(h.Clients.GetAll))).Methods("HEAD").Name("clients.list.head")
+	api.HandleFunc("/clients", clientsBody.Then(h.Clients.Create)).Methods("POST").Name("clients.create")
+	api.HandleFunc("/clients/batch", clientsRead.Then(h.Clients.GetBatch)).Methods("GET").Name("clients.batch")
+	api.HandleFunc("/clients/batch", clientsRead.Then(handlers.Head(h.Clients.GetBatch))).Methods("HEAD").Name("clients.batch.head")
+	clientsExport := clientsRead.Append(middleware.Deprecate(csvExportSunset, "/api/v1/clients"))
+	api.HandleFunc("/clients/export.csv", clientsExport.Then(h.Clients.ExportCSV)).Methods("GET").Name("clients.export")
+	api.HandleFunc("/clients/export.csv", clientsExport.Then(handlers.Head(h.Clients.ExportCSV))).Methods("HEAD").Name("clients.export.head")
+	api.HandleFunc("/clients/import", clientsBody.Then(h.Clients.ImportCSV)).Methods("POST").Name("clients.import")
+	api.HandleFunc("/clients/hash", clientsRead.Then(h.ClientHash.Hash)).Methods("GET").Name("clients.hash")
+	api.HandleFunc("/clients/hash", clientsRead.Then(handlers.Head(h.ClientHash.Hash))).Methods("HEAD").Name("clients.hash.head")
+	api.HandleFunc("/clients/events", clientsStream.Then(h.ClientEvents.Stream)).Methods("GET").Name("clients.events")
+	api.HandleFunc("/clients/search", clientsRead.Then(h.Clients.Search)).Methods("GET").Name("clients.search")
+	api.HandleFunc("/clients/domains", clientsRead.Then(h.Clients.Domains)).Methods("GET").Name("clients.domains")
+	api.HandleFunc("/clients/{id}", clientsRead.Then(h.Clients.GetByID)).Methods("GET").Name("clients.get")
+	api.HandleFunc("/clients/{id}", clientsRead.Then(handlers.Head(h.Clients.GetByID))).Methods("HEAD").Name("clients.get.head")
+	api.HandleFunc("/clients/{id}", clientsBody.Then(h.Clients.Update)).Methods("PUT").Name("clients.update")
+	api.HandleFunc("/clients/{id}", clientsBody.Append(mergePatch).Then(h.Clients.Patch)).Methods("PATCH").Name("clients.patch")
+	api.HandleFunc("/clients/{id}", clientsWrite.Then(h.Clients.Delete)).Methods("DELETE").Name("clients.delete")
+	api.HandleFunc("/clients/{id}/items", itemsRead.Then(h.Items.GetByClient)).Methods("GET").Name("clients.items")
+	api.HandleFunc("/clients/{id}/items", itemsRead.Then(handlers.Head(h.Items.GetByClient))).Methods("HEAD").Name("clients.items.head")
+	api.HandleFunc("/clients/{id}/credits", clientsBody.Then(h.Ledger.Credit)).Methods("POST").Name("clients.credits")
+	api.HandleFunc("/clients/{id}/debits", clientsBody.Then(h.Ledger.Debit)).Methods("POST").Name("clients.debits")
+	api.HandleFunc("/clients/{id}/ledger", clientsRead.Then(h.Ledger.History)).Methods("GET").Name("clients.ledger")
+	api.HandleFunc("/clients/{id}/status", clientsBody.Then(h.ClientStatus.UpdateStatus)).Methods("PATCH").Name("clients.status")
+	api.HandleFunc("/clients/{id}/status-history", clientsRead.Then(h.ClientStatus.History)).Methods("GET").Name("clients.status_history")
+
+	// Contact routes, nested under their client
+	api.HandleFunc("/clients/{id}/contacts", clientsRead.Then(h.Contacts.GetAll)).Methods("GET").Name("clients.contacts.list")
+	api.HandleFunc("/clients/{id}/contacts", clientsRead.Then(handlers.Head(h.Contacts.GetAll))).Methods("HEAD").Name("clients.contacts.list.head")
+	api.HandleFunc("/clients/{id}/contacts", clientsBody.Then(h.Contacts.Create)).Methods("POST").Name("clients.contacts.create")
+	api.HandleFunc("/clients/{id}/contacts/{contact_id}", clientsBody.Then(h.Contacts.Update)).Methods("PUT").Name("clients.contacts.update")
+	api.HandleFunc("/clients/{id}/contacts/{contact_id}", clientsWrite.Then(h.Contacts.Delete)).Methods("DELETE").Name("clients.contacts.delete")
+
+	// Server-side sessions, keyed by the session_id cookie
+	if h.Sessions != nil {
+		api.HandleFunc("/sessions", base.Append(limitBody).Then(h.Sessions.Create)).Methods("POST").Name("sessions.create")
+		api.HandleFunc("/sessions/me", base.Then(h.Sessions.Me)).Methods("GET").Name("sessions.me")
+		api.HandleFunc("/sessions/me", base.Then(h.Sessions.Delete)).Methods("DELETE").Name("sessions.delete")
+	}
+
+	// Issue bearer tokens, and revoke the caller's token on logout
+	if h.Tokens != nil {
+		api.HandleFunc("/auth/token", base.Then(h.Auth.Issue)).Methods("POST").Name("auth.token.create")
+	}
+	if h.Blocklist != nil {
+		api.HandleFunc("/auth/token", base.Then(h.Auth.Logout)).Methods("DELETE").Name("auth.token.delete")
+	}
+
+	// Export jobs read every entity, so they need both read scopes
+	exportRead := scope(middleware.ScopeItemsRead, middleware.ScopeClientsRead)
+	api.HandleFunc("/export/jobs", exportRead.Append(limitBody).Then(h.ExportJobs.Create)).Methods("POST").Name("export.jobs.create")
+	api.HandleFunc("/export/jobs/{id}", exportRead.Then(h.ExportJobs.Get)).Methods("GET").Name("export.jobs.get")
+	api.HandleFunc("/export/jobs/{id}/download", exportRead.Then(h.ExportJobs.Download)).Methods("GET").Name("export.jobs.download")
+	api.HandleFunc("/import/jobs/{id}", itemsRead.Then(h.ImportJobs.Get)).Methods("GET").Name("import.jobs.get")
 
-	// Global middleware
+	// API v2 routes share the stores and middleware of v1; only the models
+	// and the response envelope change
+	if cfg.APIV2Enabled {
+		apiV2 := router.PathPrefix("/api/v2").Subrouter()
+		apiV2.HandleFunc("/items", itemsRead.Then(h.ItemsV2.List)).Methods("GET").Name("v2.items.list")
+		apiV2.HandleFunc("/items", itemsBody.Then(h.ItemsV2.Create)).Methods("POST").Name("v2.items.create")
+		apiV2.HandleFunc("/items/{id}", itemsRead.Then(h.ItemsV2.Get)).Methods("GET").Name("v2.items.get")
+		apiV2.HandleFunc("/items/{id}", itemsBody.Then(h.ItemsV2.Update)).Methods("PUT").Name("v2.items.update")
+		apiV2.HandleFunc("/items/{id}", itemsWrite.Then(h.ItemsV2.Delete)).Methods("DELETE").Name("v2.items.delete")
+	}
+
+	// Admin routes
+	api.HandleFunc("/admin/items", adminOnly.Then(h.Admin.ClearItems)).Methods("DELETE").Name("admin.items.clear")
+	api.HandleFunc("/admin/clients", adminOnly.Then(h.Admin.ClearClients)).Methods("DELETE").Name("admin.clients.clear")
+	api.HandleFunc("/admin/snapshot", adminOnly.Then(h.Admin.Snapshot)).Methods("GET").Name("admin.snapshot")
+	api.HandleFunc("/admin/snapshot/restore", adminOnly.Then(h.Admin.Restore)).Methods("POST").Name("admin.restore")
+	api.HandleFunc("/admin/stores/copy", adminOnly.Then(h.Admin.CopyStores)).Methods("POST").Name("admin.stores.copy")
+	api.HandleFunc("/admin/stats", adminOnly.Then(h.Admin.Stats)).Methods("GET").Name("admin.stats")
+	api.HandleFunc("/admin/flags", adminOnly.Then(h.Admin.Flags)).Methods("GET").Name("admin.flags")
+	api.HandleFunc("/admin/webhooks/dlq", adminOnly.Then(h.Webhooks.ListDLQ)).Methods("GET").Name("admin.dlq.list")
+	api.HandleFunc("/admin/webhooks/dlq/{id}/retry", adminOnly.Then(h.Webhooks.RetryDLQ)).Methods("POST").Name("admin.dlq.retry")
+	if h.UserRateLimiter != nil {
+		api.HandleFunc("/admin/ratelimit/users", adminOnly.Then(handlers.UserRateLimits(h.UserRateLimiter))).Methods("GET").Name("admin.ratelimit.users")
+	}
+
+	// Route changes of each API version
+	api.HandleFunc("/changelog", open.Then(handlers.Changelog(changes))).Methods("GET").Name("changelog")
+
+	// Prometheus metrics
+	router.Handle("/metrics", open.Then(metrics.Handler().ServeHTTP)).Methods("GET").Name("metrics")
+
+	// Route listing and a Postman collection, for admins and tooling
+	api.HandleFunc("/routes", adminOnly.Then(handlers.ListRoutes(router))).Methods("GET").Name("routes")
+	api.HandleFunc("/admin/postman", adminOnly.Then(handlers.Postman(router))).Methods("GET").Name("admin.postman")
+	api.HandleFunc("/admin/postman/environment", adminOnly.Then(handlers.PostmanEnvironment)).Methods("GET").Name("admin.postman.environment")
+
+	// OpenAPI document and Swagger UI
+	if cfg.DocsEnabled {
+		api.HandleFunc("/openapi.json", open.Then(handlers.OpenAPI(router))).Methods("GET").Name("openapi")
+		router.Handle("/docs", open.Then(http.RedirectHandler("/docs/", http.StatusMovedPermanently).ServeHTTP)).Methods("GET").Name("docs.redirect")
+		router.PathPrefix("/docs/").Handler(open.Then(http.StripPrefix("/docs", static.SwaggerUI("/api/v1/openapi.json")).ServeHTTP)).Name("docs")
+	}
+
+	if err := changelog.Validate(router, changes); err != nil {
+		panic(err)
+	}
+	for _, route := range Routes(router) {
+		if route.Method != "*" {
+			metrics.InitRoute(route.Method, route.Path)
+		}
+	}
+
+	// Location headers point at named routes
+	urls := func(name string, pairs ...string) (string, error) {
+		return URL(router, name, pairs...)
+	}
+	h.Items.SetURLBuilder(urls)
+	h.Clients.SetURLBuilder(urls)
+	h.ExportJobs.SetURLBuilder(urls)
+	h.ImportJobs.SetURLBuilder(urls)
+	if cfg.APIV2Enabled {
+		h.ItemsV2.SetURLBuilder(urls)
+	}
+
+	// Global middleware; the request and correlation IDs come first so
+	// every log line carries them, SLO and Metrics time everything else,
+	// and the content type is negotiated before anything writes a response
+	router.Use(middleware.RequestID)
+	router.Use(middleware.CorrelationID)
+	router.Use(middleware.SLO(cfg.SLOLimits))
+	router.Use(middleware.Metrics)
+	router.Use(middleware.Trace(telemetry.Tracer()))
 	router.Use(middleware.Logging)
-	router.Use(middleware.JSON)
-	router.Use(middleware.CORS)
+	if cfg.LogLevel == "debug" {
+		router.Use(middleware.BodyLogger(cfg.BodyLogMaxBytes))
+	}
+	router.Use(middleware.CORS(h.CORS))
+	router.Use(middleware.ContentNegotiation)
+	router.Use(middleware.Transform(h.Transforms...))
+	if cfg.Env == "staging" || cfg.Env == "development" {
+		router.Use(middleware.MaskPII(cfg.PIIFields))
+	}
+	router.Use(middleware.IPFilter(h.IPFilter))
+	if h.RateLimiter != nil && h.UserRateLimiter == nil {
+		router.Use(limitIP)
+	}
 
 	return router
 }
+
+// URL returns the path of the route registered under name, with its
+// variables filled from pairs of key and value
+func URL(r *mux.Router, name string, pairs ...string) (string, error) {
+	route := r.Get(name)
+	if route == nil {
+		return "", fmt.Errorf("router: no route named %q", name)
+	}
+	u, err := route.URL(pairs...)
+	if err != nil {
+		return "", fmt.Errorf("router: build %s: %w", name, err)
+	}
+	return u.String(), nil
+}
+
+// Route is the method and path template of a registered route
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Routes returns every method and path template registered in r, in the
+// order they were registered. Routes without a method, such as the docs
+// prefix, are listed with method "*"; subrouter prefixes are left out.
+func Routes(r *mux.Router) []Route {
+	var routes []Route
+	r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"*"}
+		}
+		for _, method := range methods {
+			routes = append(routes, Route{Method: method, Path: path})
+		}
+		return nil
+	})
+	return routes
+}
@@ -0,0 +1,227 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-api/blocklist"
+	"go-api/config"
+	"go-api/handlers"
+	v2 "go-api/handlers/v2"
+	"go-api/middleware"
+	"go-api/models"
+	"go-api/storage"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// testHandlers returns the handlers Setup needs, over tenant-aware memory
+// stores as main wires them
+func testHandlers(t *testing.T) Handlers {
+	t.Helper()
+	t.Setenv("BASIC_AUTH_USERS", "alice:secret,bob:pass,carol:word")
+	t.Setenv("BASIC_AUTH_TENANTS", "alice=acme,bob=globex,carol=acme")
+	credentials, err := middleware.NewEnvCredentialChecker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := storage.NewTenantStore[models.Item](storage.NewMemoryStore[models.Item]())
+	clients := storage.NewTenantStore[models.Client](storage.NewMemoryStore[models.Client]())
+	contacts := storage.NewTenantStore[models.Contact](storage.NewMemoryStore[models.Contact]())
+	return Handlers{
+		Items:       handlers.NewItemHandler(items, storage.NewTombstones(time.Hour)),
+		Clients:     handlers.NewClientHandler(clients, contacts, nil),
+		ExportJobs:  handlers.NewExportJobHandler(storage.NewTenantStore[models.ExportJob](storage.NewMemoryStore[models.ExportJob]()), items, clients),
+		ImportJobs:  handlers.NewImportJobHandler(storage.NewTenantStore[models.ImportJob](storage.NewMemoryStore[models.ImportJob]()), items),
+		ItemsV2:     v2.NewV2ItemHandler(items),
+		Credentials: credentials,
+	}
+}
+
+// decorated wraps store in the decorators main.go puts around the item and
+// client stores, in the same order, returning the chain and its HookedStore
+func decorated[T any](store storage.Store[T]) (storage.Store[T], *storage.HookedStore[T]) {
+	store = storage.NewInstrumentedStore(store, "items", "memory", prometheus.NewRegistry())
+	store = storage.NewCircuitBreaker(store, 5, time.Minute)
+	store = storage.NewSingleFlightStore(store)
+	store = storage.NewLRUStore(store, 100)
+	store = storage.NewTenantStore(store)
+	hooks := storage.NewHookedStore(store)
+	return storage.NewTracedStore[T](hooks, noop.NewTracerProvider().Tracer("test"), "items"), hooks
+}
+
+// call sends an authenticated request to router as user
+func call(router http.Handler, user, password, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.SetBasicAuth(user, password)
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestTenantsDontSeeEachOthersItems(t *testing.T) {
+	router := Setup(config.Default(), testHandlers(t))
+
+	w := call(router, "alice", "secret", http.MethodPost, "/api/v1/items", `{"name":"Widget","quantity":3}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", w.Code, w.Body)
+	}
+	var created models.Item
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/v1/items/" + created.ID
+
+	list := func(user, password string) []models.Item {
+		t.Helper()
+		w := call(router, user, password, http.MethodGet, "/api/v1/items", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s list: status %d: %s", user, w.Code, w.Body)
+		}
+		var items []models.Item
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+	if items := list("bob", "pass"); len(items) != 0 {
+		t.Errorf("bob of globex lists %v, want none of acme's items", items)
+	}
+	if w := call(router, "bob", "pass", http.MethodGet, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("bob get: status %d, want 404", w.Code)
+	}
+	if w := call(router, "bob", "pass", http.MethodPut, path, `{"name":"Stolen","quantity":1}`); w.Code != http.StatusNotFound {
+		t.Errorf("bob update: status %d, want 404", w.Code)
+	}
+	if w := call(router, "bob", "pass", http.MethodDelete, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("bob delete: status %d, want 404", w.Code)
+	}
+
+	// Another user of the same tenant sees the item, unchanged
+	items := list("carol", "word")
+	if len(items) != 1 || items[0].ID != created.ID || items[0].Name != "Widget" {
+		t.Errorf("carol of acme lists %v, want alice's item", items)
+	}
+}
+
+func TestRevokedTokenIsRejected(t *testing.T) {
+	h := testHandlers(t)
+	h.Tokens = middleware.NewTokenIssuer([]byte("test secret"), time.Hour)
+	h.Blocklist = blocklist.NewMemoryBlocklist()
+	h.Auth = handlers.NewAuthHandler(h.Tokens, h.Blocklist)
+	router := Setup(config.Default(), h)
+
+	w := call(router, "alice", "secret", http.MethodPost, "/api/v1/auth/token", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("issue: status %d: %s", w.Code, w.Body)
+	}
+	var issued struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &issued); err != nil {
+		t.Fatal(err)
+	}
+	withToken := func(method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Authorization", "Bearer "+issued.Token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := withToken(http.MethodGet, "/api/v1/items"); w.Code != http.StatusOK {
+		t.Fatalf("before logout: status %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := withToken(http.MethodDelete, "/api/v1/auth/token"); w.Code != http.StatusNoContent {
+		t.Fatalf("logout: status %d, want 204: %s", w.Code, w.Body)
+	}
+	if w := withToken(http.MethodGet, "/api/v1/items"); w.Code != http.StatusUnauthorized {
+		t.Errorf("after logout: status %d, want 401", w.Code)
+	}
+	if w := withToken(http.MethodPost, "/api/v1/auth/token"); w.Code != http.StatusUnauthorized {
+		t.Errorf("new token from a revoked one: status %d, want 401", w.Code)
+	}
+
+	// Only that token is revoked; the user signs in again
+	if w := call(router, "alice", "secret", http.MethodGet, "/api/v1/items", ""); w.Code != http.StatusOK {
+		t.Errorf("basic credentials after logout: status %d, want 200", w.Code)
+	}
+}
+
+func TestDeleteReturnsBodyThroughStoreChain(t *testing.T) {
+	items, itemHooks := decorated[models.Item](storage.NewMemoryStore[models.Item]())
+	clients, clientHooks := decorated[models.Client](storage.NewMemoryStore[models.Client]())
+	var deletes atomic.Int32
+	countDelete := func(context.Context, string) { deletes.Add(1) }
+	itemHooks.AddDeleteHook(countDelete)
+	clientHooks.AddDeleteHook(countDelete)
+
+	h := testHandlers(t)
+	h.Items = handlers.NewItemHandler(items, storage.NewTombstones(time.Hour))
+	h.Items.SetDeleteReturnsBody(true)
+	h.Clients = handlers.NewClientHandler(clients, storage.NewMemoryStore[models.Contact](), nil)
+	h.Clients.SetDeleteReturnsBody(true)
+	router := Setup(config.Default(), h)
+
+	tests := []struct {
+		collection string
+		body       string
+		name       string
+	}{
+		{"/api/v1/items", `{"name":"Widget","quantity":3}`, "Widget"},
+		{"/api/v1/clients", `{"name":"Acme Corp","email":"ops@acme.example"}`, "Acme Corp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collection, func(t *testing.T) {
+			w := call(router, "alice", "secret", http.MethodPost, tt.collection, tt.body)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("create: status %d: %s", w.Code, w.Body)
+			}
+			var created struct{ ID string }
+			if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+				t.Fatal(err)
+			}
+			path := tt.collection + "/" + created.ID
+
+			if w := call(router, "bob", "pass", http.MethodDelete, path, ""); w.Code != http.StatusNotFound {
+				t.Errorf("bob of globex delete: status %d, want 404", w.Code)
+			}
+			w = call(router, "alice", "secret", http.MethodDelete, path, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("delete: status %d, want 200: %s", w.Code, w.Body)
+			}
+			var deleted struct {
+				ID       string `json:"id"`
+				Name     string `json:"name"`
+				TenantID string `json:"tenant_id"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &deleted); err != nil {
+				t.Fatal(err)
+			}
+			if deleted.ID != created.ID || deleted.Name != tt.name || deleted.TenantID != "acme" {
+				t.Errorf("delete returned %+v, want the created record of acme", deleted)
+			}
+			if w := call(router, "alice", "secret", http.MethodDelete, path, ""); w.Code != http.StatusNotFound {
+				t.Errorf("second delete: status %d, want 404", w.Code)
+			}
+			if w := call(router, "alice", "secret", http.MethodGet, path, ""); w.Code == http.StatusOK {
+				t.Errorf("get after delete: status %d, want the record gone", w.Code)
+			}
+		})
+	}
+	itemHooks.Close()
+	clientHooks.Close()
+	if n := deletes.Load(); n != 2 {
+		t.Errorf("delete hooks ran %d times, want once per record", n)
+	}
+}
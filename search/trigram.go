@@ -0,0 +1,132 @@
+// Package search finds documents by approximate text match.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ScoredResult is a document matching a query, with its similarity to the
+// query from 0 to 1
+type ScoredResult struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// TrigramIndex finds documents sharing three-character substrings with a
+// query, so misspelt queries still match. It is not safe for concurrent
+// use.
+type TrigramIndex struct {
+	// postings maps each trigram to the IDs of the documents containing it
+	postings map[string]map[string]struct{}
+	// docs holds the trigrams of each document
+	docs map[string]map[string]struct{}
+}
+
+// NewTrigramIndex creates an empty index
+func NewTrigramIndex() *TrigramIndex {
+	return &TrigramIndex{
+		postings: make(map[string]map[string]struct{}),
+		docs:     make(map[string]map[string]struct{}),
+	}
+}
+
+// Trigrams returns the set of trigrams of text. Text is lowercased and split
+// into words at anything but letters and digits; each word is padded with
+// two spaces in front and one behind, so short words and word starts count.
+func Trigrams(text string) map[string]struct{} {
+	grams := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			grams[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+	return grams
+}
+
+// Add indexes text as the document id, replacing what was indexed for id
+func (ix *TrigramIndex) Add(id, text string) {
+	ix.Remove(id)
+	grams := Trigrams(text)
+	if len(grams) == 0 {
+		return
+	}
+	ix.docs[id] = grams
+	for gram := range grams {
+		ids, ok := ix.postings[gram]
+		if !ok {
+			ids = make(map[string]struct{})
+			ix.postings[gram] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// Remove drops the document id from the index
+func (ix *TrigramIndex) Remove(id string) {
+	for gram := range ix.docs[id] {
+		delete(ix.postings[gram], id)
+		if len(ix.postings[gram]) == 0 {
+			delete(ix.postings, gram)
+		}
+	}
+	delete(ix.docs, id)
+}
+
+// Len returns the number of indexed documents
+func (ix *TrigramIndex) Len() int {
+	return len(ix.docs)
+}
+
+// Search returns the documents whose Jaccard similarity with query, the
+// shared trigrams over all trigrams of both, is at least minScore, best
+// first. Documents sharing no trigram with the query are never returned.
+func (ix *TrigramIndex) Search(query string, minScore float64) []ScoredResult {
+	grams := Trigrams(query)
+	shared := make(map[string]int)
+	for gram := range grams {
+		for id := range ix.postings[gram] {
+			shared[id]++
+		}
+	}
+
+	results := make([]ScoredResult, 0)
+	for id, n := range shared {
+		score := float64(n) / float64(len(grams)+len(ix.docs[id])-n)
+		if score >= minScore {
+			results = append(results, ScoredResult{ID: id, Score: score})
+		}
+	}
+	SortResults(results)
+	return results
+}
+
+// Similarity returns the Jaccard similarity of the trigrams of a and b
+func Similarity(a, b string) float64 {
+	ga, gb := Trigrams(a), Trigrams(b)
+	shared := 0
+	for gram := range ga {
+		if _, ok := gb[gram]; ok {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return 0
+	}
+	return float64(shared) / float64(len(ga)+len(gb)-shared)
+}
+
+// SortResults orders results best first, then by ID
+func SortResults(results []ScoredResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+}
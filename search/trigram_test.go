@@ -0,0 +1,66 @@
+package search
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrigramIndexToleratesTypos(t *testing.T) {
+	ix := NewTrigramIndex()
+	docs := map[string]string{
+		"keyboard": "Mechanical keyboard with brown switches",
+		"mouse":    "Wireless mouse",
+		"monitor":  "27 inch monitor",
+		"cable":    "USB-C charging cable",
+	}
+	for id, text := range docs {
+		ix.Add(id, text)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"keybaord", "keyboard"},
+		{"mechanicl keybord", "keyboard"},
+		{"wirless mose", "mouse"},
+		{"moniter", "monitor"},
+		{"charging cabel", "cable"},
+		{"MOUSE", "mouse"},
+	}
+	for _, tt := range tests {
+		results := ix.Search(tt.query, 0.1)
+		if len(results) == 0 || results[0].ID != tt.want {
+			t.Errorf("Search(%q) = %v, want %s first", tt.query, results, tt.want)
+			continue
+		}
+		// The index scores as Similarity does
+		if want := Similarity(tt.query, docs[tt.want]); math.Abs(results[0].Score-want) > 1e-9 {
+			t.Errorf("Search(%q) scored %s %.4f, Similarity gives %.4f", tt.query, tt.want, results[0].Score, want)
+		}
+	}
+
+	if results := ix.Search("xyzzy", 0); len(results) != 0 {
+		t.Errorf("a query sharing no trigram matched %v", results)
+	}
+	if results := ix.Search("keybaord", 0.99); len(results) != 0 {
+		t.Errorf("minScore 0.99 let through %v", results)
+	}
+}
+
+func TestTrigramIndexReplaceAndRemove(t *testing.T) {
+	ix := NewTrigramIndex()
+	ix.Add("1", "red widget")
+	ix.Add("1", "blue box")
+	if results := ix.Search("widget", 0.1); len(results) != 0 {
+		t.Errorf("the replaced text still matches: %v", results)
+	}
+	if results := ix.Search("blue boxx", 0.1); len(results) != 1 {
+		t.Errorf("the new text doesn't match: %v", results)
+	}
+
+	ix.Remove("1")
+	if ix.Len() != 0 || len(ix.postings) != 0 {
+		t.Errorf("after Remove: %d documents and %d trigrams left", ix.Len(), len(ix.postings))
+	}
+}
@@ -0,0 +1,79 @@
+// Package service holds queries that span several stores, keeping the joins
+// out of the HTTP handlers
+package service
+
+import (
+	"context"
+	"errors"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+// ClientService answers client queries that need the item store too
+type ClientService struct {
+	clients storage.Store[models.Client]
+	items   storage.Store[models.Item]
+}
+
+// NewClientService creates a client service over the client and item stores
+func NewClientService(clientStore storage.Store[models.Client], itemStore storage.Store[models.Item]) *ClientService {
+	return &ClientService{clients: clientStore, items: itemStore}
+}
+
+// ByItems returns the caller's clients that own at least one item with
+// itemStatus, or, when hasItems is false, the clients owning none. An empty
+// itemStatus matches items of any status.
+func (s *ClientService) ByItems(ctx context.Context, hasItems bool, itemStatus string) []models.Client {
+	owners := make(map[string]bool)
+	var ids []string
+	storage.View(scoped(ctx, s.items), func(items []models.Item) {
+		for _, item := range items {
+			if item.ClientID == "" || owners[item.ClientID] || (itemStatus != "" && item.Status != itemStatus) {
+				continue
+			}
+			owners[item.ClientID] = true
+			ids = append(ids, item.ClientID)
+		}
+	})
+
+	clients := scoped(ctx, s.clients)
+	if hasItems {
+		return storage.FilterByIDs(clients, ids)
+	}
+
+	without := make([]models.Client, 0)
+	for _, client := range clients.GetAll() {
+		if !owners[client.ID] {
+			without = append(without, client)
+		}
+	}
+	return without
+}
+
+// updateOne replaces the record of store for which match is true with the
+// result of apply, in a single atomic update where the store supports it.
+// It returns storage.ErrNotFound when nothing matches and apply's own error
+// when it fails.
+func updateOne[T any](store storage.Store[T], match func(T) bool, apply func(T) (T, error)) (T, error) {
+	var zero T
+	updated, err := storage.UpdateWhere(store, match, apply)
+	var recordErr *storage.RecordError
+	switch {
+	case errors.As(err, &recordErr):
+		return zero, recordErr.Err
+	case err != nil:
+		return zero, err
+	case len(updated) == 0:
+		return zero, storage.ErrNotFound
+	}
+	return updated[0], nil
+}
+
+// scoped returns the view of store for the caller described by ctx
+func scoped[T any](ctx context.Context, store storage.Store[T]) storage.Store[T] {
+	if s, ok := store.(storage.Scoper[T]); ok {
+		return s.For(ctx)
+	}
+	return store
+}
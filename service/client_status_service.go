@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+// TransitionError is returned by ChangeStatus when the client's current
+// status can't move to the requested one
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("transition from '%s' to '%s' is not allowed", e.From, e.To)
+}
+
+// ClientStatusService changes client statuses and keeps an event for every
+// change
+type ClientStatusService struct {
+	clients storage.Store[models.Client]
+	events  storage.Store[models.ClientStatusEvent]
+
+	// mu keeps each status change and its event together
+	mu sync.Mutex
+}
+
+// NewClientStatusService creates a client status service over the client and
+// status event stores
+func NewClientStatusService(clientStore storage.Store[models.Client], eventStore storage.Store[models.ClientStatusEvent]) *ClientStatusService {
+	return &ClientStatusService{clients: clientStore, events: eventStore}
+}
+
+// ChangeStatus moves the caller's client with clientID to status and records
+// the change, made by actorID for reason. The status changes in a single
+// atomic client update; if the event can't be stored the change is undone.
+// It returns a *TransitionError when the state machine doesn't allow the move.
+func (s *ClientStatusService) ChangeStatus(ctx context.Context, clientID, status, reason, actorID string) (models.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clients := scoped(ctx, s.clients)
+	var old string
+	client, err := updateOne(clients, func(client models.Client) bool {
+		return client.ID == clientID
+	}, func(client models.Client) (models.Client, error) {
+		old = statusOf(client)
+		if models.ValidateClientTransition(old, status) != nil {
+			return client, &TransitionError{From: old, To: status, Allowed: models.NextClientStatuses(old)}
+		}
+		client.Status = status
+		return client, nil
+	})
+	if err != nil {
+		return models.Client{}, err
+	}
+
+	_, err = s.events.TryCreate(models.ClientStatusEvent{
+		ClientID:  clientID,
+		OldStatus: old,
+		NewStatus: status,
+		Reason:    reason,
+		ActorID:   actorID,
+	})
+	if err != nil {
+		if _, err := setStatus(clients, clientID, old); err != nil {
+			log.Printf("WARN: client %s: undo status change from %s to %s: %v", clientID, old, status, err)
+		}
+		return models.Client{}, err
+	}
+	return client, nil
+}
+
+// History returns the status events of the caller's client with clientID,
+// oldest first. It returns storage.ErrNotFound when there is no such client.
+func (s *ClientStatusService) History(ctx context.Context, clientID string) ([]models.ClientStatusEvent, error) {
+	if _, exists := scoped(ctx, s.clients).GetByID(clientID); !exists {
+		return nil, storage.ErrNotFound
+	}
+
+	events := make([]models.ClientStatusEvent, 0)
+	for _, event := range s.events.GetAll() {
+		if event.ClientID == clientID {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+	return events, nil
+}
+
+// statusOf returns the status of client. Clients stored before statuses
+// existed have none and count as active.
+func statusOf(client models.Client) string {
+	if client.Status == "" {
+		return models.ClientActive
+	}
+	return client.Status
+}
+
+// setStatus sets the status of the client with id in a single atomic update
+func setStatus(clients storage.Store[models.Client], id, status string) (models.Client, error) {
+	return updateOne(clients, func(client models.Client) bool {
+		return client.ID == id
+	}, func(client models.Client) (models.Client, error) {
+		client.Status = status
+		return client, nil
+	})
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+// ErrInsufficientBalance is returned by Debit when the client's balance
+// would go negative
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// LedgerService changes client balances and keeps a ledger entry for every
+// change
+type LedgerService struct {
+	clients storage.Store[models.Client]
+	entries storage.Store[models.Ledger]
+
+	// mu keeps each balance change and its ledger entry together
+	mu sync.Mutex
+}
+
+// NewLedgerService creates a ledger service over the client and ledger stores
+func NewLedgerService(clientStore storage.Store[models.Client], ledgerStore storage.Store[models.Ledger]) *LedgerService {
+	return &LedgerService{clients: clientStore, entries: ledgerStore}
+}
+
+// Credit adds amount to the balance of the caller's client with clientID
+func (s *LedgerService) Credit(ctx context.Context, clientID string, amount float64, reference string) (models.Ledger, error) {
+	return s.post(ctx, clientID, models.LedgerCredit, amount, reference)
+}
+
+// Debit takes amount from the balance of the caller's client with clientID.
+// It returns ErrInsufficientBalance instead of letting the balance go
+// negative.
+func (s *LedgerService) Debit(ctx context.Context, clientID string, amount float64, reference string) (models.Ledger, error) {
+	return s.post(ctx, clientID, models.LedgerDebit, amount, reference)
+}
+
+// post applies one ledger entry. The balance changes in a single atomic
+// client update; if the entry can't be stored the change is undone.
+func (s *LedgerService) post(ctx context.Context, clientID, entryType string, amount float64, reference string) (models.Ledger, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delta := amount
+	if entryType == models.LedgerDebit {
+		delta = -amount
+	}
+
+	var zero models.Ledger
+	clients := scoped(ctx, s.clients)
+	client, err := adjustBalance(clients, clientID, delta)
+	if err != nil {
+		return zero, err
+	}
+
+	entry, err := s.entries.TryCreate(models.Ledger{
+		ClientID:  clientID,
+		Type:      entryType,
+		Amount:    amount,
+		Balance:   client.Balance,
+		Reference: reference,
+	})
+	if err != nil {
+		if _, err := adjustBalance(clients, clientID, -delta); err != nil {
+			log.Printf("WARN: ledger: undo %s of %v for client %s: %v", entryType, amount, clientID, err)
+		}
+		return zero, err
+	}
+	return entry, nil
+}
+
+// History returns the ledger entries of the caller's client with clientID,
+// newest first. It returns storage.ErrNotFound when there is no such client.
+func (s *LedgerService) History(ctx context.Context, clientID string) ([]models.Ledger, error) {
+	if _, exists := scoped(ctx, s.clients).GetByID(clientID); !exists {
+		return nil, storage.ErrNotFound
+	}
+
+	entries := make([]models.Ledger, 0)
+	for _, entry := range s.entries.GetAll() {
+		if entry.ClientID == clientID {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.After(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+// adjustBalance adds delta to the balance of the client with id in a single
+// atomic update and returns the updated client
+func adjustBalance(clients storage.Store[models.Client], id string, delta float64) (models.Client, error) {
+	return updateOne(clients, func(client models.Client) bool {
+		return client.ID == id
+	}, func(client models.Client) (models.Client, error) {
+		if client.Balance+delta < 0 {
+			return client, ErrInsufficientBalance
+		}
+		client.Balance += delta
+		return client, nil
+	})
+}
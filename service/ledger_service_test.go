@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+func TestConcurrentDebitsNeverGoNegative(t *testing.T) {
+	clients := storage.NewMemoryStore[models.Client]()
+	s := NewLedgerService(clients, storage.NewMemoryStore[models.Ledger]())
+	client := clients.Create(models.Client{Name: "Acme", Email: "billing@acme.example"})
+
+	ctx := context.Background()
+	if _, err := s.Credit(ctx, client.ID, 100, "opening"); err != nil {
+		t.Fatal(err)
+	}
+
+	var debited, credited atomic.Int64
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			_, err := s.Debit(ctx, client.ID, 3, "order")
+			switch {
+			case err == nil:
+				debited.Add(3)
+			case !errors.Is(err, ErrInsufficientBalance):
+				t.Errorf("debit: %v", err)
+			}
+		})
+		wg.Go(func() {
+			if _, err := s.Credit(ctx, client.ID, 1, "refund"); err != nil {
+				t.Errorf("credit: %v", err)
+				return
+			}
+			credited.Add(1)
+		})
+	}
+	wg.Wait()
+
+	entries, err := s.History(ctx, client.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Balance < 0 {
+			t.Errorf("%s of %v left a balance of %v", e.Type, e.Amount, e.Balance)
+		}
+	}
+	got, _ := clients.GetByID(client.ID)
+	if want := float64(100 + credited.Load() - debited.Load()); got.Balance != want {
+		t.Errorf("balance = %v, want %v", got.Balance, want)
+	}
+	if want := 1 + int(credited.Load()) + int(debited.Load()/3); len(entries) != want {
+		t.Errorf("%d ledger entries, want %d", len(entries), want)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+var (
+	// ErrInsufficientQuantity is returned by Reserve when the item has fewer
+	// units left than requested
+	ErrInsufficientQuantity = errors.New("insufficient quantity")
+	// ErrReservationExists is returned by Reserve when the reservation ID is
+	// already in use
+	ErrReservationExists = errors.New("reservation already exists")
+)
+
+// ReservationService holds item quantities for reservations and gives them
+// back when a reservation is released or expires
+type ReservationService struct {
+	items        storage.Store[models.Item]
+	reservations storage.Store[models.Reservation]
+
+	// mu keeps each reservation and the quantity it holds changing together
+	mu sync.Mutex
+}
+
+// NewReservationService creates a reservation service over the item store
+// and a reservation store that supports expiry
+func NewReservationService(itemStore storage.Store[models.Item], reservationStore storage.Store[models.Reservation]) *ReservationService {
+	return &ReservationService{items: itemStore, reservations: reservationStore}
+}
+
+// Reserve takes quantity units of the caller's item with itemID and records
+// them under reservationID until ttl passes. The quantity is taken in one
+// atomic item update, so concurrent reservations can't oversell the item.
+func (s *ReservationService) Reserve(ctx context.Context, itemID, reservationID string, quantity int, ttl time.Duration) (models.Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zero models.Reservation
+	if _, exists := s.reservations.GetByID(reservationID); exists {
+		return zero, ErrReservationExists
+	}
+
+	items := scoped(ctx, s.items)
+	if err := adjustQuantity(items, itemID, -quantity); err != nil {
+		return zero, err
+	}
+
+	reservation, err := scoped(ctx, s.reservations).CreateWithTTL(models.Reservation{
+		ID:        reservationID,
+		ItemID:    itemID,
+		Quantity:  quantity,
+		ExpiresAt: time.Now().Add(ttl),
+	}, ttl)
+	if err != nil {
+		if err := adjustQuantity(items, itemID, quantity); err != nil {
+			log.Printf("WARN: reservation %s: give back %d of item %s: %v", reservationID, quantity, itemID, err)
+		}
+		return zero, err
+	}
+	return reservation, nil
+}
+
+// Release deletes the caller's reservation and gives its quantity back to
+// the item. It returns storage.ErrNotFound when the item has no such
+// reservation.
+func (s *ReservationService) Release(ctx context.Context, itemID, reservationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservations := scoped(ctx, s.reservations)
+	reservation, exists := reservations.GetByID(reservationID)
+	if !exists || reservation.ItemID != itemID || !reservations.Delete(reservationID) {
+		return storage.ErrNotFound
+	}
+
+	// A deleted item has nothing to give the quantity back to
+	if err := adjustQuantity(scoped(ctx, s.items), itemID, reservation.Quantity); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Expire gives the quantity of an expired reservation back to its item. It
+// is registered as the reservation store's expiry hook.
+func (s *ReservationService) Expire(reservation models.Reservation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := adjustQuantity(s.items, reservation.ItemID, reservation.Quantity)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("WARN: reservation %s expired: give back %d of item %s: %v", reservation.ID, reservation.Quantity, reservation.ItemID, err)
+	}
+}
+
+// List returns the active reservations of the caller's item with itemID,
+// oldest first
+func (s *ReservationService) List(ctx context.Context, itemID string) ([]models.Reservation, error) {
+	if _, exists := scoped(ctx, s.items).GetByID(itemID); !exists {
+		return nil, storage.ErrNotFound
+	}
+
+	reservations := make([]models.Reservation, 0)
+	for _, reservation := range scoped(ctx, s.reservations).GetAll() {
+		if reservation.ItemID == itemID {
+			reservations = append(reservations, reservation)
+		}
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].CreatedAt.Before(reservations[j].CreatedAt)
+	})
+	return reservations, nil
+}
+
+// adjustQuantity adds delta to the quantity of the item with id in a single
+// atomic update. It returns ErrInsufficientQuantity instead of letting the
+// quantity go negative.
+func adjustQuantity(items storage.Store[models.Item], id string, delta int) error {
+	_, err := updateOne(items, func(item models.Item) bool {
+		return item.ID == id
+	}, func(item models.Item) (models.Item, error) {
+		if item.Quantity+delta < 0 {
+			return item, ErrInsufficientQuantity
+		}
+		item.Quantity += delta
+		return item, nil
+	})
+	return err
+}
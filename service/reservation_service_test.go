@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-api/models"
+	"go-api/storage"
+)
+
+func TestReserveReusedIDsDoNotLeakStock(t *testing.T) {
+	const (
+		stock   = 100
+		workers = 20
+		rounds  = 10
+	)
+	items := storage.NewMemoryStore[models.Item]()
+	reservations := storage.NewMemoryStore[models.Reservation]()
+	s := NewReservationService(items, reservations)
+	reservations.OnExpire(s.Expire)
+	item := items.Create(models.Item{Name: "widget", Quantity: stock})
+
+	// Each worker reuses one reservation ID, reserving again after the last
+	// reservation expired but before a janitor removed it
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Go(func() {
+			id := fmt.Sprintf("r%d", w)
+			for round := range rounds {
+				ttl := time.Millisecond
+				if round == rounds-1 {
+					ttl = time.Hour
+				}
+				if _, err := s.Reserve(ctx, item.ID, id, 1, ttl); err != nil {
+					t.Errorf("reserve %s: %v", id, err)
+					return
+				}
+				time.Sleep(2 * time.Millisecond)
+			}
+		})
+	}
+	wg.Wait()
+
+	// Expiry hooks run on their own goroutines; wait for them to give the
+	// stock back
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		current, _ := items.GetByID(item.ID)
+		held := 0
+		for _, r := range reservations.GetAll() {
+			held += r.Quantity
+		}
+		if current.Quantity+held == stock && held == workers {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("quantity %d with %d held, want %d in total with %d held", current.Quantity, held, stock, workers)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisSessionStore keeps sessions in Redis as JSON strings under
+// "session:<id>" keys that expire with the session, so every instance
+// shares them
+type RedisSessionStore struct {
+	client redis.Cmdable
+}
+
+// NewRedisSessionStore creates a session store on client
+func NewRedisSessionStore(client redis.Cmdable) *RedisSessionStore {
+	return &RedisSessionStore{client: client}
+}
+
+// Save implements Store. A session that has already expired is deleted.
+func (s *RedisSessionStore) Save(ctx context.Context, session Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return s.Delete(ctx, session.ID)
+	}
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	if err := s.client.Set(ctx, "session:"+session.ID, raw, ttl).Err(); err != nil {
+		return fmt.Errorf("session: save: %w", err)
+	}
+	return nil
+}
+
+// Get implements Store
+func (s *RedisSessionStore) Get(ctx context.Context, id string) (Session, error) {
+	raw, err := s.client.Get(ctx, "session:"+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Session{}, ErrNotFound
+	}
+	if err != nil {
+		return Session{}, fmt.Errorf("session: get: %w", err)
+	}
+	var session Session
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return Session{}, fmt.Errorf("session: decode %s: %w", id, err)
+	}
+	return session, nil
+}
+
+// Delete implements Store
+func (s *RedisSessionStore) Delete(ctx context.Context, id string) error {
+	if err := s.client.Del(ctx, "session:"+id).Err(); err != nil {
+		return fmt.Errorf("session: delete: %w", err)
+	}
+	return nil
+}
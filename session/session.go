@@ -0,0 +1,95 @@
+// Package session keeps server-side sessions for clients that need state
+// across requests, such as multi-step checkout flows.
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CookieName is the cookie carrying the session ID
+const CookieName = "session_id"
+
+// ErrNotFound is returned by Store.Get for unknown and expired sessions
+var ErrNotFound = errors.New("session not found")
+
+// Session is the server-side state of one client
+type Session struct {
+	ID        string         `json:"id"`
+	Data      map[string]any `json:"data"`
+	UserID    string         `json:"user_id,omitempty"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// Store keeps sessions until they expire at their ExpiresAt
+type Store interface {
+	Save(ctx context.Context, s Session) error
+	Get(ctx context.Context, id string) (Session, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type contextKey struct{}
+
+// WithSession returns a copy of ctx carrying s
+func WithSession(ctx context.Context, s Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the session stored by WithSession
+func FromContext(ctx context.Context) (Session, bool) {
+	s, ok := ctx.Value(contextKey{}).(Session)
+	return s, ok
+}
+
+// MemorySessionStore keeps sessions in this process. Expired sessions are
+// dropped when they are read and whenever a session is saved.
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+// NewMemorySessionStore creates an empty session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]Session)}
+}
+
+// Save implements Store
+func (s *MemorySessionStore) Save(_ context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, existing := range s.sessions {
+		if !now.Before(existing.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+	s.sessions[session.ID] = session
+	return nil
+}
+
+// Get implements Store
+func (s *MemorySessionStore) Get(_ context.Context, id string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return Session{}, ErrNotFound
+	}
+	return session, nil
+}
+
+// Delete implements Store
+func (s *MemorySessionStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
@@ -0,0 +1,18 @@
+#!/bin/sh
+# Downloads the Swagger UI distribution into swagger-ui/ for embedding.
+# Run through go generate ./static; set SWAGGER_UI_VERSION to pick a release.
+set -eu
+
+version="${SWAGGER_UI_VERSION:-5.17.14}"
+tmp="$(mktemp -d)"
+trap 'rm -rf "$tmp"' EXIT
+
+curl -fsSL "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-$version.tgz" | tar -xz -C "$tmp"
+
+rm -rf swagger-ui
+mkdir swagger-ui
+for f in index.html index.css swagger-initializer.js swagger-ui.css swagger-ui-bundle.js \
+	swagger-ui-standalone-preset.js oauth2-redirect.html favicon-16x16.png favicon-32x32.png; do
+	cp "$tmp/package/$f" swagger-ui/
+done
+echo "Swagger UI $version written to swagger-ui/"
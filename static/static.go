@@ -0,0 +1,59 @@
+// Package static holds files served by the API, such as the Swagger UI
+package static
+
+//go:generate sh fetch-swagger-ui.sh
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// swaggerUIFS is the Swagger UI distribution fetched by go generate
+//
+//go:embed swagger-ui
+var swaggerUIFS embed.FS
+
+// defaultSpecURL is the example spec the Swagger UI distribution points at
+const defaultSpecURL = "https://petstore.swagger.io/v2/swagger.json"
+
+// SwaggerUI serves the Swagger UI with specURL as the document it loads.
+// The URL is written into index.html and swagger-initializer.js as they are
+// served.
+func SwaggerUI(specURL string) http.Handler {
+	root, err := fs.Sub(swaggerUIFS, "swagger-ui")
+	if err != nil {
+		panic(err)
+	}
+	files := http.FileServer(http.FS(root))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Let the file server pick the type from the file name
+		w.Header().Del("Content-Type")
+
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" {
+			name = "index.html"
+		}
+		if name != "index.html" && name != "swagger-initializer.js" {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		data, err := fs.ReadFile(root, name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		data = bytes.ReplaceAll(data, []byte(defaultSpecURL), []byte(specURL))
+		if name == "index.html" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		}
+		w.Write(data)
+	})
+}
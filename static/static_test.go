@@ -0,0 +1,30 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSwaggerUI(t *testing.T) {
+	docs := http.StripPrefix("/docs", SwaggerUI("/api/v1/openapi.json"))
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		docs.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	index := get("/docs/")
+	if index.Code != http.StatusOK {
+		t.Fatalf("GET /docs/: status %d, want 200", index.Code)
+	}
+	if ct := index.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("GET /docs/: Content-Type = %q, want text/html", ct)
+	}
+
+	initializer := get("/docs/swagger-initializer.js")
+	if body := initializer.Body.String(); !strings.Contains(body, "/api/v1/openapi.json") || strings.Contains(body, defaultSpecURL) {
+		t.Errorf("swagger-initializer.js doesn't point at the local spec:\n%s", body)
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecordError reports which record of a batch made it fail
+type RecordError struct {
+	ID  string
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %s: %v", e.ID, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// BatchUpdater is implemented by stores that can update every record
+// matching a predicate as one all-or-nothing change
+type BatchUpdater[T any] interface {
+	UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error)
+}
+
+// UpdateWhere replaces every record of store for which match is true with
+// the result of apply, and returns the updated records. If apply fails for
+// any record nothing is updated and the error is a *RecordError. Stores that
+// don't implement BatchUpdater are updated one record at a time once every
+// record has been applied, so a concurrent change can still stop the batch
+// part way.
+func UpdateWhere[T any](store Store[T], match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	if bu, ok := store.(BatchUpdater[T]); ok {
+		return bu.UpdateWhere(match, apply)
+	}
+
+	var staged []T
+	for _, item := range store.GetAll() {
+		if !match(item) {
+			continue
+		}
+		next, err := apply(item)
+		if err != nil {
+			return nil, &RecordError{ID: idOf(item), Err: err}
+		}
+		staged = append(staged, next)
+	}
+
+	updated := make([]T, 0, len(staged))
+	for _, next := range staged {
+		id := idOf(next)
+		u, err := store.Update(id, next)
+		if err != nil {
+			return updated, &RecordError{ID: id, Err: err}
+		}
+		updated = append(updated, u)
+	}
+	return updated, nil
+}
+
+// UpdateWhere updates every matching item under a single write lock. The
+// new versions are staged in a copy and only written once all of them
+// succeed.
+func (s *MemoryStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	staged := make(map[string]T)
+	for id, old := range s.items {
+		if s.expired(id, now) || !match(old) {
+			continue
+		}
+		next, err := apply(old)
+		if err == nil {
+			err = stampUpdate(&next, id, old)
+		}
+		if err != nil {
+			return nil, &RecordError{ID: id, Err: err}
+		}
+		staged[id] = next
+	}
+
+	updated := make([]T, 0, len(staged))
+	for id, next := range staged {
+		old := s.items[id]
+		s.counters.totalBytes.Add(sizeOf(next) - sizeOf(old))
+		s.items[id] = next
+		s.reindex(id, &old, &next)
+		s.touch(id)
+		s.counters.updates.Add(1)
+		updated = append(updated, next)
+	}
+	return updated, nil
+}
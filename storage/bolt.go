@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// BoltStore implements Store interface on top of a BoltDB bucket.
+// Records are stored as JSON values keyed by ID.
+type BoltStore[T any] struct {
+	db     *bbolt.DB
+	bucket []byte
+}
+
+// NewBoltStore creates a store backed by the named bucket, creating it if needed
+func NewBoltStore[T any](db *bbolt.DB, bucket string) (*BoltStore[T], error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bolt: create bucket %s: %w", bucket, err)
+	}
+
+	return &BoltStore[T]{db: db, bucket: []byte(bucket)}, nil
+}
+
+// GetAll returns all items
+func (s *BoltStore[T]) GetAll() []T {
+	items := make([]T, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(s.bucket).ForEach(func(_, v []byte) error {
+			var item T
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		})
+	})
+	if err != nil {
+		log.Printf("bolt: %s: get all: %v", s.bucket, err)
+	}
+	return items
+}
+
+// GetSorted returns every item ordered by fields
+func (s *BoltStore[T]) GetSorted(fields []SortField) []T {
+	items := s.GetAll()
+	SortRecords(items, fields)
+	return items
+}
+
+// GetByID retrieves an item by ID
+func (s *BoltStore[T]) GetByID(id string) (T, bool) {
+	var item T
+	var found bool
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(s.bucket).Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &item)
+	})
+	if err != nil {
+		log.Printf("bolt: %s: get %s: %v", s.bucket, id, err)
+		var zero T
+		return zero, false
+	}
+	return item, found
+}
+
+// GetMany retrieves the items with the given IDs in one read transaction
+func (s *BoltStore[T]) GetMany(ids []string) map[string]T {
+	found := make(map[string]T, len(ids))
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		for _, id := range ids {
+			v := b.Get([]byte(id))
+			if v == nil {
+				continue
+			}
+			var item T
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			found[id] = item
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("bolt: %s: get many: %v", s.bucket, err)
+	}
+	return found
+}
+
+// Create adds a new item
+func (s *BoltStore[T]) Create(data T) T {
+	created, err := s.TryCreate(data)
+	if err != nil {
+		log.Printf("bolt: %s: create %s: %v", s.bucket, idOf(created), err)
+	}
+	return created
+}
+
+// TryCreate adds a new item, returning the error bolt fails to write it with
+func (s *BoltStore[T]) TryCreate(data T) (T, error) {
+	id := stampCreate(&data)
+	if id == "" {
+		return data, nil
+	}
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		return s.put(tx, id, data)
+	})
+	return data, err
+}
+
+// CreateWithTTL returns ErrTTLUnsupported; bolt records don't expire
+func (s *BoltStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	var zero T
+	return zero, ErrTTLUnsupported
+}
+
+// CreateMany adds several items in one transaction
+func (s *BoltStore[T]) CreateMany(data []T) []T {
+	created := make([]T, 0, len(data))
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		for _, item := range data {
+			if id := stampCreate(&item); id != "" {
+				if err := s.put(tx, id, item); err != nil {
+					return err
+				}
+			}
+			created = append(created, item)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("bolt: %s: create many: %v", s.bucket, err)
+		return nil
+	}
+	return created
+}
+
+// Update modifies an existing item
+func (s *BoltStore[T]) Update(id string, data T) (T, error) {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(s.bucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+
+		var old T
+		if err := json.Unmarshal(v, &old); err != nil {
+			return err
+		}
+		if err := stampUpdate(&data, id, old); err != nil {
+			return err
+		}
+		return s.put(tx, id, data)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return data, nil
+}
+
+// Delete removes an item
+func (s *BoltStore[T]) Delete(id string) bool {
+	_, deleted := s.DeleteAndReturn(id)
+	return deleted
+}
+
+// DeleteAndReturn reads and removes an item in one transaction
+func (s *BoltStore[T]) DeleteAndReturn(id string) (T, bool) {
+	var item T
+	var found bool
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		v := b.Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+		if err := json.Unmarshal(v, &item); err != nil {
+			return err
+		}
+		found = true
+		return b.Delete([]byte(id))
+	})
+	if err != nil {
+		log.Printf("bolt: %s: delete %s: %v", s.bucket, id, err)
+		var zero T
+		return zero, false
+	}
+	return item, found
+}
+
+// Clear removes all items by recreating the bucket in one transaction
+func (s *BoltStore[T]) Clear() error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket(s.bucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(s.bucket)
+		return err
+	})
+}
+
+// Replace swaps the bucket contents for items in one transaction
+func (s *BoltStore[T]) Replace(items []T) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket(s.bucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucket(s.bucket); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := s.put(tx, idOf(item), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Export writes every record to w; see ExportRecords
+func (s *BoltStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return ExportRecords[T](ctx, s, w)
+}
+
+// Import replaces the records with those read from r; see ImportRecords
+func (s *BoltStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return ImportRecords[T](ctx, s, r)
+}
+
+// View calls fn with all items inside a single read transaction
+func (s *BoltStore[T]) View(fn func(items []T)) {
+	items := make([]T, 0)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		err := tx.Bucket(s.bucket).ForEach(func(_, v []byte) error {
+			var item T
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fn(items)
+		return nil
+	})
+	if err != nil {
+		log.Printf("bolt: %s: view: %v", s.bucket, err)
+	}
+}
+
+// Ping reports whether the database is open
+func (s *BoltStore[T]) Ping() error {
+	// A read transaction fails with bbolt.ErrDatabaseNotOpen once the DB is closed
+	return s.db.View(func(tx *bbolt.Tx) error { return nil })
+}
+
+func (s *BoltStore[T]) put(tx *bbolt.Tx, id string, data T) error {
+	v, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(s.bucket).Put([]byte(id), v)
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-api/models"
+
+	"go.etcd.io/bbolt"
+)
+
+// openBolt opens the bolt file at path
+func openBolt(t *testing.T, path string) *bbolt.DB {
+	t.Helper()
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestBoltStoreRoundTrip(t *testing.T) {
+	db := openBolt(t, filepath.Join(t.TempDir(), "items.db"))
+	defer db.Close()
+	store, err := NewBoltStore[models.Item](db, "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testRoundTrip(t, store)
+}
+
+func TestBoltStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.db")
+	db := openBolt(t, path)
+	store, err := NewBoltStore[models.Item](db, "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := store.Create(models.Item{Name: "widget", Quantity: 3})
+	db.Close()
+
+	db = openBolt(t, path)
+	defer db.Close()
+	store, err = NewBoltStore[models.Item](db, "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exists := store.GetByID(created.ID); !exists || got.Name != "widget" || got.Version != 1 {
+		t.Errorf("GetByID after reopening = %+v, %v; want the created item", got, exists)
+	}
+}
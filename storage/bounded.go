@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// EvictionPolicy picks the record a full bounded store drops to make room
+type EvictionPolicy[T any] interface {
+	// Evict returns the ID of the record to remove from items
+	Evict(items map[string]T) string
+}
+
+// accessTracker is implemented by policies that need to know when records
+// are read, written and removed
+type accessTracker interface {
+	touch(id string)
+	forget(id string)
+	reset()
+}
+
+// NewBoundedMemoryStore creates an in-memory store holding at most capacity
+// records. When it is full, Create evicts the record chosen by policy; with a
+// nil policy TryCreate returns ErrStoreFull instead.
+func NewBoundedMemoryStore[T any](capacity int, policy EvictionPolicy[T]) *MemoryStore[T] {
+	s := NewMemoryStore[T]()
+	s.capacity = capacity
+	s.policy = policy
+	return s
+}
+
+// OldestFirst evicts the record created first
+type OldestFirst[T any] struct{}
+
+// Evict returns the ID of the record with the earliest CreatedAt
+func (OldestFirst[T]) Evict(items map[string]T) string {
+	var victim string
+	var oldest time.Time
+	for id, item := range items {
+		if created := createdAt(item); victim == "" || created.Before(oldest) {
+			victim, oldest = id, created
+		}
+	}
+	return victim
+}
+
+// LeastRecentlyUsed evicts the record that was read or written longest ago
+type LeastRecentlyUsed[T any] struct {
+	mu   sync.Mutex
+	seq  uint64
+	used map[string]uint64
+}
+
+// NewLeastRecentlyUsed creates a least recently used eviction policy
+func NewLeastRecentlyUsed[T any]() *LeastRecentlyUsed[T] {
+	return &LeastRecentlyUsed[T]{used: make(map[string]uint64)}
+}
+
+// Evict returns the ID of the least recently used record. Records never
+// used since the store was last replaced go first.
+func (p *LeastRecentlyUsed[T]) Evict(items map[string]T) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var victim string
+	var oldest uint64
+	for id := range items {
+		if used := p.used[id]; victim == "" || used < oldest {
+			victim, oldest = id, used
+		}
+	}
+	return victim
+}
+
+func (p *LeastRecentlyUsed[T]) touch(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seq++
+	p.used[id] = p.seq
+}
+
+func (p *LeastRecentlyUsed[T]) forget(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.used, id)
+}
+
+func (p *LeastRecentlyUsed[T]) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.used = make(map[string]uint64)
+}
+
+func (s *MemoryStore[T]) touch(id string) {
+	if t, ok := s.policy.(accessTracker); ok {
+		t.touch(id)
+	}
+}
+
+func (s *MemoryStore[T]) forget(id string) {
+	if t, ok := s.policy.(accessTracker); ok {
+		t.forget(id)
+	}
+}
+
+func (s *MemoryStore[T]) resetAccess() {
+	if t, ok := s.policy.(accessTracker); ok {
+		t.reset()
+	}
+}
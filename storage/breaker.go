@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned while a CircuitBreaker is rejecting calls
+var ErrCircuitOpen = errors.New("storage unavailable: circuit open")
+
+// Circuit breaker states
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// CircuitBreaker wraps a Store and stops calling it after FailureThreshold
+// consecutive backend errors. While open, methods that return an error
+// fail with ErrCircuitOpen; callers use Allow to reject requests before
+// they reach the other methods. After RecoveryTimeout one trial call is let
+// through: success closes the breaker, failure opens it again.
+type CircuitBreaker[T any] struct {
+	Store[T]
+	FailureThreshold int
+	RecoveryTimeout  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	trial    bool // a half-open trial call is in flight
+}
+
+// NewCircuitBreaker creates a closed breaker around store
+func NewCircuitBreaker[T any](store Store[T], failureThreshold int, recoveryTimeout time.Duration) *CircuitBreaker[T] {
+	return &CircuitBreaker[T]{
+		Store:            store,
+		FailureThreshold: failureThreshold,
+		RecoveryTimeout:  recoveryTimeout,
+		state:            StateClosed,
+	}
+}
+
+// State returns closed, open or half-open
+func (b *CircuitBreaker[T]) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance()
+	return b.state
+}
+
+// Allow returns ErrCircuitOpen when a call should not reach the store.
+// In the half-open state only the first caller is allowed, after a trial
+// Ping; stores that can't be pinged are trusted to have recovered.
+func (b *CircuitBreaker[T]) Allow() error {
+	b.mu.Lock()
+	b.advance()
+	switch {
+	case b.state == StateClosed:
+		b.mu.Unlock()
+		return nil
+	case b.state == StateOpen || b.trial:
+		b.mu.Unlock()
+		return ErrCircuitOpen
+	}
+	b.trial = true
+	b.mu.Unlock()
+
+	p, ok := b.Store.(Pinger)
+	if !ok {
+		b.record(nil)
+		return nil
+	}
+	if err := b.record(p.Ping()); err != nil {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// advance moves an open breaker to half-open once RecoveryTimeout passed.
+// b.mu must be held.
+func (b *CircuitBreaker[T]) advance() {
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.RecoveryTimeout {
+		b.state = StateHalfOpen
+		b.trial = false
+	}
+}
+
+// record updates the breaker with the outcome of a call and returns err.
+// Not-found and version conflicts are answers from a healthy backend.
+func (b *CircuitBreaker[T]) record(err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if err == nil || errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersionConflict) {
+		b.state = StateClosed
+		b.failures = 0
+		return err
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+	return err
+}
+
+// Update forwards to the wrapped store unless the breaker is open
+func (b *CircuitBreaker[T]) Update(id string, data T) (T, error) {
+	if err := b.Allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+	updated, err := b.Store.Update(id, data)
+	return updated, b.record(err)
+}
+
+// Clear forwards to the wrapped store unless the breaker is open
+func (b *CircuitBreaker[T]) Clear() error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	return b.record(b.Store.Clear())
+}
+
+// Replace forwards to the wrapped store unless the breaker is open
+func (b *CircuitBreaker[T]) Replace(items []T) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	return b.record(b.Store.Replace(items))
+}
+
+// Export forwards to the wrapped store unless the breaker is open
+func (b *CircuitBreaker[T]) Export(ctx context.Context, w io.Writer) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	return b.record(b.Store.Export(ctx, w))
+}
+
+// Import forwards to the wrapped store unless the breaker is open
+func (b *CircuitBreaker[T]) Import(ctx context.Context, r io.Reader) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	return b.record(b.Store.Import(ctx, r))
+}
+
+// Range forwards to the wrapped store so records still aren't collected into a slice
+func (b *CircuitBreaker[T]) Range(fn func(T) bool) {
+	Range(b.Store, fn)
+}
+
+// StreamAll forwards to the wrapped store so records are still streamed
+func (b *CircuitBreaker[T]) StreamAll(ctx context.Context, out chan<- T) {
+	StreamAll(ctx, b.Store, out)
+}
+
+// UpdateWhere forwards to the wrapped store so batches stay all-or-nothing
+func (b *CircuitBreaker[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	return UpdateWhere(b.Store, match, apply)
+}
+
+// Filter forwards to the wrapped store so its indexes are still used
+func (b *CircuitBreaker[T]) Filter(query map[string]string) ([]T, error) {
+	return Filter(b.Store, query)
+}
+
+// Explain forwards to the wrapped store so its indexes are described
+func (b *CircuitBreaker[T]) Explain(predicates map[string]string) QueryPlan {
+	return Explain(b.Store, predicates)
+}
+
+// ByTags forwards to the wrapped store so its tag index is still used
+func (b *CircuitBreaker[T]) ByTags(tags []string, all bool) []T {
+	return ByTags(b.Store, tags, all)
+}
+
+// TagCounts forwards to the wrapped store so its tag index is still used
+func (b *CircuitBreaker[T]) TagCounts() map[string]int {
+	return TagCounts(b.Store)
+}
+
+// Search forwards to the wrapped store so its text index is still used
+func (b *CircuitBreaker[T]) Search(query string, minScore float64) []Scored[T] {
+	return Search(b.Store, query, minScore)
+}
+
+// ByEmailDomain forwards to the wrapped store so its domain index is still used
+func (b *CircuitBreaker[T]) ByEmailDomain(domain string) []T {
+	return ByEmailDomain(b.Store, domain)
+}
+
+// EmailDomains forwards to the wrapped store so its domain index is still used
+func (b *CircuitBreaker[T]) EmailDomains() map[string]int {
+	return EmailDomains(b.Store)
+}
+
+// Ping checks the wrapped store and records the outcome. Stores that can't
+// be pinged are reported healthy.
+func (b *CircuitBreaker[T]) Ping() error {
+	p, ok := b.Store.(Pinger)
+	if !ok {
+		return nil
+	}
+	if b.State() == StateOpen {
+		return ErrCircuitOpen
+	}
+	return b.record(p.Ping())
+}
+
+// View forwards to the wrapped store so callers still get a consistent view
+func (b *CircuitBreaker[T]) View(fn func(items []T)) {
+	View(b.Store, fn)
+}
+
+// Stats forwards to the wrapped store
+func (b *CircuitBreaker[T]) Stats() Stats {
+	return StatsOf(b.Store)
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-api/models"
+	"go-api/tenant"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// decorated wraps store in the decorators main.go puts around the item and
+// client stores, in the same order, and returns the view of it a request
+// of tenantID gets, with the HookedStore of the chain.
+func decorated[T any](store Store[T], tenantID string) (view Store[T], hooks *HookedStore[T]) {
+	store = NewInstrumentedStore(store, "items", "memory", prometheus.NewRegistry())
+	store = NewCircuitBreaker(store, 5, time.Minute)
+	store = NewSingleFlightStore(store)
+	store = NewLRUStore(store, 100)
+	store = NewTenantStore(store)
+	hooks = NewHookedStore(store)
+	store = NewTracedStore[T](hooks, noop.NewTracerProvider().Tracer("test"), "items")
+	return store.(Scoper[T]).For(tenant.WithID(context.Background(), tenantID)), hooks
+}
+
+func TestChainTryCreateReportsFullStore(t *testing.T) {
+	view, hooks := decorated[models.Item](NewBoundedMemoryStore[models.Item](10, nil), "acme")
+	var created atomic.Int32
+	hooks.AddCreateHook(func(context.Context, models.Item) { created.Add(1) })
+
+	var wg sync.WaitGroup
+	var ok, full atomic.Int32
+	for i := range 50 {
+		wg.Go(func() {
+			_, err := view.TryCreate(models.Item{Name: "Widget", Quantity: i})
+			switch {
+			case err == nil:
+				ok.Add(1)
+			case errors.Is(err, ErrStoreFull):
+				full.Add(1)
+			default:
+				t.Errorf("TryCreate: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	hooks.Close()
+
+	if ok.Load() != 10 || full.Load() != 40 {
+		t.Errorf("%d created and %d refused, want 10 and 40", ok.Load(), full.Load())
+	}
+	if n := created.Load(); n != 10 {
+		t.Errorf("create hooks ran %d times, want 10", n)
+	}
+	if n := len(view.GetAll()); n != 10 {
+		t.Errorf("store has %d items, want 10", n)
+	}
+}
+
+func TestChainGetSortedIsScoped(t *testing.T) {
+	memory := NewMemoryStore[models.Item]()
+	acme, _ := decorated[models.Item](memory, "acme")
+	globex, _ := decorated[models.Item](memory, "globex")
+	for _, q := range []int{3, 1, 2} {
+		acme.Create(models.Item{Name: "Widget", Quantity: q})
+	}
+	globex.Create(models.Item{Name: "Gadget", Quantity: 0})
+
+	var got []int
+	for _, item := range acme.GetSorted([]SortField{{Field: "quantity", Desc: true}}) {
+		got = append(got, item.Quantity)
+	}
+	if !slices.Equal(got, []int{3, 2, 1}) {
+		t.Errorf("acme sorted quantities %v, want [3 2 1] without globex's item", got)
+	}
+}
+
+func TestChainDeleteAndReturnRemovesOnce(t *testing.T) {
+	view, hooks := decorated[models.Item](NewMemoryStore[models.Item](), "acme")
+	var deleted atomic.Int32
+	hooks.AddDeleteHook(func(context.Context, string) { deleted.Add(1) })
+	item := view.Create(models.Item{Name: "Widget", Quantity: 3})
+
+	var wg sync.WaitGroup
+	var removed atomic.Int32
+	for range 20 {
+		wg.Go(func() {
+			old, ok := view.DeleteAndReturn(item.ID)
+			if !ok {
+				return
+			}
+			removed.Add(1)
+			if old.ID != item.ID || old.Name != "Widget" {
+				t.Errorf("DeleteAndReturn returned %+v, want the created item", old)
+			}
+		})
+	}
+	wg.Wait()
+	hooks.Close()
+
+	if n := removed.Load(); n != 1 {
+		t.Errorf("%d deletes returned the item, want 1", n)
+	}
+	if n := deleted.Load(); n != 1 {
+		t.Errorf("delete hooks ran %d times, want 1", n)
+	}
+	if _, ok := view.GetByID(item.ID); ok {
+		t.Error("item still readable after DeleteAndReturn")
+	}
+}
+
+func TestChainCreateWithTTL(t *testing.T) {
+	view, _ := decorated[models.Item](NewMemoryStore[models.Item](), "acme")
+	if _, err := view.CreateWithTTL(models.Item{Name: "Widget"}, time.Minute); !errors.Is(err, ErrTTLUnsupported) {
+		t.Errorf("CreateWithTTL through the cache: %v, want ErrTTLUnsupported", err)
+	}
+
+	// Without the cache, as the job and reservation stores are wired
+	store := NewTenantStore[models.Item](NewMemoryStore[models.Item]())
+	scoped := store.For(tenant.WithID(context.Background(), "acme"))
+	created, err := scoped.CreateWithTTL(models.Item{Name: "Widget"}, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("CreateWithTTL: %v", err)
+	}
+	if _, ok := scoped.GetByID(created.ID); !ok {
+		t.Fatal("record missing before its expiry")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := scoped.GetByID(created.ID); ok {
+		t.Error("record still readable after its expiry")
+	}
+}
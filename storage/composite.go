@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+)
+
+// Putter is implemented by stores that can hold a record under the ID it
+// already has, replacing any record with that ID
+type Putter[T any] interface {
+	Put(data T) error
+}
+
+// CompositeStore implements Store over a primary store and replicas that
+// serve its reads. Writes go to the primary and are then copied to every
+// replica; a replica that fails to take a write is logged and left behind
+// until the next Sync. Reads try the replicas in order and fall back to the
+// primary, so a replica that is unhealthy or hasn't been synced yet is never
+// the only answer.
+type CompositeStore[T any] struct {
+	primary  Store[T]
+	replicas []Store[T]
+}
+
+// NewCompositeStore creates a store writing to primary and reading from
+// replicas, in order. Call Sync to fill the replicas before serving reads.
+func NewCompositeStore[T any](primary Store[T], replicas ...Store[T]) *CompositeStore[T] {
+	return &CompositeStore[T]{primary: primary, replicas: replicas}
+}
+
+// Sync copies every record of the primary to every replica, replacing what
+// they hold. It returns the first replica failure after trying them all.
+func (s *CompositeStore[T]) Sync(ctx context.Context) error {
+	records := s.primary.GetAll()
+	var first error
+	for i, replica := range s.replicas {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := replica.Replace(records); err != nil {
+			log.Printf("WARN: composite store: sync replica %d: %v", i, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// readers returns the stores to read from in order: the healthy replicas,
+// then the primary
+func (s *CompositeStore[T]) readers() []Store[T] {
+	readers := make([]Store[T], 0, len(s.replicas)+1)
+	for _, replica := range s.replicas {
+		if p, ok := replica.(Pinger); ok && p.Ping() != nil {
+			continue
+		}
+		readers = append(readers, replica)
+	}
+	return append(readers, s.primary)
+}
+
+// GetAll returns every record from the first healthy replica. An empty
+// replica may not have been synced, so the primary answers instead.
+func (s *CompositeStore[T]) GetAll() []T {
+	for _, store := range s.readers() {
+		if items := store.GetAll(); len(items) > 0 {
+			return items
+		}
+	}
+	return []T{}
+}
+
+// GetSorted returns every record of the first store holding any ordered by fields
+func (s *CompositeStore[T]) GetSorted(fields []SortField) []T {
+	items := s.GetAll()
+	SortRecords(items, fields)
+	return items
+}
+
+// GetByID retrieves a record from the first store holding it
+func (s *CompositeStore[T]) GetByID(id string) (T, bool) {
+	for _, store := range s.readers() {
+		if item, exists := store.GetByID(id); exists {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// GetMany retrieves the records with the given IDs, asking each store in
+// turn for the ones still missing
+func (s *CompositeStore[T]) GetMany(ids []string) map[string]T {
+	found := make(map[string]T, len(ids))
+	missing := ids
+	for _, store := range s.readers() {
+		for id, item := range store.GetMany(missing) {
+			found[id] = item
+		}
+		missing = missing[:0:0]
+		for _, id := range ids {
+			if _, ok := found[id]; !ok {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+	}
+	return found
+}
+
+// View calls fn with the records GetAll returns
+func (s *CompositeStore[T]) View(fn func(items []T)) {
+	fn(s.GetAll())
+}
+
+// put copies a record written to the primary to every replica
+func (s *CompositeStore[T]) put(data T) {
+	for i, replica := range s.replicas {
+		var err error
+		if p, ok := replica.(Putter[T]); ok {
+			err = p.Put(data)
+		} else {
+			_, err = replica.Update(idOf(data), data)
+		}
+		if err != nil {
+			log.Printf("WARN: composite store: write %s to replica %d: %v", idOf(data), i, err)
+		}
+	}
+}
+
+// Create adds a new record to the primary and copies it to the replicas
+func (s *CompositeStore[T]) Create(data T) T {
+	created := s.primary.Create(data)
+	s.put(created)
+	return created
+}
+
+// TryCreate adds a new record to the primary and copies it to the replicas
+func (s *CompositeStore[T]) TryCreate(data T) (T, error) {
+	created, err := s.primary.TryCreate(data)
+	if err != nil {
+		return created, err
+	}
+	s.put(created)
+	return created, nil
+}
+
+// CreateWithTTL returns ErrTTLUnsupported: the replicas would keep serving
+// a record after it expired in the primary
+func (s *CompositeStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	var zero T
+	return zero, ErrTTLUnsupported
+}
+
+// CreateMany adds several records to the primary and copies them to the
+// replicas
+func (s *CompositeStore[T]) CreateMany(data []T) []T {
+	created := s.primary.CreateMany(data)
+	for _, item := range created {
+		s.put(item)
+	}
+	return created
+}
+
+// Update modifies a record of the primary and copies it to the replicas
+func (s *CompositeStore[T]) Update(id string, data T) (T, error) {
+	updated, err := s.primary.Update(id, data)
+	if err != nil {
+		return updated, err
+	}
+	s.put(updated)
+	return updated, nil
+}
+
+// UpdateWhere updates the matching records of the primary as one change
+// and copies them to the replicas
+func (s *CompositeStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	updated, err := UpdateWhere(s.primary, match, apply)
+	if err != nil {
+		return updated, err
+	}
+	for _, item := range updated {
+		s.put(item)
+	}
+	return updated, nil
+}
+
+// Delete removes a record from the primary, then from the replicas
+func (s *CompositeStore[T]) Delete(id string) bool {
+	if !s.primary.Delete(id) {
+		return false
+	}
+	for _, replica := range s.replicas {
+		replica.Delete(id)
+	}
+	return true
+}
+
+// DeleteAndReturn removes a record from the primary, returning it, then
+// from the replicas
+func (s *CompositeStore[T]) DeleteAndReturn(id string) (T, bool) {
+	old, deleted := s.primary.DeleteAndReturn(id)
+	if !deleted {
+		return old, false
+	}
+	for _, replica := range s.replicas {
+		replica.Delete(id)
+	}
+	return old, true
+}
+
+// Clear removes every record from the primary, then from the replicas
+func (s *CompositeStore[T]) Clear() error {
+	if err := s.primary.Clear(); err != nil {
+		return err
+	}
+	for i, replica := range s.replicas {
+		if err := replica.Clear(); err != nil {
+			log.Printf("WARN: composite store: clear replica %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Replace swaps the contents of the primary for items, then of the replicas
+func (s *CompositeStore[T]) Replace(items []T) error {
+	if err := s.primary.Replace(items); err != nil {
+		return err
+	}
+	for i, replica := range s.replicas {
+		if err := replica.Replace(items); err != nil {
+			log.Printf("WARN: composite store: replace replica %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Export writes every record to w; see ExportRecords
+func (s *CompositeStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return ExportRecords[T](ctx, s, w)
+}
+
+// Import replaces the records with those read from r; see ImportRecords
+func (s *CompositeStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return ImportRecords[T](ctx, s, r)
+}
+
+// Ping reports the health of the primary
+func (s *CompositeStore[T]) Ping() error {
+	if p, ok := s.primary.(Pinger); ok {
+		return p.Ping()
+	}
+	return nil
+}
+
+// Stats reports the counters of the primary
+func (s *CompositeStore[T]) Stats() Stats {
+	return StatsOf(s.primary)
+}
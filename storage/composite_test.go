@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-api/models"
+)
+
+// failingReplica is a replica whose backend is down: it fails pings and
+// writes, and reads find nothing
+type failingReplica struct {
+	*MemoryStore[models.Item]
+	reads int
+}
+
+var errReplicaDown = errors.New("replica down")
+
+func (s *failingReplica) Ping() error                        { return errReplicaDown }
+func (s *failingReplica) Replace(items []models.Item) error  { return errReplicaDown }
+func (s *failingReplica) Put(data models.Item) error         { return errReplicaDown }
+func (s *failingReplica) GetAll() []models.Item              { s.reads++; return nil }
+func (s *failingReplica) GetByID(string) (models.Item, bool) { s.reads++; return models.Item{}, false }
+
+func TestCompositeStoreFallsBackToPrimary(t *testing.T) {
+	primary := NewMemoryStore[models.Item]()
+	primary.Create(models.Item{Name: "seeded"})
+	down := &failingReplica{MemoryStore: NewMemoryStore[models.Item]()}
+	store := NewCompositeStore[models.Item](primary, down)
+
+	if err := store.Sync(context.Background()); !errors.Is(err, errReplicaDown) {
+		t.Errorf("Sync = %v, want the replica's failure", err)
+	}
+	created := store.Create(models.Item{Name: "widget"})
+	if got, exists := store.GetByID(created.ID); !exists || got.Name != "widget" {
+		t.Errorf("GetByID = %+v, %v; want the item from the primary", got, exists)
+	}
+	if all := store.GetAll(); len(all) != 2 {
+		t.Errorf("GetAll returned %d items, want the primary's 2", len(all))
+	}
+	if down.reads != 0 {
+		t.Errorf("the unhealthy replica was read %d times", down.reads)
+	}
+}
+
+func TestCompositeStoreReadsReplicasInOrder(t *testing.T) {
+	primary := NewMemoryStore[models.Item]()
+	first, second := NewMemoryStore[models.Item](), NewMemoryStore[models.Item]()
+	store := NewCompositeStore[models.Item](primary, first, second)
+
+	// Unsynced replicas are empty, so the primary answers
+	seeded := primary.Create(models.Item{Name: "seeded"})
+	if all := store.GetAll(); len(all) != 1 || all[0].ID != seeded.ID {
+		t.Errorf("GetAll before Sync = %+v, want the primary's item", all)
+	}
+	if err := store.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := second.GetByID(seeded.ID); !exists {
+		t.Fatal("Sync didn't copy the primary to the replicas")
+	}
+
+	created := store.Create(models.Item{Name: "widget"})
+	for name, replica := range map[string]*MemoryStore[models.Item]{"first": first, "second": second} {
+		if _, exists := replica.GetByID(created.ID); !exists {
+			t.Errorf("the create wasn't copied to the %s replica", name)
+		}
+	}
+
+	// A record only the second replica has is still found, and one the
+	// first replica has wins
+	first.Delete(created.ID)
+	if _, exists := store.GetByID(created.ID); !exists {
+		t.Error("GetByID didn't fall back to the second replica")
+	}
+	stale := seeded
+	stale.Name = "stale"
+	first.Replace([]models.Item{stale})
+	if got, _ := store.GetByID(seeded.ID); got.Name != "stale" {
+		t.Errorf("GetByID answered %q, want the first replica's record", got.Name)
+	}
+}
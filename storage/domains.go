@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"go-api/models"
+)
+
+// DomainIndex is implemented by stores that index the email domains of
+// their records
+type DomainIndex[T any] interface {
+	ByEmailDomain(domain string) []T
+	EmailDomains() map[string]int
+}
+
+// ByEmailDomain returns the records of store whose email address is at
+// domain, ignoring case. Stores that don't implement DomainIndex are
+// scanned.
+func ByEmailDomain[T any](store Store[T], domain string) []T {
+	if d, ok := store.(DomainIndex[T]); ok {
+		return d.ByEmailDomain(domain)
+	}
+	domain = strings.ToLower(domain)
+	matched := make([]T, 0)
+	for _, record := range store.GetAll() {
+		if d, ok := domainOf(record); ok && d == domain {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// EmailDomains returns how many records of store have an email address at
+// each domain. Stores that don't implement DomainIndex are scanned.
+func EmailDomains[T any](store Store[T]) map[string]int {
+	if d, ok := store.(DomainIndex[T]); ok {
+		return d.EmailDomains()
+	}
+	return emailDomainsScan(store.GetAll())
+}
+
+// emailDomainsScan counts the email domains of records
+func emailDomainsScan[T any](records []T) map[string]int {
+	counts := make(map[string]int)
+	for _, record := range records {
+		if d, ok := domainOf(record); ok {
+			counts[d]++
+		}
+	}
+	return counts
+}
+
+// domainOf returns the lowercased domain of a record's email address, and
+// false for types without one or records without an address
+func domainOf[T any](data T) (string, bool) {
+	var email string
+	switch v := any(data).(type) {
+	case models.Client:
+		email = v.Email
+	default:
+		return "", false
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 || at == len(email)-1 {
+		return "", false
+	}
+	return strings.ToLower(email[at+1:]), true
+}
+
+// domainsOf returns the email domain of a record as a list, so it can be
+// kept in a tagIndex
+func domainsOf[T any](data T) []string {
+	if d, ok := domainOf(data); ok {
+		return []string{d}
+	}
+	return nil
+}
+
+// ByEmailDomain looks the items up in the domain index, leaving out
+// expired ones
+func (s *MemoryStore[T]) ByEmailDomain(domain string) []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	ids := s.domains[strings.ToLower(domain)]
+	matched := make([]T, 0, len(ids))
+	for id := range ids {
+		if !s.expired(id, now) {
+			matched = append(matched, s.items[id])
+		}
+	}
+	return matched
+}
+
+// EmailDomains returns the size of each domain's set, leaving out expired
+// items
+func (s *MemoryStore[T]) EmailDomains() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	counts := make(map[string]int, len(s.domains))
+	for domain, ids := range s.domains {
+		for id := range ids {
+			if !s.expired(id, now) {
+				counts[domain]++
+			}
+		}
+	}
+	return counts
+}
@@ -0,0 +1,14 @@
+package storage
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when no record has the requested ID
+	ErrNotFound = errors.New("record not found")
+	// ErrVersionConflict is returned by Update when the record was modified
+	// since the version the caller read
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrStoreFull is returned when a bounded store has no room for a new
+	// record and no eviction policy to make some
+	ErrStoreFull = errors.New("store is full")
+)
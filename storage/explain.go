@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+)
+
+// Query plan step methods
+const (
+	// MethodIndex looks the field up in a secondary index
+	MethodIndex = "index"
+	// MethodTagIndex looks the tags up in the tag index
+	MethodTagIndex = "tag_index"
+	// MethodScan checks the field of every record
+	MethodScan = "scan"
+	// MethodUnsupported marks fields that can't be filtered on, which match
+	// nothing
+	MethodUnsupported = "unsupported"
+)
+
+// tagsPredicate is the predicate naming tags that must all be present,
+// as comma-separated values
+const tagsPredicate = "tags"
+
+// QueryPlan describes how a store would answer a filter query, like SQL
+// EXPLAIN
+type QueryPlan struct {
+	// Method is how the query as a whole runs: index when every field is
+	// looked up in an index, scan when any field forces a full scan
+	Method string     `json:"method"`
+	Steps  []PlanStep `json:"steps"`
+	// EstimatedRows is an upper bound on the records the query returns
+	EstimatedRows int `json:"estimated_rows"`
+	// Recommendation names the indexes that would avoid the scan, or is
+	// empty when there is nothing to add
+	Recommendation string `json:"recommendation"`
+}
+
+// PlanStep describes how one field of a query is matched
+type PlanStep struct {
+	Field  string `json:"field"`
+	Method string `json:"method"`
+	// EstimatedRows is the number of records matching this field alone on
+	// an index, or the number of records read on a scan
+	EstimatedRows int `json:"estimated_rows"`
+}
+
+// Explainer is implemented by stores that can describe how they would run
+// a filter query
+type Explainer interface {
+	Explain(predicates map[string]string) QueryPlan
+}
+
+// Explain describes how store would answer a query filtering on
+// predicates, where "tags" is a comma-separated list of tags that must all
+// be present and other fields are matched as by Filter. Stores that don't
+// implement Explainer scan every field.
+func Explain[T any](store Store[T], predicates map[string]string) QueryPlan {
+	if e, ok := store.(Explainer); ok {
+		return e.Explain(predicates)
+	}
+	total := len(store.GetAll())
+	return explainQuery[T](predicates, func(field, value string) (int, bool) {
+		if field == tagsPredicate {
+			if _, ok := store.(Tagger[T]); ok {
+				return len(ByTags(store, splitTags(value), true)), true
+			}
+		}
+		return total, false
+	}, total)
+}
+
+// explainQuery builds the plan of predicates. lookup returns the rows of a
+// field's index entry and true, or the rows a scan reads and false when the
+// field has no index.
+func explainQuery[T any](predicates map[string]string, lookup func(field, value string) (int, bool), total int) QueryPlan {
+	filterable := FilterableFields[T]()
+	fields := make([]string, 0, len(predicates))
+	for field := range predicates {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	plan := QueryPlan{Method: MethodIndex, Steps: make([]PlanStep, 0, len(fields)), EstimatedRows: total}
+	var missing []string
+	for _, field := range fields {
+		step := PlanStep{Field: field}
+		_, known := filterable[field]
+		known = known || field == tagsPredicate
+		switch rows, indexed := lookup(field, predicates[field]); {
+		case indexed && field == tagsPredicate:
+			step.Method, step.EstimatedRows = MethodTagIndex, rows
+		case indexed:
+			step.Method, step.EstimatedRows = MethodIndex, rows
+		case known:
+			step.Method, step.EstimatedRows = MethodScan, rows
+			plan.Method = MethodScan
+			missing = append(missing, field)
+		default:
+			step.Method, step.EstimatedRows = MethodUnsupported, 0
+		}
+		if step.Method != MethodScan {
+			plan.EstimatedRows = min(plan.EstimatedRows, step.EstimatedRows)
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	if len(missing) > 0 {
+		plan.Recommendation = "add index on " + strings.Join(missing, ", ")
+	}
+	return plan
+}
+
+// splitTags splits a comma-separated list of tags
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Explain implements Explainer from the sizes of the index entries the
+// query would read
+func (s *IndexedMemoryStore[T]) Explain(predicates map[string]string) QueryPlan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	total := len(s.store.GetAll())
+	return explainQuery[T](predicates, func(field, value string) (int, bool) {
+		if field == tagsPredicate {
+			return len(s.store.ByTags(splitTags(value), true)), true
+		}
+		index, ok := s.indexes[field]
+		if !ok {
+			return total, false
+		}
+		return len(index[value]), true
+	}, total)
+}
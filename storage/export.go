@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportRecords writes every record in store to w as one JSON object per
+// line, stopping with ctx's error when it's done. It implements Export for
+// stores that have no faster way than Range.
+func ExportRecords[T any](ctx context.Context, store Store[T], w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	var err error
+	Range(store, func(record T) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		err = enc.Encode(record)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ImportRecords replaces the contents of store with the newline-delimited
+// JSON records read from r, keeping their IDs and timestamps. Every record
+// is decoded before Replace, so nothing is replaced when one can't be. It
+// implements Import for stores whose Replace is all they need.
+func ImportRecords[T any](ctx context.Context, store Store[T], r io.Reader) error {
+	records, err := decodeNDJSON[T](ctx, r)
+	if err != nil {
+		return err
+	}
+	return store.Replace(records)
+}
+
+// decodeNDJSON reads every newline-delimited JSON record from r
+func decodeNDJSON[T any](ctx context.Context, r io.Reader) ([]T, error) {
+	dec := json.NewDecoder(r)
+	var records []T
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var record T
+		err := dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("import record %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// Export writes every record to w as newline-delimited JSON. The records are copied under the read lock
+// and written after it's released, so a slow w doesn't hold up writers.
+func (s *MemoryStore[T]) Export(ctx context.Context, w io.Writer) error {
+	s.mu.RLock()
+	now := time.Now()
+	records := make([]T, 0, len(s.items))
+	for id, item := range s.items {
+		if !s.expired(id, now) {
+			records = append(records, item)
+		}
+	}
+	s.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Import replaces the records with those read from r; see ImportRecords
+func (s *MemoryStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return ImportRecords[T](ctx, s, r)
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Filterer is implemented by stores that can find the records whose fields
+// equal given values faster than a full scan
+type Filterer[T any] interface {
+	Filter(query map[string]string) ([]T, error)
+}
+
+// Filter returns the records of store whose fields, named by their JSON
+// names, equal every value of query. Stores that don't implement Filterer
+// are scanned. Fields T has no such scalar field for match nothing.
+func Filter[T any](store Store[T], query map[string]string) ([]T, error) {
+	if f, ok := store.(Filterer[T]); ok {
+		return f.Filter(query)
+	}
+	return filterScan(store.GetAll(), query), nil
+}
+
+// FilterableFields returns the JSON names of the string, bool and number
+// fields of T, with their struct field index
+func FilterableFields[T any]() map[string]int {
+	fields := SortableFields[T]()
+	t := reflect.TypeFor[T]()
+	for name, i := range fields {
+		if t.Field(i).Type == timeType {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
+
+// filterScan returns the records matching query by checking each one
+func filterScan[T any](records []T, query map[string]string) []T {
+	fields := FilterableFields[T]()
+	matched := make([]T, 0)
+	for _, record := range records {
+		if matchesQuery(record, fields, query) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// matchesQuery reports whether every field of query has its value in record
+func matchesQuery[T any](record T, fields map[string]int, query map[string]string) bool {
+	v := reflect.ValueOf(record)
+	for name, want := range query {
+		i, ok := fields[name]
+		if !ok || fieldValue(v.Field(i)) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValue formats a scalar field the way it is written in a query
+func fieldValue(v reflect.Value) string {
+	return strings.TrimSpace(fmt.Sprint(v.Interface()))
+}
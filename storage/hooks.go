@@ -0,0 +1,493 @@
+package storage
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"go-api/correlation"
+	"go-api/events"
+	"go-api/tenant"
+)
+
+// hookWorkers is the number of goroutines running hooks. Hooks for the same
+// record always run on the same worker, so they see its changes in order.
+const hookWorkers = 5
+
+// hookQueueSize is how many pending hook calls each worker buffers before
+// new ones are dropped
+const hookQueueSize = 256
+
+// CreateHook is called with every record added to a store
+type CreateHook[T any] func(ctx context.Context, item T)
+
+// UpdateHook is called with every record updated in a store
+type UpdateHook[T any] func(ctx context.Context, item T)
+
+// DeleteHook is called with the ID of every record removed from a store
+type DeleteHook func(ctx context.Context, id string)
+
+// ResetHook is called after a store is cleared or its contents replaced
+type ResetHook func(ctx context.Context)
+
+// HookedStore wraps a Store and calls registered hooks after every
+// successful create, update and delete. Hooks run asynchronously on a small
+// pool of workers, so a slow hook never holds up the request that triggered
+// it; calls are dropped with a warning when the pool falls behind.
+type HookedStore[T any] struct {
+	Store[T]
+
+	mu      sync.RWMutex
+	creates []CreateHook[T]
+	updates []UpdateHook[T]
+	deletes []DeleteHook
+	resets  []ResetHook
+	closed  bool
+
+	queues []chan func()
+	wg     sync.WaitGroup
+}
+
+// NewHookedStore creates a store that runs hooks for mutations of store
+func NewHookedStore[T any](store Store[T]) *HookedStore[T] {
+	s := &HookedStore[T]{Store: store, queues: make([]chan func(), hookWorkers)}
+	for i := range s.queues {
+		s.queues[i] = make(chan func(), hookQueueSize)
+		s.wg.Add(1)
+		go s.work(s.queues[i])
+	}
+	return s
+}
+
+// AddCreateHook registers a hook called after each create
+func (s *HookedStore[T]) AddCreateHook(hook CreateHook[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.creates = append(s.creates, hook)
+}
+
+// AddUpdateHook registers a hook called after each successful update
+func (s *HookedStore[T]) AddUpdateHook(hook UpdateHook[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updates = append(s.updates, hook)
+}
+
+// AddDeleteHook registers a hook called after each successful delete
+func (s *HookedStore[T]) AddDeleteHook(hook DeleteHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deletes = append(s.deletes, hook)
+}
+
+// AddResetHook registers a hook called after each successful Clear or Replace
+func (s *HookedStore[T]) AddResetHook(hook ResetHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resets = append(s.resets, hook)
+}
+
+// OnChange registers fn to be called after any change to the store
+func (s *HookedStore[T]) OnChange(fn func()) {
+	s.AddCreateHook(func(context.Context, T) { fn() })
+	s.AddUpdateHook(func(context.Context, T) { fn() })
+	s.AddDeleteHook(func(context.Context, string) { fn() })
+	s.AddResetHook(func(context.Context) { fn() })
+}
+
+// Close stops the workers once every queued hook has run. Mutations after
+// Close no longer run hooks.
+func (s *HookedStore[T]) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	for _, q := range s.queues {
+		close(q)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// For returns a view of the store that passes ctx to the hooks it triggers
+func (s *HookedStore[T]) For(ctx context.Context) Store[T] {
+	store := s.Store
+	if sc, ok := store.(Scoper[T]); ok {
+		store = sc.For(ctx)
+	}
+	return &hookedView[T]{store: store, ctx: ctx, hooks: s}
+}
+
+// Create adds a new item and runs the create hooks
+func (s *HookedStore[T]) Create(data T) T {
+	return s.unscoped().Create(data)
+}
+
+// TryCreate adds a new item and runs the create hooks, or returns the
+// wrapped store's error
+func (s *HookedStore[T]) TryCreate(data T) (T, error) {
+	return s.unscoped().TryCreate(data)
+}
+
+// CreateWithTTL adds an expiring item and runs the create hooks. No hooks
+// run when it expires.
+func (s *HookedStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	return s.unscoped().CreateWithTTL(data, ttl)
+}
+
+// Range forwards to the wrapped store so records still aren't collected into a slice
+func (s *HookedStore[T]) Range(fn func(T) bool) {
+	Range(s.Store, fn)
+}
+
+// StreamAll forwards to the wrapped store so records are still streamed
+func (s *HookedStore[T]) StreamAll(ctx context.Context, out chan<- T) {
+	StreamAll(ctx, s.Store, out)
+}
+
+// UpdateWhere updates every matching item and runs the update hooks for each
+func (s *HookedStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	return s.unscoped().UpdateWhere(match, apply)
+}
+
+// Filter forwards to the wrapped store so its indexes are still used
+func (s *HookedStore[T]) Filter(query map[string]string) ([]T, error) {
+	return Filter(s.Store, query)
+}
+
+// Explain forwards to the wrapped store so its indexes are described
+func (s *HookedStore[T]) Explain(predicates map[string]string) QueryPlan {
+	return Explain(s.Store, predicates)
+}
+
+// ByTags forwards to the wrapped store so its tag index is still used
+func (s *HookedStore[T]) ByTags(tags []string, all bool) []T {
+	return ByTags(s.Store, tags, all)
+}
+
+// TagCounts forwards to the wrapped store so its tag index is still used
+func (s *HookedStore[T]) TagCounts() map[string]int {
+	return TagCounts(s.Store)
+}
+
+// Search forwards to the wrapped store so its text index is still used
+func (s *HookedStore[T]) Search(query string, minScore float64) []Scored[T] {
+	return Search(s.Store, query, minScore)
+}
+
+// ByEmailDomain forwards to the wrapped store so its domain index is still used
+func (s *HookedStore[T]) ByEmailDomain(domain string) []T {
+	return ByEmailDomain(s.Store, domain)
+}
+
+// EmailDomains forwards to the wrapped store so its domain index is still used
+func (s *HookedStore[T]) EmailDomains() map[string]int {
+	return EmailDomains(s.Store)
+}
+
+// CreateMany adds several items and runs the create hooks for each
+func (s *HookedStore[T]) CreateMany(data []T) []T {
+	return s.unscoped().CreateMany(data)
+}
+
+// Update modifies an existing item and runs the update hooks
+func (s *HookedStore[T]) Update(id string, data T) (T, error) {
+	return s.unscoped().Update(id, data)
+}
+
+// Delete removes an item and runs the delete hooks
+func (s *HookedStore[T]) Delete(id string) bool {
+	return s.unscoped().Delete(id)
+}
+
+// DeleteAndReturn removes an item, runs the delete hooks and returns it
+func (s *HookedStore[T]) DeleteAndReturn(id string) (T, bool) {
+	return s.unscoped().DeleteAndReturn(id)
+}
+
+// Clear removes all items and runs the reset hooks
+func (s *HookedStore[T]) Clear() error {
+	return s.unscoped().Clear()
+}
+
+// Replace swaps the store contents for items and runs the reset hooks
+func (s *HookedStore[T]) Replace(items []T) error {
+	return s.unscoped().Replace(items)
+}
+
+// Import replaces the store contents with the records read from r and runs
+// the reset hooks
+func (s *HookedStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return s.unscoped().Import(ctx, r)
+}
+
+// View forwards to the wrapped store so callers still get a consistent view
+func (s *HookedStore[T]) View(fn func(items []T)) {
+	View(s.Store, fn)
+}
+
+// Stats forwards to the wrapped store
+func (s *HookedStore[T]) Stats() Stats {
+	return StatsOf(s.Store)
+}
+
+func (s *HookedStore[T]) unscoped() *hookedView[T] {
+	return &hookedView[T]{store: s.Store, ctx: context.Background(), hooks: s}
+}
+
+func (s *HookedStore[T]) work(queue chan func()) {
+	defer s.wg.Done()
+	for job := range queue {
+		runHook(job)
+	}
+}
+
+// runHook calls job, logging instead of crashing the worker if it panics
+func runHook(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("WARN: store hook panicked: %v", r)
+		}
+	}()
+	job()
+}
+
+// enqueue schedules job on the worker owning id, dropping it if that
+// worker's queue is full
+func (s *HookedStore[T]) enqueue(id string, job func()) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	select {
+	case s.queues[h.Sum32()%hookWorkers] <- job:
+	default:
+		log.Printf("WARN: store hook queue full, dropping hooks for %s", id)
+	}
+}
+
+func (s *HookedStore[T]) created(ctx context.Context, item T) {
+	s.mu.RLock()
+	hooks := s.creates
+	s.mu.RUnlock()
+	for _, hook := range hooks {
+		s.enqueue(idOf(item), func() { hook(ctx, item) })
+	}
+}
+
+func (s *HookedStore[T]) updated(ctx context.Context, item T) {
+	s.mu.RLock()
+	hooks := s.updates
+	s.mu.RUnlock()
+	for _, hook := range hooks {
+		s.enqueue(idOf(item), func() { hook(ctx, item) })
+	}
+}
+
+func (s *HookedStore[T]) deleted(ctx context.Context, id string) {
+	s.mu.RLock()
+	hooks := s.deletes
+	s.mu.RUnlock()
+	for _, hook := range hooks {
+		s.enqueue(id, func() { hook(ctx, id) })
+	}
+}
+
+func (s *HookedStore[T]) reset(ctx context.Context) {
+	s.mu.RLock()
+	hooks := s.resets
+	s.mu.RUnlock()
+	for _, hook := range hooks {
+		s.enqueue("", func() { hook(ctx) })
+	}
+}
+
+// hookedView runs the hooks of a HookedStore for mutations made through a
+// single request
+type hookedView[T any] struct {
+	store Store[T]
+	ctx   context.Context
+	hooks *HookedStore[T]
+}
+
+// hookContext returns the view's context without its cancellation, since hooks
+// usually run after the request has finished
+func (v *hookedView[T]) hookContext() context.Context {
+	return context.WithoutCancel(v.ctx)
+}
+
+func (v *hookedView[T]) GetAll() []T {
+	return v.store.GetAll()
+}
+
+func (v *hookedView[T]) GetSorted(fields []SortField) []T {
+	return v.store.GetSorted(fields)
+}
+
+func (v *hookedView[T]) GetByID(id string) (T, bool) {
+	return v.store.GetByID(id)
+}
+
+func (v *hookedView[T]) GetMany(ids []string) map[string]T {
+	return v.store.GetMany(ids)
+}
+
+func (v *hookedView[T]) Create(data T) T {
+	created := v.store.Create(data)
+	v.hooks.created(v.hookContext(), created)
+	return created
+}
+
+func (v *hookedView[T]) TryCreate(data T) (T, error) {
+	created, err := v.store.TryCreate(data)
+	if err == nil {
+		v.hooks.created(v.hookContext(), created)
+	}
+	return created, err
+}
+
+func (v *hookedView[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	created, err := v.store.CreateWithTTL(data, ttl)
+	if err == nil {
+		v.hooks.created(v.hookContext(), created)
+	}
+	return created, err
+}
+
+func (v *hookedView[T]) Range(fn func(T) bool) {
+	Range(v.store, fn)
+}
+
+func (v *hookedView[T]) StreamAll(ctx context.Context, out chan<- T) {
+	StreamAll(ctx, v.store, out)
+}
+
+func (v *hookedView[T]) CreateMany(data []T) []T {
+	created := v.store.CreateMany(data)
+	ctx := v.hookContext()
+	for _, item := range created {
+		v.hooks.created(ctx, item)
+	}
+	return created
+}
+
+func (v *hookedView[T]) Update(id string, data T) (T, error) {
+	updated, err := v.store.Update(id, data)
+	if err == nil {
+		v.hooks.updated(v.hookContext(), updated)
+	}
+	return updated, err
+}
+
+func (v *hookedView[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	updated, err := UpdateWhere(v.store, match, apply)
+	ctx := v.hookContext()
+	for _, item := range updated {
+		v.hooks.updated(ctx, item)
+	}
+	return updated, err
+}
+
+func (v *hookedView[T]) Filter(query map[string]string) ([]T, error) {
+	return Filter(v.store, query)
+}
+
+func (v *hookedView[T]) ByTags(tags []string, all bool) []T {
+	return ByTags(v.store, tags, all)
+}
+
+func (v *hookedView[T]) TagCounts() map[string]int {
+	return TagCounts(v.store)
+}
+
+func (v *hookedView[T]) Search(query string, minScore float64) []Scored[T] {
+	return Search(v.store, query, minScore)
+}
+
+func (v *hookedView[T]) ByEmailDomain(domain string) []T {
+	return ByEmailDomain(v.store, domain)
+}
+
+func (v *hookedView[T]) EmailDomains() map[string]int {
+	return EmailDomains(v.store)
+}
+
+func (v *hookedView[T]) Delete(id string) bool {
+	deleted := v.store.Delete(id)
+	if deleted {
+		v.hooks.deleted(v.hookContext(), id)
+	}
+	return deleted
+}
+
+func (v *hookedView[T]) DeleteAndReturn(id string) (T, bool) {
+	old, deleted := v.store.DeleteAndReturn(id)
+	if deleted {
+		v.hooks.deleted(v.hookContext(), id)
+	}
+	return old, deleted
+}
+
+func (v *hookedView[T]) Clear() error {
+	err := v.store.Clear()
+	if err == nil {
+		v.hooks.reset(v.hookContext())
+	}
+	return err
+}
+
+func (v *hookedView[T]) Replace(items []T) error {
+	err := v.store.Replace(items)
+	if err == nil {
+		v.hooks.reset(v.hookContext())
+	}
+	return err
+}
+
+func (v *hookedView[T]) Export(ctx context.Context, w io.Writer) error {
+	return v.store.Export(ctx, w)
+}
+
+func (v *hookedView[T]) Import(ctx context.Context, r io.Reader) error {
+	err := v.store.Import(ctx, r)
+	if err == nil {
+		v.hooks.reset(v.hookContext())
+	}
+	return err
+}
+
+// PublishHooks registers hooks on store that publish each mutation of
+// entity to bus, on the topic of its type under prefix (prefix.created and
+// so on)
+func PublishHooks[T any](store *HookedStore[T], bus *events.Bus, entity, prefix string) {
+	publish := func(ctx context.Context, typ, id, tenantID string, data any) {
+		bus.Publish(events.Topic(prefix, typ), events.Event{
+			Type:          typ,
+			Entity:        entity,
+			ID:            id,
+			Data:          data,
+			Time:          time.Now(),
+			CorrelationID: correlation.IDFromContext(ctx),
+			TenantID:      tenantID,
+		})
+	}
+	store.AddCreateHook(func(ctx context.Context, item T) {
+		publish(ctx, events.Created, idOf(item), tenantOf(item), item)
+	})
+	store.AddUpdateHook(func(ctx context.Context, item T) {
+		publish(ctx, events.Updated, idOf(item), tenantOf(item), item)
+	})
+	// Only the deleting caller's tenant can see the record, so it owns it
+	store.AddDeleteHook(func(ctx context.Context, id string) {
+		publish(ctx, events.Deleted, id, tenant.IDFromContext(ctx), nil)
+	})
+}
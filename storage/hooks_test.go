@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-api/events"
+	"go-api/models"
+	"go-api/tenant"
+)
+
+// nextEvent returns the next event of stream, failing after a second
+func nextEvent(t *testing.T, stream <-chan events.Event) events.Event {
+	t.Helper()
+	select {
+	case e := <-stream:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event published")
+		return events.Event{}
+	}
+}
+
+func TestPublishHooksTenant(t *testing.T) {
+	bus := events.NewBus()
+	hooked := NewHookedStore[models.Item](NewTenantStore[models.Item](NewMemoryStore[models.Item]()))
+	defer hooked.Close()
+	PublishHooks(hooked, bus, "items", "item")
+	stream, cancel := bus.Subscribe("item.*")
+	defer cancel()
+
+	store := hooked.For(tenant.WithID(context.Background(), "acme"))
+	created := store.Create(models.Item{Name: "widget"})
+	if e := nextEvent(t, stream); e.Type != events.Created || e.TenantID != "acme" {
+		t.Errorf("create event = %s for tenant %q, want created for acme", e.Type, e.TenantID)
+	}
+
+	created.Name = "gadget"
+	if _, err := store.Update(created.ID, created); err != nil {
+		t.Fatal(err)
+	}
+	if e := nextEvent(t, stream); e.Type != events.Updated || e.TenantID != "acme" {
+		t.Errorf("update event = %s for tenant %q, want updated for acme", e.Type, e.TenantID)
+	}
+
+	store.Delete(created.ID)
+	if e := nextEvent(t, stream); e.Type != events.Deleted || e.TenantID != "acme" {
+		t.Errorf("delete event = %s for tenant %q, want deleted for acme", e.Type, e.TenantID)
+	}
+}
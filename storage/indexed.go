@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrNotIndexed is returned by IndexedMemoryStore.Filter when RequireIndex
+// is set and the query names a field without an index
+var ErrNotIndexed = errors.New("field is not indexed")
+
+// IndexedMemoryStore is a MemoryStore keeping secondary indexes from field
+// value to record IDs, so Filter on indexed fields looks up only the
+// matching records instead of scanning them all. Records created with a TTL
+// are not supported.
+type IndexedMemoryStore[T any] struct {
+	store *MemoryStore[T]
+	// RequireIndex makes Filter fail with ErrNotIndexed instead of
+	// scanning when a queried field has no index
+	RequireIndex bool
+
+	// mu is held for writing while a change and its index updates are made
+	mu sync.RWMutex
+	// indexes maps field name to field value to the set of record IDs
+	indexes map[string]map[string]map[string]struct{}
+	fields  map[string]int
+}
+
+// NewIndexedMemoryStore creates an empty in-memory store indexing the fields
+// of T with the given JSON names. It fails for fields T has no string, bool
+// or number field for.
+func NewIndexedMemoryStore[T any](indexes []string) (*IndexedMemoryStore[T], error) {
+	filterable := FilterableFields[T]()
+	s := &IndexedMemoryStore[T]{
+		store:   NewMemoryStore[T](),
+		indexes: make(map[string]map[string]map[string]struct{}, len(indexes)),
+		fields:  make(map[string]int, len(indexes)),
+	}
+	for _, name := range indexes {
+		i, ok := filterable[name]
+		if !ok {
+			return nil, fmt.Errorf("index %q: no such field", name)
+		}
+		s.fields[name] = i
+		s.indexes[name] = make(map[string]map[string]struct{})
+	}
+	return s, nil
+}
+
+// add indexes record. s.mu must be held for writing.
+func (s *IndexedMemoryStore[T]) add(record T) {
+	id := idOf(record)
+	v := reflect.ValueOf(record)
+	for name, i := range s.fields {
+		value := fieldValue(v.Field(i))
+		ids, ok := s.indexes[name][value]
+		if !ok {
+			ids = make(map[string]struct{})
+			s.indexes[name][value] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// remove drops record from the indexes. s.mu must be held for writing.
+func (s *IndexedMemoryStore[T]) remove(record T) {
+	id := idOf(record)
+	v := reflect.ValueOf(record)
+	for name, i := range s.fields {
+		value := fieldValue(v.Field(i))
+		delete(s.indexes[name][value], id)
+		if len(s.indexes[name][value]) == 0 {
+			delete(s.indexes[name], value)
+		}
+	}
+}
+
+// reindex rebuilds every index from records. s.mu must be held for writing.
+func (s *IndexedMemoryStore[T]) reindex(records []T) {
+	for name := range s.indexes {
+		s.indexes[name] = make(map[string]map[string]struct{})
+	}
+	for _, record := range records {
+		s.add(record)
+	}
+}
+
+// Filter returns the records whose fields equal every value of query. When
+// every queried field is indexed, only the records in the intersection of
+// their index entries are read; otherwise the store is scanned, or
+// ErrNotIndexed is returned when RequireIndex is set.
+func (s *IndexedMemoryStore[T]) Filter(query map[string]string) ([]T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(query) == 0 {
+		return s.store.GetAll(), nil
+	}
+
+	var smallest map[string]struct{}
+	first := true
+	for name, value := range query {
+		index, ok := s.indexes[name]
+		if !ok {
+			if s.RequireIndex {
+				return nil, fmt.Errorf("filter on %q: %w", name, ErrNotIndexed)
+			}
+			return filterScan(s.store.GetAll(), query), nil
+		}
+		if ids := index[value]; first || len(ids) < len(smallest) {
+			smallest, first = ids, false
+		}
+	}
+
+	// Intersect by checking the candidates of the smallest set against the
+	// other indexes
+	ids := make([]string, 0, len(smallest))
+	for id := range smallest {
+		in := true
+		for name, value := range query {
+			if _, ok := s.indexes[name][value][id]; !ok {
+				in = false
+				break
+			}
+		}
+		if in {
+			ids = append(ids, id)
+		}
+	}
+
+	found := s.store.GetMany(ids)
+	matched := make([]T, 0, len(found))
+	for _, id := range ids {
+		if record, ok := found[id]; ok {
+			matched = append(matched, record)
+		}
+	}
+	return matched, nil
+}
+
+// GetAll returns all records
+func (s *IndexedMemoryStore[T]) GetAll() []T {
+	return s.store.GetAll()
+}
+
+// GetSorted returns every record ordered by fields
+func (s *IndexedMemoryStore[T]) GetSorted(fields []SortField) []T {
+	return s.store.GetSorted(fields)
+}
+
+// GetByID retrieves a record by ID
+func (s *IndexedMemoryStore[T]) GetByID(id string) (T, bool) {
+	return s.store.GetByID(id)
+}
+
+// GetMany retrieves the records with the given IDs
+func (s *IndexedMemoryStore[T]) GetMany(ids []string) map[string]T {
+	return s.store.GetMany(ids)
+}
+
+// View calls fn with all records while holding the read lock
+func (s *IndexedMemoryStore[T]) View(fn func(items []T)) {
+	s.store.View(fn)
+}
+
+// Range calls fn with each record until fn returns false
+func (s *IndexedMemoryStore[T]) Range(fn func(T) bool) {
+	s.store.Range(fn)
+}
+
+// StreamAll sends every record to out, then closes it
+func (s *IndexedMemoryStore[T]) StreamAll(ctx context.Context, out chan<- T) {
+	s.store.StreamAll(ctx, out)
+}
+
+// Stats reports the counters of the store
+func (s *IndexedMemoryStore[T]) Stats() Stats {
+	return s.store.Stats()
+}
+
+// ByTags looks records up in the tag index of the store
+func (s *IndexedMemoryStore[T]) ByTags(tags []string, all bool) []T {
+	return s.store.ByTags(tags, all)
+}
+
+// TagCounts counts the records of each tag in the tag index of the store
+func (s *IndexedMemoryStore[T]) TagCounts() map[string]int {
+	return s.store.TagCounts()
+}
+
+// Search looks records up in the text index of the store
+func (s *IndexedMemoryStore[T]) Search(query string, minScore float64) []Scored[T] {
+	return s.store.Search(query, minScore)
+}
+
+// ByEmailDomain looks records up in the domain index of the store
+func (s *IndexedMemoryStore[T]) ByEmailDomain(domain string) []T {
+	return s.store.ByEmailDomain(domain)
+}
+
+// EmailDomains counts the records at each domain in the domain index of the
+// store
+func (s *IndexedMemoryStore[T]) EmailDomains() map[string]int {
+	return s.store.EmailDomains()
+}
+
+// Create adds and indexes a new record. A full bounded store logs the
+// failure and returns the zero value; use TryCreate to get the error.
+func (s *IndexedMemoryStore[T]) Create(data T) T {
+	created, err := s.TryCreate(data)
+	if err != nil {
+		log.Printf("WARN: indexed store: create: %v", err)
+	}
+	return created
+}
+
+// TryCreate adds and indexes a new record
+func (s *IndexedMemoryStore[T]) TryCreate(data T) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created, err := s.store.TryCreate(data)
+	if err == nil {
+		s.add(created)
+	}
+	return created, err
+}
+
+// CreateWithTTL returns ErrTTLUnsupported: records expiring in the
+// underlying store would stay in the indexes
+func (s *IndexedMemoryStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	var zero T
+	return zero, ErrTTLUnsupported
+}
+
+// CreateMany adds and indexes several records
+func (s *IndexedMemoryStore[T]) CreateMany(data []T) []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := s.store.CreateMany(data)
+	for _, record := range created {
+		s.add(record)
+	}
+	return created
+}
+
+// Update modifies a record and moves it to its new index entries
+func (s *IndexedMemoryStore[T]) Update(id string, data T) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.store.GetByID(id)
+	updated, err := s.store.Update(id, data)
+	if err == nil && exists {
+		s.remove(old)
+		s.add(updated)
+	}
+	return updated, err
+}
+
+// UpdateWhere updates the matching records as one change and reindexes them
+func (s *IndexedMemoryStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var before []T
+	updated, err := s.store.UpdateWhere(func(record T) bool {
+		if match(record) {
+			before = append(before, record)
+			return true
+		}
+		return false
+	}, apply)
+	if err != nil {
+		return updated, err
+	}
+	for _, record := range before {
+		s.remove(record)
+	}
+	for _, record := range updated {
+		s.add(record)
+	}
+	return updated, nil
+}
+
+// Delete removes a record and its index entries
+func (s *IndexedMemoryStore[T]) Delete(id string) bool {
+	_, deleted := s.DeleteAndReturn(id)
+	return deleted
+}
+
+// DeleteAndReturn removes a record and its index entries and returns it
+func (s *IndexedMemoryStore[T]) DeleteAndReturn(id string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, deleted := s.store.DeleteAndReturn(id)
+	if deleted {
+		s.remove(old)
+	}
+	return old, deleted
+}
+
+// Clear removes all records and empties the indexes
+func (s *IndexedMemoryStore[T]) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reindex(nil)
+	return s.store.Clear()
+}
+
+// Replace swaps the store contents for items and rebuilds the indexes
+func (s *IndexedMemoryStore[T]) Replace(items []T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.Replace(items); err != nil {
+		return err
+	}
+	s.reindex(items)
+	return nil
+}
+
+// Export forwards to the underlying store, which doesn't need the indexes
+func (s *IndexedMemoryStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return s.store.Export(ctx, w)
+}
+
+// Import replaces the records with those read from r and rebuilds the
+// indexes; see ImportRecords
+func (s *IndexedMemoryStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return ImportRecords[T](ctx, s, r)
+}
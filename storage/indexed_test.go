@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"go-api/models"
+)
+
+func TestIndexedMemoryStoreRoundTrip(t *testing.T) {
+	store, err := NewIndexedMemoryStore[models.Item]([]string{"status", "client_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testRoundTrip(t, store)
+}
+
+func TestIndexedMemoryStoreFilter(t *testing.T) {
+	store, err := NewIndexedMemoryStore[models.Item]([]string{"status", "client_id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Create(models.Item{Name: "a", Status: "draft", ClientID: "c1"})
+	b := store.Create(models.Item{Name: "b", Status: "published", ClientID: "c1"})
+	c := store.Create(models.Item{Name: "c", Status: "draft", ClientID: "c2"})
+
+	names := func(query map[string]string) []string {
+		t.Helper()
+		found, err := store.Filter(query)
+		if err != nil {
+			t.Fatalf("Filter(%v): %v", query, err)
+		}
+		var names []string
+		for _, item := range found {
+			names = append(names, item.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	tests := []struct {
+		query map[string]string
+		want  []string
+	}{
+		{map[string]string{"status": "draft"}, []string{"a", "c"}},
+		{map[string]string{"status": "draft", "client_id": "c1"}, []string{"a"}},
+		{map[string]string{"status": "archived"}, nil},
+		// Fields without an index are scanned
+		{map[string]string{"name": "b", "client_id": "c1"}, []string{"b"}},
+	}
+	for _, tt := range tests {
+		if got := names(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("Filter(%v) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// Writes keep the indexes current
+	b.Status = "draft"
+	if _, err := store.Update(b.ID, b); err != nil {
+		t.Fatal(err)
+	}
+	store.Delete(c.ID)
+	if got := names(map[string]string{"status": "draft"}); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("after an update and a delete, drafts are %v, want a and b", got)
+	}
+	if got := names(map[string]string{"status": "published"}); got != nil {
+		t.Errorf("published = %v after the update, want none", got)
+	}
+
+	store.RequireIndex = true
+	if _, err := store.Filter(map[string]string{"name": "a"}); !errors.Is(err, ErrNotIndexed) {
+		t.Errorf("Filter on an unindexed field with RequireIndex: %v, want ErrNotIndexed", err)
+	}
+	if _, err := NewIndexedMemoryStore[models.Item]([]string{"colour"}); err == nil {
+		t.Error("an index on a missing field was accepted")
+	}
+}
+
+// benchmarkFilter fills store with 100,000 items spread over 1,000 clients
+// and filters them by one client
+func benchmarkFilter(b *testing.B, store Store[models.Item]) {
+	items := make([]models.Item, 100_000)
+	for i := range items {
+		items[i] = models.Item{Name: "widget", ClientID: fmt.Sprintf("client-%d", i%1000)}
+	}
+	store.CreateMany(items)
+	query := map[string]string{"client_id": "client-42"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for b.Loop() {
+		found, err := Filter(store, query)
+		if err != nil || len(found) != 100 {
+			b.Fatalf("Filter found %d items, %v; want 100", len(found), err)
+		}
+	}
+}
+
+func BenchmarkMemoryStoreFilter(b *testing.B) {
+	benchmarkFilter(b, NewMemoryStore[models.Item]())
+}
+
+func BenchmarkIndexedMemoryStoreFilter(b *testing.B) {
+	store, err := NewIndexedMemoryStore[models.Item]([]string{"client_id"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkFilter(b, store)
+}
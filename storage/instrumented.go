@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// storeOperationBuckets are the latency buckets of store operations, in
+// seconds
+var storeOperationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1.0}
+
+// storeOperations are the operations InstrumentedStore records
+var storeOperations = []string{
+	"get_all", "get_by_id", "get_many", "create", "create_many", "create_with_ttl",
+	"update", "update_where", "delete", "clear", "replace", "filter", "by_tags",
+	"tag_counts", "search", "by_email_domain", "email_domains", "stream_all", "range",
+	"view", "ping", "export", "import",
+}
+
+// instrumentedOp holds the collectors of one operation, with their labels
+// already applied so that recording a call doesn't look them up
+type instrumentedOp struct {
+	duration prometheus.Observer
+	errors   prometheus.Counter
+}
+
+// InstrumentedStore wraps a Store and records the latency of every call to
+// it in the api_store_operation_duration_seconds histogram, and the calls
+// that failed in api_store_errors_total. Not-found and version conflicts
+// are answers from a healthy backend and aren't counted as errors.
+type InstrumentedStore[T any] struct {
+	Store[T]
+	ops map[string]instrumentedOp
+}
+
+// NewInstrumentedStore creates a store recording the operations on entity,
+// kept in backend, with collectors registered on reg. Stores of several
+// entities may share reg.
+func NewInstrumentedStore[T any](store Store[T], entity, backend string, reg prometheus.Registerer) *InstrumentedStore[T] {
+	duration := register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "api_store_operation_duration_seconds",
+		Help:    "Latency of store operations.",
+		Buckets: storeOperationBuckets,
+	}, []string{"operation", "entity", "backend"}))
+	failures := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "api_store_errors_total",
+		Help: "Store operations that failed.",
+	}, []string{"operation", "entity", "backend"}))
+
+	ops := make(map[string]instrumentedOp, len(storeOperations))
+	for _, op := range storeOperations {
+		ops[op] = instrumentedOp{
+			duration: duration.WithLabelValues(op, entity, backend),
+			errors:   failures.WithLabelValues(op, entity, backend),
+		}
+	}
+	return &InstrumentedStore[T]{Store: store, ops: ops}
+}
+
+// register registers c on reg, or returns the equal collector registered
+// before it
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) C {
+	if err := reg.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			return already.ExistingCollector.(C)
+		}
+		panic(err)
+	}
+	return c
+}
+
+// observe records a call to op that started at start and returned err
+func (s *InstrumentedStore[T]) observe(op string, start time.Time, err error) {
+	o := s.ops[op]
+	o.duration.Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionConflict) {
+		o.errors.Inc()
+	}
+}
+
+// GetAll forwards to the wrapped store
+func (s *InstrumentedStore[T]) GetAll() []T {
+	defer s.observe("get_all", time.Now(), nil)
+	return s.Store.GetAll()
+}
+
+// GetByID forwards to the wrapped store
+func (s *InstrumentedStore[T]) GetByID(id string) (T, bool) {
+	defer s.observe("get_by_id", time.Now(), nil)
+	return s.Store.GetByID(id)
+}
+
+// GetMany forwards to the wrapped store
+func (s *InstrumentedStore[T]) GetMany(ids []string) map[string]T {
+	defer s.observe("get_many", time.Now(), nil)
+	return s.Store.GetMany(ids)
+}
+
+// Create forwards to the wrapped store
+func (s *InstrumentedStore[T]) Create(data T) T {
+	defer s.observe("create", time.Now(), nil)
+	return s.Store.Create(data)
+}
+
+// CreateMany forwards to the wrapped store
+func (s *InstrumentedStore[T]) CreateMany(data []T) []T {
+	defer s.observe("create_many", time.Now(), nil)
+	return s.Store.CreateMany(data)
+}
+
+// Update forwards to the wrapped store
+func (s *InstrumentedStore[T]) Update(id string, data T) (T, error) {
+	start := time.Now()
+	updated, err := s.Store.Update(id, data)
+	s.observe("update", start, err)
+	return updated, err
+}
+
+// Delete forwards to the wrapped store
+func (s *InstrumentedStore[T]) Delete(id string) bool {
+	defer s.observe("delete", time.Now(), nil)
+	return s.Store.Delete(id)
+}
+
+// DeleteAndReturn forwards to the wrapped store so the delete stays atomic
+func (s *InstrumentedStore[T]) DeleteAndReturn(id string) (T, bool) {
+	defer s.observe("delete", time.Now(), nil)
+	return s.Store.DeleteAndReturn(id)
+}
+
+// Clear forwards to the wrapped store
+func (s *InstrumentedStore[T]) Clear() error {
+	start := time.Now()
+	err := s.Store.Clear()
+	s.observe("clear", start, err)
+	return err
+}
+
+// Replace forwards to the wrapped store
+func (s *InstrumentedStore[T]) Replace(items []T) error {
+	start := time.Now()
+	err := s.Store.Replace(items)
+	s.observe("replace", start, err)
+	return err
+}
+
+// Export forwards to the wrapped store
+func (s *InstrumentedStore[T]) Export(ctx context.Context, w io.Writer) error {
+	start := time.Now()
+	err := s.Store.Export(ctx, w)
+	s.observe("export", start, err)
+	return err
+}
+
+// Import forwards to the wrapped store
+func (s *InstrumentedStore[T]) Import(ctx context.Context, r io.Reader) error {
+	start := time.Now()
+	err := s.Store.Import(ctx, r)
+	s.observe("import", start, err)
+	return err
+}
+
+// Range forwards to the wrapped store so records still aren't collected into a slice
+func (s *InstrumentedStore[T]) Range(fn func(T) bool) {
+	defer s.observe("range", time.Now(), nil)
+	Range(s.Store, fn)
+}
+
+// StreamAll forwards to the wrapped store so records are still streamed
+func (s *InstrumentedStore[T]) StreamAll(ctx context.Context, out chan<- T) {
+	defer s.observe("stream_all", time.Now(), nil)
+	StreamAll(ctx, s.Store, out)
+}
+
+// UpdateWhere forwards to the wrapped store so batches stay all-or-nothing
+func (s *InstrumentedStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	start := time.Now()
+	updated, err := UpdateWhere(s.Store, match, apply)
+	s.observe("update_where", start, err)
+	return updated, err
+}
+
+// Filter forwards to the wrapped store so its indexes are still used
+func (s *InstrumentedStore[T]) Filter(query map[string]string) ([]T, error) {
+	start := time.Now()
+	matched, err := Filter(s.Store, query)
+	s.observe("filter", start, err)
+	return matched, err
+}
+
+// Explain forwards to the wrapped store so its indexes are described
+func (s *InstrumentedStore[T]) Explain(predicates map[string]string) QueryPlan {
+	return Explain(s.Store, predicates)
+}
+
+// ByTags forwards to the wrapped store so its tag index is still used
+func (s *InstrumentedStore[T]) ByTags(tags []string, all bool) []T {
+	defer s.observe("by_tags", time.Now(), nil)
+	return ByTags(s.Store, tags, all)
+}
+
+// TagCounts forwards to the wrapped store so its tag index is still used
+func (s *InstrumentedStore[T]) TagCounts() map[string]int {
+	defer s.observe("tag_counts", time.Now(), nil)
+	return TagCounts(s.Store)
+}
+
+// Search forwards to the wrapped store so its text index is still used
+func (s *InstrumentedStore[T]) Search(query string, minScore float64) []Scored[T] {
+	defer s.observe("search", time.Now(), nil)
+	return Search(s.Store, query, minScore)
+}
+
+// ByEmailDomain forwards to the wrapped store so its domain index is still used
+func (s *InstrumentedStore[T]) ByEmailDomain(domain string) []T {
+	defer s.observe("by_email_domain", time.Now(), nil)
+	return ByEmailDomain(s.Store, domain)
+}
+
+// EmailDomains forwards to the wrapped store so its domain index is still used
+func (s *InstrumentedStore[T]) EmailDomains() map[string]int {
+	defer s.observe("email_domains", time.Now(), nil)
+	return EmailDomains(s.Store)
+}
+
+// CreateWithTTL forwards to the wrapped store so the record still expires
+func (s *InstrumentedStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	start := time.Now()
+	created, err := s.Store.CreateWithTTL(data, ttl)
+	s.observe("create_with_ttl", start, err)
+	return created, err
+}
+
+// TryCreate forwards to the wrapped store so its create errors reach the caller
+func (s *InstrumentedStore[T]) TryCreate(data T) (T, error) {
+	start := time.Now()
+	created, err := s.Store.TryCreate(data)
+	s.observe("create", start, err)
+	return created, err
+}
+
+// Ping forwards to the wrapped store. Stores that can't be pinged are
+// reported healthy.
+func (s *InstrumentedStore[T]) Ping() error {
+	p, ok := s.Store.(Pinger)
+	if !ok {
+		return nil
+	}
+	start := time.Now()
+	err := p.Ping()
+	s.observe("ping", start, err)
+	return err
+}
+
+// View forwards to the wrapped store so callers still get a consistent view
+func (s *InstrumentedStore[T]) View(fn func(items []T)) {
+	defer s.observe("view", time.Now(), nil)
+	View(s.Store, fn)
+}
+
+// Stats forwards to the wrapped store
+func (s *InstrumentedStore[T]) Stats() Stats {
+	return StatsOf(s.Store)
+}
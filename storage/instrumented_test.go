@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-api/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// failingUpdates is a store whose backend fails every update
+type failingUpdates struct {
+	Store[models.Item]
+}
+
+func (failingUpdates) Update(string, models.Item) (models.Item, error) {
+	return models.Item{}, errors.New("connection reset")
+}
+
+func TestInstrumentedStoreRecordsOperations(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	store := NewInstrumentedStore[models.Item](failingUpdates{NewMemoryStore[models.Item]()}, "items", "memory", reg)
+	clients := NewInstrumentedStore[models.Client](NewMemoryStore[models.Client](), "clients", "memory", reg)
+
+	item := store.Create(models.Item{Name: "widget"})
+	store.GetByID(item.ID)
+	store.GetByID("missing")
+	store.GetAll()
+	store.Update(item.ID, item)
+	clients.Create(models.Client{Name: "Acme"})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != "api_store_operation_duration_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			counts[labels["entity"]+" "+labels["operation"]] = m.GetHistogram().GetSampleCount()
+		}
+	}
+	for key, want := range map[string]uint64{
+		"items create":    1,
+		"items get_by_id": 2,
+		"items get_all":   1,
+		"items update":    1,
+		"items delete":    0,
+		"clients create":  1,
+	} {
+		if counts[key] != want {
+			t.Errorf("%s recorded %d times, want %d", key, counts[key], want)
+		}
+	}
+
+	failures := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "api_store_errors_total",
+		Help: "Store operations that failed.",
+	}, []string{"operation", "entity", "backend"}))
+	if got := testutil.ToFloat64(failures.WithLabelValues("update", "items", "memory")); got != 1 {
+		t.Errorf("update errors = %v, want 1", got)
+	}
+	// A missing record is an answer, not a failure
+	if got := testutil.ToFloat64(failures.WithLabelValues("get_by_id", "items", "memory")); got != 0 {
+		t.Errorf("get_by_id errors = %v, want 0", got)
+	}
+}
+
+// BenchmarkInstrumentedStoreOverhead measures what instrumentation adds to
+// a GetByID, which must stay under a microsecond
+func BenchmarkInstrumentedStoreOverhead(b *testing.B) {
+	plain := NewMemoryStore[models.Item]()
+	id := plain.Create(models.Item{Name: "widget"}).ID
+	instrumented := NewInstrumentedStore[models.Item](plain, "items", "memory", prometheus.NewRegistry())
+
+	b.ReportAllocs()
+	start := time.Now()
+	for range b.N {
+		plain.GetByID(id)
+	}
+	base := time.Since(start)
+	b.ResetTimer()
+	for range b.N {
+		instrumented.GetByID(id)
+	}
+	b.StopTimer()
+
+	overhead := (b.Elapsed() - base) / time.Duration(b.N)
+	b.ReportMetric(float64(overhead.Nanoseconds()), "overhead-ns/op")
+	if b.N >= 1000 && overhead >= time.Microsecond {
+		b.Errorf("instrumentation adds %s per call, want under 1µs", overhead)
+	}
+}
@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LRUStore wraps a Store with a fixed-size cache of GetByID results.
+// Entries are dropped when their record is updated or deleted; GetAll is
+// never cached. CreateWithTTL is refused, since a cached record would
+// outlive its expiry.
+type LRUStore[T any] struct {
+	Store[T]
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	gen     uint64     // bumped on every invalidation
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type lruEntry[T any] struct {
+	id   string
+	data T
+}
+
+// NewLRUStore creates a store caching up to capacity records of store
+func NewLRUStore[T any](store Store[T], capacity int) *LRUStore[T] {
+	return &LRUStore[T]{
+		Store:    store,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// GetByID returns the cached record, reading through to the wrapped store
+// on a miss
+func (s *LRUStore[T]) GetByID(id string) (T, bool) {
+	s.mu.Lock()
+	if el, ok := s.entries[id]; ok {
+		s.order.MoveToFront(el)
+		data := el.Value.(*lruEntry[T]).data
+		s.mu.Unlock()
+		s.hits.Add(1)
+		return data, true
+	}
+	gen := s.gen
+	s.mu.Unlock()
+	s.misses.Add(1)
+
+	data, exists := s.Store.GetByID(id)
+	if !exists {
+		return data, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A mutation while we were reading may have made data stale
+	if s.gen == gen {
+		s.add(id, data)
+	}
+	return data, true
+}
+
+// Update forwards to the wrapped store and drops the cached record
+func (s *LRUStore[T]) Update(id string, data T) (T, error) {
+	defer s.invalidate(id)
+	return s.Store.Update(id, data)
+}
+
+// Delete forwards to the wrapped store and drops the cached record
+func (s *LRUStore[T]) Delete(id string) bool {
+	defer s.invalidate(id)
+	return s.Store.Delete(id)
+}
+
+// DeleteAndReturn forwards to the wrapped store and evicts the record
+func (s *LRUStore[T]) DeleteAndReturn(id string) (T, bool) {
+	defer s.invalidate(id)
+	return s.Store.DeleteAndReturn(id)
+}
+
+// CreateWithTTL returns ErrTTLUnsupported, since GetByID could keep serving
+// the record from the cache after it expired
+func (s *LRUStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	var zero T
+	return zero, ErrTTLUnsupported
+}
+
+// Clear forwards to the wrapped store and empties the cache
+func (s *LRUStore[T]) Clear() error {
+	defer s.invalidateAll()
+	return s.Store.Clear()
+}
+
+// Replace forwards to the wrapped store and empties the cache
+func (s *LRUStore[T]) Replace(items []T) error {
+	defer s.invalidateAll()
+	return s.Store.Replace(items)
+}
+
+// Import forwards to the wrapped store and empties the cache
+func (s *LRUStore[T]) Import(ctx context.Context, r io.Reader) error {
+	defer s.invalidateAll()
+	return s.Store.Import(ctx, r)
+}
+
+// Range forwards to the wrapped store so records still aren't collected into a slice
+func (s *LRUStore[T]) Range(fn func(T) bool) {
+	Range(s.Store, fn)
+}
+
+// StreamAll forwards to the wrapped store so records are still streamed
+func (s *LRUStore[T]) StreamAll(ctx context.Context, out chan<- T) {
+	StreamAll(ctx, s.Store, out)
+}
+
+// UpdateWhere updates the matching records and drops them from the cache
+func (s *LRUStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	updated, err := UpdateWhere(s.Store, match, apply)
+	for _, item := range updated {
+		s.invalidate(idOf(item))
+	}
+	return updated, err
+}
+
+// Filter forwards to the wrapped store so its indexes are still used
+func (s *LRUStore[T]) Filter(query map[string]string) ([]T, error) {
+	return Filter(s.Store, query)
+}
+
+// Explain forwards to the wrapped store so its indexes are described
+func (s *LRUStore[T]) Explain(predicates map[string]string) QueryPlan {
+	return Explain(s.Store, predicates)
+}
+
+// ByTags forwards to the wrapped store so its tag index is still used
+func (s *LRUStore[T]) ByTags(tags []string, all bool) []T {
+	return ByTags(s.Store, tags, all)
+}
+
+// TagCounts forwards to the wrapped store so its tag index is still used
+func (s *LRUStore[T]) TagCounts() map[string]int {
+	return TagCounts(s.Store)
+}
+
+// Search forwards to the wrapped store so its text index is still used
+func (s *LRUStore[T]) Search(query string, minScore float64) []Scored[T] {
+	return Search(s.Store, query, minScore)
+}
+
+// ByEmailDomain forwards to the wrapped store so its domain index is still used
+func (s *LRUStore[T]) ByEmailDomain(domain string) []T {
+	return ByEmailDomain(s.Store, domain)
+}
+
+// EmailDomains forwards to the wrapped store so its domain index is still used
+func (s *LRUStore[T]) EmailDomains() map[string]int {
+	return EmailDomains(s.Store)
+}
+
+// CacheStats returns the number of GetByID calls served from the cache and
+// the number that read through to the wrapped store
+func (s *LRUStore[T]) CacheStats() (hits, misses int64) {
+	return s.hits.Load(), s.misses.Load()
+}
+
+// View forwards to the wrapped store so callers still get a consistent view
+func (s *LRUStore[T]) View(fn func(items []T)) {
+	View(s.Store, fn)
+}
+
+// Stats forwards to the wrapped store
+func (s *LRUStore[T]) Stats() Stats {
+	return StatsOf(s.Store)
+}
+
+// add caches data, evicting the least recently used record when full.
+// s.mu must be held.
+func (s *LRUStore[T]) add(id string, data T) {
+	if s.capacity <= 0 {
+		return
+	}
+	if el, ok := s.entries[id]; ok {
+		el.Value.(*lruEntry[T]).data = data
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[id] = s.order.PushFront(&lruEntry[T]{id: id, data: data})
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry[T]).id)
+	}
+}
+
+func (s *LRUStore[T]) invalidate(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	if el, ok := s.entries[id]; ok {
+		s.order.Remove(el)
+		delete(s.entries, id)
+	}
+}
+
+func (s *LRUStore[T]) invalidateAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	s.entries = make(map[string]*list.Element)
+	s.order.Init()
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go-api/models"
+)
+
+// countingStore counts the GetByID calls that reach the wrapped store
+type countingStore[T any] struct {
+	Store[T]
+	reads atomic.Int64
+}
+
+func (s *countingStore[T]) GetByID(id string) (T, bool) {
+	s.reads.Add(1)
+	return s.Store.GetByID(id)
+}
+
+func TestLRUStoreRoundTrip(t *testing.T) {
+	testRoundTrip(t, NewLRUStore[models.Item](NewMemoryStore[models.Item](), 10))
+}
+
+func TestLRUStoreHitRate(t *testing.T) {
+	under := &countingStore[models.Item]{Store: NewMemoryStore[models.Item]()}
+	store := NewLRUStore[models.Item](under, 100)
+	var ids []string
+	for i := range 10 {
+		ids = append(ids, store.Create(models.Item{Name: fmt.Sprintf("item %d", i)}).ID)
+	}
+
+	const readers, rounds = 20, 50
+	var wg sync.WaitGroup
+	var missing atomic.Int64
+	for range readers {
+		wg.Go(func() {
+			for range rounds {
+				for _, id := range ids {
+					if _, ok := store.GetByID(id); !ok {
+						missing.Add(1)
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if missing.Load() != 0 {
+		t.Fatalf("%d reads found nothing", missing.Load())
+	}
+	hits, misses := store.CacheStats()
+	if total := int64(readers * rounds * len(ids)); hits+misses != total {
+		t.Errorf("hits %d + misses %d, want %d reads", hits, misses, total)
+	}
+	if misses != under.reads.Load() {
+		t.Errorf("%d misses but %d reads of the wrapped store", misses, under.reads.Load())
+	}
+	// Only the first read of each ID misses, give or take readers racing
+	// on it before it's cached
+	if misses > int64(readers*len(ids)) {
+		t.Errorf("%d misses for %d IDs", misses, len(ids))
+	}
+	if rate := float64(hits) / float64(hits+misses); rate < 0.9 {
+		t.Errorf("hit rate %.2f, want at least 0.9", rate)
+	}
+}
+
+func TestLRUStoreInvalidates(t *testing.T) {
+	under := &countingStore[models.Item]{Store: NewMemoryStore[models.Item]()}
+	store := NewLRUStore[models.Item](under, 2)
+	a := store.Create(models.Item{Name: "a"})
+	b := store.Create(models.Item{Name: "b"})
+	c := store.Create(models.Item{Name: "c"})
+
+	store.GetByID(a.ID)
+	a.Name = "renamed"
+	if _, err := store.Update(a.ID, a); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetByID(a.ID); got.Name != "renamed" {
+		t.Errorf("Name = %q after Update, want renamed", got.Name)
+	}
+
+	store.GetByID(b.ID)
+	store.Delete(b.ID)
+	if _, ok := store.GetByID(b.ID); ok {
+		t.Error("deleted record still served from the cache")
+	}
+
+	// With a and c cached, caching d evicts a, read least recently
+	store.GetByID(c.ID)
+	store.GetByID(a.ID)
+	before := under.reads.Load()
+	store.GetByID(a.ID)
+	store.GetByID(c.ID)
+	if reads := under.reads.Load() - before; reads != 0 {
+		t.Errorf("%d reads of the wrapped store for cached records", reads)
+	}
+	d := store.Create(models.Item{Name: "d"})
+	store.GetByID(d.ID)
+	before = under.reads.Load()
+	store.GetByID(a.ID)
+	if reads := under.reads.Load() - before; reads != 1 {
+		t.Errorf("the least recently used record was read %d times from the wrapped store, want 1", reads)
+	}
+}
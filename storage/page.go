@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+)
+
+// SortByID orders records by ID
+func SortByID[T any](records []T) {
+	sort.Slice(records, func(i, j int) bool {
+		return idOf(records[i]) < idOf(records[j])
+	})
+}
+
+// GetPage returns up to limit records of store starting at offset, ordered
+// by creation time, along with the total number of records
+func GetPage[T any](store Store[T], offset, limit int) ([]T, int) {
+	var page []T
+	var total int
+	View(store, func(items []T) {
+		page, total = PageOf(items, offset, limit)
+	})
+	return page, total
+}
+
+// PageOf returns up to limit of records starting at offset, ordered by
+// creation time, along with len(records). records is left unchanged.
+func PageOf[T any](records []T, offset, limit int) ([]T, int) {
+	if offset >= len(records) || limit <= 0 {
+		return nil, len(records)
+	}
+	sorted := append([]T(nil), records...)
+	sort.Slice(sorted, func(i, j int) bool {
+		ci, cj := createdAt(sorted[i]), createdAt(sorted[j])
+		if !ci.Equal(cj) {
+			return ci.Before(cj)
+		}
+		return strings.Compare(idOf(sorted[i]), idOf(sorted[j])) < 0
+	})
+	return Window(sorted, offset, limit)
+}
+
+// Window returns up to limit of records starting at offset, in their
+// order, along with len(records)
+func Window[T any](records []T, offset, limit int) ([]T, int) {
+	total := len(records)
+	if offset >= total || limit <= 0 {
+		return nil, total
+	}
+	// offset+limit would overflow for the open-ended limit of math.MaxInt
+	return records[offset : offset+min(limit, total-offset)], total
+}
+
+// FilterByIDs returns the records of store with the given IDs, in the order
+// of ids, with one GetMany call. IDs that don't exist are skipped.
+func FilterByIDs[T any](store Store[T], ids []string) []T {
+	found := store.GetMany(ids)
+	records := make([]T, 0, len(found))
+	for _, id := range ids {
+		if record, ok := found[id]; ok {
+			records = append(records, record)
+		}
+	}
+	return records
+}
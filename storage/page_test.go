@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"go-api/models"
+)
+
+func pageItems(n int) []models.Item {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := make([]models.Item, n)
+	for i := range items {
+		items[i] = models.Item{ID: fmt.Sprintf("item-%02d", i), CreatedAt: base.Add(time.Duration(i) * time.Second)}
+	}
+	return items
+}
+
+func TestPageOf(t *testing.T) {
+	items := pageItems(5)
+	tests := []struct {
+		name          string
+		offset, limit int
+		want          []string
+	}{
+		{"first page", 0, 2, []string{"item-00", "item-01"}},
+		{"last partial page", 4, 2, []string{"item-04"}},
+		{"past the end", 5, 2, nil},
+		{"zero limit", 0, 0, nil},
+		{"open-ended", 1, math.MaxInt, []string{"item-01", "item-02", "item-03", "item-04"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, total := PageOf(items, tt.offset, tt.limit)
+			if total != len(items) {
+				t.Errorf("total = %d, want %d", total, len(items))
+			}
+			var ids []string
+			for _, item := range page {
+				ids = append(ids, item.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("page = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Expirer is implemented by models that should expire from the RedisStore
+type Expirer interface {
+	ExpiresIn() time.Duration
+}
+
+// RedisStore implements Store interface with Redis. Each record is a hash
+// under "{entity}:{id}" holding one JSON-encoded value per field, and the
+// sorted set "{entity}:created_at" keeps IDs in creation order.
+type RedisStore[T any] struct {
+	client *redis.Client
+	entity string
+}
+
+// NewRedisStore creates a store for the given entity type
+func NewRedisStore[T any](client *redis.Client, entity string) *RedisStore[T] {
+	return &RedisStore[T]{client: client, entity: entity}
+}
+
+// GetAll returns all items in creation order
+func (s *RedisStore[T]) GetAll() []T {
+	ctx := context.Background()
+	items := make([]T, 0)
+
+	ids, err := s.client.ZRange(ctx, s.indexKey(), 0, -1).Result()
+	if err != nil {
+		log.Printf("redis: %s: get all: %v", s.entity, err)
+		return items
+	}
+
+	cmds, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			pipe.HGetAll(ctx, s.key(id))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis: %s: get all: %v", s.entity, err)
+		return items
+	}
+
+	var expired []any
+	for i, cmd := range cmds {
+		fields := cmd.(*redis.MapStringStringCmd).Val()
+		if len(fields) == 0 {
+			// The hash expired but its ID is still indexed
+			expired = append(expired, ids[i])
+			continue
+		}
+		item, err := decodeHash[T](fields)
+		if err != nil {
+			log.Printf("redis: %s: decode %s: %v", s.entity, ids[i], err)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if len(expired) > 0 {
+		s.client.ZRem(ctx, s.indexKey(), expired...)
+	}
+	return items
+}
+
+// GetSorted returns every item ordered by fields
+func (s *RedisStore[T]) GetSorted(fields []SortField) []T {
+	items := s.GetAll()
+	SortRecords(items, fields)
+	return items
+}
+
+// GetByID retrieves an item by ID
+func (s *RedisStore[T]) GetByID(id string) (T, bool) {
+	var zero T
+	fields, err := s.client.HGetAll(context.Background(), s.key(id)).Result()
+	if err != nil {
+		log.Printf("redis: %s: get %s: %v", s.entity, id, err)
+		return zero, false
+	}
+	if len(fields) == 0 {
+		return zero, false
+	}
+
+	item, err := decodeHash[T](fields)
+	if err != nil {
+		log.Printf("redis: %s: decode %s: %v", s.entity, id, err)
+		return zero, false
+	}
+	return item, true
+}
+
+// GetMany retrieves the items with the given IDs in one pipeline
+func (s *RedisStore[T]) GetMany(ids []string) map[string]T {
+	ctx := context.Background()
+	found := make(map[string]T, len(ids))
+
+	cmds, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			pipe.HGetAll(ctx, s.key(id))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis: %s: get many: %v", s.entity, err)
+		return found
+	}
+
+	for i, cmd := range cmds {
+		fields := cmd.(*redis.MapStringStringCmd).Val()
+		if len(fields) == 0 {
+			continue
+		}
+		item, err := decodeHash[T](fields)
+		if err != nil {
+			log.Printf("redis: %s: decode %s: %v", s.entity, ids[i], err)
+			continue
+		}
+		found[ids[i]] = item
+	}
+	return found
+}
+
+// Create adds a new item
+func (s *RedisStore[T]) Create(data T) T {
+	created, _ := s.create(data, 0)
+	return created
+}
+
+// TryCreate adds a new item, returning the error Redis fails it with
+func (s *RedisStore[T]) TryCreate(data T) (T, error) {
+	return s.create(data, 0)
+}
+
+// CreateWithTTL adds a new item that Redis expires after ttl
+func (s *RedisStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	return s.create(data, ttl)
+}
+
+// create adds a new item, expiring it after ttl when ttl is positive
+func (s *RedisStore[T]) create(data T, ttl time.Duration) (T, error) {
+	id := stampCreate(&data)
+	if id == "" {
+		return data, nil
+	}
+
+	ctx := context.Background()
+	fields, err := encodeHash(data)
+	if err != nil {
+		log.Printf("redis: %s: encode %s: %v", s.entity, id, err)
+		return data, err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.key(id), fields)
+		pipe.ZAdd(ctx, s.indexKey(), redis.Z{Score: float64(createdAt(data).UnixNano()), Member: id})
+		s.expire(ctx, pipe, id, data)
+		if ttl > 0 {
+			pipe.Expire(ctx, s.key(id), ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis: %s: create %s: %v", s.entity, id, err)
+	}
+	return data, err
+}
+
+// CreateMany adds several items in one transaction
+func (s *RedisStore[T]) CreateMany(data []T) []T {
+	ctx := context.Background()
+	created := make([]T, 0, len(data))
+	hashes := make([]map[string]any, 0, len(data))
+	for _, item := range data {
+		if stampCreate(&item) == "" {
+			continue
+		}
+		fields, err := encodeHash(item)
+		if err != nil {
+			log.Printf("redis: %s: encode %s: %v", s.entity, idOf(item), err)
+			continue
+		}
+		created = append(created, item)
+		hashes = append(hashes, fields)
+	}
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, item := range created {
+			id := idOf(item)
+			pipe.HSet(ctx, s.key(id), hashes[i])
+			pipe.ZAdd(ctx, s.indexKey(), redis.Z{Score: float64(createdAt(item).UnixNano()), Member: id})
+			s.expire(ctx, pipe, id, item)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis: %s: create many: %v", s.entity, err)
+		return nil
+	}
+	return created
+}
+
+// Update modifies an existing item
+func (s *RedisStore[T]) Update(id string, data T) (T, error) {
+	var zero T
+	ctx := context.Background()
+	key := s.key(id)
+
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if len(current) == 0 {
+			return ErrNotFound
+		}
+		old, err := decodeHash[T](current)
+		if err != nil {
+			return err
+		}
+
+		if err := stampUpdate(&data, id, old); err != nil {
+			return err
+		}
+		fields, err := encodeHash(data)
+		if err != nil {
+			return err
+		}
+
+		// Overwriting the fields in place, rather than deleting the key
+		// first, keeps a TTL set by CreateWithTTL; fields the new record
+		// leaves out are removed one by one
+		var stale []string
+		for field := range current {
+			if _, ok := fields[field]; !ok {
+				stale = append(stale, field)
+			}
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if len(stale) > 0 {
+				pipe.HDel(ctx, key, stale...)
+			}
+			pipe.HSet(ctx, key, fields)
+			s.expire(ctx, pipe, id, data)
+			return nil
+		})
+		return err
+	}, key)
+
+	if errors.Is(err, redis.TxFailedErr) {
+		// Another client changed the record after we read it
+		return zero, ErrVersionConflict
+	}
+	if err != nil {
+		return zero, err
+	}
+	return data, nil
+}
+
+// Delete removes an item
+func (s *RedisStore[T]) Delete(id string) bool {
+	_, deleted := s.DeleteAndReturn(id)
+	return deleted
+}
+
+// DeleteAndReturn reads and removes an item in one MULTI transaction, so
+// no write can come between the two
+func (s *RedisStore[T]) DeleteAndReturn(id string) (T, bool) {
+	ctx := context.Background()
+	var zero T
+
+	var get *redis.MapStringStringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.HGetAll(ctx, s.key(id))
+		pipe.Del(ctx, s.key(id))
+		pipe.ZRem(ctx, s.indexKey(), id)
+		return nil
+	})
+	if err != nil {
+		log.Printf("redis: %s: delete %s: %v", s.entity, id, err)
+		return zero, false
+	}
+	if len(get.Val()) == 0 {
+		return zero, false
+	}
+
+	item, err := decodeHash[T](get.Val())
+	if err != nil {
+		log.Printf("redis: %s: decode %s: %v", s.entity, id, err)
+		return zero, true
+	}
+	return item, true
+}
+
+// Clear removes all items and the creation index in one transaction
+func (s *RedisStore[T]) Clear() error {
+	ctx := context.Background()
+	ids, err := s.client.ZRange(ctx, s.indexKey(), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, s.key(id))
+	}
+	keys = append(keys, s.indexKey())
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		return nil
+	})
+	return err
+}
+
+// Replace swaps the store contents for items in one transaction
+func (s *RedisStore[T]) Replace(items []T) error {
+	ctx := context.Background()
+	ids, err := s.client.ZRange(ctx, s.indexKey(), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, s.key(id))
+	}
+	keys = append(keys, s.indexKey())
+
+	hashes := make([]map[string]any, len(items))
+	for i, item := range items {
+		if hashes[i], err = encodeHash(item); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		for i, item := range items {
+			id := idOf(item)
+			pipe.HSet(ctx, s.key(id), hashes[i])
+			pipe.ZAdd(ctx, s.indexKey(), redis.Z{Score: float64(createdAt(item).UnixNano()), Member: id})
+			s.expire(ctx, pipe, id, item)
+		}
+		return nil
+	})
+	return err
+}
+
+// Export writes every record to w; see ExportRecords
+func (s *RedisStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return ExportRecords[T](ctx, s, w)
+}
+
+// Import replaces the records with those read from r; see ImportRecords
+func (s *RedisStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return ImportRecords[T](ctx, s, r)
+}
+
+// Ping reports whether Redis is reachable
+func (s *RedisStore[T]) Ping() error {
+	return s.client.Ping(context.Background()).Err()
+}
+
+func (s *RedisStore[T]) key(id string) string {
+	return s.entity + ":" + id
+}
+
+func (s *RedisStore[T]) indexKey() string {
+	return s.entity + ":created_at"
+}
+
+// expire queues an EXPIRE for models that implement Expirer
+func (s *RedisStore[T]) expire(ctx context.Context, pipe redis.Pipeliner, id string, data T) {
+	if e, ok := any(data).(Expirer); ok {
+		if ttl := e.ExpiresIn(); ttl > 0 {
+			pipe.Expire(ctx, s.key(id), ttl)
+		}
+	}
+}
+
+// encodeHash flattens a record into hash fields holding JSON values
+func encodeHash[T any](data T) (map[string]any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]any, len(fields))
+	for k, v := range fields {
+		out[k] = string(v)
+	}
+	return out, nil
+}
+
+// decodeHash rebuilds a record from the fields written by encodeHash
+func decodeHash[T any](fields map[string]string) (T, error) {
+	var item T
+	obj := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		obj[k] = json.RawMessage(v)
+	}
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return item, err
+	}
+	err = json.Unmarshal(raw, &item)
+	return item, err
+}
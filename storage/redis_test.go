@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"go-api/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newRedisClient returns a client of an in-process Redis server
+func newRedisClient(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func TestRedisStoreRoundTrip(t *testing.T) {
+	client, _ := newRedisClient(t)
+	testRoundTrip(t, NewRedisStore[models.Item](client, "items"))
+}
+
+func TestRedisStoreGetAllInCreationOrder(t *testing.T) {
+	client, _ := newRedisClient(t)
+	store := NewRedisStore[models.Item](client, "items")
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		ids = append(ids, store.Create(models.Item{Name: name}).ID)
+		time.Sleep(time.Millisecond)
+	}
+
+	all := store.GetAll()
+	if len(all) != len(ids) {
+		t.Fatalf("GetAll returned %d items, want %d", len(all), len(ids))
+	}
+	for i, item := range all {
+		if item.ID != ids[i] {
+			t.Errorf("GetAll[%d] = %s (%s), want %s", i, item.ID, item.Name, ids[i])
+		}
+	}
+}
+
+func TestRedisStoreCreateWithTTL(t *testing.T) {
+	client, server := newRedisClient(t)
+	store := NewRedisStore[models.Item](client, "items")
+	created, err := store.CreateWithTTL(models.Item{Name: "widget"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := store.GetByID(created.ID); !exists {
+		t.Fatal("item is gone before its TTL")
+	}
+
+	server.FastForward(2 * time.Minute)
+	if _, exists := store.GetByID(created.ID); exists {
+		t.Error("item is still readable after its TTL")
+	}
+}
+
+func TestRedisStoreUpdateKeepsTTL(t *testing.T) {
+	client, server := newRedisClient(t)
+	store := NewRedisStore[models.Item](client, "items")
+	created, err := store.CreateWithTTL(models.Item{Name: "widget"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(30 * time.Second)
+	if _, err := store.Update(created.ID, models.Item{Name: "gadget"}); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := server.TTL("items:" + created.ID); ttl <= 0 || ttl > 30*time.Second {
+		t.Errorf("TTL after the update = %s, want the 30s left", ttl)
+	}
+
+	server.FastForward(time.Minute)
+	if _, exists := store.GetByID(created.ID); exists {
+		t.Error("updated item is still readable after its TTL")
+	}
+}
+
+func TestRedisStoreUpdateRemovesOmittedFields(t *testing.T) {
+	client, _ := newRedisClient(t)
+	store := NewRedisStore[models.ImportJob](client, "import_jobs")
+	created := store.Create(models.ImportJob{URL: "https://example.com/feed.json", Status: models.ImportFailed, Error: "feed timed out"})
+
+	// Error is omitted from JSON when empty, so its hash field must go
+	if _, err := store.Update(created.ID, models.ImportJob{URL: created.URL, Status: models.ImportPending}); err != nil {
+		t.Fatal(err)
+	}
+	job, _ := store.GetByID(created.ID)
+	if job.Error != "" || job.Status != models.ImportPending {
+		t.Errorf("after the update: status %q, error %q; want pending with no error", job.Status, job.Error)
+	}
+}
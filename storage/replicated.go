@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicatedStore implements Store with a primary MemoryStore taking the
+// writes and a read-only replica serving the reads. After writes, a
+// background goroutine copies the primary into a new map and swaps it in
+// through an atomic pointer, so reads never wait on the write lock. Reads
+// are eventually consistent: they miss writes made in the last Lag.
+type ReplicatedStore[T any] struct {
+	primary  *MemoryStore[T]
+	replica  atomic.Pointer[map[string]T]
+	interval time.Duration
+
+	// dirty wakes the replicator; pendingSince holds the UnixNano time of
+	// the oldest write not yet in the replica, or 0
+	dirty        chan struct{}
+	pendingSince atomic.Int64
+}
+
+// NewReplicatedStore creates an empty replicated store. The replicator waits
+// interval after a write before copying, so a burst of writes is copied
+// once; 0 copies right away.
+func NewReplicatedStore[T any](interval time.Duration) *ReplicatedStore[T] {
+	s := &ReplicatedStore[T]{
+		primary:  NewMemoryStore[T](),
+		interval: interval,
+		dirty:    make(chan struct{}, 1),
+	}
+	s.replica.Store(&map[string]T{})
+	go s.replicate()
+	return s
+}
+
+// Lag returns how long the oldest write missing from the replica has been
+// waiting, or 0 when the replica is up to date
+func (s *ReplicatedStore[T]) Lag() time.Duration {
+	since := s.pendingSince.Load()
+	if since == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, since))
+}
+
+// replicate copies the primary into the replica each time it is written
+func (s *ReplicatedStore[T]) replicate() {
+	for range s.dirty {
+		if s.interval > 0 {
+			time.Sleep(s.interval)
+		}
+		// Writes from here on are caught by the next copy
+		s.pendingSince.Store(0)
+		snapshot := make(map[string]T)
+		s.primary.View(func(items []T) {
+			for _, item := range items {
+				snapshot[idOf(item)] = item
+			}
+		})
+		s.replica.Store(&snapshot)
+	}
+}
+
+// written schedules a copy of the primary after a write
+func (s *ReplicatedStore[T]) written() {
+	s.pendingSince.CompareAndSwap(0, time.Now().UnixNano())
+	select {
+	case s.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// GetAll returns every record of the replica
+func (s *ReplicatedStore[T]) GetAll() []T {
+	replica := *s.replica.Load()
+	items := make([]T, 0, len(replica))
+	for _, item := range replica {
+		items = append(items, item)
+	}
+	return items
+}
+
+// GetSorted returns every record of the replica ordered by fields
+func (s *ReplicatedStore[T]) GetSorted(fields []SortField) []T {
+	items := s.GetAll()
+	SortRecords(items, fields)
+	return items
+}
+
+// GetByID retrieves a record from the replica
+func (s *ReplicatedStore[T]) GetByID(id string) (T, bool) {
+	item, exists := (*s.replica.Load())[id]
+	return item, exists
+}
+
+// GetMany retrieves the records with the given IDs from the replica
+func (s *ReplicatedStore[T]) GetMany(ids []string) map[string]T {
+	replica := *s.replica.Load()
+	found := make(map[string]T, len(ids))
+	for _, id := range ids {
+		if item, exists := replica[id]; exists {
+			found[id] = item
+		}
+	}
+	return found
+}
+
+// View calls fn with every record of the replica
+func (s *ReplicatedStore[T]) View(fn func(items []T)) {
+	fn(s.GetAll())
+}
+
+// Create adds a new record to the primary
+func (s *ReplicatedStore[T]) Create(data T) T {
+	defer s.written()
+	return s.primary.Create(data)
+}
+
+// TryCreate adds a new record to the primary
+func (s *ReplicatedStore[T]) TryCreate(data T) (T, error) {
+	defer s.written()
+	return s.primary.TryCreate(data)
+}
+
+// CreateWithTTL returns ErrTTLUnsupported: the replica would keep serving
+// a record after it expired in the primary
+func (s *ReplicatedStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	var zero T
+	return zero, ErrTTLUnsupported
+}
+
+// CreateMany adds several records to the primary
+func (s *ReplicatedStore[T]) CreateMany(data []T) []T {
+	defer s.written()
+	return s.primary.CreateMany(data)
+}
+
+// Update modifies a record of the primary
+func (s *ReplicatedStore[T]) Update(id string, data T) (T, error) {
+	defer s.written()
+	return s.primary.Update(id, data)
+}
+
+// UpdateWhere updates the matching records of the primary as one change
+func (s *ReplicatedStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	defer s.written()
+	return s.primary.UpdateWhere(match, apply)
+}
+
+// Delete removes a record from the primary
+func (s *ReplicatedStore[T]) Delete(id string) bool {
+	defer s.written()
+	return s.primary.Delete(id)
+}
+
+// DeleteAndReturn removes a record from the primary and returns it
+func (s *ReplicatedStore[T]) DeleteAndReturn(id string) (T, bool) {
+	defer s.written()
+	return s.primary.DeleteAndReturn(id)
+}
+
+// Clear removes every record from the primary
+func (s *ReplicatedStore[T]) Clear() error {
+	defer s.written()
+	return s.primary.Clear()
+}
+
+// Replace swaps the contents of the primary for items
+func (s *ReplicatedStore[T]) Replace(items []T) error {
+	defer s.written()
+	return s.primary.Replace(items)
+}
+
+// Export writes every record to w; see ExportRecords
+func (s *ReplicatedStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return ExportRecords[T](ctx, s, w)
+}
+
+// Import replaces the records with those read from r; see ImportRecords
+func (s *ReplicatedStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return ImportRecords[T](ctx, s, r)
+}
+
+// Stats reports the counters of the primary
+func (s *ReplicatedStore[T]) Stats() Stats {
+	return s.primary.Stats()
+}
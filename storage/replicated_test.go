@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-api/models"
+)
+
+// eventually waits for cond to hold, failing after a second
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: still not true after a second", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReplicatedStoreCatchesUp(t *testing.T) {
+	store := NewReplicatedStore[models.Item](20 * time.Millisecond)
+	item := store.Create(models.Item{Name: "widget"})
+
+	if _, exists := store.GetByID(item.ID); exists {
+		t.Fatal("the replica has the write before the replication interval")
+	}
+	if lag := store.Lag(); lag <= 0 {
+		t.Errorf("Lag = %s with a write pending, want positive", lag)
+	}
+	eventually(t, "the write reached the replica", func() bool {
+		_, exists := store.GetByID(item.ID)
+		return exists
+	})
+	if lag := store.Lag(); lag != 0 {
+		t.Errorf("Lag = %s once replicated, want 0", lag)
+	}
+
+	item.Name = "gadget"
+	if _, err := store.Update(item.ID, item); err != nil {
+		t.Fatal(err)
+	}
+	store.Delete(store.Create(models.Item{Name: "short-lived"}).ID)
+	eventually(t, "the update reached the replica", func() bool {
+		got, _ := store.GetByID(item.ID)
+		return got.Name == "gadget"
+	})
+	if all := store.GetAll(); len(all) != 1 {
+		t.Errorf("GetAll = %+v, want only the updated item", all)
+	}
+}
+
+// benchmarkReadHeavy runs b.N operations from 32 goroutines, one write for
+// every 10 reads
+func benchmarkReadHeavy(b *testing.B, store Store[models.Item]) {
+	const goroutines = 32
+	var ids []string
+	for range 1000 {
+		ids = append(ids, store.Create(models.Item{Name: "widget"}).ID)
+	}
+	var next atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for n := next.Add(1); n <= int64(b.N); n = next.Add(1) {
+				id := ids[n%int64(len(ids))]
+				if n%11 == 0 {
+					store.Update(id, models.Item{Name: "updated", Quantity: int(n)})
+					continue
+				}
+				store.GetByID(id)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func BenchmarkMemoryStoreReadHeavy(b *testing.B) {
+	benchmarkReadHeavy(b, NewMemoryStore[models.Item]())
+}
+
+func BenchmarkReplicatedStoreReadHeavy(b *testing.B) {
+	benchmarkReadHeavy(b, NewReplicatedStore[models.Item](10*time.Millisecond))
+}
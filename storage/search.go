@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"time"
+
+	"go-api/models"
+	"go-api/search"
+)
+
+// Scored is a record matching a text search, with its similarity to the
+// query from 0 to 1
+type Scored[T any] struct {
+	Record T
+	Score  float64
+}
+
+// TextSearcher is implemented by stores that index the text of their
+// records
+type TextSearcher[T any] interface {
+	Search(query string, minScore float64) []Scored[T]
+}
+
+// Search returns the records of store whose text has a trigram similarity
+// of at least minScore with query, best first. Stores that don't implement
+// TextSearcher are scanned.
+func Search[T any](store Store[T], query string, minScore float64) []Scored[T] {
+	if s, ok := store.(TextSearcher[T]); ok {
+		return s.Search(query, minScore)
+	}
+	return searchScan(store.GetAll(), query, minScore)
+}
+
+// textOf returns the searchable text of a record, and false for types
+// without one
+func textOf[T any](data T) (string, bool) {
+	switch v := any(data).(type) {
+	case models.Item:
+		return v.Name + " " + v.Description, true
+	}
+	return "", false
+}
+
+// searchScan scores every record against query
+func searchScan[T any](records []T, query string, minScore float64) []Scored[T] {
+	byID := make(map[string]T, len(records))
+	scores := make([]search.ScoredResult, 0)
+	for _, record := range records {
+		text, ok := textOf(record)
+		if !ok {
+			continue
+		}
+		if score := search.Similarity(query, text); score > 0 && score >= minScore {
+			id := idOf(record)
+			byID[id] = record
+			scores = append(scores, search.ScoredResult{ID: id, Score: score})
+		}
+	}
+	search.SortResults(scores)
+
+	matched := make([]Scored[T], len(scores))
+	for i, result := range scores {
+		matched[i] = Scored[T]{Record: byID[result.ID], Score: result.Score}
+	}
+	return matched
+}
+
+// Search looks the items up in the trigram index, leaving out expired ones
+func (s *MemoryStore[T]) Search(query string, minScore float64) []Scored[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	matched := make([]Scored[T], 0)
+	for _, result := range s.text.Search(query, minScore) {
+		if !s.expired(result.ID, now) {
+			matched = append(matched, Scored[T]{Record: s.items[result.ID], Score: result.Score})
+		}
+	}
+	return matched
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+
+	"go-api/models"
+)
+
+func TestMemoryStoreSearch(t *testing.T) {
+	store := NewMemoryStore[models.Item]()
+	keyboard := store.Create(models.Item{Name: "Keyboard", Description: "mechanical, brown switches"})
+	mouse := store.Create(models.Item{Name: "Mouse", Description: "wireless"})
+
+	first := func(query string) string {
+		t.Helper()
+		results := Search[models.Item](store, query, 0.1)
+		if len(results) == 0 {
+			return ""
+		}
+		return results[0].Record.ID
+	}
+	if got := first("mechanicl keybord"); got != keyboard.ID {
+		t.Errorf("a misspelt name and description found %q, want the keyboard", got)
+	}
+	if got := first("wirless"); got != mouse.ID {
+		t.Errorf("a misspelt description found %q, want the mouse", got)
+	}
+
+	// Updates and deletes keep the index current
+	mouse.Name, mouse.Description = "Trackball", "optical"
+	if _, err := store.Update(mouse.ID, mouse); err != nil {
+		t.Fatal(err)
+	}
+	if got := first("wirless mouse"); got != "" {
+		t.Errorf("the old text still finds %q", got)
+	}
+	if got := first("trakball"); got != mouse.ID {
+		t.Errorf("the new name found %q, want the trackball", got)
+	}
+	store.Delete(keyboard.ID)
+	if got := first("keybord"); got != "" {
+		t.Errorf("a deleted item is still found: %q", got)
+	}
+
+	// The index scores as a scan does
+	indexed := store.Search("trakball opticl", 0)
+	scanned := searchScan(store.GetAll(), "trakball opticl", 0)
+	if len(indexed) != 1 || len(scanned) != 1 || indexed[0].Score != scanned[0].Score {
+		t.Errorf("index found %+v, scan found %+v", indexed, scanned)
+	}
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultShards is the number of shards a ShardedMemoryStore uses when none
+// is given
+const DefaultShards = 16
+
+// ShardedMemoryStore implements Store in memory, spreading records over
+// several shards that each have their own lock, so writers to different
+// records rarely wait on each other
+type ShardedMemoryStore[T any] struct {
+	shards   []shard[T]
+	mask     uint32
+	counters storeCounters
+}
+
+type shard[T any] struct {
+	mu    sync.RWMutex
+	items map[string]T
+}
+
+// NewShardedMemoryStore creates an in-memory store with n shards, rounded up
+// to a power of two. n <= 0 uses DefaultShards.
+func NewShardedMemoryStore[T any](n int) *ShardedMemoryStore[T] {
+	if n <= 0 {
+		n = DefaultShards
+	}
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+
+	s := &ShardedMemoryStore[T]{shards: make([]shard[T], size), mask: uint32(size - 1)}
+	for i := range s.shards {
+		s.shards[i].items = make(map[string]T)
+	}
+	return s
+}
+
+func (s *ShardedMemoryStore[T]) shardFor(id string) *shard[T] {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return &s.shards[h.Sum32()&s.mask]
+}
+
+// rlockAll read-locks every shard, always in the same order, and returns a
+// function releasing them
+func (s *ShardedMemoryStore[T]) rlockAll() func() {
+	for i := range s.shards {
+		s.shards[i].mu.RLock()
+	}
+	return func() {
+		for i := range s.shards {
+			s.shards[i].mu.RUnlock()
+		}
+	}
+}
+
+// lockAll write-locks every shard, always in the same order, and returns a
+// function releasing them
+func (s *ShardedMemoryStore[T]) lockAll() func() {
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+	}
+	return func() {
+		for i := range s.shards {
+			s.shards[i].mu.Unlock()
+		}
+	}
+}
+
+// all returns every record; the caller must hold the read locks
+func (s *ShardedMemoryStore[T]) all() []T {
+	n := 0
+	for i := range s.shards {
+		n += len(s.shards[i].items)
+	}
+	items := make([]T, 0, n)
+	for i := range s.shards {
+		for _, item := range s.shards[i].items {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetAll returns all items
+func (s *ShardedMemoryStore[T]) GetAll() []T {
+	defer s.rlockAll()()
+	return s.all()
+}
+
+// GetSorted returns every item ordered by fields
+func (s *ShardedMemoryStore[T]) GetSorted(fields []SortField) []T {
+	items := s.GetAll()
+	SortRecords(items, fields)
+	return items
+}
+
+// GetByID retrieves an item by ID
+func (s *ShardedMemoryStore[T]) GetByID(id string) (T, bool) {
+	sh := s.shardFor(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	item, exists := sh.items[id]
+	return item, exists
+}
+
+// GetMany retrieves the items with the given IDs. IDs that don't exist are
+// absent from the result.
+func (s *ShardedMemoryStore[T]) GetMany(ids []string) map[string]T {
+	found := make(map[string]T, len(ids))
+	for _, id := range ids {
+		if item, exists := s.GetByID(id); exists {
+			found[id] = item
+		}
+	}
+	return found
+}
+
+// Create adds a new item
+func (s *ShardedMemoryStore[T]) Create(data T) T {
+	id := stampCreate(&data)
+	if id == "" {
+		return data
+	}
+
+	sh := s.shardFor(id)
+	sh.mu.Lock()
+	sh.items[id] = data
+	sh.mu.Unlock()
+
+	s.counters.creates.Add(1)
+	s.counters.totalBytes.Add(sizeOf(data))
+	s.counters.inserts.Add(1)
+	return data
+}
+
+// TryCreate adds a new item; a sharded store never refuses one
+func (s *ShardedMemoryStore[T]) TryCreate(data T) (T, error) {
+	return s.Create(data), nil
+}
+
+// CreateWithTTL returns ErrTTLUnsupported; sharded records don't expire
+func (s *ShardedMemoryStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	var zero T
+	return zero, ErrTTLUnsupported
+}
+
+// CreateMany adds several items
+func (s *ShardedMemoryStore[T]) CreateMany(data []T) []T {
+	created := make([]T, 0, len(data))
+	for _, item := range data {
+		created = append(created, s.Create(item))
+	}
+	return created
+}
+
+// Update modifies an existing item
+func (s *ShardedMemoryStore[T]) Update(id string, data T) (T, error) {
+	sh := s.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	var zero T
+	old, exists := sh.items[id]
+	if !exists {
+		return zero, ErrNotFound
+	}
+
+	if err := stampUpdate(&data, id, old); err != nil {
+		return zero, err
+	}
+	sh.items[id] = data
+	s.counters.updates.Add(1)
+	s.counters.totalBytes.Add(sizeOf(data) - sizeOf(old))
+
+	return data, nil
+}
+
+// Delete removes an item
+func (s *ShardedMemoryStore[T]) Delete(id string) bool {
+	_, deleted := s.DeleteAndReturn(id)
+	return deleted
+}
+
+// DeleteAndReturn removes an item under its shard's lock and returns it
+func (s *ShardedMemoryStore[T]) DeleteAndReturn(id string) (T, bool) {
+	sh := s.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	old, exists := sh.items[id]
+	if !exists {
+		var zero T
+		return zero, false
+	}
+
+	delete(sh.items, id)
+	s.counters.deletes.Add(1)
+	s.counters.totalBytes.Add(-sizeOf(old))
+	return old, true
+}
+
+// Clear removes all items
+func (s *ShardedMemoryStore[T]) Clear() error {
+	defer s.lockAll()()
+
+	for i := range s.shards {
+		s.shards[i].items = make(map[string]T)
+	}
+	s.counters.totalBytes.Store(0)
+	return nil
+}
+
+// Replace swaps the store contents for items, keeping their IDs and timestamps
+func (s *ShardedMemoryStore[T]) Replace(items []T) error {
+	replaced := make([]map[string]T, len(s.shards))
+	for i := range replaced {
+		replaced[i] = make(map[string]T)
+	}
+	var total int64
+	h := fnv.New32a()
+	for _, item := range items {
+		id := idOf(item)
+		h.Reset()
+		h.Write([]byte(id))
+		replaced[h.Sum32()&s.mask][id] = item
+		total += sizeOf(item)
+	}
+
+	defer s.lockAll()()
+
+	for i := range s.shards {
+		s.shards[i].items = replaced[i]
+	}
+	s.counters.totalBytes.Store(total)
+	return nil
+}
+
+// Export writes every record to w; see ExportRecords
+func (s *ShardedMemoryStore[T]) Export(ctx context.Context, w io.Writer) error {
+	return ExportRecords[T](ctx, s, w)
+}
+
+// Import replaces the records with those read from r; see ImportRecords
+func (s *ShardedMemoryStore[T]) Import(ctx context.Context, r io.Reader) error {
+	return ImportRecords[T](ctx, s, r)
+}
+
+// View calls fn with all items while holding every shard's read lock
+func (s *ShardedMemoryStore[T]) View(fn func(items []T)) {
+	defer s.rlockAll()()
+	fn(s.all())
+}
+
+// Stats reports the record count, average record size and mutation counters
+func (s *ShardedMemoryStore[T]) Stats() Stats {
+	unlock := s.rlockAll()
+	items := s.all()
+	unlock()
+
+	return Stats{
+		Count:             len(items),
+		AvgSizeBytes:      sampleSize(items),
+		TotalBytes:        s.counters.totalBytes.Load(),
+		Creates:           s.counters.creates.Load(),
+		Updates:           s.counters.updates.Load(),
+		Deletes:           s.counters.deletes.Load(),
+		InsertsLastMinute: s.counters.inserts.Sum(),
+	}
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+
+	"go-api/models"
+)
+
+func TestShardedMemoryStoreRoundTrip(t *testing.T) {
+	testRoundTrip(t, NewShardedMemoryStore[models.Item](0))
+}
+
+func TestShardedMemoryStoreRoundsShardsUp(t *testing.T) {
+	for n, want := range map[int]int{0: DefaultShards, 1: 1, 5: 8, 16: 16} {
+		if got := len(NewShardedMemoryStore[models.Item](n).shards); got != want {
+			t.Errorf("NewShardedMemoryStore(%d) has %d shards, want %d", n, got, want)
+		}
+	}
+}
+
+// benchmarkWriters creates b.N items in store from 16 concurrent writers,
+// each updating the item it created
+func benchmarkWriters(b *testing.B, store Store[models.Item]) {
+	const writers = 16
+	b.ReportAllocs()
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Go(func() {
+			for i := w; i < b.N; i += writers {
+				item := store.Create(models.Item{Name: "widget", Quantity: i})
+				item.Quantity++
+				if _, err := store.Update(item.ID, item); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func BenchmarkMemoryStoreWriters(b *testing.B) {
+	benchmarkWriters(b, NewMemoryStore[models.Item]())
+}
+
+func BenchmarkShardedMemoryStoreWriters(b *testing.B) {
+	benchmarkWriters(b, NewShardedMemoryStore[models.Item](DefaultShards))
+}
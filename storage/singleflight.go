@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// SingleFlightStore wraps a Store so concurrent GetByID calls for the same
+// ID share a single read of the wrapped store. Every other method passes
+// straight through.
+type SingleFlightStore[T any] struct {
+	Store[T]
+	group singleflight.Group
+
+	calls atomic.Int64
+	reads atomic.Int64
+}
+
+// NewSingleFlightStore creates a store coalescing concurrent reads of store
+func NewSingleFlightStore[T any](store Store[T]) *SingleFlightStore[T] {
+	return &SingleFlightStore[T]{Store: store}
+}
+
+type flightResult[T any] struct {
+	item   T
+	exists bool
+}
+
+// GetByID retrieves an item by ID, joining a read already in flight for it
+func (s *SingleFlightStore[T]) GetByID(id string) (T, bool) {
+	s.calls.Add(1)
+	v, _, _ := s.group.Do(id, func() (any, error) {
+		s.reads.Add(1)
+		item, exists := s.Store.GetByID(id)
+		return flightResult[T]{item: item, exists: exists}, nil
+	})
+	res := v.(flightResult[T])
+	return res.item, res.exists
+}
+
+// Range forwards to the wrapped store so records still aren't collected into a slice
+func (s *SingleFlightStore[T]) Range(fn func(T) bool) {
+	Range(s.Store, fn)
+}
+
+// StreamAll forwards to the wrapped store so records are still streamed
+func (s *SingleFlightStore[T]) StreamAll(ctx context.Context, out chan<- T) {
+	StreamAll(ctx, s.Store, out)
+}
+
+// UpdateWhere forwards to the wrapped store so batches stay all-or-nothing
+func (s *SingleFlightStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	return UpdateWhere(s.Store, match, apply)
+}
+
+// Filter forwards to the wrapped store so its indexes are still used
+func (s *SingleFlightStore[T]) Filter(query map[string]string) ([]T, error) {
+	return Filter(s.Store, query)
+}
+
+// Explain forwards to the wrapped store so its indexes are described
+func (s *SingleFlightStore[T]) Explain(predicates map[string]string) QueryPlan {
+	return Explain(s.Store, predicates)
+}
+
+// ByTags forwards to the wrapped store so its tag index is still used
+func (s *SingleFlightStore[T]) ByTags(tags []string, all bool) []T {
+	return ByTags(s.Store, tags, all)
+}
+
+// TagCounts forwards to the wrapped store so its tag index is still used
+func (s *SingleFlightStore[T]) TagCounts() map[string]int {
+	return TagCounts(s.Store)
+}
+
+// Search forwards to the wrapped store so its text index is still used
+func (s *SingleFlightStore[T]) Search(query string, minScore float64) []Scored[T] {
+	return Search(s.Store, query, minScore)
+}
+
+// ByEmailDomain forwards to the wrapped store so its domain index is still used
+func (s *SingleFlightStore[T]) ByEmailDomain(domain string) []T {
+	return ByEmailDomain(s.Store, domain)
+}
+
+// EmailDomains forwards to the wrapped store so its domain index is still used
+func (s *SingleFlightStore[T]) EmailDomains() map[string]int {
+	return EmailDomains(s.Store)
+}
+
+// DedupStats reports how many GetByID calls were made and how many of them
+// were answered by another caller's read
+func (s *SingleFlightStore[T]) DedupStats() (calls, deduped int64) {
+	reads := s.reads.Load()
+	calls = s.calls.Load()
+	return calls, calls - reads
+}
+
+// View forwards to the wrapped store so callers still get a consistent view
+func (s *SingleFlightStore[T]) View(fn func(items []T)) {
+	View(s.Store, fn)
+}
+
+// Stats forwards to the wrapped store
+func (s *SingleFlightStore[T]) Stats() Stats {
+	return StatsOf(s.Store)
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go-api/models"
+)
+
+// blockingStore holds every GetByID until release is closed
+type blockingStore[T any] struct {
+	countingStore[T]
+	release chan struct{}
+}
+
+func (s *blockingStore[T]) GetByID(id string) (T, bool) {
+	<-s.release
+	return s.countingStore.GetByID(id)
+}
+
+func TestSingleFlightStoreCoalescesReads(t *testing.T) {
+	under := &blockingStore[models.Item]{countingStore: countingStore[models.Item]{Store: NewMemoryStore[models.Item]()}, release: make(chan struct{})}
+	item := under.Create(models.Item{Name: "widget"})
+	store := NewSingleFlightStore[models.Item](under)
+
+	const callers = 50
+	results := make([]models.Item, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Go(func() {
+			results[i], _ = store.GetByID(item.ID)
+		})
+	}
+	// Let every caller join the read before it finishes
+	deadline := time.Now().Add(time.Second)
+	for calls, _ := store.DedupStats(); calls < callers; calls, _ = store.DedupStats() {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d callers started", calls, callers)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(under.release)
+	wg.Wait()
+
+	if reads := under.reads.Load(); reads != 1 {
+		t.Errorf("the wrapped store was read %d times, want once", reads)
+	}
+	for i, got := range results {
+		if got.ID != item.ID || got.Name != "widget" {
+			t.Fatalf("caller %d got %+v", i, got)
+		}
+	}
+	if calls, deduped := store.DedupStats(); calls != callers || deduped != callers-1 {
+		t.Errorf("DedupStats = %d, %d; want %d, %d", calls, deduped, callers, callers-1)
+	}
+
+	// A later read isn't answered by the finished one
+	store.GetByID(item.ID)
+	if reads := under.reads.Load(); reads != 2 {
+		t.Errorf("after one more call the wrapped store was read %d times, want 2", reads)
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SortField is one level of a sort: a JSON field name and its direction
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// SortableFields returns the JSON names of the fields of T that records can
+// be sorted by, with their struct field index
+func SortableFields[T any]() map[string]int {
+	t := reflect.TypeFor[T]()
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.String, reflect.Bool,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Float32, reflect.Float64:
+			fields[name] = i
+		case reflect.Struct:
+			if f.Type == timeType {
+				fields[name] = i
+			}
+		}
+	}
+	return fields
+}
+
+// SortRecords orders records by fields, each level breaking ties of the one
+// before; records still tied are ordered by ID. Fields T can't be sorted by
+// are ignored. Stores implement GetSorted with it.
+func SortRecords[T any](records []T, fields []SortField) {
+	sortable := SortableFields[T]()
+	type level struct {
+		index int
+		desc  bool
+	}
+	levels := make([]level, 0, len(fields))
+	for _, f := range fields {
+		if i, ok := sortable[f.Field]; ok {
+			levels = append(levels, level{index: i, desc: f.Desc})
+		}
+	}
+
+	slices.SortFunc(records, func(a, b T) int {
+		va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+		for _, l := range levels {
+			c := compareValues(va.Field(l.index), vb.Field(l.index))
+			if l.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(idOf(a), idOf(b))
+	})
+}
+
+// compareValues compares two values of a sortable field
+func compareValues(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmp.Compare(boolRank(a.Bool()), boolRank(b.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.Struct:
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time))
+	}
+	return 0
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+
+	"go-api/models"
+)
+
+func TestGetSorted(t *testing.T) {
+	store := NewMemoryStore[models.Item]()
+	store.Replace([]models.Item{
+		{ID: "1", Name: "bolt", Status: "published", Quantity: 5},
+		{ID: "2", Name: "axle", Status: "draft", Quantity: 5},
+		{ID: "3", Name: "cog", Status: "published", Quantity: 9},
+		{ID: "4", Name: "axle", Status: "draft", Quantity: 1},
+		{ID: "5", Name: "dial", Status: "archived", Quantity: 5},
+		{ID: "6", Name: "bolt", Status: "published", Quantity: 5},
+	})
+
+	tests := []struct {
+		name   string
+		fields []SortField
+		want   []string
+	}{
+		{"no fields sorts by ID", nil, []string{"1", "2", "3", "4", "5", "6"}},
+		{"ascending", []SortField{{Field: "name"}}, []string{"2", "4", "1", "6", "3", "5"}},
+		{"descending", []SortField{{Field: "quantity", Desc: true}}, []string{"3", "1", "2", "5", "6", "4"}},
+		{"ties fall through to the next level", []SortField{{Field: "status"}, {Field: "quantity", Desc: true}}, []string{"5", "2", "4", "3", "1", "6"}},
+		{"three levels", []SortField{{Field: "status", Desc: true}, {Field: "name"}, {Field: "quantity"}}, []string{"1", "6", "3", "4", "2", "5"}},
+		{"unknown fields are ignored", []SortField{{Field: "tags"}, {Field: "nope"}, {Field: "name", Desc: true}}, []string{"5", "3", "1", "6", "2", "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range store.GetSorted(tt.fields) {
+				got = append(got, item.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
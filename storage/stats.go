@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes the contents and recent activity of a store
+type Stats struct {
+	Count             int   `json:"count"`
+	AvgSizeBytes      int   `json:"avg_size_bytes"`
+	TotalBytes        int64 `json:"total_bytes,omitempty"`
+	Creates           int64 `json:"creates"`
+	Updates           int64 `json:"updates"`
+	Deletes           int64 `json:"deletes"`
+	InsertsLastMinute int64 `json:"inserts_last_minute"`
+}
+
+// StatsReporter is implemented by stores that track their own activity
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// StatsOf returns the stats of store. Stores that don't track activity
+// report only their record count and average record size.
+func StatsOf[T any](store Store[T]) Stats {
+	if sr, ok := store.(StatsReporter); ok {
+		return sr.Stats()
+	}
+	var stats Stats
+	View(store, func(items []T) {
+		stats.Count = len(items)
+		stats.AvgSizeBytes = sampleSize(items)
+	})
+	return stats
+}
+
+// sampleSize returns the average JSON size of every tenth record
+func sampleSize[T any](items []T) int {
+	var total, sampled int
+	for i := 0; i < len(items); i += 10 {
+		data, err := json.Marshal(items[i])
+		if err != nil {
+			continue
+		}
+		total += len(data)
+		sampled++
+	}
+	if sampled == 0 {
+		return 0
+	}
+	return total / sampled
+}
+
+// sizeOf returns the JSON size of a record
+func sizeOf[T any](data T) int64 {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return 0
+	}
+	return int64(len(b))
+}
+
+// storeCounters tracks the mutations of a store
+type storeCounters struct {
+	creates    atomic.Int64
+	updates    atomic.Int64
+	deletes    atomic.Int64
+	totalBytes atomic.Int64
+	inserts    rollingWindow
+}
+
+// rollingWindow counts events over the last minute in one-second buckets
+type rollingWindow struct {
+	mu      sync.Mutex
+	buckets [60]int64
+	seconds [60]int64
+}
+
+// Add records n events now
+func (w *rollingWindow) Add(n int64) {
+	now := time.Now().Unix()
+	i := now % int64(len(w.buckets))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.seconds[i] != now {
+		w.seconds[i] = now
+		w.buckets[i] = 0
+	}
+	w.buckets[i] += n
+}
+
+// Sum returns the number of events recorded in the last minute
+func (w *rollingWindow) Sum() int64 {
+	now := time.Now().Unix()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var sum int64
+	for i, sec := range w.seconds {
+		if now-sec < int64(len(w.buckets)) {
+			sum += w.buckets[i]
+		}
+	}
+	return sum
+}
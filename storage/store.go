@@ -1,10 +1,14 @@
 package storage
 
 import (
+	"context"
+	"io"
+	"log"
 	"sync"
 	"time"
 
 	"go-api/models"
+	"go-api/search"
 
 	"github.com/google/uuid"
 )
@@ -12,22 +16,109 @@ import (
 // Store interface defines the contract for data storage
 type Store[T any] interface {
 	GetAll() []T
+	// GetSorted returns every record ordered by fields; see SortRecords
+	GetSorted(fields []SortField) []T
 	GetByID(id string) (T, bool)
+	GetMany(ids []string) map[string]T
 	Create(data T) T
-	Update(id string, data T) (T, bool)
+	// TryCreate is Create reporting why data could not be added, such as
+	// ErrStoreFull
+	TryCreate(data T) (T, error)
+	// CreateWithTTL is TryCreate for a record that disappears after ttl.
+	// Stores that can't expire records return ErrTTLUnsupported.
+	CreateWithTTL(data T, ttl time.Duration) (T, error)
+	CreateMany(data []T) []T
+	Update(id string, data T) (T, error)
 	Delete(id string) bool
+	// DeleteAndReturn is Delete returning the record as it was removed, in
+	// one atomic step
+	DeleteAndReturn(id string) (T, bool)
+	Clear() error
+	Replace(items []T) error
+	// Export writes every record to w as newline-delimited JSON
+	Export(ctx context.Context, w io.Writer) error
+	// Import replaces every record with the newline-delimited JSON records
+	// read from r, keeping their IDs and timestamps. Nothing is replaced
+	// when a record can't be decoded.
+	Import(ctx context.Context, r io.Reader) error
+}
+
+// Pinger is implemented by stores that can report the health of their backend
+type Pinger interface {
+	Ping() error
+}
+
+// ReadLocker is implemented by stores that can run a callback against a
+// consistent view of all their records
+type ReadLocker[T any] interface {
+	View(fn func(items []T))
+}
+
+// View calls fn with every record in store. Stores implementing ReadLocker
+// keep their read lock held while fn runs; others fall back to GetAll.
+func View[T any](store Store[T], fn func(items []T)) {
+	if rl, ok := store.(ReadLocker[T]); ok {
+		rl.View(fn)
+		return
+	}
+	fn(store.GetAll())
+}
+
+// LastModifier is implemented by stores that can tell when their records
+// last changed without returning them
+type LastModifier interface {
+	LastModified() time.Time
+}
+
+// LastModified returns the latest UpdatedAt of the records in store, or
+// the zero time when it is empty or T has no UpdatedAt. Stores that don't
+// implement LastModifier fall back to GetAll.
+func LastModified[T any](store Store[T]) time.Time {
+	if lm, ok := store.(LastModifier); ok {
+		return lm.LastModified()
+	}
+	return latestUpdate(store.GetAll())
+}
+
+// latestUpdate returns the latest UpdatedAt of records
+func latestUpdate[T any](records []T) time.Time {
+	var latest time.Time
+	for _, record := range records {
+		if t := updatedAt(record); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
 }
 
 // MemoryStore implements Store interface with in-memory storage
 type MemoryStore[T any] struct {
-	mu    sync.RWMutex
-	items map[string]T
+	mu       sync.RWMutex
+	items    map[string]T
+	counters storeCounters
+	// expiry holds when each item created with a TTL expires
+	expiry map[string]time.Time
+	// onExpire runs with each expired item once it is removed
+	onExpire []func(T)
+	// tags indexes the tags of the items, domains their email domains and
+	// text their searchable text
+	tags    tagIndex
+	domains tagIndex
+	text    *search.TrigramIndex
+
+	// capacity caps the number of records; 0 means unbounded
+	capacity int
+	policy   EvictionPolicy[T]
 }
 
 // NewMemoryStore creates a new in-memory store
 func NewMemoryStore[T any]() *MemoryStore[T] {
 	return &MemoryStore[T]{
-		items: make(map[string]T),
+		items:   make(map[string]T),
+		expiry:  make(map[string]time.Time),
+		tags:    make(tagIndex),
+		domains: make(tagIndex),
+		text:    search.NewTrigramIndex(),
 	}
 }
 
@@ -36,10 +127,14 @@ func (s *MemoryStore[T]) GetAll() []T {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	items := make([]T, 0, len(s.items))
-	for _, item := range s.items {
-		items = append(items, item)
-	}
+	items := s.live()
+	return items
+}
+
+// GetSorted returns every item ordered by fields
+func (s *MemoryStore[T]) GetSorted(fields []SortField) []T {
+	items := s.GetAll()
+	SortRecords(items, fields)
 	return items
 }
 
@@ -49,71 +144,506 @@ func (s *MemoryStore[T]) GetByID(id string) (T, bool) {
 	defer s.mu.RUnlock()
 
 	item, exists := s.items[id]
-	return item, exists
+	if !exists || s.expired(id, time.Now()) {
+		var zero T
+		return zero, false
+	}
+	s.touch(id)
+	return item, true
+}
+
+// GetMany retrieves the items with the given IDs under a single read lock.
+// IDs that don't exist are absent from the result.
+func (s *MemoryStore[T]) GetMany(ids []string) map[string]T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	found := make(map[string]T, len(ids))
+	for _, id := range ids {
+		if item, exists := s.items[id]; exists && !s.expired(id, now) {
+			found[id] = item
+			s.touch(id)
+		}
+	}
+	return found
 }
 
-// Create adds a new item
+// Create adds a new item. A full bounded store without an eviction policy
+// logs the failure and returns the zero value; use TryCreate to get the error.
 func (s *MemoryStore[T]) Create(data T) T {
+	created, err := s.TryCreate(data)
+	if err != nil {
+		log.Printf("WARN: memory store: create: %v", err)
+	}
+	return created
+}
+
+// TryCreate adds a new item, or returns ErrStoreFull when a bounded store
+// has no room for it
+func (s *MemoryStore[T]) TryCreate(data T) (T, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Use reflection to set ID and timestamps for models
-	switch v := any(&data).(type) {
-	case *models.Item:
-		v.ID = uuid.New().String()
-		v.CreatedAt = time.Now()
-		v.UpdatedAt = time.Now()
-		s.items[v.ID] = any(*v).(T)
-	case *models.Client:
-		v.ID = uuid.New().String()
-		v.CreatedAt = time.Now()
-		v.UpdatedAt = time.Now()
-		s.items[v.ID] = any(*v).(T)
+	if id := stampCreate(&data); id != "" {
+		if err := s.insert(id, data); err != nil {
+			var zero T
+			return zero, err
+		}
 	}
 
-	return data
+	return data, nil
+}
+
+// CreateMany adds several items under a single write lock. Items that don't
+// fit in a full bounded store are left out of the result.
+func (s *MemoryStore[T]) CreateMany(data []T) []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := make([]T, 0, len(data))
+	for _, item := range data {
+		if id := stampCreate(&item); id != "" {
+			if err := s.insert(id, item); err != nil {
+				log.Printf("WARN: memory store: create many: %v", err)
+				break
+			}
+		}
+		created = append(created, item)
+	}
+	return created
+}
+
+// insert stores a new record, evicting one first when the store is at
+// capacity. An expired record the janitor hasn't removed yet under the same
+// ID is expired first, so its OnExpire hooks still run. The caller must hold
+// the write lock.
+func (s *MemoryStore[T]) insert(id string, data T) error {
+	if s.expired(id, time.Now()) {
+		s.expire(id)
+	}
+	if s.capacity > 0 && len(s.items) >= s.capacity {
+		s.sweep(time.Now())
+	}
+	if s.capacity > 0 && len(s.items) >= s.capacity {
+		if s.policy == nil {
+			return ErrStoreFull
+		}
+		victim := s.policy.Evict(s.items)
+		old, exists := s.items[victim]
+		if !exists {
+			return ErrStoreFull
+		}
+		delete(s.items, victim)
+		s.reindex(victim, &old, nil)
+		s.forget(victim)
+		s.counters.totalBytes.Add(-sizeOf(old))
+		log.Printf("WARN: memory store: at capacity (%d), evicted %s", s.capacity, victim)
+	}
+
+	s.items[id] = data
+	s.reindex(id, nil, &data)
+	s.countCreate(data)
+	s.touch(id)
+	return nil
 }
 
 // Update modifies an existing item
-func (s *MemoryStore[T]) Update(id string, data T) (T, bool) {
+func (s *MemoryStore[T]) Update(id string, data T) (T, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.items[id]; !exists {
-		var zero T
-		return zero, false
+	var zero T
+	old, exists := s.items[id]
+	if !exists || s.expired(id, time.Now()) {
+		return zero, ErrNotFound
 	}
 
-	// Preserve ID and CreatedAt, update UpdatedAt
-	switch v := any(&data).(type) {
-	case *models.Item:
-		old := s.items[id]
-		oldItem := any(old).(models.Item)
-		v.ID = id
-		v.CreatedAt = oldItem.CreatedAt
-		v.UpdatedAt = time.Now()
-		s.items[id] = any(*v).(T)
-	case *models.Client:
-		old := s.items[id]
-		oldClient := any(old).(models.Client)
-		v.ID = id
-		v.CreatedAt = oldClient.CreatedAt
-		v.UpdatedAt = time.Now()
-		s.items[id] = any(*v).(T)
+	if err := stampUpdate(&data, id, old); err != nil {
+		return zero, err
 	}
+	s.items[id] = data
+	s.reindex(id, &old, &data)
+	s.touch(id)
+	s.counters.updates.Add(1)
+	s.counters.totalBytes.Add(sizeOf(data) - sizeOf(old))
 
-	return data, true
+	return data, nil
 }
 
 // Delete removes an item
 func (s *MemoryStore[T]) Delete(id string) bool {
+	_, deleted := s.DeleteAndReturn(id)
+	return deleted
+}
+
+// DeleteAndReturn removes an item and returns it
+func (s *MemoryStore[T]) DeleteAndReturn(id string) (T, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.items[id]; !exists {
-		return false
+	var zero T
+	old, exists := s.items[id]
+	if !exists {
+		return zero, false
+	}
+	if s.expired(id, time.Now()) {
+		s.sweep(time.Now())
+		return zero, false
 	}
 
 	delete(s.items, id)
-	return true
+	delete(s.expiry, id)
+	s.reindex(id, &old, nil)
+	s.forget(id)
+	s.counters.deletes.Add(1)
+	s.counters.totalBytes.Add(-sizeOf(old))
+	return old, true
+}
+
+// Clear removes all items
+func (s *MemoryStore[T]) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]T)
+	s.expiry = make(map[string]time.Time)
+	s.tags = make(tagIndex)
+	s.domains = make(tagIndex)
+	s.text = search.NewTrigramIndex()
+	s.resetAccess()
+	s.counters.totalBytes.Store(0)
+	return nil
+}
+
+// Replace swaps the store contents for items, keeping their IDs and timestamps
+func (s *MemoryStore[T]) Replace(items []T) error {
+	replaced := make(map[string]T, len(items))
+	var total int64
+	for _, item := range items {
+		replaced[idOf(item)] = item
+		total += sizeOf(item)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = replaced
+	s.expiry = make(map[string]time.Time)
+	s.rebuildIndexes()
+	s.resetAccess()
+	s.counters.totalBytes.Store(total)
+	return nil
+}
+
+// Put stores data under its own ID, replacing the item with that ID if
+// there is one
+func (s *MemoryStore[T]) Put(data T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idOf(data)
+	if id == "" {
+		return nil
+	}
+	old, exists := s.items[id]
+	if !exists {
+		return s.insert(id, data)
+	}
+	s.items[id] = data
+	s.reindex(id, &old, &data)
+	delete(s.expiry, id)
+	s.touch(id)
+	s.counters.totalBytes.Add(sizeOf(data) - sizeOf(old))
+	return nil
+}
+
+// View calls fn with all items while holding the read lock
+func (s *MemoryStore[T]) View(fn func(items []T)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.live()
+	fn(items)
+}
+
+// Stats reports the record count, average record size and mutation counters
+func (s *MemoryStore[T]) Stats() Stats {
+	s.mu.RLock()
+	items := s.live()
+	s.mu.RUnlock()
+
+	return Stats{
+		Count:             len(items),
+		AvgSizeBytes:      sampleSize(items),
+		TotalBytes:        s.counters.totalBytes.Load(),
+		Creates:           s.counters.creates.Load(),
+		Updates:           s.counters.updates.Load(),
+		Deletes:           s.counters.deletes.Load(),
+		InsertsLastMinute: s.counters.inserts.Sum(),
+	}
+}
+
+// live returns every item that hasn't expired. The caller must hold a lock.
+func (s *MemoryStore[T]) live() []T {
+	now := time.Now()
+	items := make([]T, 0, len(s.items))
+	for id, item := range s.items {
+		if !s.expired(id, now) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// LastModified returns the latest UpdatedAt of the live items, scanning
+// them under the read lock
+func (s *MemoryStore[T]) LastModified() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	var latest time.Time
+	for id, item := range s.items {
+		if t := updatedAt(item); t.After(latest) && !s.expired(id, now) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+func (s *MemoryStore[T]) countCreate(data T) {
+	s.counters.creates.Add(1)
+	s.counters.totalBytes.Add(sizeOf(data))
+	s.counters.inserts.Add(1)
+}
+
+// stampCreate assigns a new ID and timestamps to a record and returns the ID.
+// It returns "" for types it doesn't know how to stamp.
+func stampCreate[T any](data *T) string {
+	now := time.Now()
+	switch v := any(data).(type) {
+	case *models.Item:
+		v.ID = uuid.New().String()
+		if v.Status == "" {
+			v.Status = models.StatusDraft
+		}
+		v.Version = 1
+		v.CreatedAt = now
+		v.UpdatedAt = now
+		return v.ID
+	case *models.Client:
+		v.ID = uuid.New().String()
+		if v.Status == "" {
+			v.Status = models.ClientActive
+		}
+		v.Version = 1
+		v.CreatedAt = now
+		v.UpdatedAt = now
+		return v.ID
+	case *models.Contact:
+		v.ID = uuid.New().String()
+		v.Version = 1
+		v.CreatedAt = now
+		v.UpdatedAt = now
+		return v.ID
+	case *models.DeadLetter:
+		v.ID = uuid.New().String()
+		v.CreatedAt = now
+		v.UpdatedAt = now
+		return v.ID
+	case *models.Ledger:
+		v.ID = uuid.New().String()
+		v.CreatedAt = now
+		return v.ID
+	case *models.ClientStatusEvent:
+		v.ID = uuid.New().String()
+		v.OccurredAt = now
+		return v.ID
+	case *models.ExportJob:
+		v.ID = uuid.New().String()
+		v.CreatedAt = now
+		v.UpdatedAt = now
+		return v.ID
+	case *models.ImportJob:
+		v.ID = uuid.New().String()
+		v.CreatedAt = now
+		v.UpdatedAt = now
+		return v.ID
+	case *models.Reservation:
+		// Callers pick reservation IDs so that retries are idempotent
+		if v.ID == "" {
+			v.ID = uuid.New().String()
+		}
+		v.CreatedAt = now
+		v.UpdatedAt = now
+		return v.ID
+	}
+	return ""
+}
+
+// idOf returns the ID of a record, or "" for unknown types
+func idOf[T any](data T) string {
+	switch v := any(data).(type) {
+	case models.Item:
+		return v.ID
+	case models.Client:
+		return v.ID
+	case models.Contact:
+		return v.ID
+	case models.DeadLetter:
+		return v.ID
+	case models.Ledger:
+		return v.ID
+	case models.ClientStatusEvent:
+		return v.ID
+	case models.ExportJob:
+		return v.ID
+	case models.ImportJob:
+		return v.ID
+	case models.Reservation:
+		return v.ID
+	}
+	return ""
+}
+
+// createdAt returns the creation time of a record, or the zero time for unknown types
+func createdAt[T any](data T) time.Time {
+	switch v := any(data).(type) {
+	case models.Item:
+		return v.CreatedAt
+	case models.Client:
+		return v.CreatedAt
+	case models.Contact:
+		return v.CreatedAt
+	case models.DeadLetter:
+		return v.CreatedAt
+	case models.Ledger:
+		return v.CreatedAt
+	case models.ClientStatusEvent:
+		return v.OccurredAt
+	case models.ExportJob:
+		return v.CreatedAt
+	case models.ImportJob:
+		return v.CreatedAt
+	case models.Reservation:
+		return v.CreatedAt
+	}
+	return time.Time{}
+}
+
+// updatedAt returns the last modification time of a record, or the zero
+// time for unknown types
+func updatedAt[T any](data T) time.Time {
+	switch v := any(data).(type) {
+	case models.Item:
+		return v.UpdatedAt
+	case models.Client:
+		return v.UpdatedAt
+	case models.Contact:
+		return v.UpdatedAt
+	case models.DeadLetter:
+		return v.UpdatedAt
+	case models.Reservation:
+		return v.UpdatedAt
+	case models.ExportJob:
+		return v.UpdatedAt
+	case models.ImportJob:
+		return v.UpdatedAt
+	}
+	return time.Time{}
+}
+
+// tenantOf returns the tenant ID of a record
+func tenantOf[T any](data T) string {
+	switch v := any(data).(type) {
+	case models.Item:
+		return v.TenantID
+	case models.Client:
+		return v.TenantID
+	case models.Contact:
+		return v.TenantID
+	case models.Reservation:
+		return v.TenantID
+	case models.ExportJob:
+		return v.TenantID
+	case models.ImportJob:
+		return v.TenantID
+	}
+	return ""
+}
+
+// setTenant assigns a record to a tenant
+func setTenant[T any](data *T, tenantID string) {
+	switch v := any(data).(type) {
+	case *models.Item:
+		v.TenantID = tenantID
+	case *models.Client:
+		v.TenantID = tenantID
+	case *models.Contact:
+		v.TenantID = tenantID
+	case *models.Reservation:
+		v.TenantID = tenantID
+	case *models.ExportJob:
+		v.TenantID = tenantID
+	case *models.ImportJob:
+		v.TenantID = tenantID
+	}
+}
+
+// stampUpdate preserves ID, TenantID and CreatedAt from the old record, update UpdatedAt
+// and bump Version. It returns ErrVersionConflict when data carries a
+// non-zero Version that doesn't match the old record.
+func stampUpdate[T any](data *T, id string, old T) error {
+	switch v := any(data).(type) {
+	case *models.Item:
+		oldItem := any(old).(models.Item)
+		if v.Version != 0 && v.Version != oldItem.Version {
+			return ErrVersionConflict
+		}
+		v.ID = id
+		v.TenantID = oldItem.TenantID
+		v.Version = oldItem.Version + 1
+		v.CreatedAt = oldItem.CreatedAt
+		v.UpdatedAt = time.Now()
+	case *models.Client:
+		oldClient := any(old).(models.Client)
+		if v.Version != 0 && v.Version != oldClient.Version {
+			return ErrVersionConflict
+		}
+		v.ID = id
+		v.TenantID = oldClient.TenantID
+		v.Version = oldClient.Version + 1
+		v.CreatedAt = oldClient.CreatedAt
+		v.UpdatedAt = time.Now()
+	case *models.Contact:
+		oldContact := any(old).(models.Contact)
+		if v.Version != 0 && v.Version != oldContact.Version {
+			return ErrVersionConflict
+		}
+		v.ID = id
+		v.ClientID = oldContact.ClientID
+		v.TenantID = oldContact.TenantID
+		v.Version = oldContact.Version + 1
+		v.CreatedAt = oldContact.CreatedAt
+		v.UpdatedAt = time.Now()
+	case *models.DeadLetter:
+		oldLetter := any(old).(models.DeadLetter)
+		v.ID = id
+		v.CreatedAt = oldLetter.CreatedAt
+		v.UpdatedAt = time.Now()
+	case *models.ExportJob:
+		oldJob := any(old).(models.ExportJob)
+		v.ID = id
+		v.TenantID = oldJob.TenantID
+		v.CreatedAt = oldJob.CreatedAt
+		v.UpdatedAt = time.Now()
+	case *models.ImportJob:
+		oldJob := any(old).(models.ImportJob)
+		v.ID = id
+		v.TenantID = oldJob.TenantID
+		v.CreatedAt = oldJob.CreatedAt
+		v.UpdatedAt = time.Now()
+	}
+	return nil
 }
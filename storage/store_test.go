@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-api/models"
+)
+
+// testRoundTrip runs an item through every write of store and checks that
+// each one reads back
+func testRoundTrip(t *testing.T, store Store[models.Item]) {
+	t.Helper()
+
+	created := store.Create(models.Item{Name: "widget", Quantity: 3, Tags: []string{"blue"}})
+	if created.ID == "" || created.Version != 1 || created.CreatedAt.IsZero() {
+		t.Fatalf("Create returned %+v, want an ID, version 1 and a creation time", created)
+	}
+	got, exists := store.GetByID(created.ID)
+	if !exists || got.Name != "widget" || got.Quantity != 3 || len(got.Tags) != 1 || got.Tags[0] != "blue" {
+		t.Fatalf("GetByID = %+v, %v; want the created item", got, exists)
+	}
+	if !got.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("CreatedAt = %s after reading back, want %s", got.CreatedAt, created.CreatedAt)
+	}
+
+	got.Name = "gadget"
+	updated, err := store.Update(created.ID, got)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("Version = %d after Update, want 2", updated.Version)
+	}
+	if _, err := store.Update(created.ID, got); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Update with a stale version: %v, want ErrVersionConflict", err)
+	}
+	if _, err := store.Update("missing", got); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of a missing item: %v, want ErrNotFound", err)
+	}
+	if got, _ := store.GetByID(created.ID); got.Name != "gadget" {
+		t.Errorf("Name = %q after Update, want gadget", got.Name)
+	}
+
+	more := store.CreateMany([]models.Item{{Name: "a"}, {Name: "b"}})
+	if len(more) != 2 {
+		t.Fatalf("CreateMany created %d items, want 2", len(more))
+	}
+	found := store.GetMany([]string{more[0].ID, more[1].ID, "missing"})
+	if len(found) != 2 || found[more[1].ID].Name != "b" {
+		t.Errorf("GetMany = %v, want a and b", found)
+	}
+	if all := store.GetAll(); len(all) != 3 {
+		t.Errorf("GetAll returned %d items, want 3", len(all))
+	}
+
+	if !store.Delete(created.ID) {
+		t.Error("Delete of an existing item returned false")
+	}
+	if store.Delete(created.ID) {
+		t.Error("second Delete returned true")
+	}
+	if _, exists := store.GetByID(created.ID); exists {
+		t.Error("deleted item is still readable")
+	}
+}
+
+func TestMemoryStoreRoundTrip(t *testing.T) {
+	testRoundTrip(t, NewMemoryStore[models.Item]())
+}
+
+func TestClear(t *testing.T) {
+	store := NewMemoryStore[models.Item]()
+	for i := range 10 {
+		store.Create(models.Item{Name: "widget", Quantity: i, Tags: []string{"blue"}})
+	}
+
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if all := store.GetAll(); len(all) != 0 {
+		t.Errorf("GetAll returned %d items after Clear, want none", len(all))
+	}
+	if tagged := ByTags[models.Item](store, []string{"blue"}, true); len(tagged) != 0 {
+		t.Errorf("ByTags returned %d items after Clear, want none", len(tagged))
+	}
+	if created := store.Create(models.Item{Name: "after"}); len(store.GetAll()) != 1 || created.ID == "" {
+		t.Error("store doesn't take new items after Clear")
+	}
+}
+
+func TestLastModified(t *testing.T) {
+	store := NewMemoryStore[models.Item]()
+	if got := LastModified[models.Item](store); !got.IsZero() {
+		t.Errorf("empty store last modified at %v, want zero", got)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	store.Replace([]models.Item{{ID: "a", Name: "widget", UpdatedAt: old}, {ID: "b", Name: "gadget", UpdatedAt: old.Add(-time.Minute)}})
+	if got := LastModified[models.Item](store); !got.Equal(old) {
+		t.Errorf("last modified at %v, want %v", got, old)
+	}
+
+	updated, err := store.Update("b", models.Item{Name: "gadget"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := LastModified[models.Item](store); !got.Equal(updated.UpdatedAt) {
+		t.Errorf("after an update: %v, want %v", got, updated.UpdatedAt)
+	}
+	// Stores without LastModified are scanned
+	if got := LastModified[models.Item](struct{ Store[models.Item] }{store}); !got.Equal(updated.UpdatedAt) {
+		t.Errorf("through a wrapper: %v, want %v", got, updated.UpdatedAt)
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import "context"
+
+// Streamer is implemented by stores that can send their records one by one
+// instead of collecting them into a slice first
+type Streamer[T any] interface {
+	StreamAll(ctx context.Context, out chan<- T)
+}
+
+// StreamAll sends every record in store to out and closes it, stopping
+// early when ctx is done. Stores that don't implement Streamer fall back to
+// GetAll.
+func StreamAll[T any](ctx context.Context, store Store[T], out chan<- T) {
+	if s, ok := store.(Streamer[T]); ok {
+		s.StreamAll(ctx, out)
+		return
+	}
+
+	defer close(out)
+	for _, item := range store.GetAll() {
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Ranger is implemented by stores that can call a function with each
+// record in turn instead of collecting them into a slice first
+type Ranger[T any] interface {
+	Range(fn func(T) bool)
+}
+
+// Range calls fn with every record in store until fn returns false. Stores
+// that don't implement Ranger fall back to GetAll.
+func Range[T any](store Store[T], fn func(T) bool) {
+	if r, ok := store.(Ranger[T]); ok {
+		r.Range(fn)
+		return
+	}
+	for _, item := range store.GetAll() {
+		if !fn(item) {
+			return
+		}
+	}
+}
+
+// Range calls fn with each item until fn returns false. The items are
+// copied under the read lock and fn runs after it's released, as in
+// Export, so an fn writing to a slow client doesn't hold up writers.
+func (s *MemoryStore[T]) Range(fn func(T) bool) {
+	for _, item := range s.snapshot() {
+		if !fn(item) {
+			return
+		}
+	}
+}
+
+// snapshot returns the live items, copied under the read lock
+func (s *MemoryStore[T]) snapshot() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.live()
+}
+
+// StreamAll sends every item to out, then closes out. The items are copied
+// under the read lock and sent after it's released, so a reader that
+// drains out slowly doesn't hold up writers.
+func (s *MemoryStore[T]) StreamAll(ctx context.Context, out chan<- T) {
+	defer close(out)
+	for _, item := range s.snapshot() {
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
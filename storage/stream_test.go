@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-api/models"
+)
+
+// testWritesWhileVisiting checks that a write to store finishes while visit
+// is stopped inside its callback, as a stream to a stalled client is
+func testWritesWhileVisiting(t *testing.T, store *MemoryStore[models.Item], visit func(stall func())) {
+	t.Helper()
+	stalled := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		first := true
+		visit(func() {
+			if first {
+				first = false
+				close(stalled)
+				<-release
+			}
+		})
+	}()
+	defer func() {
+		close(release)
+		<-done
+	}()
+
+	<-stalled
+	created := make(chan struct{})
+	go func() {
+		store.Create(models.Item{Name: "written meanwhile"})
+		close(created)
+	}()
+	select {
+	case <-created:
+	case <-time.After(time.Second):
+		t.Fatal("a create waited for the stalled visit")
+	}
+}
+
+func TestRangeDoesNotBlockWriters(t *testing.T) {
+	store := NewMemoryStore[models.Item]()
+	for range 3 {
+		store.Create(models.Item{Name: "widget"})
+	}
+	seen := 0
+	testWritesWhileVisiting(t, store, func(stall func()) {
+		store.Range(func(models.Item) bool {
+			stall()
+			seen++
+			return true
+		})
+	})
+	if seen != 3 {
+		t.Errorf("Range visited %d items, want the 3 there when it started", seen)
+	}
+}
+
+func TestStreamAllDoesNotBlockWriters(t *testing.T) {
+	store := NewMemoryStore[models.Item]()
+	for range 3 {
+		store.Create(models.Item{Name: "widget"})
+	}
+	seen := 0
+	testWritesWhileVisiting(t, store, func(stall func()) {
+		// An unbuffered channel read slowly, as writeNDJSON reads it
+		out := make(chan models.Item)
+		go store.StreamAll(context.Background(), out)
+		for range out {
+			stall()
+			seen++
+		}
+	})
+	if seen != 3 {
+		t.Errorf("StreamAll sent %d items, want the 3 there when it started", seen)
+	}
+}
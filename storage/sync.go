@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-api/tenant"
+)
+
+// DefaultTombstoneRetention is how long deletions are remembered for
+// differential sync
+const DefaultTombstoneRetention = 7 * 24 * time.Hour
+
+// SinceReader is implemented by stores that can list the records changed
+// after a point in time
+type SinceReader[T any] interface {
+	UpdatedSince(t time.Time) []T
+}
+
+// UpdatedSince returns the records of store modified after t. Stores that
+// don't implement SinceReader are filtered in full.
+func UpdatedSince[T any](store Store[T], t time.Time) []T {
+	if sr, ok := store.(SinceReader[T]); ok {
+		return sr.UpdatedSince(t)
+	}
+	var changed []T
+	View(store, func(items []T) {
+		changed = updatedAfter(items, t)
+	})
+	return changed
+}
+
+// UpdatedSince returns the items modified after t
+func (s *MemoryStore[T]) UpdatedSince(t time.Time) []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	changed := make([]T, 0)
+	for id, item := range s.items {
+		if updatedAt(item).After(t) && !s.expired(id, now) {
+			changed = append(changed, item)
+		}
+	}
+	return changed
+}
+
+func updatedAfter[T any](items []T, t time.Time) []T {
+	changed := make([]T, 0)
+	for _, item := range items {
+		if updatedAt(item).After(t) {
+			changed = append(changed, item)
+		}
+	}
+	return changed
+}
+
+// Tombstones remembers when records were deleted, per tenant, so clients
+// syncing changes can drop them too. Register Record as a delete hook.
+type Tombstones struct {
+	retention time.Duration
+
+	mu      sync.Mutex
+	deleted map[string]tombstone
+	horizon time.Time // deletions before this are forgotten
+}
+
+type tombstone struct {
+	tenant string
+	at     time.Time
+}
+
+// NewTombstones creates a deletion log keeping deletions for retention
+func NewTombstones(retention time.Duration) *Tombstones {
+	return &Tombstones{retention: retention, deleted: make(map[string]tombstone), horizon: time.Now()}
+}
+
+// Record notes that id was deleted by the tenant in ctx
+func (t *Tombstones) Record(ctx context.Context, id string) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.deleted[id] = tombstone{tenant: tenant.IDFromContext(ctx), at: now}
+	t.prune(now)
+}
+
+// Since returns the IDs of the tenant's records deleted after since. It
+// reports false when deletions that old may have been forgotten.
+func (t *Tombstones) Since(tenantID string, since time.Time) ([]string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune(time.Now())
+	if since.Before(t.horizon) {
+		return nil, false
+	}
+	ids := make([]string, 0)
+	for id, ts := range t.deleted {
+		if ts.tenant == tenantID && ts.at.After(since) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, true
+}
+
+// prune forgets deletions older than the retention
+func (t *Tombstones) prune(now time.Time) {
+	cutoff := now.Add(-t.retention)
+	if !cutoff.After(t.horizon) {
+		return
+	}
+	for id, ts := range t.deleted {
+		if ts.at.Before(cutoff) {
+			delete(t.deleted, id)
+		}
+	}
+	t.horizon = cutoff
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"time"
+
+	"go-api/models"
+	"go-api/search"
+)
+
+// Tagger is implemented by stores that index the tags of their records
+type Tagger[T any] interface {
+	ByTags(tags []string, all bool) []T
+	TagCounts() map[string]int
+}
+
+// ByTags returns the records of store carrying every one of tags when all
+// is true, or at least one of them otherwise. Stores that don't implement
+// Tagger are scanned.
+func ByTags[T any](store Store[T], tags []string, all bool) []T {
+	if t, ok := store.(Tagger[T]); ok {
+		return t.ByTags(tags, all)
+	}
+	return byTagsScan(store.GetAll(), tags, all)
+}
+
+// TagCounts returns how many records of store carry each tag. Stores that
+// don't implement Tagger are scanned.
+func TagCounts[T any](store Store[T]) map[string]int {
+	if t, ok := store.(Tagger[T]); ok {
+		return t.TagCounts()
+	}
+	return tagCountsScan(store.GetAll())
+}
+
+// tagsOf returns the tags of a record, or nil for types without tags
+func tagsOf[T any](data T) []string {
+	switch v := any(data).(type) {
+	case models.Item:
+		return v.Tags
+	}
+	return nil
+}
+
+// hasTags reports whether record carries every one of tags, or one of them
+// when all is false
+func hasTags[T any](record T, tags []string, all bool) bool {
+	own := make(map[string]struct{})
+	for _, tag := range tagsOf(record) {
+		own[tag] = struct{}{}
+	}
+	for _, tag := range tags {
+		_, ok := own[tag]
+		if ok != all {
+			return ok
+		}
+	}
+	return all
+}
+
+// byTagsScan returns the records matching tags by checking each one
+func byTagsScan[T any](records []T, tags []string, all bool) []T {
+	matched := make([]T, 0)
+	for _, record := range records {
+		if hasTags(record, tags, all) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// tagCountsScan counts the tags of records
+func tagCountsScan[T any](records []T) map[string]int {
+	counts := make(map[string]int)
+	for _, record := range records {
+		for _, tag := range tagsOf(record) {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+// tagIndex maps each tag to the IDs of the records carrying it. It also
+// maps each email domain to the IDs of the records with an address there.
+type tagIndex map[string]map[string]struct{}
+
+// add indexes the tags of the record with id
+func (ix tagIndex) add(id string, tags []string) {
+	for _, tag := range tags {
+		ids, ok := ix[tag]
+		if !ok {
+			ids = make(map[string]struct{})
+			ix[tag] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// remove drops the record with id from the sets of tags
+func (ix tagIndex) remove(id string, tags []string) {
+	for _, tag := range tags {
+		delete(ix[tag], id)
+		if len(ix[tag]) == 0 {
+			delete(ix, tag)
+		}
+	}
+}
+
+// reindex moves the record with id from the tag, domain and text indexes of
+// old to those of data; either may be nil. The caller must hold the write
+// lock.
+func (s *MemoryStore[T]) reindex(id string, old, data *T) {
+	if old != nil {
+		s.tags.remove(id, tagsOf(*old))
+		s.domains.remove(id, domainsOf(*old))
+		s.text.Remove(id)
+	}
+	if data != nil {
+		s.tags.add(id, tagsOf(*data))
+		s.domains.add(id, domainsOf(*data))
+		if text, ok := textOf(*data); ok {
+			s.text.Add(id, text)
+		}
+	}
+}
+
+// rebuildIndexes rebuilds the tag, domain and text indexes from every item.
+// The caller must hold the write lock.
+func (s *MemoryStore[T]) rebuildIndexes() {
+	s.tags = make(tagIndex)
+	s.domains = make(tagIndex)
+	s.text = search.NewTrigramIndex()
+	for id, item := range s.items {
+		s.reindex(id, nil, &item)
+	}
+}
+
+// ByTags looks the items up in the tag index. Carrying every tag is found
+// by walking the smallest of the tags' sets and checking the others.
+func (s *MemoryStore[T]) ByTags(tags []string, all bool) []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make(map[string]struct{})
+	if all {
+		if len(tags) == 0 {
+			return []T{}
+		}
+		smallest := s.tags[tags[0]]
+		for _, tag := range tags[1:] {
+			if len(s.tags[tag]) < len(smallest) {
+				smallest = s.tags[tag]
+			}
+		}
+	next:
+		for id := range smallest {
+			for _, tag := range tags {
+				if _, ok := s.tags[tag][id]; !ok {
+					continue next
+				}
+			}
+			ids[id] = struct{}{}
+		}
+	} else {
+		for _, tag := range tags {
+			for id := range s.tags[tag] {
+				ids[id] = struct{}{}
+			}
+		}
+	}
+
+	now := time.Now()
+	matched := make([]T, 0, len(ids))
+	for id := range ids {
+		if !s.expired(id, now) {
+			matched = append(matched, s.items[id])
+		}
+	}
+	return matched
+}
+
+// TagCounts returns the size of each tag's set, leaving out expired items
+func (s *MemoryStore[T]) TagCounts() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	counts := make(map[string]int, len(s.tags))
+	for tag, ids := range s.tags {
+		for id := range ids {
+			if !s.expired(id, now) {
+				counts[tag]++
+			}
+		}
+	}
+	return counts
+}
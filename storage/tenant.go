@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go-api/tenant"
+)
+
+// Scoper is implemented by stores that can narrow themselves to the caller
+// described by a request context
+type Scoper[T any] interface {
+	For(ctx context.Context) Store[T]
+}
+
+// TenantStore wraps a Store so each tenant only sees its own records.
+// Used directly it behaves like the wrapped store; call For to get a view
+// limited to the tenant in a request context.
+type TenantStore[T any] struct {
+	Store[T]
+}
+
+// NewTenantStore creates a tenant-aware wrapper around store
+func NewTenantStore[T any](store Store[T]) *TenantStore[T] {
+	return &TenantStore[T]{Store: store}
+}
+
+// For returns a view of the store limited to the tenant in ctx
+func (s *TenantStore[T]) For(ctx context.Context) Store[T] {
+	return &tenantView[T]{store: s.Store, tenant: tenant.IDFromContext(ctx)}
+}
+
+// Range forwards to the wrapped store so records still aren't collected into a slice
+func (s *TenantStore[T]) Range(fn func(T) bool) {
+	Range(s.Store, fn)
+}
+
+// StreamAll forwards to the wrapped store so records are still streamed
+func (s *TenantStore[T]) StreamAll(ctx context.Context, out chan<- T) {
+	StreamAll(ctx, s.Store, out)
+}
+
+// UpdateWhere forwards to the wrapped store so batches stay all-or-nothing
+func (s *TenantStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	return UpdateWhere(s.Store, match, apply)
+}
+
+// Filter forwards to the wrapped store so its indexes are still used
+func (s *TenantStore[T]) Filter(query map[string]string) ([]T, error) {
+	return Filter(s.Store, query)
+}
+
+// Explain forwards to the wrapped store so its indexes are described
+func (s *TenantStore[T]) Explain(predicates map[string]string) QueryPlan {
+	return Explain(s.Store, predicates)
+}
+
+// ByTags forwards to the wrapped store so its tag index is still used
+func (s *TenantStore[T]) ByTags(tags []string, all bool) []T {
+	return ByTags(s.Store, tags, all)
+}
+
+// TagCounts forwards to the wrapped store so its tag index is still used
+func (s *TenantStore[T]) TagCounts() map[string]int {
+	return TagCounts(s.Store)
+}
+
+// Search forwards to the wrapped store so its text index is still used
+func (s *TenantStore[T]) Search(query string, minScore float64) []Scored[T] {
+	return Search(s.Store, query, minScore)
+}
+
+// ByEmailDomain forwards to the wrapped store so its domain index is still used
+func (s *TenantStore[T]) ByEmailDomain(domain string) []T {
+	return ByEmailDomain(s.Store, domain)
+}
+
+// EmailDomains forwards to the wrapped store so its domain index is still used
+func (s *TenantStore[T]) EmailDomains() map[string]int {
+	return EmailDomains(s.Store)
+}
+
+// View forwards to the wrapped store so callers still get a consistent view
+func (s *TenantStore[T]) View(fn func(items []T)) {
+	View(s.Store, fn)
+}
+
+// Stats forwards to the wrapped store
+func (s *TenantStore[T]) Stats() Stats {
+	return StatsOf(s.Store)
+}
+
+// tenantView is a Store limited to the records of a single tenant
+type tenantView[T any] struct {
+	store  Store[T]
+	tenant string
+}
+
+func (v *tenantView[T]) owns(item T) bool {
+	return tenantOf(item) == v.tenant
+}
+
+func (v *tenantView[T]) GetAll() []T {
+	all := v.store.GetAll()
+	items := make([]T, 0, len(all))
+	for _, item := range all {
+		if v.owns(item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (v *tenantView[T]) GetSorted(fields []SortField) []T {
+	items := v.GetAll()
+	SortRecords(items, fields)
+	return items
+}
+
+func (v *tenantView[T]) GetByID(id string) (T, bool) {
+	item, exists := v.store.GetByID(id)
+	if !exists || !v.owns(item) {
+		var zero T
+		return zero, false
+	}
+	return item, true
+}
+
+func (v *tenantView[T]) GetMany(ids []string) map[string]T {
+	found := v.store.GetMany(ids)
+	for id, item := range found {
+		if !v.owns(item) {
+			delete(found, id)
+		}
+	}
+	return found
+}
+
+func (v *tenantView[T]) Create(data T) T {
+	setTenant(&data, v.tenant)
+	return v.store.Create(data)
+}
+
+func (v *tenantView[T]) TryCreate(data T) (T, error) {
+	setTenant(&data, v.tenant)
+	return v.store.TryCreate(data)
+}
+
+func (v *tenantView[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	setTenant(&data, v.tenant)
+	return v.store.CreateWithTTL(data, ttl)
+}
+
+func (v *tenantView[T]) Range(fn func(T) bool) {
+	Range(v.store, func(item T) bool {
+		return !v.owns(item) || fn(item)
+	})
+}
+
+func (v *tenantView[T]) StreamAll(ctx context.Context, out chan<- T) {
+	defer close(out)
+	all := make(chan T)
+	go StreamAll(ctx, v.store, all)
+	for item := range all {
+		if !v.owns(item) {
+			continue
+		}
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			// Drain so the inner stream can finish
+			for range all {
+			}
+			return
+		}
+	}
+}
+
+func (v *tenantView[T]) CreateMany(data []T) []T {
+	for i := range data {
+		setTenant(&data[i], v.tenant)
+	}
+	return v.store.CreateMany(data)
+}
+
+func (v *tenantView[T]) Update(id string, data T) (T, error) {
+	if _, exists := v.GetByID(id); !exists {
+		var zero T
+		return zero, ErrNotFound
+	}
+	return v.store.Update(id, data)
+}
+
+func (v *tenantView[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	return UpdateWhere(v.store, func(item T) bool { return v.owns(item) && match(item) }, apply)
+}
+
+func (v *tenantView[T]) Filter(query map[string]string) ([]T, error) {
+	matched, err := Filter(v.store, query)
+	owned := make([]T, 0, len(matched))
+	for _, item := range matched {
+		if v.owns(item) {
+			owned = append(owned, item)
+		}
+	}
+	return owned, err
+}
+
+func (v *tenantView[T]) ByTags(tags []string, all bool) []T {
+	matched := ByTags(v.store, tags, all)
+	owned := make([]T, 0, len(matched))
+	for _, item := range matched {
+		if v.owns(item) {
+			owned = append(owned, item)
+		}
+	}
+	return owned
+}
+
+// TagCounts counts the tenant's records only, so it can't use the tag index
+func (v *tenantView[T]) TagCounts() map[string]int {
+	return tagCountsScan(v.GetAll())
+}
+
+func (v *tenantView[T]) Search(query string, minScore float64) []Scored[T] {
+	matched := Search(v.store, query, minScore)
+	owned := make([]Scored[T], 0, len(matched))
+	for _, result := range matched {
+		if v.owns(result.Record) {
+			owned = append(owned, result)
+		}
+	}
+	return owned
+}
+
+func (v *tenantView[T]) ByEmailDomain(domain string) []T {
+	matched := ByEmailDomain(v.store, domain)
+	owned := make([]T, 0, len(matched))
+	for _, record := range matched {
+		if v.owns(record) {
+			owned = append(owned, record)
+		}
+	}
+	return owned
+}
+
+// EmailDomains counts the tenant's records only, so it can't use the domain
+// index
+func (v *tenantView[T]) EmailDomains() map[string]int {
+	return emailDomainsScan(v.GetAll())
+}
+
+func (v *tenantView[T]) Delete(id string) bool {
+	if _, exists := v.GetByID(id); !exists {
+		return false
+	}
+	return v.store.Delete(id)
+}
+
+func (v *tenantView[T]) DeleteAndReturn(id string) (T, bool) {
+	if _, exists := v.GetByID(id); !exists {
+		var zero T
+		return zero, false
+	}
+	return v.store.DeleteAndReturn(id)
+}
+
+// Clear removes the tenant's records only
+func (v *tenantView[T]) Clear() error {
+	for _, item := range v.GetAll() {
+		v.store.Delete(idOf(item))
+	}
+	return nil
+}
+
+func (v *tenantView[T]) Replace(items []T) error {
+	return errors.New("replace is not supported on a tenant view")
+}
+
+// Export writes only the tenant's records
+func (v *tenantView[T]) Export(ctx context.Context, w io.Writer) error {
+	return ExportRecords[T](ctx, v, w)
+}
+
+func (v *tenantView[T]) Import(ctx context.Context, r io.Reader) error {
+	return errors.New("import is not supported on a tenant view")
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-api/models"
+	"go-api/tenant"
+)
+
+func TestTenantCannotReadOtherTenants(t *testing.T) {
+	store := NewTenantStore[models.Item](NewMemoryStore[models.Item]())
+	acme := store.For(tenant.WithID(context.Background(), "acme"))
+	globex := store.For(tenant.WithID(context.Background(), "globex"))
+
+	a := acme.Create(models.Item{Name: "anvil", Tags: []string{"heavy"}})
+	b := globex.Create(models.Item{Name: "blimp", Tags: []string{"heavy"}})
+	if a.TenantID != "acme" || b.TenantID != "globex" {
+		t.Fatalf("tenants = %q, %q; want acme and globex", a.TenantID, b.TenantID)
+	}
+
+	if _, exists := acme.GetByID(b.ID); exists {
+		t.Error("acme can read globex's item by ID")
+	}
+	if all := acme.GetAll(); len(all) != 1 || all[0].ID != a.ID {
+		t.Errorf("acme lists %v, want only its own item", all)
+	}
+	if found := acme.GetMany([]string{a.ID, b.ID}); len(found) != 1 {
+		t.Errorf("acme GetMany found %d items, want 1", len(found))
+	}
+	if tagged := ByTags(acme, []string{"heavy"}, true); len(tagged) != 1 || tagged[0].ID != a.ID {
+		t.Errorf("acme ByTags = %v, want only its own item", tagged)
+	}
+
+	if _, err := acme.Update(b.ID, models.Item{Name: "stolen"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("acme Update of globex's item: %v, want ErrNotFound", err)
+	}
+	if acme.Delete(b.ID) {
+		t.Error("acme deleted globex's item")
+	}
+	if got, exists := globex.GetByID(b.ID); !exists || got.Name != "blimp" {
+		t.Errorf("globex's item = %+v, %v; want it unchanged", got, exists)
+	}
+
+	// A created record can't be planted in another tenant
+	planted := acme.Create(models.Item{Name: "planted", TenantID: "globex"})
+	if planted.TenantID != "acme" {
+		t.Errorf("created with tenant %q, want the caller's acme", planted.TenantID)
+	}
+	planted.TenantID = "globex"
+	if moved, err := acme.Update(planted.ID, planted); err != nil || moved.TenantID != "acme" {
+		t.Errorf("update to tenant globex = %q, %v; want it kept in acme", moved.TenantID, err)
+	}
+}
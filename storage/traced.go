@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedStore wraps a Store so the operations of each request are recorded
+// as child spans of the request's span. Call For with the request context.
+type TracedStore[T any] struct {
+	Store[T]
+	tracer trace.Tracer
+	entity string
+}
+
+// NewTracedStore creates a store that traces operations on entity
+func NewTracedStore[T any](store Store[T], tracer trace.Tracer, entity string) *TracedStore[T] {
+	return &TracedStore[T]{Store: store, tracer: tracer, entity: entity}
+}
+
+// For returns a view of the store, scoped like the wrapped store, whose
+// operations are traced under the span in ctx
+func (s *TracedStore[T]) For(ctx context.Context) Store[T] {
+	store := s.Store
+	if sc, ok := store.(Scoper[T]); ok {
+		store = sc.For(ctx)
+	}
+	return &tracedView[T]{store: store, ctx: ctx, tracer: s.tracer, entity: s.entity}
+}
+
+// Range forwards to the wrapped store so records still aren't collected into a slice
+func (s *TracedStore[T]) Range(fn func(T) bool) {
+	Range(s.Store, fn)
+}
+
+// StreamAll forwards to the wrapped store so records are still streamed
+func (s *TracedStore[T]) StreamAll(ctx context.Context, out chan<- T) {
+	StreamAll(ctx, s.Store, out)
+}
+
+// UpdateWhere forwards to the wrapped store so batches stay all-or-nothing
+func (s *TracedStore[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	return UpdateWhere(s.Store, match, apply)
+}
+
+// Filter forwards to the wrapped store so its indexes are still used
+func (s *TracedStore[T]) Filter(query map[string]string) ([]T, error) {
+	return Filter(s.Store, query)
+}
+
+// Explain forwards to the wrapped store so its indexes are described
+func (s *TracedStore[T]) Explain(predicates map[string]string) QueryPlan {
+	return Explain(s.Store, predicates)
+}
+
+// ByTags forwards to the wrapped store so its tag index is still used
+func (s *TracedStore[T]) ByTags(tags []string, all bool) []T {
+	return ByTags(s.Store, tags, all)
+}
+
+// TagCounts forwards to the wrapped store so its tag index is still used
+func (s *TracedStore[T]) TagCounts() map[string]int {
+	return TagCounts(s.Store)
+}
+
+// Search forwards to the wrapped store so its text index is still used
+func (s *TracedStore[T]) Search(query string, minScore float64) []Scored[T] {
+	return Search(s.Store, query, minScore)
+}
+
+// ByEmailDomain forwards to the wrapped store so its domain index is still used
+func (s *TracedStore[T]) ByEmailDomain(domain string) []T {
+	return ByEmailDomain(s.Store, domain)
+}
+
+// EmailDomains forwards to the wrapped store so its domain index is still used
+func (s *TracedStore[T]) EmailDomains() map[string]int {
+	return EmailDomains(s.Store)
+}
+
+// View forwards to the wrapped store so callers still get a consistent view
+func (s *TracedStore[T]) View(fn func(items []T)) {
+	View(s.Store, fn)
+}
+
+// Stats forwards to the wrapped store
+func (s *TracedStore[T]) Stats() Stats {
+	return StatsOf(s.Store)
+}
+
+// tracedView records a span for every operation on store
+type tracedView[T any] struct {
+	store  Store[T]
+	ctx    context.Context
+	tracer trace.Tracer
+	entity string
+}
+
+func (v *tracedView[T]) start(op string) trace.Span {
+	_, span := v.tracer.Start(v.ctx, "store."+v.entity+"."+op,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("store.entity", v.entity)),
+	)
+	return span
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (v *tracedView[T]) GetAll() []T {
+	span := v.start("GetAll")
+	defer span.End()
+	return v.store.GetAll()
+}
+
+func (v *tracedView[T]) GetSorted(fields []SortField) []T {
+	span := v.start("GetSorted")
+	defer span.End()
+	return v.store.GetSorted(fields)
+}
+
+func (v *tracedView[T]) GetByID(id string) (T, bool) {
+	span := v.start("GetByID")
+	defer span.End()
+	return v.store.GetByID(id)
+}
+
+func (v *tracedView[T]) GetMany(ids []string) map[string]T {
+	span := v.start("GetMany")
+	defer span.End()
+	return v.store.GetMany(ids)
+}
+
+func (v *tracedView[T]) Create(data T) T {
+	span := v.start("Create")
+	defer span.End()
+	return v.store.Create(data)
+}
+
+func (v *tracedView[T]) TryCreate(data T) (T, error) {
+	span := v.start("Create")
+	created, err := v.store.TryCreate(data)
+	end(span, err)
+	return created, err
+}
+
+func (v *tracedView[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	span := v.start("CreateWithTTL")
+	created, err := v.store.CreateWithTTL(data, ttl)
+	end(span, err)
+	return created, err
+}
+
+func (v *tracedView[T]) Range(fn func(T) bool) {
+	span := v.start("Range")
+	defer span.End()
+	Range(v.store, fn)
+}
+
+func (v *tracedView[T]) StreamAll(ctx context.Context, out chan<- T) {
+	span := v.start("StreamAll")
+	defer span.End()
+	StreamAll(ctx, v.store, out)
+}
+
+func (v *tracedView[T]) CreateMany(data []T) []T {
+	span := v.start("CreateMany")
+	defer span.End()
+	return v.store.CreateMany(data)
+}
+
+func (v *tracedView[T]) Update(id string, data T) (T, error) {
+	span := v.start("Update")
+	updated, err := v.store.Update(id, data)
+	end(span, err)
+	return updated, err
+}
+
+func (v *tracedView[T]) UpdateWhere(match func(T) bool, apply func(T) (T, error)) ([]T, error) {
+	span := v.start("UpdateWhere")
+	updated, err := UpdateWhere(v.store, match, apply)
+	end(span, err)
+	return updated, err
+}
+
+func (v *tracedView[T]) Filter(query map[string]string) ([]T, error) {
+	span := v.start("Filter")
+	matched, err := Filter(v.store, query)
+	end(span, err)
+	return matched, err
+}
+
+func (v *tracedView[T]) ByTags(tags []string, all bool) []T {
+	span := v.start("ByTags")
+	defer span.End()
+	return ByTags(v.store, tags, all)
+}
+
+func (v *tracedView[T]) TagCounts() map[string]int {
+	span := v.start("TagCounts")
+	defer span.End()
+	return TagCounts(v.store)
+}
+
+func (v *tracedView[T]) Search(query string, minScore float64) []Scored[T] {
+	span := v.start("Search")
+	defer span.End()
+	return Search(v.store, query, minScore)
+}
+
+func (v *tracedView[T]) ByEmailDomain(domain string) []T {
+	span := v.start("ByEmailDomain")
+	defer span.End()
+	return ByEmailDomain(v.store, domain)
+}
+
+func (v *tracedView[T]) EmailDomains() map[string]int {
+	span := v.start("EmailDomains")
+	defer span.End()
+	return EmailDomains(v.store)
+}
+
+func (v *tracedView[T]) Delete(id string) bool {
+	span := v.start("Delete")
+	defer span.End()
+	return v.store.Delete(id)
+}
+
+func (v *tracedView[T]) DeleteAndReturn(id string) (T, bool) {
+	span := v.start("DeleteAndReturn")
+	defer span.End()
+	return v.store.DeleteAndReturn(id)
+}
+
+func (v *tracedView[T]) Clear() error {
+	span := v.start("Clear")
+	err := v.store.Clear()
+	end(span, err)
+	return err
+}
+
+func (v *tracedView[T]) Replace(items []T) error {
+	span := v.start("Replace")
+	err := v.store.Replace(items)
+	end(span, err)
+	return err
+}
+
+func (v *tracedView[T]) Export(ctx context.Context, w io.Writer) error {
+	span := v.start("Export")
+	err := v.store.Export(ctx, w)
+	end(span, err)
+	return err
+}
+
+func (v *tracedView[T]) Import(ctx context.Context, r io.Reader) error {
+	span := v.start("Import")
+	err := v.store.Import(ctx, r)
+	end(span, err)
+	return err
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// JanitorInterval is how often StartJanitor sweeps expired records
+const JanitorInterval = 30 * time.Second
+
+// ErrTTLUnsupported is returned by CreateWithTTL of stores that can't
+// expire records
+var ErrTTLUnsupported = errors.New("store does not support record expiry")
+
+// CreateWithTTL adds a new item that expires after ttl. Expired items are
+// hidden from every read right away and removed by the janitor, without
+// counting as deletes.
+func (s *MemoryStore[T]) CreateWithTTL(data T, ttl time.Duration) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id := stampCreate(&data); id != "" {
+		if err := s.insert(id, data); err != nil {
+			var zero T
+			return zero, err
+		}
+		s.expiry[id] = time.Now().Add(ttl)
+	}
+	return data, nil
+}
+
+// OnExpire registers fn to run with every item removed because it expired.
+// fn runs on its own goroutine, after the item is gone.
+func (s *MemoryStore[T]) OnExpire(fn func(T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onExpire = append(s.onExpire, fn)
+}
+
+// StartJanitor removes expired items every JanitorInterval until ctx is done
+func (s *MemoryStore[T]) StartJanitor(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(JanitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.mu.Lock()
+				s.sweep(now)
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// expired reports whether the item with id has expired by now. The caller
+// must hold a lock.
+func (s *MemoryStore[T]) expired(id string, now time.Time) bool {
+	at, ok := s.expiry[id]
+	return ok && !now.Before(at)
+}
+
+// sweep removes every item expired by now and runs the OnExpire hooks for
+// them. The caller must hold the write lock.
+func (s *MemoryStore[T]) sweep(now time.Time) {
+	for id, at := range s.expiry {
+		if !now.Before(at) {
+			s.expire(id)
+		}
+	}
+}
+
+// expire removes the expired item with id and runs the OnExpire hooks for
+// it. The caller must hold the write lock.
+func (s *MemoryStore[T]) expire(id string) {
+	item := s.items[id]
+	s.counters.totalBytes.Add(-sizeOf(item))
+	delete(s.items, id)
+	delete(s.expiry, id)
+	s.reindex(id, &item, nil)
+	s.forget(id)
+	for _, fn := range s.onExpire {
+		go fn(item)
+	}
+}
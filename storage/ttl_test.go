@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-api/models"
+)
+
+func TestCreateWithTTLOverExpiredRunsOnExpire(t *testing.T) {
+	store := NewMemoryStore[models.Reservation]()
+	expired := make(chan models.Reservation, 1)
+	store.OnExpire(func(r models.Reservation) { expired <- r })
+
+	if _, err := store.CreateWithTTL(models.Reservation{ID: "r1", Quantity: 5}, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, exists := store.GetByID("r1"); exists {
+		t.Fatal("expired reservation is still visible")
+	}
+
+	// The janitor hasn't run, so the old record is still stored under r1
+	if _, err := store.CreateWithTTL(models.Reservation{ID: "r1", Quantity: 2}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case r := <-expired:
+		if r.Quantity != 5 {
+			t.Errorf("expired reservation has quantity %d, want 5", r.Quantity)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("replacing an expired record didn't run OnExpire")
+	}
+	if got, exists := store.GetByID("r1"); !exists || got.Quantity != 2 {
+		t.Errorf("r1 = %+v, %v; want the new reservation", got, exists)
+	}
+}
+
+func TestCreateWithTTLExpires(t *testing.T) {
+	store := NewMemoryStore[models.Item]()
+	kept := store.Create(models.Item{Name: "kept"})
+	short, err := store.CreateWithTTL(models.Item{Name: "short-lived"}, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := store.GetByID(short.ID); !exists {
+		t.Fatal("record missing before its TTL")
+	}
+	if n := len(store.GetAll()); n != 2 {
+		t.Fatalf("GetAll returned %d records before the TTL, want 2", n)
+	}
+
+	// The janitor isn't running, so it's the reads that hide the record
+	time.Sleep(100 * time.Millisecond)
+	if _, exists := store.GetByID(short.ID); exists {
+		t.Error("GetByID still finds the record after its TTL")
+	}
+	all := store.GetAll()
+	if len(all) != 1 || all[0].ID != kept.ID {
+		t.Errorf("GetAll = %+v after the TTL, want only the record without one", all)
+	}
+	if _, err := store.Update(short.ID, short); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of an expired record: %v, want ErrNotFound", err)
+	}
+
+	store.mu.Lock()
+	store.sweep(time.Now())
+	_, stored := store.items[short.ID]
+	store.mu.Unlock()
+	if stored {
+		t.Error("the sweep left the expired record stored")
+	}
+}
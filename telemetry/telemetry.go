@@ -0,0 +1,64 @@
+// Package telemetry sets up OpenTelemetry tracing.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the API in traces
+const ServiceName = "go-api"
+
+// shutdownTimeout bounds how long pending spans are flushed on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// Setup installs the global tracer provider and W3C trace context
+// propagation. Spans are exported over OTLP/HTTP to
+// OTEL_EXPORTER_OTLP_ENDPOINT; without it tracing is a no-op. The returned
+// func flushes and stops the exporter.
+func Setup() (shutdown func(), err error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return func() {}, nil
+	}
+
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("telemetry: create OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("telemetry: build resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			log.Printf("telemetry: shutdown: %v", err)
+		}
+	}, nil
+}
+
+// Tracer returns the tracer used for API spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
@@ -0,0 +1,16 @@
+package tenant
+
+import "context"
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the caller's tenant ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// IDFromContext returns the tenant ID stored by WithID, or "" when there is none
+func IDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,108 @@
+// Package transform rewrites JSON response bodies, such as to strip
+// internal fields before they reach external clients.
+package transform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Transformer rewrites one JSON object of a response body. It may change
+// body in place and return it, or return a new map.
+type Transformer interface {
+	Transform(ctx context.Context, body map[string]any) map[string]any
+}
+
+// Pipeline runs transformers in order, each seeing the result of the last
+type Pipeline []Transformer
+
+// Transform implements Transformer
+func (p Pipeline) Transform(ctx context.Context, body map[string]any) map[string]any {
+	for _, t := range p {
+		body = t.Transform(ctx, body)
+	}
+	return body
+}
+
+// stripFields drops fields from the body
+type stripFields []string
+
+// StripFields removes the named top-level fields
+func StripFields(fields []string) Transformer {
+	return stripFields(fields)
+}
+
+func (s stripFields) Transform(_ context.Context, body map[string]any) map[string]any {
+	for _, field := range s {
+		delete(body, field)
+	}
+	return body
+}
+
+// renameField moves a field to a new name
+type renameField struct {
+	from, to string
+}
+
+// RenameField moves the top-level field from to to, replacing any field
+// already named to. Bodies without from are left alone.
+func RenameField(from, to string) Transformer {
+	return renameField{from: from, to: to}
+}
+
+func (f renameField) Transform(_ context.Context, body map[string]any) map[string]any {
+	if v, ok := body[f.from]; ok {
+		delete(body, f.from)
+		body[f.to] = v
+	}
+	return body
+}
+
+// builders make the transformers Parse knows by name from their arguments
+var builders = map[string]func(args string) (Transformer, error){
+	"strip": func(args string) (Transformer, error) {
+		var fields []string
+		for _, field := range strings.Split(args, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("strip needs the fields to remove, such as strip:cost,margin")
+		}
+		return StripFields(fields), nil
+	},
+	"rename": func(args string) (Transformer, error) {
+		from, to, ok := strings.Cut(args, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("rename needs from=to, such as rename:name=title")
+		}
+		return RenameField(from, to), nil
+	},
+}
+
+// Parse builds a pipeline from a semicolon-separated list of transformers,
+// each a name and its arguments, such as "strip:cost,margin;rename:name=title".
+// An empty spec gives an empty pipeline.
+func Parse(spec string) (Pipeline, error) {
+	var pipeline Pipeline
+	for _, step := range strings.Split(spec, ";") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+		name, args, _ := strings.Cut(step, ":")
+		build, ok := builders[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("transform: unknown transformer %q", name)
+		}
+		t, err := build(args)
+		if err != nil {
+			return nil, fmt.Errorf("transform: %w", err)
+		}
+		pipeline = append(pipeline, t)
+	}
+	return pipeline, nil
+}
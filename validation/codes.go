@@ -0,0 +1,12 @@
+package validation
+
+// Error codes carried by FieldError. Clients should branch on the code,
+// never on the human-readable message, which may change.
+const (
+	CodeRequired        = "REQUIRED"
+	CodeMinValue        = "MIN_VALUE"
+	CodeMaxValue        = "MAX_VALUE"
+	CodeMaxLength       = "MAX_LENGTH"
+	CodeInvalidFormat   = "INVALID_FORMAT"
+	CodeUniqueViolation = "UNIQUE_VIOLATION"
+)
@@ -0,0 +1,93 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go-api/models"
+)
+
+const (
+	// maxNameLength is the longest accepted name, in characters
+	maxNameLength = 255
+	// maxTags is the most tags an item may carry
+	maxTags = 20
+)
+
+// tagPattern is the form of a tag: letters, digits and "-_.:", at most 50
+// characters, so it fits in URL paths and comma-separated queries
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,49}$`)
+
+// Tag validates a single item tag
+func Tag(tag string) error {
+	v := &ValidationError{}
+	checkTag(v, tag)
+	return v.Err()
+}
+
+func checkTag(v *ValidationError, tag string) {
+	if !tagPattern.MatchString(tag) {
+		v.Add("tags", ErrInvalidFormat, fmt.Sprintf("invalid tag '%s': use up to 50 letters, digits and -_.:", tag))
+	}
+}
+
+// Item validates an item before it is created or updated
+func Item(item models.Item) error {
+	v := &ValidationError{}
+	name(v, item.Name)
+	if item.Status != "" && !models.ValidStatus(item.Status) {
+		v.Add("status", ErrInvalidFormat, fmt.Sprintf("invalid status '%s'", item.Status))
+	}
+	if item.Quantity < 0 {
+		v.Add("quantity", ErrInvalidFormat, "quantity must not be negative")
+	}
+	if len(item.Tags) > maxTags {
+		v.Add("tags", ErrMaxLength, fmt.Sprintf("an item can have at most %d tags", maxTags))
+	}
+	seen := make(map[string]bool, len(item.Tags))
+	for _, tag := range item.Tags {
+		checkTag(v, tag)
+		if seen[tag] {
+			v.Add("tags", ErrInvalidFormat, fmt.Sprintf("duplicate tag '%s'", tag))
+		}
+		seen[tag] = true
+	}
+	return v.Err()
+}
+
+// Client validates a client before it is created or updated
+func Client(client models.Client) error {
+	v := &ValidationError{}
+	name(v, client.Name)
+	email(v, client.Email)
+	return v.Err()
+}
+
+// Contact validates a contact before it is created or updated
+func Contact(contact models.Contact) error {
+	v := &ValidationError{}
+	name(v, contact.Name)
+	email(v, contact.Email)
+	return v.Err()
+}
+
+func name(v *ValidationError, name string) {
+	switch {
+	case strings.TrimSpace(name) == "":
+		v.Add("name", ErrRequired, "name is required")
+	case utf8.RuneCountInString(name) > maxNameLength:
+		v.Add("name", ErrMaxLength, fmt.Sprintf("name must be at most %d characters", maxNameLength))
+	}
+}
+
+func email(v *ValidationError, email string) {
+	if email == "" {
+		return
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		v.Add("email", ErrInvalidFormat, "email must be a valid address")
+	}
+}
@@ -0,0 +1,269 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go-api/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesReloadInterval is how often ItemValidator looks at the rules file's
+// modification time
+const rulesReloadInterval = 5 * time.Second
+
+// Rule constrains one field of a model, named as in its JSON. Min and Max
+// bound numbers, and MaxLength and Pattern apply to strings; an empty
+// string only fails Required.
+type Rule struct {
+	Field     string   `yaml:"field"`
+	Required  bool     `yaml:"required"`
+	Min       *float64 `yaml:"min"`
+	Max       *float64 `yaml:"max"`
+	MaxLength *int     `yaml:"max_length"`
+	Pattern   string   `yaml:"pattern"`
+
+	index   int
+	pattern *regexp.Regexp
+}
+
+// RuleSet is the rules of one model, checked in order
+type RuleSet []Rule
+
+// ParseRules reads a YAML list of rules on the fields of T, each a mapping
+// such as {field: quantity, min: 1, max: 1000} or {field: name,
+// max_length: 100, pattern: "^[a-zA-Z0-9 _-]+$"}. Rules naming fields T
+// doesn't have, or bounds the field's type can't take, are errors.
+func ParseRules[T any](data []byte) (RuleSet, error) {
+	var rules RuleSet
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	fields := jsonFields(reflect.TypeFor[T]())
+	for i := range rules {
+		rule := &rules[i]
+		index, ok := fields[rule.Field]
+		if !ok {
+			return nil, fmt.Errorf("rule %d: unknown field %q", i+1, rule.Field)
+		}
+		rule.index = index
+
+		kind := reflect.TypeFor[T]().Field(index).Type.Kind()
+		numeric := kind >= reflect.Int && kind <= reflect.Float64
+		switch {
+		case (rule.Min != nil || rule.Max != nil) && !numeric:
+			return nil, fmt.Errorf("rule %d: %s is not a number; min and max don't apply", i+1, rule.Field)
+		case (rule.MaxLength != nil || rule.Pattern != "") && kind != reflect.String:
+			return nil, fmt.Errorf("rule %d: %s is not a string; max_length and pattern don't apply", i+1, rule.Field)
+		case rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max:
+			return nil, fmt.Errorf("rule %d: min of %s is above max", i+1, rule.Field)
+		case rule.MaxLength != nil && *rule.MaxLength < 0:
+			return nil, fmt.Errorf("rule %d: max_length of %s is negative", i+1, rule.Field)
+		}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: pattern of %s: %w", i+1, rule.Field, err)
+			}
+			rule.pattern = pattern
+		}
+	}
+	return rules, nil
+}
+
+// jsonFields maps the JSON names of the fields of struct type t to their
+// index
+func jsonFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// Check adds an error to v for every rule record breaks, skipping fields
+// that already have one. record must be of the type the rules were parsed
+// for.
+func (rs RuleSet) Check(v *ValidationError, record any) {
+	failed := make(map[string]bool, len(v.Errors))
+	for _, fe := range v.Errors {
+		failed[fe.Field] = true
+	}
+	value := reflect.ValueOf(record)
+	for _, rule := range rs {
+		if !failed[rule.Field] {
+			rule.check(v, value.Field(rule.index))
+		}
+	}
+}
+
+func (r Rule) check(v *ValidationError, field reflect.Value) {
+	if field.Kind() == reflect.String {
+		s := field.String()
+		switch {
+		case strings.TrimSpace(s) == "":
+			if r.Required {
+				v.Add(r.Field, ErrRequired, r.Field+" is required")
+			}
+		case r.MaxLength != nil && utf8.RuneCountInString(s) > *r.MaxLength:
+			v.Add(r.Field, ErrMaxLength, fmt.Sprintf("%s must be at most %d characters", r.Field, *r.MaxLength))
+		case r.pattern != nil && !r.pattern.MatchString(s):
+			v.Add(r.Field, ErrInvalidFormat, fmt.Sprintf("%s must match %s", r.Field, r.Pattern))
+		}
+		return
+	}
+
+	if r.Required && field.IsZero() {
+		v.Add(r.Field, ErrRequired, r.Field+" is required")
+		return
+	}
+	var n float64
+	switch {
+	case field.CanInt():
+		n = float64(field.Int())
+	case field.CanUint():
+		n = float64(field.Uint())
+	case field.CanFloat():
+		n = field.Float()
+	default:
+		return
+	}
+	switch {
+	case r.Min != nil && n < *r.Min:
+		v.Add(r.Field, ErrMinValue, fmt.Sprintf("%s must be at least %s", r.Field, formatBound(*r.Min)))
+	case r.Max != nil && n > *r.Max:
+		v.Add(r.Field, ErrMaxValue, fmt.Sprintf("%s must be at most %s", r.Field, formatBound(*r.Max)))
+	}
+}
+
+func formatBound(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// ItemValidator validates items with Item and then the rules of a YAML
+// file, so the file can only tighten the built-in checks. The file is read
+// again when its modification time changes, looked at no more than every
+// 5 seconds, so rules change without a restart. Without the file, only
+// the built-in checks apply.
+type ItemValidator struct {
+	path string
+
+	mu      sync.RWMutex
+	rules   RuleSet
+	modTime time.Time
+	checked time.Time
+}
+
+// NewItemValidator reads the rules file at path. A missing file is not an
+// error, and is read if it appears later.
+func NewItemValidator(path string) (*ItemValidator, error) {
+	v := &ItemValidator{path: path, checked: time.Now()}
+	rules, modTime, err := readRuleFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("No validation rules at %s; using the built-in checks", path)
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	v.rules, v.modTime = rules, modTime
+	log.Printf("Loaded %d validation rules from %s", len(rules), path)
+	return v, nil
+}
+
+// readRuleFile parses the item rules file at path, returning its
+// modification time
+func readRuleFile(path string) (RuleSet, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	rules, err := ParseRules[models.Item](data)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("validation rules %s: %w", path, err)
+	}
+	return rules, info.ModTime(), nil
+}
+
+// reload reads the file again if it changed since it was last read. A file
+// that fails to load is logged and the previous rules are kept; a file
+// that was removed leaves only the built-in checks.
+func (v *ItemValidator) reload() {
+	v.mu.RLock()
+	due := time.Since(v.checked) >= rulesReloadInterval
+	v.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if time.Since(v.checked) < rulesReloadInterval {
+		return
+	}
+	v.checked = time.Now()
+
+	info, err := os.Stat(v.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if v.rules != nil {
+			log.Printf("Validation rules %s removed; using the built-in checks", v.path)
+		}
+		v.rules, v.modTime = nil, time.Time{}
+		return
+	}
+	if err != nil {
+		log.Printf("WARN: validation rules: %v; keeping the previous rules", err)
+		return
+	}
+	if info.ModTime().Equal(v.modTime) {
+		return
+	}
+	rules, modTime, err := readRuleFile(v.path)
+	if err != nil {
+		log.Printf("WARN: %v; keeping the previous rules", err)
+		return
+	}
+	v.rules, v.modTime = rules, modTime
+	log.Printf("Validation rules reloaded from %s", v.path)
+}
+
+// Validate checks item with Item and then the current rules
+func (v *ItemValidator) Validate(item models.Item) error {
+	v.reload()
+
+	verr := &ValidationError{}
+	if err := Item(item); err != nil {
+		verr = err.(*ValidationError)
+	}
+	v.mu.RLock()
+	v.rules.Check(verr, item)
+	v.mu.RUnlock()
+	return verr.Err()
+}
@@ -0,0 +1,148 @@
+package validation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-api/models"
+)
+
+// codes returns the field and code of each error of err
+func codes(err error) []string {
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		return nil
+	}
+	out := make([]string, len(verr.Errors))
+	for i, fe := range verr.Errors {
+		out[i] = fe.Field + " " + fe.Code
+	}
+	return out
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		want  string
+	}{
+		{"unknown field", `[{field: price, min: 0.01}]`, `unknown field "price"`},
+		{"bounds on a string", `[{field: name, min: 1}]`, "name is not a number"},
+		{"length of a number", `[{field: quantity, max_length: 3}]`, "quantity is not a string"},
+		{"min above max", `[{field: quantity, min: 10, max: 1}]`, "min of quantity is above max"},
+		{"negative length", `[{field: name, max_length: -1}]`, "max_length of name is negative"},
+		{"bad pattern", `[{field: name, pattern: "("}]`, "pattern of name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules[models.Item]([]byte(tt.rules))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	rules, err := ParseRules[models.Item]([]byte(`[{field: quantity, min: 1, max: 1000}, {field: name, max_length: 10, pattern: "^[a-z ]+$"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &ValidationError{}
+	rules.Check(v, models.Item{Name: "Widget", Quantity: 0})
+	if got := codes(v.Err()); strings.Join(got, ", ") != "quantity "+CodeMinValue+", name "+CodeInvalidFormat {
+		t.Errorf("errors %v, want quantity below min and name not matching", got)
+	}
+}
+
+func TestItemValidatorReloadsRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "validation-rules.yaml")
+	mod := time.Now().Add(-time.Hour)
+	write := func(rules string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		mod = mod.Add(time.Second)
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// due makes the next Validate look at the file, as it does every
+	// rulesReloadInterval
+	due := func(v *ItemValidator) {
+		v.mu.Lock()
+		v.checked = time.Now().Add(-rulesReloadInterval)
+		v.mu.Unlock()
+	}
+
+	write("- {field: quantity, max: 10}\n")
+	v, err := NewItemValidator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := models.Item{Name: "Widget", Quantity: 50}
+	if got := codes(v.Validate(item)); len(got) != 1 || got[0] != "quantity "+CodeMaxValue {
+		t.Fatalf("errors %v, want quantity above max", got)
+	}
+
+	// Within the interval the change isn't seen yet
+	write("- {field: quantity, max: 100}\n")
+	if v.Validate(item) == nil {
+		t.Error("rules changed before the reload interval passed")
+	}
+	due(v)
+	if err := v.Validate(item); err != nil {
+		t.Errorf("after raising max to 100: %v", err)
+	}
+
+	// A broken file keeps the rules loaded before it
+	write("- {field: price, max: 1}\n")
+	due(v)
+	if err := v.Validate(item); err != nil {
+		t.Errorf("after a broken file: %v, want the previous rules kept", err)
+	}
+	if err := v.Validate(models.Item{Name: "Widget", Quantity: 500}); err == nil {
+		t.Error("quantity 500 passed; the previous max of 100 wasn't kept")
+	}
+
+	// Rules only tighten the built-in checks, and go with the file
+	write("- {field: name, max_length: 3}\n")
+	due(v)
+	if got := codes(v.Validate(models.Item{Name: "Widget", Quantity: -1})); strings.Join(got, ", ") != "quantity "+CodeInvalidFormat+", name "+CodeMaxLength {
+		t.Errorf("errors %v, want the built-in quantity check and the name rule", got)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	due(v)
+	if err := v.Validate(models.Item{Name: "Widget", Quantity: 500}); err != nil {
+		t.Errorf("after removing the file: %v, want only the built-in checks", err)
+	}
+}
+
+func TestItemValidatorWithoutFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "validation-rules.yaml")
+	v, err := NewItemValidator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate(models.Item{Name: "Widget", Quantity: 1 << 20}); err != nil {
+		t.Errorf("without a file: %v", err)
+	}
+	if v.Validate(models.Item{}) == nil {
+		t.Error("the built-in checks didn't apply without a file")
+	}
+
+	if err := os.WriteFile(path, []byte("- {field: quantity, max: 10}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v.mu.Lock()
+	v.checked = time.Now().Add(-rulesReloadInterval)
+	v.mu.Unlock()
+	if v.Validate(models.Item{Name: "Widget", Quantity: 50}) == nil {
+		t.Error("a file created after startup wasn't read")
+	}
+}
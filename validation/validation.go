@@ -0,0 +1,77 @@
+// Package validation checks models before they are stored and reports every
+// problem as a FieldError with a machine-readable code.
+package validation
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors mapped to codes by CodeForError
+var (
+	ErrRequired        = errors.New("required")
+	ErrMinValue        = errors.New("below minimum")
+	ErrMaxValue        = errors.New("above maximum")
+	ErrMaxLength       = errors.New("too long")
+	ErrInvalidFormat   = errors.New("invalid format")
+	ErrUniqueViolation = errors.New("already in use")
+)
+
+// FieldError describes a problem with one field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationError lists every field that failed validation
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add records a field error whose code is derived from err
+func (e *ValidationError) Add(field string, err error, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Code: CodeForError(err), Message: message})
+}
+
+// Err returns e when it holds any errors and nil otherwise
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// CodeForError returns the error code for err, defaulting to INVALID_FORMAT
+func CodeForError(err error) string {
+	var fe FieldError
+	switch {
+	case errors.As(err, &fe):
+		return fe.Code
+	case errors.Is(err, ErrRequired):
+		return CodeRequired
+	case errors.Is(err, ErrMinValue):
+		return CodeMinValue
+	case errors.Is(err, ErrMaxValue):
+		return CodeMaxValue
+	case errors.Is(err, ErrMaxLength):
+		return CodeMaxLength
+	case errors.Is(err, ErrUniqueViolation):
+		return CodeUniqueViolation
+	}
+	return CodeInvalidFormat
+}
@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go-api/correlation"
+	"go-api/events"
+)
+
+const (
+	// maxRetries is how many times a failed delivery is retried before it
+	// goes to the dead letter queue
+	maxRetries = 5
+	// baseBackoff is the wait before the first retry; it doubles each time
+	baseBackoff = 500 * time.Millisecond
+)
+
+// Subscription is a webhook URL receiving the events of one tenant
+type Subscription struct {
+	TenantID string
+	URL      string
+}
+
+// ParseSubscription reads a webhook registration of the form
+// <tenant>=<url>. A bare URL subscribes to the default (empty) tenant.
+func ParseSubscription(s string) Subscription {
+	if tenantID, url, ok := strings.Cut(s, "="); ok && !strings.ContainsAny(tenantID, ":/") {
+		return Subscription{TenantID: tenantID, URL: url}
+	}
+	return Subscription{URL: s}
+}
+
+// Dispatcher delivers store events to webhook URLs
+type Dispatcher struct {
+	subs    []Subscription
+	client  *http.Client
+	dlq     *DLQ
+	backoff time.Duration
+
+	wg sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher posting each event to the subscriptions
+// of its tenant. Deliveries that still fail after every retry are written to
+// dlq.
+func NewDispatcher(subs []Subscription, dlq *DLQ) *Dispatcher {
+	return &Dispatcher{
+		subs:    subs,
+		client:  &http.Client{Timeout: 10 * time.Second, Transport: &correlation.Transport{}},
+		dlq:     dlq,
+		backoff: baseBackoff,
+	}
+}
+
+// Run delivers the events of bus on the topics matching any of patterns
+// until ctx is cancelled, then waits for in-flight deliveries to finish
+func (d *Dispatcher) Run(ctx context.Context, bus *events.Bus, patterns ...string) {
+	var subs sync.WaitGroup
+	for _, pattern := range patterns {
+		stream, cancel := bus.Subscribe(pattern)
+		subs.Add(1)
+		go func() {
+			defer subs.Done()
+			defer cancel()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case e, ok := <-stream:
+					if !ok {
+						return
+					}
+					d.dispatch(ctx, e)
+				}
+			}
+		}()
+	}
+
+	subs.Wait()
+	d.wg.Wait()
+}
+
+// dispatch starts one delivery per subscription of the event's tenant
+func (d *Dispatcher) dispatch(ctx context.Context, e events.Event) {
+	for _, sub := range d.subs {
+		if !e.VisibleTo(sub.TenantID) {
+			continue
+		}
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliverWithRetry(ctx, sub.URL, e)
+		}()
+	}
+}
+
+func (d *Dispatcher) deliverWithRetry(ctx context.Context, url string, e events.Event) {
+	var err error
+	attempts := 0
+	for wait := d.backoff; attempts <= maxRetries; wait *= 2 {
+		attempts++
+		if err = d.deliver(ctx, url, e); err == nil {
+			return
+		}
+		if attempts > maxRetries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			// Shutting down; keep the event rather than lose it
+			d.dlq.Add(e, url, attempts, err)
+			return
+		case <-time.After(wait):
+		}
+	}
+
+	log.Printf("webhook: %s: giving up on %s %s after %d attempts: %v", url, e.Entity, e.Type, attempts, err)
+	d.dlq.Add(e, url, attempts, err)
+}
+
+// deliver posts the event once, treating any non-2xx status as a failure
+func (d *Dispatcher) deliver(ctx context.Context, url string, e events.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// The delivery belongs to the operation of the request that made the change
+	ctx = correlation.WithID(ctx, e.CorrelationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go-api/events"
+	"go-api/models"
+	"go-api/storage"
+)
+
+func TestParseSubscription(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Subscription
+	}{
+		{"https://hooks.example.com/all", Subscription{URL: "https://hooks.example.com/all"}},
+		{"acme=https://hooks.example.com/acme", Subscription{TenantID: "acme", URL: "https://hooks.example.com/acme"}},
+		{"https://hooks.example.com/?a=b", Subscription{URL: "https://hooks.example.com/?a=b"}},
+	}
+	for _, tt := range tests {
+		if got := ParseSubscription(tt.in); got != tt.want {
+			t.Errorf("ParseSubscription(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// recorder is a webhook endpoint remembering the IDs of the events it got
+type recorder struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var e events.Event
+	json.NewDecoder(r.Body).Decode(&e)
+	rec.mu.Lock()
+	rec.ids = append(rec.ids, e.ID)
+	rec.mu.Unlock()
+}
+
+func TestDispatchOnlyToEventsTenant(t *testing.T) {
+	acme, globex := &recorder{}, &recorder{}
+	acmeServer, globexServer := httptest.NewServer(acme), httptest.NewServer(globex)
+	defer acmeServer.Close()
+	defer globexServer.Close()
+
+	d := NewDispatcher([]Subscription{
+		{TenantID: "acme", URL: acmeServer.URL},
+		{TenantID: "globex", URL: globexServer.URL},
+	}, NewDLQ(storage.NewMemoryStore[models.DeadLetter]()))
+	d.dispatch(context.Background(), events.Event{ID: "a", TenantID: "acme"})
+	d.dispatch(context.Background(), events.Event{ID: "g", TenantID: "globex"})
+	d.dispatch(context.Background(), events.Event{ID: "default"})
+	d.wg.Wait()
+
+	if len(acme.ids) != 1 || acme.ids[0] != "a" {
+		t.Errorf("acme got %v, want [a]", acme.ids)
+	}
+	if len(globex.ids) != 1 || globex.ids[0] != "g" {
+		t.Errorf("globex got %v, want [g]", globex.ids)
+	}
+}
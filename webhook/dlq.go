@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-api/events"
+	"go-api/models"
+	"go-api/storage"
+)
+
+// ErrNotFound is returned by Retry for an unknown dead letter ID
+var ErrNotFound = errors.New("dead letter not found")
+
+// DLQ keeps webhook deliveries that failed after every retry
+type DLQ struct {
+	store storage.Store[models.DeadLetter]
+}
+
+// NewDLQ creates a dead letter queue backed by store
+func NewDLQ(store storage.Store[models.DeadLetter]) *DLQ {
+	return &DLQ{store: store}
+}
+
+// Add records a failed delivery
+func (q *DLQ) Add(e events.Event, url string, attempts int, err error) models.DeadLetter {
+	letter := models.DeadLetter{Event: e, URL: url, Attempts: attempts}
+	if err != nil {
+		letter.LastError = err.Error()
+	}
+	return q.store.Create(letter)
+}
+
+// List returns every dead letter
+func (q *DLQ) List() []models.DeadLetter {
+	return q.store.GetAll()
+}
+
+// Retry attempts one more delivery of a dead letter. The entry is removed on
+// success and updated with the new error on failure.
+func (q *DLQ) Retry(ctx context.Context, d *Dispatcher, id string) (models.DeadLetter, error) {
+	letter, exists := q.store.GetByID(id)
+	if !exists {
+		return letter, ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	err := d.deliver(ctx, letter.URL, letter.Event)
+	if err == nil {
+		q.store.Delete(id)
+		return letter, nil
+	}
+
+	letter.Attempts++
+	letter.LastError = err.Error()
+	if updated, updateErr := q.store.Update(id, letter); updateErr == nil {
+		letter = updated
+	}
+	return letter, err
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-api/events"
+	"go-api/models"
+	"go-api/storage"
+)
+
+// flakyEndpoint fails every delivery while failing is set
+type flakyEndpoint struct {
+	failing atomic.Bool
+	calls   atomic.Int64
+}
+
+func (f *flakyEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls.Add(1)
+	if f.failing.Load() {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func TestRepeatedFailuresGoToDLQ(t *testing.T) {
+	endpoint := &flakyEndpoint{}
+	endpoint.failing.Store(true)
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	dlq := NewDLQ(storage.NewMemoryStore[models.DeadLetter]())
+	d := NewDispatcher([]Subscription{{URL: server.URL}}, dlq)
+	d.backoff = time.Millisecond
+	d.dispatch(context.Background(), events.Event{Type: events.Created, Entity: "items", ID: "i1"})
+	d.wg.Wait()
+
+	if got := endpoint.calls.Load(); got != maxRetries+1 {
+		t.Errorf("endpoint called %d times, want %d", got, maxRetries+1)
+	}
+	letters := dlq.List()
+	if len(letters) != 1 {
+		t.Fatalf("DLQ has %d letters, want 1", len(letters))
+	}
+	letter := letters[0]
+	if letter.URL != server.URL || letter.Event.ID != "i1" || letter.Attempts != maxRetries+1 || letter.LastError != "unexpected status 500" {
+		t.Errorf("letter = %+v, want i1 to %s after %d attempts with the last status", letter, server.URL, maxRetries+1)
+	}
+
+	// A failed retry counts the attempt and keeps the letter
+	if _, err := dlq.Retry(context.Background(), d, letter.ID); err == nil {
+		t.Error("Retry to a failing endpoint succeeded")
+	}
+	if letters := dlq.List(); len(letters) != 1 || letters[0].Attempts != maxRetries+2 {
+		t.Errorf("after a failed retry the DLQ has %v, want the letter at %d attempts", letters, maxRetries+2)
+	}
+
+	endpoint.failing.Store(false)
+	if _, err := dlq.Retry(context.Background(), d, letter.ID); err != nil {
+		t.Errorf("Retry: %v", err)
+	}
+	if letters := dlq.List(); len(letters) != 0 {
+		t.Errorf("DLQ has %d letters after a successful retry, want none", len(letters))
+	}
+	if _, err := dlq.Retry(context.Background(), d, letter.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Retry of a removed letter: %v, want ErrNotFound", err)
+	}
+}
+
+func TestShutdownKeepsPendingDeliveries(t *testing.T) {
+	endpoint := &flakyEndpoint{}
+	endpoint.failing.Store(true)
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	dlq := NewDLQ(storage.NewMemoryStore[models.DeadLetter]())
+	d := NewDispatcher([]Subscription{{URL: server.URL}}, dlq)
+	d.backoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	d.dispatch(ctx, events.Event{Type: events.Created, Entity: "items", ID: "i1"})
+	for endpoint.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	d.wg.Wait()
+
+	if letters := dlq.List(); len(letters) != 1 || letters[0].Attempts != 1 {
+		t.Errorf("DLQ has %v after shutdown, want the pending delivery after 1 attempt", letters)
+	}
+}